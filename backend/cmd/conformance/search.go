@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/internal/repository/postgres"
+	"github.com/paper-app/backend/internal/search"
+	"github.com/paper-app/backend/internal/search/searchtest"
+	"github.com/paper-app/backend/pkg/opensearch"
+)
+
+// searchTargets are where the search contract runs against the postgres
+// and opensearch backends. Both are scratch: their papers are replaced.
+type searchTargets struct {
+	DatabaseURL   string
+	OpenSearchURL string
+	Index         string
+}
+
+// newSearchBackend sets a backend up for searchtest.Run. The returned
+// func tears it down.
+func newSearchBackend(ctx context.Context, name string, t *searchTargets) (*searchtest.Backend, func(), error) {
+	switch name {
+	case "local":
+		dir, err := os.MkdirTemp("", "conformance-search-")
+		if err != nil {
+			return nil, nil, err
+		}
+		local, err := search.OpenLocal(dir)
+		if err != nil {
+			os.RemoveAll(dir)
+			return nil, nil, err
+		}
+		b := &searchtest.Backend{Name: name, Searcher: local, Load: reindex(local, nil)}
+		return b, func() { local.Close(); os.RemoveAll(dir) }, nil
+
+	case "opensearch":
+		if t.OpenSearchURL == "" {
+			return nil, nil, fmt.Errorf("needs --search-opensearch")
+		}
+		client := opensearch.NewClient(opensearch.Config{
+			Endpoint: strings.TrimRight(t.OpenSearchURL, "/"),
+		}).WithIndex(t.Index)
+		searcher := search.NewOpenSearch(client)
+		b := &searchtest.Backend{Name: name, Searcher: searcher, Load: reindex(searcher, client.Refresh)}
+		return b, func() { client.DeleteIndex(context.Background()) }, nil
+
+	case "postgres":
+		if t.DatabaseURL == "" {
+			return nil, nil, fmt.Errorf("needs --search-db")
+		}
+		pool, err := pgxpool.New(ctx, t.DatabaseURL)
+		if err != nil {
+			return nil, nil, err
+		}
+		b := &searchtest.Backend{Name: name, Searcher: postgres.NewPaperSearcher(pool), Load: loadPapers(pool)}
+		return b, pool.Close, nil
+	}
+	return nil, nil, fmt.Errorf("unknown search backend (want local, postgres or opensearch)")
+}
+
+// reindex loads docs into an index the searcher writes itself.
+func reindex(s domain.PaperSearcher, refresh func(context.Context) error) func(context.Context, []*domain.SearchDoc) error {
+	return func(ctx context.Context, docs []*domain.SearchDoc) error {
+		if err := s.DeleteIndex(ctx); err != nil {
+			return err
+		}
+		if err := s.CreateIndex(ctx); err != nil {
+			return err
+		}
+		if err := s.BulkIndex(ctx, docs); err != nil {
+			return err
+		}
+		if refresh != nil {
+			return refresh(ctx)
+		}
+		return nil
+	}
+}
+
+// loadPapers replaces the papers table's rows with docs. The most cited
+// doc of each work is its primary record.
+func loadPapers(pool *pgxpool.Pool) func(context.Context, []*domain.SearchDoc) error {
+	return func(ctx context.Context, docs []*domain.SearchDoc) error {
+		primary := map[string]*domain.SearchDoc{}
+		for _, d := range docs {
+			if p := primary[d.WorkID]; p == nil || d.CitationCount > p.CitationCount {
+				primary[d.WorkID] = d
+			}
+		}
+
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(context.Background())
+		if _, err := tx.Exec(ctx, `DELETE FROM papers`); err != nil {
+			return err
+		}
+		for _, d := range docs {
+			authors, err := json.Marshal(d.Authors)
+			if err != nil {
+				return err
+			}
+			var published *time.Time
+			if d.PublishedDate != nil {
+				t, err := time.Parse("2006-01-02", *d.PublishedDate)
+				if err != nil {
+					return err
+				}
+				published = &t
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO papers (id, external_id, source, title, abstract, authors, published_date,
+					primary_category, categories, doi, citation_count, work_id, work_primary, visibility)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, 'public')
+			`, d.ID, d.ExternalID, d.Source, d.Title, d.Abstract, authors, published,
+				d.PrimaryCategory, d.Categories, d.DOI, d.CitationCount, d.WorkID, primary[d.WorkID] == d); err != nil {
+				return fmt.Errorf("insert %s: %w", d.ExternalID, err)
+			}
+			if len(d.FullTextSections) == 0 {
+				continue
+			}
+			sections, err := json.Marshal(d.FullTextSections)
+			if err != nil {
+				return err
+			}
+			var text []string
+			for _, s := range d.FullTextSections {
+				text = append(text, s.Text)
+			}
+			if _, err := tx.Exec(ctx, `
+				INSERT INTO paper_fulltext (paper_id, blob_key, text, sections) VALUES ($1, '', $2, $3)
+			`, d.ID, strings.Join(text, "\n\n"), sections); err != nil {
+				return fmt.Errorf("insert full text of %s: %w", d.ExternalID, err)
+			}
+		}
+		return tx.Commit(ctx)
+	}
+}
@@ -0,0 +1,121 @@
+// Digest: Sends daily or weekly email digests of new papers to users who opted in.
+// Papers are grouped per user by the categories of the papers in their library.
+//
+// Usage:
+//
+//	go run ./cmd/digest --frequency=daily                   # Run from cron once a day
+//	go run ./cmd/digest --frequency=weekly                  # Run from cron on Mondays
+//	go run ./cmd/digest --frequency=daily --dry-run         # Print emails, record nothing
+//
+// SMTP settings come from SMTP_HOST/SMTP_PORT/SMTP_USER/SMTP_PASS/SMTP_FROM.
+// The defaults (localhost:1025, no auth) point at a local MailHog instance.
+//
+// Each (user, frequency, period) is claimed in digest_sends before sending, so
+// re-running the job — or running it after a crash — never sends a digest twice.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/internal/repository/postgres"
+	"github.com/paper-app/backend/internal/usecase"
+	"github.com/paper-app/backend/pkg/mailer"
+)
+
+func main() {
+	cfg := config.Load()
+
+	dbURL := flag.String("db", cfg.Database.URL, "PostgreSQL connection URL")
+	frequency := flag.String("frequency", "daily", "Digest frequency to send: daily or weekly")
+	dryRun := flag.Bool("dry-run", false, "Log rendered emails instead of sending; do not record send state")
+	at := flag.String("at", "", "Pretend the job runs at this date (YYYY-MM-DD, UTC) — for backfilling a missed run")
+	flag.Parse()
+
+	now := time.Now()
+	if *at != "" {
+		t, err := time.Parse("2006-01-02", *at)
+		if err != nil {
+			log.Fatalf("Invalid --at date: %v", err)
+		}
+		now = t
+	}
+
+	start, end, err := usecase.DigestPeriod(*frequency, now)
+	if err != nil {
+		log.Fatalf("Invalid --frequency %q (want daily or weekly)", *frequency)
+	}
+
+	log.Println("=== Email Digest ===")
+	log.Printf("Frequency: %s | Period: %s → %s | DryRun: %v",
+		*frequency, start.Format("2006-01-02"), end.Format("2006-01-02"), *dryRun)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, *dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(ctx); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+	log.Println("Connected to PostgreSQL")
+
+	// Handle graceful shutdown: finish the current email, then stop
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("\nReceived shutdown signal, stopping after current email...")
+		cancel()
+	}()
+
+	var sender mailer.Sender = mailer.LogSender{}
+	if !*dryRun {
+		sender = mailer.NewSMTPSender(mailer.Config{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			StartTLS: cfg.SMTP.StartTLS,
+		})
+		log.Printf("Sending via SMTP %s:%s", cfg.SMTP.Host, cfg.SMTP.Port)
+	}
+
+	digestUsecase := usecase.NewDigestUsecase(
+		postgres.NewDigestRepository(pool),
+		postgres.NewUserPaperRepository(pool),
+		postgres.NewPaperRepository(pool),
+		sender,
+		cfg.SMTP.From,
+		&cfg.Digest,
+	)
+
+	startTime := time.Now()
+	stats, err := digestUsecase.Run(ctx, *frequency, now, *dryRun)
+	if stats != nil {
+		log.Println("========================================")
+		log.Printf("Recipients:       %d", stats.Recipients)
+		log.Printf("Sent:             %d", stats.Sent)
+		log.Printf("Nothing new:      %d", stats.Skipped)
+		log.Printf("Already claimed:  %d", stats.Claimed)
+		log.Printf("Failed:           %d", stats.Failed)
+		log.Printf("Duration:         %v", time.Since(startTime).Round(time.Second))
+		log.Println("========================================")
+	}
+	if err != nil {
+		log.Fatalf("Digest run failed: %v", err)
+	}
+	if stats.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/pkg/crossref"
+	"github.com/paper-app/backend/pkg/ingestjob"
+)
+
+// provenanceSource tags the fields this mode writes in metadata.provenance.
+const provenanceSource = "crossref"
+
+// crossrefRow is a paper with a DOI, as read for enrichment.
+type crossrefRow struct {
+	id         uuid.UUID
+	doi        string
+	journalRef string
+	license    string
+	published  *time.Time
+	metadata   map[string]interface{}
+}
+
+// fieldCounts tracks how many papers got each field filled.
+type fieldCounts map[string]int
+
+// runCrossref fills publication details for papers with a DOI from Crossref:
+// journal_ref, license and published_date columns, and venue, volume,
+// issue, pages, publisher, ISSN and funders in metadata.
+//
+// Existing data wins: a field is only written when it is empty, or when
+// Crossref wrote it on an earlier run (metadata.provenance.<field>.source
+// = "crossref"). The one exception is a year-only published_date (stored
+// as Jan 1), which a day-precise Crossref date in the same year replaces.
+// Each checked paper gets metadata.crossref_checked_at, so later runs skip
+// it unless --force. The job's cursor is the last paper ID stored, so an
+// interrupted --force run resumes after it.
+func runCrossref(ctx context.Context, run *ingestjob.Run, pool *pgxpool.Pool, mailto string, batchSize, limit int, force bool) {
+	if batchSize > 100 {
+		batchSize = 100 // DOIs per /works filter; longer URLs get rejected
+	}
+	if mailto == "" {
+		log.Println("WARN: no --mailto; Crossref serves anonymous requests from the slower public pool")
+	}
+
+	var pending int
+	err := pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM papers
+		WHERE doi IS NOT NULL AND doi <> ''
+		  AND ($1 OR NOT (COALESCE(metadata, '{}'::jsonb) ? 'crossref_checked_at'))
+	`, force).Scan(&pending)
+	if err != nil {
+		log.Fatalf("Failed to count papers: %v", err)
+	}
+	log.Printf("Papers with a DOI to check against Crossref: %d", pending)
+	if limit > 0 && limit < pending {
+		pending = limit
+	}
+	if pending == 0 {
+		log.Println("Nothing to enrich. Done!")
+		return
+	}
+
+	client := crossref.NewClient(mailto)
+
+	lastID, _ := uuid.Parse(run.Cursor())
+	if lastID != uuid.Nil {
+		log.Printf("Resuming after paper %s", lastID)
+	}
+
+	var (
+		processed int
+		enriched  int
+		notFound  int
+		apiErrors int
+		filled    = fieldCounts{}
+		startTime = time.Now()
+		lastLog   = time.Now()
+	)
+
+	for processed < pending && run.Context().Err() == nil {
+		n := batchSize
+		if processed+n > pending {
+			n = pending - processed
+		}
+		papers, err := loadCrossrefBatch(ctx, pool, lastID, n, force)
+		if err != nil {
+			log.Fatalf("Failed to fetch papers: %v", err)
+		}
+		if len(papers) == 0 {
+			break
+		}
+
+		dois := make([]string, len(papers))
+		for i, p := range papers {
+			dois[i] = p.doi
+		}
+		works, err := client.WorksByDOI(ctx, dois)
+		if err != nil {
+			apiErrors++
+			run.Error(err)
+			if apiErrors >= 5 {
+				log.Fatalf("Crossref keeps failing, giving up: %v", err)
+			}
+			log.Printf("WARN: Crossref batch failed: %v", err)
+			time.Sleep(time.Duration(apiErrors) * 5 * time.Second)
+			continue
+		}
+		apiErrors = 0
+
+		now := time.Now().UTC()
+		batch := &pgx.Batch{}
+		for _, p := range papers {
+			p.metadata["crossref_checked_at"] = now.Format(time.RFC3339)
+			w := works[crossref.NormalizeDOI(p.doi)]
+			if w == nil {
+				notFound++
+				run.Add("not_found", 1)
+			} else if changed := mergeCrossref(p, w, now); len(changed) > 0 {
+				enriched++
+				run.Add("enriched", 1)
+				for _, f := range changed {
+					filled[f]++
+				}
+			}
+			metadataJSON, _ := json.Marshal(p.metadata)
+			batch.Queue(`
+				UPDATE papers SET journal_ref = NULLIF($2, ''), license = NULLIF($3, ''), published_date = $4, metadata = $5
+				WHERE id = $1
+			`, p.id, p.journalRef, p.license, p.published, metadataJSON)
+		}
+
+		batchCtx, batchCancel := context.WithTimeout(ctx, 60*time.Second)
+		err = pool.SendBatch(batchCtx, batch).Close()
+		batchCancel()
+		if err != nil {
+			log.Fatalf("Failed to update papers: %v", err)
+		}
+
+		processed += len(papers)
+		lastID = papers[len(papers)-1].id
+		run.SetCursor(lastID.String())
+		run.Add("processed", int64(len(papers)))
+
+		if time.Since(lastLog) > 10*time.Second {
+			log.Printf("Progress: %d/%d (%.1f%%) | enriched: %d | not in Crossref: %d",
+				processed, pending, float64(processed)/float64(pending)*100, enriched, notFound)
+			lastLog = time.Now()
+		}
+	}
+
+	log.Println("=== Crossref Enrichment Complete ===")
+	log.Printf("Processed:       %d", processed)
+	log.Printf("Enriched:        %d", enriched)
+	log.Printf("Not in Crossref: %d", notFound)
+	for _, f := range []string{"journal_ref", "license", "published_date", "venue", "volume", "issue", "pages", "publisher", "issn", "funders"} {
+		if filled[f] > 0 {
+			log.Printf("  %-15s %d", f+":", filled[f])
+		}
+	}
+	log.Printf("Duration:        %s", time.Since(startTime).Round(time.Second))
+}
+
+func loadCrossrefBatch(ctx context.Context, pool *pgxpool.Pool, after uuid.UUID, n int, force bool) ([]*crossrefRow, error) {
+	rows, err := pool.Query(ctx, `
+		SELECT id, doi, COALESCE(journal_ref, ''), COALESCE(license, ''), published_date, COALESCE(metadata, '{}'::jsonb)
+		FROM papers
+		WHERE doi IS NOT NULL AND doi <> '' AND id > $1
+		  AND ($2 OR NOT (COALESCE(metadata, '{}'::jsonb) ? 'crossref_checked_at'))
+		ORDER BY id
+		LIMIT $3
+	`, after, force, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var papers []*crossrefRow
+	for rows.Next() {
+		p := &crossrefRow{}
+		var metadataJSON []byte
+		if err := rows.Scan(&p.id, &p.doi, &p.journalRef, &p.license, &p.published, &metadataJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(metadataJSON, &p.metadata); err != nil || p.metadata == nil {
+			// Non-object metadata can't carry provenance; start over
+			p.metadata = map[string]interface{}{}
+		}
+		papers = append(papers, p)
+	}
+	return papers, rows.Err()
+}
+
+// mergeCrossref copies Crossref values into p where allowed and returns the
+// fields it changed.
+func mergeCrossref(p *crossrefRow, w *crossref.Work, now time.Time) []string {
+	prov, _ := p.metadata["provenance"].(map[string]interface{})
+	if prov == nil {
+		prov = map[string]interface{}{}
+	}
+	// ours reports whether Crossref wrote the field last
+	ours := func(field string) bool {
+		entry, _ := prov[field].(map[string]interface{})
+		return entry != nil && entry["source"] == provenanceSource
+	}
+
+	var changed []string
+	record := func(field string) {
+		changed = append(changed, field)
+		prov[field] = map[string]interface{}{"source": provenanceSource, "at": now.Format(time.RFC3339)}
+	}
+	setColumn := func(field string, dst *string, value string) {
+		if value == "" || value == *dst || (*dst != "" && !ours(field)) {
+			return
+		}
+		*dst = value
+		record(field)
+	}
+	setMeta := func(field string, value interface{}) {
+		if isEmpty(value) {
+			return
+		}
+		current, exists := p.metadata[field]
+		if exists && !isEmpty(current) && !ours(field) {
+			return
+		}
+		if exists && sameJSON(current, value) {
+			return
+		}
+		p.metadata[field] = value
+		record(field)
+	}
+
+	setColumn("journal_ref", &p.journalRef, w.JournalRef())
+	setColumn("license", &p.license, w.LicenseURL())
+
+	if t, precision, ok := w.PublishedDate(); ok {
+		switch {
+		case p.published == nil, ours("published_date") && !p.published.Equal(t):
+			p.published = &t
+			record("published_date")
+		case precision == 3 && p.published.YearDay() == 1 && p.published.Year() == t.Year() && !p.published.Equal(t):
+			p.published = &t
+			record("published_date")
+		}
+	}
+
+	setMeta("venue", w.Venue())
+	setMeta("volume", w.Volume)
+	setMeta("issue", w.Issue)
+	setMeta("pages", w.Page)
+	setMeta("publisher", w.Publisher)
+	if len(w.ISSN) > 0 {
+		setMeta("issn", w.ISSN)
+	}
+	if len(w.Funder) > 0 {
+		funders := make([]map[string]interface{}, 0, len(w.Funder))
+		for _, f := range w.Funder {
+			funder := map[string]interface{}{"name": f.Name}
+			if f.DOI != "" {
+				funder["doi"] = f.DOI
+			}
+			if len(f.Award) > 0 {
+				funder["awards"] = f.Award
+			}
+			funders = append(funders, funder)
+		}
+		setMeta("funders", funders)
+	}
+
+	if len(changed) > 0 {
+		p.metadata["provenance"] = prov
+	}
+	return changed
+}
+
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	case []map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// sameJSON compares a stored metadata value with a new one by their JSON.
+func sameJSON(a, b interface{}) bool {
+	aj, err1 := json.Marshal(a)
+	bj, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && string(aj) == string(bj)
+}
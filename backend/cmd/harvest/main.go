@@ -1,5 +1,7 @@
 // Harvester: Fetches paper metadata from arXiv's OAI-PMH endpoint
 // and stores it in PostgreSQL. Supports incremental harvesting via checkpoints.
+// Each run is an ingestjob ("harvest", set) whose cursor is the resumption
+// token, so --resume picks up an interrupted or crashed run.
 //
 // Usage:
 //   go run ./cmd/harvest --db=$DATABASE_URL --set=cs          # Harvest all CS papers
@@ -28,6 +30,9 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/internal/repository/postgres"
+	"github.com/paper-app/backend/internal/usecase"
+	"github.com/paper-app/backend/pkg/ingestjob"
 	"github.com/paper-app/backend/pkg/oaipmh"
 )
 
@@ -72,6 +77,8 @@ func main() {
 
 	// Create OAI-PMH client
 	client := oaipmh.NewClient()
+	versionRepo := postgres.NewPaperVersionRepository(pool)
+	dedupe := usecase.NewDedupeUsecase(postgres.NewPaperRepository(pool), postgres.NewIdentifierRepository(pool))
 
 	// Load or create checkpoint
 	checkpointSet := orDefault(*setName, "_all")
@@ -86,7 +93,21 @@ func main() {
 		Set:            *setName,
 	}
 
-	if *resume && checkpoint.ResumptionToken != "" {
+	run, err := ingestjob.NewRunner(pool).Start(ctx, ingestjob.Options{
+		Type:   "harvest",
+		Name:   checkpointSet,
+		Params: map[string]interface{}{"set": *setName, "from": *fromDate, "max": *maxRecords},
+		Resume: *resume,
+	})
+	if err != nil {
+		log.Fatalf("Failed to start job: %v", err)
+	}
+	ctx = run.Context()
+
+	if run.Resumed && run.Cursor() != "" {
+		params.ResumptionToken = run.Cursor()
+		log.Printf("Resuming job: %s, token: %s...", run.Summary(), truncate(run.Cursor(), 50))
+	} else if *resume && checkpoint.ResumptionToken != "" {
 		params.ResumptionToken = checkpoint.ResumptionToken
 		log.Printf("Resuming from checkpoint: %d harvested, token: %s...", checkpoint.TotalHarvested, checkpoint.ResumptionToken[:min(50, len(checkpoint.ResumptionToken))])
 	} else if *fromDate != "" {
@@ -106,13 +127,40 @@ func main() {
 		totalUpdated int
 		totalSkipped int
 		totalDeleted int
+		totalVersions int
+		totalMerged  int
 		pageCount    int
 		paperBuf     []*domain.Paper
 		startTime    = time.Now()
 		lastLog      = time.Now()
 		lastDatestamp string
+		token        = params.ResumptionToken
+		complete     bool
 	)
 
+	flush := func() {
+		if len(paperBuf) == 0 {
+			return
+		}
+		inserted, err := bulkUpsert(ctx, pool, paperBuf)
+		if err != nil {
+			log.Printf("ERROR inserting batch: %v", err)
+			run.Error(err)
+		} else {
+			versions := recordVersions(versionRepo, paperBuf)
+			merged := dedupePapers(dedupe, paperBuf)
+			totalNew += inserted
+			totalUpdated += len(paperBuf) - inserted
+			totalVersions += versions
+			totalMerged += merged
+			run.Add("new", int64(inserted))
+			run.Add("updated", int64(len(paperBuf)-inserted))
+			run.Add("versions", int64(versions))
+			run.Add("merged", int64(merged))
+		}
+		paperBuf = paperBuf[:0]
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -123,6 +171,7 @@ func main() {
 
 		result, err := client.ListRecords(params)
 		if err != nil {
+			run.Error(err)
 			if strings.Contains(err.Error(), "rate limited") || strings.Contains(err.Error(), "503") {
 				log.Printf("Rate limited, waiting 30s...")
 				time.Sleep(30 * time.Second)
@@ -138,11 +187,13 @@ func main() {
 		for _, hp := range result.Papers {
 			if hp.IsDeleted {
 				totalDeleted++
+				run.Add("deleted", 1)
 				continue
 			}
 
 			if hp.ArXivID == "" || hp.Title == "" {
 				totalSkipped++
+				run.Add("skipped", 1)
 				continue
 			}
 
@@ -155,14 +206,7 @@ func main() {
 
 			// Flush batch
 			if len(paperBuf) >= *batchSize {
-				inserted, err := bulkUpsert(ctx, pool, paperBuf)
-				if err != nil {
-					log.Printf("ERROR inserting batch: %v", err)
-				} else {
-					totalNew += inserted
-					totalUpdated += len(paperBuf) - inserted
-				}
-				paperBuf = paperBuf[:0]
+				flush()
 			}
 
 			if *maxRecords > 0 && (totalNew+totalUpdated+totalSkipped) >= *maxRecords {
@@ -171,6 +215,13 @@ func main() {
 			}
 		}
 
+		// The page is stored once the buffer is flushed; only then may the
+		// job's cursor move past it.
+		flush()
+		token = result.ResumptionToken
+		run.SetCursor(token)
+		run.Add("pages", 1)
+
 		// Progress logging
 		total := totalNew + totalUpdated + totalSkipped + totalDeleted
 		if time.Since(lastLog) > 15*time.Second || result.ResumptionToken == "" {
@@ -191,6 +242,7 @@ func main() {
 		// Check for end of harvest
 		if result.ResumptionToken == "" {
 			log.Println("No more resumption token — harvest complete!")
+			complete = true
 			break
 		}
 
@@ -201,20 +253,22 @@ func main() {
 	}
 
 done:
-	// Flush remaining papers
-	if len(paperBuf) > 0 {
-		inserted, err := bulkUpsert(ctx, pool, paperBuf)
-		if err != nil {
-			log.Printf("ERROR inserting final batch: %v", err)
-		} else {
-			totalNew += inserted
-			totalUpdated += len(paperBuf) - inserted
-		}
+	// Flush remaining papers (after --max the page is only partly stored,
+	// so the cursor stays before it)
+	if ctx.Err() == nil {
+		flush()
 	}
 
-	// Save final checkpoint
-	saveCheckpoint(ctx, pool, checkpointSet, lastDatestamp, "", int64(totalNew+totalUpdated))
-	updateCheckpointStatus(ctx, pool, checkpointSet, "completed")
+	// Save final checkpoint. An unfinished harvest keeps its token so that
+	// --resume continues it.
+	if complete {
+		token = ""
+	}
+	saveCheckpoint(context.Background(), pool, checkpointSet, lastDatestamp, token, int64(totalNew+totalUpdated))
+	if err := run.Finish(nil); err != nil {
+		log.Printf("WARN: Failed to finish job: %v", err)
+	}
+	updateCheckpointStatus(context.Background(), pool, checkpointSet, run.Job.Status)
 
 	elapsed := time.Since(startTime)
 	log.Printf("=== Harvest Complete ===")
@@ -223,6 +277,8 @@ done:
 	log.Printf("Updated:      %d", totalUpdated)
 	log.Printf("Skipped:      %d", totalSkipped)
 	log.Printf("Deleted:      %d", totalDeleted)
+	log.Printf("New versions: %d", totalVersions)
+	log.Printf("Merged:       %d", totalMerged)
 	log.Printf("Pages:        %d", pageCount)
 }
 
@@ -280,6 +336,7 @@ func bulkUpsert(ctx context.Context, pool *pgxpool.Pool, papers []*domain.Paper)
 				journal_ref = COALESCE(NULLIF(EXCLUDED.journal_ref, ''), papers.journal_ref),
 				comments = COALESCE(NULLIF(EXCLUDED.comments, ''), papers.comments),
 				license = COALESCE(NULLIF(EXCLUDED.license, ''), papers.license)
+			RETURNING id, (xmax = 0)
 		`,
 			p.ID, p.ExternalID, p.Source, p.Title, p.Abstract, p.Authors,
 			p.PublishedDate, p.UpdatedDate, p.PDFURL, p.PrimaryCategory,
@@ -290,13 +347,15 @@ func bulkUpsert(ctx context.Context, pool *pgxpool.Pool, papers []*domain.Paper)
 	br := pool.SendBatch(ctx, batch)
 	defer br.Close()
 
+	// The stored row keeps its ID on update; note it for the dedupe step
 	inserted := 0
-	for range papers {
-		ct, err := br.Exec()
-		if err != nil {
+	for _, p := range papers {
+		var isNew bool
+		if err := br.QueryRow().Scan(&p.ID, &isNew); err != nil {
+			p.ID = uuid.Nil
 			continue
 		}
-		if ct.RowsAffected() > 0 {
+		if isNew {
 			inserted++
 		}
 	}
@@ -304,6 +363,55 @@ func bulkUpsert(ctx context.Context, pool *pgxpool.Pool, papers []*domain.Paper)
 	return inserted, nil
 }
 
+// dedupePapers adds stored papers to the identifier crosswalk and merges
+// them with records of the same paper from other sources. Returns the
+// number of merges.
+func dedupePapers(d *usecase.DedupeUsecase, papers []*domain.Paper) int {
+	merged := 0
+	for _, p := range papers {
+		if p.ID == uuid.Nil {
+			continue
+		}
+		_, merges, err := d.Ingest(p)
+		if err != nil {
+			log.Printf("WARN: dedupe %s: %v", p.ExternalID, err)
+		}
+		merged += len(merges)
+	}
+	return merged
+}
+
+// recordVersions adds the harvested papers' versions to paper_versions. The
+// arXiv metadata format only has the first (created) and latest (updated)
+// version dates, so a newly seen update is numbered one past the latest
+// version on record. Returns the number of new versions.
+func recordVersions(repo *postgres.PaperVersionRepository, papers []*domain.Paper) int {
+	var versions []*domain.PaperVersion
+	for _, p := range papers {
+		if p.PublishedDate == nil {
+			continue
+		}
+		first := &domain.PaperVersion{ExternalID: p.ExternalID, Version: 1, Date: *p.PublishedDate, Source: domain.VersionSourceOAI}
+		latest := first
+		if p.UpdatedDate != nil && p.UpdatedDate.After(*p.PublishedDate) {
+			latest = &domain.PaperVersion{ExternalID: p.ExternalID, Date: *p.UpdatedDate, Source: domain.VersionSourceOAI}
+		}
+		// The record's abstract and comments belong to the latest version
+		latest.Comments = p.Comments
+		latest.Abstract = p.Abstract
+		versions = append(versions, first)
+		if latest != first {
+			versions = append(versions, latest)
+		}
+	}
+
+	n, err := repo.Record(versions)
+	if err != nil {
+		log.Printf("WARN: recording versions: %v", err)
+	}
+	return n
+}
+
 // ---------- Checkpoint management ----------
 
 type checkpoint struct {
@@ -3,35 +3,51 @@
 //
 // Usage:
 //   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL
-//   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL --recreate  # Drop and recreate index
+//   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL --recreate  # Build a new index version and swap it in
 //   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL --category=cs.AI  # Index specific category
+//   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL --since=last  # Papers changed since the last completed run
+//   go run ./cmd/index --db=$DATABASE_URL --opensearch=$OPENSEARCH_URL --rollback  # Swap the previous version back in
+//
+// --recreate builds the next index version (papers_v3, ...) while the live
+// one keeps serving, then points the papers alias at it once it holds at
+// least --min-doc-ratio of the live index's docs. --keep old versions are
+// kept for --rollback.
 package main
 
 import (
 	"context"
 	"encoding/json"
 	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"strings"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/pkg/ingestjob"
 	"github.com/paper-app/backend/pkg/opensearch"
 )
 
 func main() {
+	cfg := config.Load()
+
 	dbURL := flag.String("db", os.Getenv("DATABASE_URL"), "PostgreSQL connection URL")
-	osURL := flag.String("opensearch", os.Getenv("OPENSEARCH_URL"), "OpenSearch endpoint URL")
-	osIndex := flag.String("index", getEnvOrDefault("OPENSEARCH_INDEX", "papers"), "OpenSearch index name")
-	osUser := flag.String("os-user", os.Getenv("OPENSEARCH_USER"), "OpenSearch username")
-	osPass := flag.String("os-pass", os.Getenv("OPENSEARCH_PASS"), "OpenSearch password")
-	recreate := flag.Bool("recreate", false, "Drop and recreate the index before indexing")
+	osURL := flag.String("opensearch", cfg.OpenSearch.Endpoint, "OpenSearch endpoint URL")
+	osIndex := flag.String("index", cfg.OpenSearch.Index, "OpenSearch index name")
+	osUser := flag.String("os-user", cfg.OpenSearch.Username, "OpenSearch username")
+	osPass := flag.String("os-pass", cfg.OpenSearch.Password, "OpenSearch password")
+	recreate := flag.Bool("recreate", false, "Build a new index version and make it live when done")
+	keep := flag.Int("keep", 2, "Old index versions to keep for rollback after --recreate")
+	minDocRatio := flag.Float64("min-doc-ratio", 0.9, "With --recreate, the new version needs at least this share of the live index's docs (0 = no check; docs only other importers wrote are not rebuilt)")
+	rollback := flag.Bool("rollback", false, "Point the index aliases back at the previous version and exit")
 	batchSize := flag.Int("batch", 500, "Number of documents per bulk request")
 	category := flag.String("category", "", "Only index papers with this primary category (e.g., cs.AI)")
 	limit := flag.Int("limit", 0, "Max papers to index (0 = all)")
+	withFullText := flag.Bool("fulltext", true, "Include extracted PDF sections from paper_fulltext")
+	since := flag.String("since", "", "Only index papers changed since this time (YYYY-MM-DD or RFC3339), or \"last\" for since the start of the last completed run")
 	flag.Parse()
 
 	if *dbURL == "" {
@@ -40,6 +56,17 @@ func main() {
 	if *osURL == "" {
 		log.Fatal("OpenSearch URL is required (--opensearch or OPENSEARCH_URL)")
 	}
+	var sinceTime *time.Time
+	if *since != "" && *since != "last" {
+		t, err := parseSince(*since)
+		if err != nil {
+			log.Fatalf("Invalid --since: %v", err)
+		}
+		sinceTime = &t
+	}
+	if *since != "" && *recreate {
+		log.Fatal("--since can't be combined with --recreate")
+	}
 
 	log.Println("=== Paper Indexer: PostgreSQL → OpenSearch ===")
 
@@ -58,28 +85,63 @@ func main() {
 	log.Println("Connected to PostgreSQL")
 
 	// Connect to OpenSearch
-	osClient := opensearch.NewClient(opensearch.Config{
-		Endpoint: strings.TrimRight(*osURL, "/"),
-		Index:    *osIndex,
-		Username: *osUser,
-		Password: *osPass,
-	})
+	osConfig := cfg.OpenSearch.ClientConfig()
+	osConfig.Endpoint, osConfig.Index = *osURL, *osIndex
+	osConfig.Username, osConfig.Password = *osUser, *osPass
+	osClient := opensearch.NewClient(osConfig)
 	if err := osClient.Ping(ctx); err != nil {
 		log.Fatalf("Failed to connect to OpenSearch: %v", err)
 	}
 	log.Println("Connected to OpenSearch")
 
-	// Recreate index if requested
-	if *recreate {
-		log.Println("Deleting existing index...")
-		if err := osClient.DeleteIndex(ctx); err != nil {
-			log.Printf("WARN: Delete index: %v", err)
+	if *rollback {
+		name, err := osClient.Rollback(ctx)
+		if err != nil {
+			log.Fatalf("Rollback failed: %v", err)
 		}
+		log.Printf("%s now serves %s", name, *osIndex)
+		return
 	}
 
-	log.Println("Creating index (if not exists)...")
-	if err := osClient.CreateIndex(ctx); err != nil {
-		log.Fatalf("Failed to create index: %v", err)
+	run, err := ingestjob.NewRunner(pool).Start(ctx, ingestjob.Options{
+		Type: "index",
+		Name: *osIndex,
+		Params: map[string]interface{}{"index": *osIndex, "recreate": *recreate, "category": *category,
+			"limit": *limit, "fulltext": *withFullText, "since": *since, "keep": *keep, "min-doc-ratio": *minDocRatio},
+	})
+	if err != nil {
+		log.Fatalf("Failed to start job: %v", err)
+	}
+	ctx = run.Context()
+	if *since == "last" {
+		if run.Previous != nil && run.Previous.StartedAt != nil {
+			t := *run.Previous.StartedAt
+			sinceTime = &t
+		} else {
+			log.Println("No completed run yet; indexing all papers")
+		}
+	}
+	if sinceTime != nil {
+		log.Printf("Indexing papers changed since %s", sinceTime.UTC().Format(time.RFC3339))
+	}
+
+	// Rebuild into a new index version; the live one keeps serving until
+	// the new one is promoted
+	target := osClient
+	if *recreate {
+		target, _, err = osClient.BuildVersion(ctx, 0)
+		if err != nil {
+			log.Fatalf("Failed to create index version: %v", err)
+		}
+		log.Printf("Building %s; %s stays live until it is promoted", target.Index(), *osIndex)
+	} else {
+		log.Println("Creating index (if not exists)...")
+		if err := osClient.CreateIndex(ctx); err != nil {
+			log.Fatalf("Failed to create index: %v", err)
+		}
+		if err := osClient.PutMapping(ctx); err != nil {
+			log.Printf("WARN: Update mapping: %v", err)
+		}
 	}
 
 	// Handle graceful shutdown
@@ -92,14 +154,18 @@ func main() {
 	}()
 
 	// Count papers to index
-	countQuery := "SELECT COUNT(*) FROM papers WHERE title IS NOT NULL AND title != ''"
+	filter := ""
 	args := []interface{}{}
-	argIdx := 1
 	if *category != "" {
-		countQuery += " AND primary_category = $1"
 		args = append(args, *category)
-		argIdx++
+		filter += fmt.Sprintf(" AND primary_category = $%d", len(args))
+	}
+	if sinceTime != nil {
+		// updated_at is a timestamp without time zone, written in UTC
+		args = append(args, sinceTime.UTC())
+		filter += fmt.Sprintf(" AND updated_at >= $%d", len(args))
 	}
+	countQuery := "SELECT COUNT(*) FROM papers WHERE title IS NOT NULL AND title != '' AND visibility = 'public'" + filter
 
 	var totalPapers int
 	if err := pool.QueryRow(ctx, countQuery, args...).Scan(&totalPapers); err != nil {
@@ -111,29 +177,25 @@ func main() {
 	log.Printf("Papers to index: %d", totalPapers)
 
 	// Stream papers from PostgreSQL and bulk-index into OpenSearch
+	fulltextCol := "NULL::jsonb"
+	if *withFullText {
+		fulltextCol = "(SELECT ft.sections FROM paper_fulltext ft WHERE ft.paper_id = papers.id)"
+	}
 	selectQuery := `
 		SELECT id, external_id, source, title, COALESCE(abstract, ''), authors,
 			published_date, updated_date, pdf_url, COALESCE(primary_category, ''),
 			categories, COALESCE(doi, ''), COALESCE(journal_ref, ''),
-			COALESCE(comments, ''), COALESCE(license, '')
+			COALESCE(comments, ''), COALESCE(license, ''), COALESCE(work_id, ''),
+			` + fulltextCol + `
 		FROM papers
-		WHERE title IS NOT NULL AND title != ''
-	`
-	selectArgs := []interface{}{}
-	if *category != "" {
-		selectQuery += " AND primary_category = $1"
-		selectArgs = append(selectArgs, *category)
-	}
+		WHERE title IS NOT NULL AND title != '' AND visibility = 'public'
+	` + filter
+	selectArgs := append([]interface{}{}, args...)
 	selectQuery += " ORDER BY external_id"
 	if *limit > 0 {
-		if *category != "" {
-			selectQuery += " LIMIT $2"
-		} else {
-			selectQuery += " LIMIT $1"
-		}
 		selectArgs = append(selectArgs, *limit)
+		selectQuery += fmt.Sprintf(" LIMIT $%d", len(selectArgs))
 	}
-	_ = argIdx
 
 	rows, err := pool.Query(ctx, selectQuery, selectArgs...)
 	if err != nil {
@@ -173,11 +235,13 @@ func main() {
 			journalRef      string
 			comments        string
 			license         string
+			workID          string
+			sectionsJSON    []byte
 		)
 
 		if err := rows.Scan(&id, &externalID, &source, &title, &abstract, &authorsJSON,
 			&publishedDate, &updatedDate, &pdfURL, &primaryCategory,
-			&categories, &doi, &journalRef, &comments, &license); err != nil {
+			&categories, &doi, &journalRef, &comments, &license, &workID, &sectionsJSON); err != nil {
 			log.Printf("WARN: Scan error: %v", err)
 			errors++
 			continue
@@ -194,6 +258,7 @@ func main() {
 			DOI:             doi,
 			JournalRef:      journalRef,
 			PDFURL:          pdfURL,
+			WorkID:          workID, // empty until linked; BulkIndex fills in the paper's own key
 		}
 
 		// Parse authors for OpenSearch nested type
@@ -218,10 +283,16 @@ func main() {
 		}
 		_ = updatedDate // UpdatedDate not in current PaperDoc; field kept for PG compat
 
+		if len(sectionsJSON) > 0 {
+			if err := json.Unmarshal(sectionsJSON, &doc.FullTextSections); err != nil {
+				log.Printf("WARN: Bad fulltext sections for %s: %v", externalID, err)
+			}
+		}
+
 		batch = append(batch, doc)
 
 		if len(batch) >= *batchSize {
-			n, err := osClient.BulkIndex(ctx, batch)
+			n, err := target.BulkIndex(ctx, batch)
 			if err != nil {
 				log.Printf("ERROR: Bulk index failed: %v", err)
 				errors += len(batch)
@@ -230,6 +301,8 @@ func main() {
 				errors += len(batch) - n
 			}
 			batch = batch[:0]
+			run.Set("indexed", int64(indexed))
+			run.Set("errors", int64(errors))
 
 			if time.Since(lastLog) > 10*time.Second {
 				elapsed := time.Since(startTime).Seconds()
@@ -246,7 +319,7 @@ func main() {
 done:
 	// Flush remaining
 	if len(batch) > 0 {
-		n, err := osClient.BulkIndex(ctx, batch)
+		n, err := target.BulkIndex(ctx, batch)
 		if err != nil {
 			log.Printf("ERROR: Final bulk index failed: %v", err)
 			errors += len(batch)
@@ -254,6 +327,24 @@ done:
 			indexed += n
 		}
 	}
+	run.Set("indexed", int64(indexed))
+	run.Set("errors", int64(errors))
+	runErr := rows.Err()
+	if *recreate && runErr == nil && ctx.Err() == nil {
+		th := opensearch.Thresholds{MinDocs: 1, MinDocRatio: *minDocRatio}
+		if err := osClient.Promote(ctx, target.Index(), th, *keep); err != nil {
+			log.Printf("ERROR: %s not promoted: %v", target.Index(), err)
+			runErr = fmt.Errorf("promote %s: %w", target.Index(), err)
+		}
+	}
+	if *recreate && (runErr != nil || ctx.Err() != nil) {
+		if err := osClient.ResetWriteAlias(context.Background()); err != nil {
+			log.Printf("WARN: Reset write alias: %v", err)
+		}
+	}
+	if err := run.Finish(runErr); err != nil {
+		log.Printf("WARN: Save job status: %v", err)
+	}
 
 	elapsed := time.Since(startTime)
 	log.Printf("=== Indexing Complete ===")
@@ -263,9 +354,10 @@ done:
 	log.Printf("Rate:     %.0f docs/sec", float64(indexed)/elapsed.Seconds())
 }
 
-func getEnvOrDefault(key, def string) string {
-	if v := os.Getenv(key); v != "" {
-		return v
+// parseSince parses a --since value: a date or an RFC 3339 time.
+func parseSince(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t, nil
 	}
-	return def
+	return time.Parse(time.RFC3339, s)
 }
@@ -0,0 +1,162 @@
+// OpenSearch admin: Compares the live index's mapping with the one the
+// code expects (opensearch.IndexMapping) and migrates it.
+//
+// Usage:
+//
+//	go run ./cmd/osadmin mapping              # Show how the live mapping differs
+//	go run ./cmd/osadmin migrate              # Add missing fields in place
+//	go run ./cmd/osadmin migrate --reindex    # Reindex into a new version if the change is breaking
+//	go run ./cmd/osadmin versions             # List the index versions
+//
+// Adding fields is applied to the live index and records the new mapping
+// version in its _meta. A breaking change (a field mapped differently, or
+// a version marked breaking in opensearch.MappingHistory) needs --reindex:
+// the live index is copied with _reindex into a new version created with
+// the current mapping, which is promoted once it holds --min-doc-ratio of
+// the live index's documents. `mapping` exits with status 1 if the index
+// needs migrating, so it can gate a deploy.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/pkg/opensearch"
+)
+
+func main() {
+	cfg := config.Load()
+
+	if len(os.Args) < 2 || strings.HasPrefix(os.Args[1], "-") {
+		fmt.Fprintln(os.Stderr, "usage: osadmin <mapping|migrate|versions> [flags]")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+
+	osURL := flag.String("opensearch", cfg.OpenSearch.Endpoint, "OpenSearch endpoint URL")
+	osIndex := flag.String("index", cfg.OpenSearch.Index, "OpenSearch index name")
+	osUser := flag.String("os-user", cfg.OpenSearch.Username, "OpenSearch username")
+	osPass := flag.String("os-pass", cfg.OpenSearch.Password, "OpenSearch password")
+	reindex := flag.Bool("reindex", false, "migrate: reindex into a new version if the change is breaking")
+	keep := flag.Int("keep", 2, "migrate: older index versions to keep after a reindex")
+	minDocRatio := flag.Float64("min-doc-ratio", 0.99, "migrate: share of the live index's docs the reindexed version must have")
+	flag.CommandLine.Parse(os.Args[2:])
+
+	if *osURL == "" {
+		log.Fatal("OpenSearch URL is required (--opensearch or OPENSEARCH_URL)")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("\nShutting down...")
+		cancel()
+	}()
+
+	osConfig := cfg.OpenSearch.ClientConfig()
+	osConfig.Endpoint, osConfig.Index = *osURL, *osIndex
+	osConfig.Username, osConfig.Password = *osUser, *osPass
+	osClient := opensearch.NewClient(osConfig)
+	if err := osClient.Ping(ctx); err != nil {
+		log.Fatalf("Failed to connect to OpenSearch: %v", err)
+	}
+
+	switch command {
+	case "mapping":
+		diff, err := osClient.CheckMapping(ctx)
+		if err != nil {
+			log.Fatalf("Failed to check mapping: %v", err)
+		}
+		printDiff(diff)
+		if !diff.Current() {
+			os.Exit(1)
+		}
+
+	case "migrate":
+		diff, err := osClient.CheckMapping(ctx)
+		if err != nil {
+			log.Fatalf("Failed to check mapping: %v", err)
+		}
+		printDiff(diff)
+		switch {
+		case diff.Current():
+			return
+		case !diff.Breaking():
+			if _, err := osClient.ApplyMapping(ctx); err != nil {
+				log.Fatalf("Failed to migrate mapping: %v", err)
+			}
+			log.Printf("Mapping of %s is at version %d", diff.Index, diff.Version)
+		case !*reindex:
+			log.Fatal("The change is breaking; run again with --reindex to copy the index into a new version")
+		default:
+			index, err := osClient.Reindex(ctx, opensearch.Thresholds{MinDocRatio: *minDocRatio}, *keep)
+			if err != nil {
+				log.Fatalf("Reindex failed: %v", err)
+			}
+			log.Printf("%s is live as %s with mapping version %d", index, *osIndex, diff.Version)
+		}
+
+	case "versions":
+		versions, legacy, err := osClient.Versions(ctx)
+		if err != nil {
+			log.Fatalf("Failed to list versions: %v", err)
+		}
+		if legacy {
+			fmt.Printf("%s (unversioned index)\n", *osIndex)
+		}
+		for _, v := range versions {
+			var flags []string
+			if v.Live {
+				flags = append(flags, "live")
+			}
+			if v.Writing {
+				flags = append(flags, "writing")
+			}
+			fmt.Printf("%-20s %10d docs  %s  %s\n", v.Name, v.Docs, v.CreatedAt.Format("2006-01-02 15:04"), strings.Join(flags, ","))
+		}
+
+	default:
+		log.Fatalf("Unknown command %q (want mapping, migrate or versions)", command)
+	}
+}
+
+// printDiff prints how the live mapping differs from the expected one.
+func printDiff(diff *opensearch.MappingDiff) {
+	fmt.Printf("Index %s: mapping version %d, code expects %d\n", diff.Index, diff.LiveVersion, diff.Version)
+	for _, m := range opensearch.MappingHistory {
+		if diff.LiveVersion > 0 && m.Version > diff.LiveVersion {
+			breaking := ""
+			if m.Breaking {
+				breaking = " (breaking)"
+			}
+			fmt.Printf("  v%d: %s%s\n", m.Version, m.Summary, breaking)
+		}
+	}
+	for _, field := range diff.Added {
+		fmt.Printf("  + %s\n", field)
+	}
+	for _, c := range diff.Changed {
+		fmt.Printf("  ~ %s: %s → %s\n", c.Field, c.Live, c.Want)
+	}
+	for _, field := range diff.Extra {
+		fmt.Printf("  ? %s (not in the mapping)\n", field)
+	}
+	switch {
+	case diff.Current():
+		fmt.Println("Up to date")
+	case diff.Breaking():
+		fmt.Println("Breaking: needs a reindex (osadmin migrate --reindex)")
+	default:
+		fmt.Println("Additive: can be applied in place (osadmin migrate)")
+	}
+}
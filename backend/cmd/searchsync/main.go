@@ -0,0 +1,113 @@
+// Search sync: Keeps OpenSearch in step with PostgreSQL by draining the
+// search outbox, which a trigger fills with every change to a paper's
+// searchable columns (whichever importer or API request made it).
+//
+// Usage:
+//
+//	go run ./cmd/searchsync                # Sync continuously
+//	go run ./cmd/searchsync --once         # Sync what is pending, then exit
+//	go run ./cmd/searchsync --retry-dead   # Requeue dead-lettered changes first
+//
+// Documents OpenSearch rejects are retried with backoff and dead-lettered
+// after --max-attempts; GET /api/v1/admin/search/sync shows the backlog and
+// how far the index trails. The API server runs the same sync in-process
+// when SEARCH_SYNC=true. The trigger only records changes once a syncer has
+// run against the database; the first run queues every paper. cmd/index is
+// still the way to build an index from scratch.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/pkg/opensearch"
+	"github.com/paper-app/backend/pkg/searchsync"
+)
+
+func main() {
+	cfg := config.Load()
+
+	dbURL := flag.String("db", cfg.Database.URL, "PostgreSQL connection URL")
+	osURL := flag.String("opensearch", cfg.OpenSearch.Endpoint, "OpenSearch endpoint URL")
+	osIndex := flag.String("index", cfg.OpenSearch.Index, "OpenSearch index name")
+	osUser := flag.String("os-user", cfg.OpenSearch.Username, "OpenSearch username")
+	osPass := flag.String("os-pass", cfg.OpenSearch.Password, "OpenSearch password")
+	batchSize := flag.Int("batch", 500, "Outbox rows per bulk request")
+	maxAttempts := flag.Int("max-attempts", 8, "Failures before a change is dead-lettered")
+	poll := flag.Duration("poll", 2*time.Second, "How often to check the outbox once it is drained")
+	once := flag.Bool("once", false, "Sync the pending changes and exit")
+	retryDead := flag.Bool("retry-dead", false, "Requeue dead-lettered changes before syncing")
+	flag.Parse()
+
+	if *osURL == "" {
+		log.Fatal("OpenSearch URL is required (--opensearch or OPENSEARCH_URL)")
+	}
+
+	log.Println("=== Search Sync: PostgreSQL → OpenSearch ===")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, *dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to PostgreSQL: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(ctx); err != nil {
+		log.Fatalf("Failed to ping PostgreSQL: %v", err)
+	}
+	log.Println("Connected to PostgreSQL")
+
+	osConfig := cfg.OpenSearch.ClientConfig()
+	osConfig.Endpoint, osConfig.Index = *osURL, *osIndex
+	osConfig.Username, osConfig.Password = *osUser, *osPass
+	osClient := opensearch.NewClient(osConfig)
+	if err := osClient.Ping(ctx); err != nil {
+		log.Fatalf("Failed to connect to OpenSearch: %v", err)
+	}
+	log.Println("Connected to OpenSearch")
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("\nShutting down...")
+		cancel()
+	}()
+
+	syncer := searchsync.New(pool, osClient)
+	syncer.BatchSize = *batchSize
+	syncer.MaxAttempts = *maxAttempts
+	syncer.PollInterval = *poll
+
+	if *retryDead {
+		n, err := syncer.RequeueDead(ctx)
+		if err != nil {
+			log.Fatalf("Failed to requeue dead-lettered changes: %v", err)
+		}
+		log.Printf("Requeued %d dead-lettered changes", n)
+	}
+
+	if !*once {
+		syncer.Run(ctx)
+		log.Println("=== Search Sync Stopped ===")
+		return
+	}
+
+	start := time.Now()
+	n, err := syncer.Drain(ctx)
+	if err != nil {
+		log.Fatalf("Sync failed after %d changes: %v", n, err)
+	}
+	log.Printf("Synced %d changes in %s", n, time.Since(start).Round(time.Millisecond))
+	if st, err := syncer.Status(ctx); err == nil {
+		log.Printf("Pending: %d (%d retrying) | Dead: %d | Lag: %.0fs", st.Pending, st.Retrying, st.Dead, st.LagSeconds)
+	}
+}
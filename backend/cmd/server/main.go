@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -15,10 +16,19 @@ import (
 
 	"github.com/paper-app/backend/internal/config"
 	delivery "github.com/paper-app/backend/internal/delivery/http"
+	"github.com/paper-app/backend/internal/domain"
 	"github.com/paper-app/backend/internal/middleware"
 	"github.com/paper-app/backend/internal/repository/postgres"
+	"github.com/paper-app/backend/internal/search"
 	"github.com/paper-app/backend/internal/usecase"
+	"github.com/paper-app/backend/pkg/blobstore"
+	"github.com/paper-app/backend/pkg/ingestjob"
 	"github.com/paper-app/backend/pkg/opensearch"
+	"github.com/paper-app/backend/pkg/pdfcache"
+	"github.com/paper-app/backend/pkg/ratelimit"
+	"github.com/paper-app/backend/pkg/scheduler"
+	"github.com/paper-app/backend/pkg/searchsync"
+	"github.com/paper-app/backend/pkg/sources"
 )
 
 func main() {
@@ -70,36 +80,101 @@ func main() {
 	userPaperRepo := postgres.NewUserPaperRepository(pool)
 	tokenRepo := postgres.NewRefreshTokenRepository(pool)
 	loginEventRepo := postgres.NewLoginEventRepository(pool)
+	digestRepo := postgres.NewDigestRepository(pool)
+	fullTextRepo := postgres.NewFullTextRepository(pool)
+	versionRepo := postgres.NewPaperVersionRepository(pool)
+	notificationRepo := postgres.NewNotificationRepository(pool)
+	identifierRepo := postgres.NewIdentifierRepository(pool)
 
 	// Initialize OpenSearch client (optional)
 	var osClient *opensearch.Client
+	var mappingPending bool // the mapping check waits for the cluster
 	if cfg.OpenSearch.Enabled {
-		osClient = opensearch.NewClient(opensearch.Config{
-			Endpoint: strings.TrimRight(cfg.OpenSearch.Endpoint, "/"),
-			Index:    cfg.OpenSearch.Index,
-			Username: cfg.OpenSearch.Username,
-			Password: cfg.OpenSearch.Password,
-		})
+		osClient = opensearch.NewClient(cfg.OpenSearch.ClientConfig())
 
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		if err := osClient.Ping(ctx); err != nil {
-			log.Printf("WARNING: OpenSearch not reachable (%v) — falling back to PostgreSQL search", err)
-			osClient = nil
+			if cfg.OpenSearch.MappingCheck == "strict" {
+				log.Fatalf("OpenSearch not reachable (%v); its mapping must be checked with OPENSEARCH_MAPPING_CHECK=strict", err)
+			}
+			// Keep the client: failed searches fall back to PostgreSQL, the
+			// circuit breaker stops trying the cluster until a probe gets
+			// through, and health checks bring nodes back. The in-process
+			// syncer waits for the mapping check (see awaitMapping).
+			log.Printf("WARNING: OpenSearch not reachable (%v) — searches fall back to PostgreSQL until it is", err)
+			mappingPending = cfg.OpenSearch.MappingCheck != "off"
 		} else {
 			log.Printf("Connected to OpenSearch at %s (index: %s)", cfg.OpenSearch.Endpoint, cfg.OpenSearch.Index)
+			checkMapping(ctx, osClient, cfg.OpenSearch.MappingCheck)
 		}
 		cancel()
-	} else {
-		log.Println("OpenSearch not configured — using PostgreSQL for search")
+	}
+
+	// Pick the search backend (SEARCH_BACKEND)
+	var searcher domain.PaperSearcher
+	var syncTarget searchsync.Target
+	var localIndex *search.Local
+	switch cfg.Search.Backend {
+	case "opensearch":
+		if osClient != nil {
+			searcher, syncTarget = search.NewOpenSearch(osClient), osClient
+		}
+	case "local":
+		var err error
+		if localIndex, err = search.OpenLocal(cfg.Search.LocalDir); err != nil {
+			log.Fatalf("Failed to open local search index: %v", err)
+		}
+		defer localIndex.Close()
+		log.Printf("Using local search index in %s (%d papers)", cfg.Search.LocalDir, localIndex.Len())
+		searcher, syncTarget = localIndex, localIndex
+	case "postgres":
+	default:
+		log.Fatalf("Invalid SEARCH_BACKEND %q: want opensearch, postgres or local", cfg.Search.Backend)
+	}
+	if searcher == nil {
+		log.Println("Using PostgreSQL for search")
+		searcher = postgres.NewPaperSearcher(pool)
 	}
 
 	// Initialize usecases
 	authUsecase := usecase.NewAuthUsecase(userRepo, tokenRepo, &cfg.JWT, &cfg.Google)
-	paperUsecase := usecase.NewPaperUsecase(paperRepo, osClient)
+	registry, err := sources.FromConfig(&sources.Config{
+		Enabled:        cfg.Sources.Enabled,
+		OpenAlexMailto: cfg.Sources.OpenAlexMailto,
+		S2APIKey:       cfg.Sources.S2APIKey,
+		NCBIAPIKey:     cfg.Sources.NCBIAPIKey,
+		NCBIEmail:      cfg.Sources.NCBIEmail,
+		OAIBaseURL:     cfg.Sources.OAIBaseURL,
+		OAISet:         cfg.Sources.OAISet,
+	}, nil)
+	if err != nil {
+		log.Fatalf("Invalid SOURCES: %v", err)
+	}
+	federated := usecase.NewFederatedSearch(registry.Select(cfg.Federated.Sources, sources.CanSearch), cfg.Federated.Timeout)
+	if len(federated.Sources()) > 0 {
+		log.Printf("Federated search sources: %s", strings.Join(federated.Sources(), ", "))
+	} else {
+		federated = nil
+	}
+	dedupeUsecase := usecase.NewDedupeUsecase(paperRepo, identifierRepo)
+	paperUsecase := usecase.NewPaperUsecase(paperRepo, versionRepo, searcher, federated, dedupeUsecase)
 	libraryUsecase := usecase.NewLibraryUsecase(userPaperRepo, paperRepo)
+	// The server only manages digest settings; emails are sent by cmd/digest.
+	digestUsecase := usecase.NewDigestUsecase(digestRepo, userPaperRepo, paperRepo, nil, cfg.SMTP.From, &cfg.Digest)
+	pdfUsecase := usecase.NewPDFUsecase(paperUsecase, newPDFCache(&cfg.PDFCache))
+	blobs, err := blobstore.New(cfg.Upload.BlobDir)
+	if err != nil {
+		log.Fatalf("Failed to open blob store: %v", err)
+	}
+	uploadUsecase := usecase.NewUploadUsecase(paperRepo, fullTextRepo, blobs, cfg.Upload.MaxSize, cfg.Upload.ExtractTimeout)
+	notificationUsecase := usecase.NewNotificationUsecase(notificationRepo)
+	jobUsecase := usecase.NewJobUsecase(ingestjob.NewStore(pool))
+	scheduleUsecase := usecase.NewScheduleUsecase(scheduler.NewStore(pool))
+	syncer := searchsync.New(pool, syncTarget)
+	searchSyncUsecase := usecase.NewSearchSyncUsecase(syncer)
 
 	// Initialize HTTP handler and middleware
-	handler := delivery.NewHandler(authUsecase, paperUsecase, libraryUsecase, userRepo, loginEventRepo)
+	handler := delivery.NewHandler(authUsecase, paperUsecase, libraryUsecase, digestUsecase, pdfUsecase, uploadUsecase, notificationUsecase, jobUsecase, scheduleUsecase, searchSyncUsecase, userRepo, loginEventRepo)
 	authMiddleware := middleware.NewAuthMiddleware(authUsecase)
 
 	// Create router
@@ -126,6 +201,52 @@ func main() {
 		}()
 	}
 
+	// Run queued and scheduled jobs and the search sync in-process if configured
+	workerCtx, stopWorker := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	if cfg.Jobs.Workers > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			ingestjob.NewWorker(pool, cfg.Jobs.Workers, cfg.Jobs.BinDir).Run(workerCtx)
+		}()
+	}
+	if cfg.Jobs.Scheduler {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			scheduler.New(pool).Run(workerCtx)
+		}()
+	}
+	switch {
+	case localIndex != nil:
+		// Only this process can write the local index, so it always syncs
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			if localIndex.Len() == 0 {
+				n, err := syncer.EnqueueAll(workerCtx)
+				if err != nil {
+					log.Printf("WARNING: cannot queue papers for the empty local index: %v", err)
+				} else {
+					log.Printf("Local search index is empty: queued %d papers", n)
+				}
+			}
+			syncer.Run(workerCtx)
+		}()
+	case cfg.OpenSearch.Sync && syncTarget != nil:
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			// Documents written before the mapping is checked could
+			// create fields with the wrong types
+			if mappingPending && !awaitMapping(workerCtx, osClient, cfg.OpenSearch.MappingCheck) {
+				return
+			}
+			syncer.Run(workerCtx)
+		}()
+	}
+
 	// Start server in goroutine
 	go func() {
 		log.Printf("Server starting on port %s", cfg.Server.Port)
@@ -148,5 +269,106 @@ func main() {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
 
+	// Running jobs end cancelled and can be retried
+	stopWorker()
+	workers.Wait()
+
 	log.Println("Server stopped gracefully")
 }
+
+// newPDFCache builds the PDF proxy cache. Returns nil (PDF requests redirect
+// upstream) if the cache storage can't be opened.
+func newPDFCache(cfg *config.PDFCacheConfig) *pdfcache.Cache {
+	var backend pdfcache.Backend
+	if cfg.S3Bucket != "" {
+		backend = pdfcache.NewS3Backend(pdfcache.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Bucket:    cfg.S3Bucket,
+			Prefix:    cfg.S3Prefix,
+			Region:    cfg.S3Region,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+		})
+	} else {
+		dir, err := pdfcache.NewDirBackend(cfg.Dir)
+		if err != nil {
+			log.Printf("WARNING: PDF cache disabled (%v)", err)
+			return nil
+		}
+		backend = dir
+	}
+
+	limiter := ratelimit.NewHostLimiter(0)
+	limiter.SetInterval("arxiv.org", time.Second)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	cache, err := pdfcache.New(ctx, backend, pdfcache.Options{
+		MaxBytes:        cfg.MaxBytes,
+		MaxFileSize:     cfg.MaxFileSize,
+		RevalidateAfter: cfg.RevalidateAfter,
+		UserAgent:       "PaperApp/1.0 (academic-reader)",
+		Limiter:         limiter,
+	})
+	if err != nil {
+		log.Printf("WARNING: PDF cache disabled (%v)", err)
+		return nil
+	}
+	stats := cache.Stats()
+	log.Printf("PDF cache ready (%d files, %d MB of %d MB)", stats.Entries, stats.Bytes>>20, cfg.MaxBytes>>20)
+	return cache
+}
+
+// mappingRetryInterval spaces awaitMapping's pings.
+const mappingRetryInterval = 15 * time.Second
+
+// awaitMapping pings the cluster until it answers, then checks its mapping.
+// It returns false if ctx is done first.
+func awaitMapping(ctx context.Context, client *opensearch.Client, mode string) bool {
+	ticker := time.NewTicker(mappingRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		if err := client.Ping(pingCtx); err == nil {
+			log.Printf("OpenSearch is reachable; checking its mapping before syncing")
+			checkMapping(pingCtx, client, mode)
+			cancel()
+			return true
+		}
+		cancel()
+	}
+}
+
+// checkMapping warns, or with mode "strict" exits, when the live index's
+// mapping is behind the one the code expects or can't be read.
+func checkMapping(ctx context.Context, client *opensearch.Client, mode string) {
+	if mode == "off" {
+		return
+	}
+	diff, err := client.CheckMapping(ctx)
+	if err != nil {
+		if mode == "strict" {
+			log.Fatalf("Cannot check OpenSearch mapping: %v", err)
+		}
+		log.Printf("WARNING: cannot check OpenSearch mapping: %v", err)
+		return
+	}
+	if diff.Current() {
+		return
+	}
+	how := "run osadmin migrate"
+	if diff.Breaking() {
+		how = "run osadmin migrate --reindex"
+	}
+	msg := fmt.Sprintf("OpenSearch index %s has mapping version %d, want %d (%d fields missing, %d changed); %s",
+		diff.Index, diff.LiveVersion, diff.Version, len(diff.Added), len(diff.Changed), how)
+	if mode == "strict" {
+		log.Fatalf("%s", msg)
+	}
+	log.Printf("WARNING: %s", msg)
+}
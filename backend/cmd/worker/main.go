@@ -0,0 +1,88 @@
+// Worker: Runs the importer jobs queued through the admin API
+// (POST /api/v1/admin/jobs) and on schedules (/api/v1/admin/schedules),
+// outside the API server.
+//
+// Usage:
+//
+//	go run ./cmd/worker                                  # 2 jobs at a time, `go run` each importer
+//	go run ./cmd/worker --concurrency=4 --bin-dir=/app   # Use the built importer binaries
+//	go run ./cmd/worker --scheduler=false                # Only run jobs; another process schedules
+//
+// The worker polls the jobs table for pending jobs and runs each job's
+// importer with the job's params as flags, storing its output in job_logs.
+// The importer reports its progress into the same job. Cancel requests stop
+// the importer with SIGTERM, so it saves its cursor and the job can be
+// retried where it stopped. The API server runs the same worker in-process
+// when JOB_WORKERS > 0; run this instead to keep imports off the API hosts.
+//
+// The scheduler queues the steps of due schedules one after the other.
+// Schedulers in several processes take turns under an advisory lock, so
+// each due time runs once.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/pkg/ingestjob"
+	"github.com/paper-app/backend/pkg/scheduler"
+)
+
+func main() {
+	cfg := config.Load()
+
+	dbURL := flag.String("db", cfg.Database.URL, "PostgreSQL connection URL")
+	concurrency := flag.Int("concurrency", max(cfg.Jobs.Workers, 2), "Jobs to run at once")
+	binDir := flag.String("bin-dir", cfg.Jobs.BinDir, "Directory with the importer binaries; empty = `go run ./cmd/<name>` from the working directory")
+	poll := flag.Duration("poll", 5*time.Second, "How often to check for pending jobs")
+	schedule := flag.Bool("scheduler", true, "Also queue the jobs of due schedules")
+	flag.Parse()
+
+	log.Println("=== Job Worker ===")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, *dbURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(ctx); err != nil {
+		log.Fatalf("Failed to ping database: %v", err)
+	}
+	log.Println("Connected to PostgreSQL")
+
+	// Handle graceful shutdown: stop the running importers; their jobs end
+	// cancelled and can be retried
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		log.Println("\nReceived shutdown signal, stopping running jobs...")
+		cancel()
+	}()
+
+	var wg sync.WaitGroup
+	if *schedule {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scheduler.New(pool).Run(ctx)
+		}()
+	}
+	worker := ingestjob.NewWorker(pool, *concurrency, *binDir)
+	worker.PollInterval = *poll
+	worker.Run(ctx)
+	wg.Wait()
+
+	log.Println("=== Job Worker Stopped ===")
+}
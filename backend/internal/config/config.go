@@ -14,6 +14,14 @@ type Config struct {
 	Google     GoogleConfig
 	CORS       CORSConfig
 	OpenSearch OpenSearchConfig
+	Search     SearchConfig
+	SMTP       SMTPConfig
+	Digest     DigestConfig
+	PDFCache   PDFCacheConfig
+	Upload     UploadConfig
+	Sources    SourcesConfig
+	Federated  FederatedConfig
+	Jobs       JobsConfig
 }
 
 type ServerConfig struct {
@@ -43,15 +51,108 @@ type CORSConfig struct {
 }
 
 type OpenSearchConfig struct {
-	Endpoint string // OpenSearch cluster URL (e.g. https://search-xxx.us-east-1.es.amazonaws.com)
+	Endpoint string // OpenSearch cluster URL (e.g. https://search-xxx.us-east-1.es.amazonaws.com); comma-separated for several nodes
 	Index    string // Index name (default: "papers")
 	Username string // For fine-grained access control
 	Password string
-	Enabled  bool // Whether to use OpenSearch for search (falls back to PG if false)
+	Enabled  bool // Whether to use OpenSearch for search: set, and SEARCH_BACKEND is opensearch
+	Sync     bool // Drain the search outbox into the index (see pkg/searchsync); cmd/searchsync does the same
+	// AWS SigV4 signing for Amazon OpenSearch Service, used instead of
+	// basic auth when AWSRegion is set
+	AWSRegion       string
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string
+	// Timeout of each request attempt, retries of reads on unavailable
+	// nodes, and the circuit breaker that sends searches straight to the
+	// PostgreSQL fallback (see opensearch.Config)
+	Timeout          time.Duration
+	Retries          int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	// MappingCheck is what the server does when the live index's mapping is
+	// behind opensearch.IndexMapping or can't be checked: "warn" (default),
+	// "strict" to refuse to start, or "off". cmd/osadmin migrates it.
+	MappingCheck string
+}
+
+// SearchConfig picks the domain.PaperSearcher the server searches with.
+type SearchConfig struct {
+	// Backend is "opensearch" (default when OPENSEARCH_URL is set),
+	// "postgres" (PG full-text search, the default otherwise) or "local",
+	// an index in LocalDir that the server keeps in sync itself.
+	Backend  string
+	LocalDir string
+}
+
+type SMTPConfig struct {
+	Host     string // e.g. "localhost" for MailHog
+	Port     string // e.g. "1025" for MailHog, "587" for most relays
+	Username string // empty disables SMTP AUTH
+	Password string
+	From     string // e.g. "Paper <digest@example.com>"
+	StartTLS bool
+}
+
+type DigestConfig struct {
+	AppURL    string // Frontend base URL used for paper links
+	APIURL    string // Public backend base URL used for unsubscribe links
+	MaxPapers int    // Max papers per digest email
+}
+
+type PDFCacheConfig struct {
+	Dir             string        // Local cache directory (used unless S3Bucket is set)
+	MaxBytes        int64         // Total cache size before LRU eviction
+	MaxFileSize     int64         // PDFs larger than this are redirected, not cached
+	RevalidateAfter time.Duration // Age after which cached PDFs are revalidated upstream
+	S3Endpoint      string        // S3-compatible endpoint, e.g. http://localhost:9000 for MinIO
+	S3Bucket        string
+	S3Prefix        string
+	S3Region        string
+	S3AccessKey     string
+	S3SecretKey     string
+}
+
+type UploadConfig struct {
+	BlobDir string // Blob store shared with cmd/fulltext
+	MaxSize int64  // Largest accepted upload
+	// ExtractTimeout bounds text extraction from an upload; past it the
+	// PDF is stored without text
+	ExtractTimeout time.Duration
+}
+
+// SourcesConfig configures the external paper APIs (pkg/sources).
+type SourcesConfig struct {
+	Enabled        []string // Registered sources; see sources.All
+	OpenAlexMailto string   // Joins OpenAlex's polite pool
+	S2APIKey       string   // Optional, raises Semantic Scholar rate limits
+	NCBIAPIKey     string   // Optional, raises PubMed E-utilities from 3 to 10 req/s
+	NCBIEmail      string   // Contact address sent to NCBI with each request
+	OAIBaseURL     string   // OAI-PMH endpoint; empty = arXiv's
+	OAISet         string   // OAI-PMH set to list, e.g. "cs"; empty = all
+}
+
+type FederatedConfig struct {
+	Sources []string      // Sources queried on ?federated=true, in priority order
+	Timeout time.Duration // Per-source timeout
+}
+
+// JobsConfig configures the in-process worker that runs jobs queued through
+// the admin API (see pkg/ingestjob) and the scheduler that queues them on
+// cron schedules (pkg/scheduler). Both can run in cmd/worker instead.
+type JobsConfig struct {
+	Workers   int    // Jobs run at once; 0 = don't run jobs in the server
+	BinDir    string // Importer binaries; empty = `go run ./cmd/<name>`
+	Scheduler bool   // Queue scheduled jobs; safe in several processes
 }
 
 func Load() *Config {
 	osEndpoint := getEnv("OPENSEARCH_URL", "")
+	searchBackend := "postgres"
+	if osEndpoint != "" {
+		searchBackend = "opensearch"
+	}
+	searchBackend = getEnv("SEARCH_BACKEND", searchBackend)
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnvMulti([]string{"PORT", "SERVER_PORT"}, "8080"),
@@ -79,7 +180,73 @@ func Load() *Config {
 			Index:    getEnv("OPENSEARCH_INDEX", "papers"),
 			Username: getEnv("OPENSEARCH_USER", ""),
 			Password: getEnv("OPENSEARCH_PASS", ""),
-			Enabled:  osEndpoint != "",
+			Enabled:  osEndpoint != "" && searchBackend == "opensearch",
+			Sync:     getEnv("SEARCH_SYNC", "false") == "true",
+
+			AWSRegion:       getEnv("OPENSEARCH_AWS_REGION", ""),
+			AWSAccessKey:    getEnvMulti([]string{"OPENSEARCH_AWS_ACCESS_KEY", "AWS_ACCESS_KEY_ID"}, ""),
+			AWSSecretKey:    getEnvMulti([]string{"OPENSEARCH_AWS_SECRET_KEY", "AWS_SECRET_ACCESS_KEY"}, ""),
+			AWSSessionToken: getEnv("AWS_SESSION_TOKEN", ""),
+
+			Timeout:          getDurationEnv("OPENSEARCH_TIMEOUT", 30*time.Second),
+			Retries:          getIntEnv("OPENSEARCH_RETRIES", 2),
+			BreakerThreshold: getIntEnv("OPENSEARCH_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  getDurationEnv("OPENSEARCH_BREAKER_COOLDOWN", 30*time.Second),
+
+			MappingCheck: getEnv("OPENSEARCH_MAPPING_CHECK", "warn"),
+		},
+		Search: SearchConfig{
+			Backend:  searchBackend,
+			LocalDir: getEnv("SEARCH_LOCAL_DIR", "./data/search"),
+		},
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", "localhost"),
+			Port:     getEnv("SMTP_PORT", "1025"),
+			Username: getEnv("SMTP_USER", ""),
+			Password: getEnv("SMTP_PASS", ""),
+			From:     getEnv("SMTP_FROM", "Paper <digest@localhost>"),
+			StartTLS: getEnv("SMTP_STARTTLS", "false") == "true",
+		},
+		Digest: DigestConfig{
+			AppURL:    strings.TrimRight(getEnv("APP_URL", "http://localhost:5173"), "/"),
+			APIURL:    strings.TrimRight(getEnv("API_URL", "http://localhost:8080"), "/"),
+			MaxPapers: getIntEnv("DIGEST_MAX_PAPERS", 20),
+		},
+		PDFCache: PDFCacheConfig{
+			Dir:             getEnv("PDF_CACHE_DIR", "./data/pdfcache"),
+			MaxBytes:        int64(getIntEnv("PDF_CACHE_MAX_MB", 10240)) << 20,
+			MaxFileSize:     int64(getIntEnv("PDF_CACHE_MAX_FILE_MB", 50)) << 20,
+			RevalidateAfter: getDurationEnv("PDF_CACHE_REVALIDATE", 7*24*time.Hour),
+			S3Endpoint:      getEnv("PDF_CACHE_S3_ENDPOINT", "https://s3.amazonaws.com"),
+			S3Bucket:        getEnv("PDF_CACHE_S3_BUCKET", ""),
+			S3Prefix:        getEnv("PDF_CACHE_S3_PREFIX", "pdfcache/"),
+			S3Region:        getEnv("PDF_CACHE_S3_REGION", "us-east-1"),
+			S3AccessKey:     getEnv("PDF_CACHE_S3_ACCESS_KEY", ""),
+			S3SecretKey:     getEnv("PDF_CACHE_S3_SECRET_KEY", ""),
+		},
+		Upload: UploadConfig{
+			BlobDir: getEnv("BLOB_DIR", "./data/blobs"),
+			MaxSize: int64(getIntEnv("UPLOAD_MAX_MB", 50)) << 20,
+
+			ExtractTimeout: getDurationEnv("UPLOAD_EXTRACT_TIMEOUT", 30*time.Second),
+		},
+		Sources: SourcesConfig{
+			Enabled:        getSliceEnv("SOURCES", []string{"arxiv", "semanticscholar", "openalex", "pubmed", "s2", "oaipmh"}),
+			OpenAlexMailto: getEnv("OPENALEX_MAILTO", ""),
+			S2APIKey:       getEnv("S2_API_KEY", ""),
+			NCBIAPIKey:     getEnv("NCBI_API_KEY", ""),
+			NCBIEmail:      getEnv("NCBI_EMAIL", ""),
+			OAIBaseURL:     getEnv("OAI_BASE_URL", ""),
+			OAISet:         getEnv("OAI_SET", ""),
+		},
+		Federated: FederatedConfig{
+			Sources: getSliceEnv("FEDERATED_SOURCES", []string{"arxiv", "semanticscholar", "openalex", "pubmed"}),
+			Timeout: getDurationEnv("FEDERATED_TIMEOUT", 4*time.Second),
+		},
+		Jobs: JobsConfig{
+			Workers:   getIntEnv("JOB_WORKERS", 0),
+			BinDir:    getEnv("JOB_BIN_DIR", ""),
+			Scheduler: getEnv("JOB_SCHEDULER", "false") == "true",
 		},
 	}
 }
@@ -107,6 +274,15 @@ func getSliceEnv(key string, defaultValue []string) []string {
 	return defaultValue
 }
 
+func getIntEnv(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
+}
+
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if seconds, err := strconv.Atoi(value); err == nil {
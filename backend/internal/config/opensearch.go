@@ -0,0 +1,25 @@
+package config
+
+import "github.com/paper-app/backend/pkg/opensearch"
+
+// ClientConfig is the opensearch.Config for these settings, so that every
+// command talks to the cluster the same way. Commands with connection flags
+// override the endpoint, index and credentials from them.
+func (c *OpenSearchConfig) ClientConfig() opensearch.Config {
+	return opensearch.Config{
+		Endpoint: c.Endpoint,
+		Index:    c.Index,
+		Username: c.Username,
+		Password: c.Password,
+
+		AWSRegion:       c.AWSRegion,
+		AWSAccessKey:    c.AWSAccessKey,
+		AWSSecretKey:    c.AWSSecretKey,
+		AWSSessionToken: c.AWSSessionToken,
+
+		Timeout:          c.Timeout,
+		Retries:          c.Retries,
+		BreakerThreshold: c.BreakerThreshold,
+		BreakerCooldown:  c.BreakerCooldown,
+	}
+}
@@ -2,8 +2,14 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -11,23 +17,40 @@ import (
 	"github.com/paper-app/backend/internal/domain"
 	"github.com/paper-app/backend/internal/middleware"
 	"github.com/paper-app/backend/internal/usecase"
+	"github.com/paper-app/backend/pkg/ingestjob"
+	"github.com/paper-app/backend/pkg/pdfcache"
+	"github.com/paper-app/backend/pkg/scheduler"
 )
 
 type Handler struct {
-	authUsecase    *usecase.AuthUsecase
-	paperUsecase   *usecase.PaperUsecase
-	libraryUsecase *usecase.LibraryUsecase
-	userRepo       domain.UserRepository
-	loginEventRepo domain.LoginEventRepository
+	authUsecase     *usecase.AuthUsecase
+	paperUsecase    *usecase.PaperUsecase
+	libraryUsecase  *usecase.LibraryUsecase
+	digestUsecase   *usecase.DigestUsecase
+	pdfUsecase      *usecase.PDFUsecase
+	uploadUsecase   *usecase.UploadUsecase
+	notifUsecase    *usecase.NotificationUsecase
+	jobUsecase      *usecase.JobUsecase
+	scheduleUsecase *usecase.ScheduleUsecase
+	syncUsecase     *usecase.SearchSyncUsecase
+	userRepo        domain.UserRepository
+	loginEventRepo  domain.LoginEventRepository
 }
 
-func NewHandler(auth *usecase.AuthUsecase, paper *usecase.PaperUsecase, library *usecase.LibraryUsecase, userRepo domain.UserRepository, loginEventRepo domain.LoginEventRepository) *Handler {
+func NewHandler(auth *usecase.AuthUsecase, paper *usecase.PaperUsecase, library *usecase.LibraryUsecase, digest *usecase.DigestUsecase, pdf *usecase.PDFUsecase, upload *usecase.UploadUsecase, notif *usecase.NotificationUsecase, job *usecase.JobUsecase, schedule *usecase.ScheduleUsecase, searchSync *usecase.SearchSyncUsecase, userRepo domain.UserRepository, loginEventRepo domain.LoginEventRepository) *Handler {
 	return &Handler{
-		authUsecase:    auth,
-		paperUsecase:   paper,
-		libraryUsecase: library,
-		userRepo:       userRepo,
-		loginEventRepo: loginEventRepo,
+		authUsecase:     auth,
+		paperUsecase:    paper,
+		libraryUsecase:  library,
+		digestUsecase:   digest,
+		pdfUsecase:      pdf,
+		uploadUsecase:   upload,
+		notifUsecase:    notif,
+		jobUsecase:      job,
+		scheduleUsecase: schedule,
+		syncUsecase:     searchSync,
+		userRepo:        userRepo,
+		loginEventRepo:  loginEventRepo,
 	}
 }
 
@@ -236,8 +259,13 @@ func (h *Handler) SearchPapers(w http.ResponseWriter, r *http.Request) {
 	}
 
 	categories := usecase.ParseCategories(catFilter)
+	opts := usecase.SearchOptions{
+		FullText:  r.URL.Query().Get("fulltext") == "true",  // also search extracted PDF text
+		Federated: r.URL.Query().Get("federated") == "true", // top up from arXiv/PubMed/OpenAlex/S2
+		Collapse:  r.URL.Query().Get("collapse") != "false", // one hit per work unless ?collapse=false
+	}
 
-	result, err := h.paperUsecase.SearchPapers(query, source, limit, offset, sortBy, categories)
+	result, err := h.paperUsecase.SearchPapers(query, source, limit, offset, sortBy, categories, opts)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "Failed to search papers")
 		return
@@ -266,12 +294,12 @@ func (h *Handler) GetGroupedCategories(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, grouped)
 }
 
-// GetPaper returns a paper by ID. Tries OpenSearch first (corpusid), then PostgreSQL (UUID).
+// GetPaper returns a paper by ID. Tries the search index first (corpusid), then PostgreSQL (UUID).
 func (h *Handler) GetPaper(w http.ResponseWriter, r *http.Request) {
 	idStr := chi.URLParam(r, "id")
 
-	// Try OpenSearch first (primary source for S2 data)
-	doc, err := h.paperUsecase.GetPaperFromOS(idStr)
+	// Try the search index first (primary source for S2 data)
+	doc, err := h.paperUsecase.GetPaperFromIndex(idStr)
 	if err == nil && doc != nil {
 		writeJSON(w, http.StatusOK, doc)
 		return
@@ -289,7 +317,9 @@ func (h *Handler) GetPaper(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, "Failed to get paper")
 		return
 	}
-	if paper == nil {
+	// Private and workspace uploads are hidden from everyone who can't view them
+	viewer, _ := middleware.GetUserID(r.Context())
+	if paper == nil || !paper.CanView(viewer) {
 		writeError(w, http.StatusNotFound, "Paper not found")
 		return
 	}
@@ -297,6 +327,167 @@ func (h *Handler) GetPaper(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, paper)
 }
 
+// ResolvePaper finds a paper by any identifier a user might paste (?id=):
+// DOI, arXiv ID or URL, PMID, PMCID, Semantic Scholar or OpenAlex URL.
+// With ?fetch=true, a paper we don't have is fetched from the external APIs
+// and imported; that requires signing in.
+func (h *Handler) ResolvePaper(w http.ResponseWriter, r *http.Request) {
+	input := r.URL.Query().Get("id")
+	if strings.TrimSpace(input) == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	viewer, _ := middleware.GetUserID(r.Context())
+	fetch := r.URL.Query().Get("fetch") == "true"
+	if fetch && viewer == uuid.Nil {
+		writeError(w, http.StatusUnauthorized, "Sign in to import papers")
+		return
+	}
+
+	result, err := h.paperUsecase.ResolveIdentifier(input, fetch, viewer)
+	switch {
+	case err == usecase.ErrUnrecognizedIdentifier:
+		writeError(w, http.StatusBadRequest, "Unrecognized identifier")
+		return
+	case err == usecase.ErrPaperNotFound:
+		writeError(w, http.StatusNotFound, "Paper not found")
+		return
+	case err != nil:
+		writeError(w, http.StatusBadGateway, "Failed to resolve paper")
+		return
+	}
+
+	status := http.StatusOK
+	if result.Imported {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, result)
+}
+
+// GetPaperVersions lists a paper's revisions, oldest first. Accepts a PG UUID
+// or an arXiv ID.
+func (h *Handler) GetPaperVersions(w http.ResponseWriter, r *http.Request) {
+	viewer, _ := middleware.GetUserID(r.Context())
+	result, err := h.paperUsecase.GetVersions(chi.URLParam(r, "id"), viewer)
+	if err == usecase.ErrPaperNotFound {
+		writeError(w, http.StatusNotFound, "Paper not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get paper versions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GetPaperWork lists all versions of a paper's work (preprint and published
+// records). Accepts a PG UUID, an arXiv ID or an OpenSearch ID.
+func (h *Handler) GetPaperWork(w http.ResponseWriter, r *http.Request) {
+	viewer, _ := middleware.GetUserID(r.Context())
+	result, err := h.paperUsecase.GetWork(chi.URLParam(r, "id"), viewer)
+	if err == usecase.ErrPaperNotFound {
+		writeError(w, http.StatusNotFound, "Paper not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get paper work")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// GetPaperPDF streams a paper's PDF from the local cache, fetching it on a miss.
+// Falls back to redirecting to the upstream URL when the PDF can't be cached.
+func (h *Handler) GetPaperPDF(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+
+	// Uploaded PDFs are served straight from the blob store
+	viewer, _ := middleware.GetUserID(r.Context())
+	f, upload, err := h.uploadUsecase.OpenPDF(viewer, idStr)
+	if err == nil {
+		defer f.Close()
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Cache-Control", "private, no-cache")
+		http.ServeContent(w, r, "", upload.CreatedAt, f)
+		return
+	}
+	if err != usecase.ErrNotUpload {
+		if err == usecase.ErrPaperNotFound {
+			writeError(w, http.StatusNotFound, "Paper not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Failed to open PDF")
+		}
+		return
+	}
+
+	// Misses download the whole PDF before the first byte is written
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Now().Add(5 * time.Minute))
+
+	obj, pdfURL, err := h.pdfUsecase.Open(r.Context(), idStr)
+	if err != nil {
+		switch {
+		case err == usecase.ErrPaperNotFound:
+			writeError(w, http.StatusNotFound, "Paper not found")
+		case err == usecase.ErrNoPDF:
+			writeError(w, http.StatusNotFound, "Paper has no PDF")
+		case err == pdfcache.ErrUpstreamGone:
+			writeError(w, http.StatusNotFound, "PDF no longer available upstream")
+		case err == usecase.ErrPDFCacheDisabled || err == pdfcache.ErrTooLarge || err == pdfcache.ErrNotPDF:
+			// Let the browser fetch it directly (paywall page, oversized file, caching off)
+			http.Redirect(w, r, pdfURL, http.StatusFound)
+		default:
+			writeError(w, http.StatusBadGateway, "Failed to fetch PDF")
+		}
+		return
+	}
+	defer obj.Body.Close()
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	w.Header().Set("X-Cache", obj.Status)
+	if obj.Meta.ETag != "" {
+		w.Header().Set("ETag", obj.Meta.ETag)
+	}
+
+	// Seekable bodies (local directory backend) support Range requests for PDF viewers
+	if rs, ok := obj.Body.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", obj.Meta.FetchedAt, rs)
+		return
+	}
+	if obj.Meta.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(obj.Meta.Size, 10))
+	}
+	io.Copy(w, obj.Body)
+}
+
+type importPaperRequest struct {
+	ID string `json:"id"` // federated search hit ID, e.g. "arxiv:2301.00001"
+}
+
+// ImportPaper stores a federated search hit in our database and returns it.
+func (h *Handler) ImportPaper(w http.ResponseWriter, r *http.Request) {
+	var req importPaperRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	paper, err := h.paperUsecase.ImportFederated(req.ID)
+	if err == usecase.ErrPaperNotFound {
+		writeError(w, http.StatusNotFound, "Paper not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "Failed to import paper")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, paper)
+}
+
 // Library handlers
 
 func (h *Handler) GetLibrary(w http.ResponseWriter, r *http.Request) {
@@ -343,7 +534,11 @@ func (h *Handler) SaveToLibrary(w http.ResponseWriter, r *http.Request) {
 
 	userPaper, err := h.libraryUsecase.SavePaper(userID, paperID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to save paper")
+		if err == usecase.ErrPaperNotFound {
+			writeError(w, http.StatusNotFound, "Paper not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Failed to save paper")
+		}
 		return
 	}
 
@@ -457,7 +652,11 @@ func (h *Handler) BookmarkPaper(w http.ResponseWriter, r *http.Request) {
 
 	userPaper, err := h.libraryUsecase.BookmarkPaper(userID, paperID)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to bookmark paper")
+		if err == usecase.ErrPaperNotFound {
+			writeError(w, http.StatusNotFound, "Paper not found")
+		} else {
+			writeError(w, http.StatusInternalServerError, "Failed to bookmark paper")
+		}
 		return
 	}
 
@@ -518,156 +717,1066 @@ func (h *Handler) GetDiscover(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, result)
 }
 
-// Admin handlers
+// Upload handlers
 
-type adminUserResponse struct {
-	ID           string  `json:"id"`
-	Email        string  `json:"email"`
-	Name         string  `json:"name"`
-	AuthProvider string  `json:"auth_provider"`
-	IsAdmin      bool    `json:"is_admin"`
-	LastLoginAt  *string `json:"last_login_at,omitempty"`
-	CreatedAt    string  `json:"created_at"`
-	UpdatedAt    string  `json:"updated_at"`
+// splitList splits a comma- or newline-separated form field.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' || r == ';' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
 }
 
-func toAdminUser(u *domain.User) adminUserResponse {
-	resp := adminUserResponse{
-		ID:           u.ID.String(),
-		Email:        u.Email,
-		Name:         u.Name,
-		AuthProvider: u.AuthProvider,
-		IsAdmin:      u.IsAdmin,
-		CreatedAt:    u.CreatedAt.Format(time.RFC3339),
-		UpdatedAt:    u.UpdatedAt.Format(time.RFC3339),
-	}
-	if u.LastLoginAt != nil {
-		t := u.LastLoginAt.Format(time.RFC3339)
-		resp.LastLoginAt = &t
+// UploadPaper accepts a multipart form with a "file" PDF and optional title,
+// abstract, authors, published_date (YYYY-MM-DD), doi, categories and visibility.
+func (h *Handler) UploadPaper(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
-	return resp
-}
 
-func (h *Handler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	// Large PDFs on slow connections outlive the server's default read timeout
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Now().Add(5 * time.Minute))
 
-	if limit == 0 {
-		limit = 50
+	// The PDF plus room for the metadata fields; parts past 8MB spill to temp files
+	r.Body = http.MaxBytesReader(w, r.Body, h.uploadUsecase.MaxSize()+1<<20)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "File is too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "Invalid multipart form")
+		return
 	}
+	defer r.MultipartForm.RemoveAll()
 
-	users, total, err := h.userRepo.ListAll(limit, offset)
+	file, header, err := r.FormFile("file")
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to list users")
+		writeError(w, http.StatusBadRequest, "Missing file")
 		return
 	}
+	defer file.Close()
+
+	in := usecase.UploadInput{
+		Title:      r.FormValue("title"),
+		Abstract:   r.FormValue("abstract"),
+		Authors:    splitList(r.FormValue("authors")),
+		DOI:        r.FormValue("doi"),
+		Categories: splitList(r.FormValue("categories")),
+		Visibility: r.FormValue("visibility"),
+		Filename:   header.Filename,
+	}
+	if strings.TrimSpace(in.Title) == "" {
+		in.Title = strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename))
+	}
+	if d := r.FormValue("published_date"); d != "" {
+		t, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "published_date must be YYYY-MM-DD")
+			return
+		}
+		in.PublishedDate = &t
+	}
 
-	var resp []adminUserResponse
-	for _, u := range users {
-		resp = append(resp, toAdminUser(u))
+	paper, err := h.uploadUsecase.Upload(userID, file, in)
+	if err != nil {
+		switch err {
+		case usecase.ErrUploadNotPDF:
+			writeError(w, http.StatusUnsupportedMediaType, "File is not a PDF")
+		case usecase.ErrUploadTooLarge:
+			writeError(w, http.StatusRequestEntityTooLarge, "File is too large")
+		case usecase.ErrInvalidVisibility, usecase.ErrUploadNoTitle:
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to upload paper")
+		}
+		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"users":  resp,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-	})
+	writeJSON(w, http.StatusCreated, paper)
 }
 
-func (h *Handler) AdminGetStats(w http.ResponseWriter, r *http.Request) {
-	_, total, err := h.userRepo.ListAll(1, 0)
+// ListUploads returns the user's uploads and those shared with the workspace.
+// ?q= searches their metadata and extracted text.
+func (h *Handler) ListUploads(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	result, err := h.uploadUsecase.List(userID, r.URL.Query().Get("q"), limit, offset)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to get stats")
+		writeError(w, http.StatusInternalServerError, "Failed to list uploads")
 		return
 	}
 
-	stats := &domain.AdminStats{
-		TotalUsers:     total,
-		LoginsByMethod: make(map[string]int),
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
-	if h.loginEventRepo != nil {
-		now := time.Now()
-		todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		weekStart := todayStart.AddDate(0, 0, -7)
-		monthStart := todayStart.AddDate(0, -1, 0)
+	paperID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid paper ID")
+		return
+	}
 
-		if n, err := h.loginEventRepo.ActiveUsers(todayStart); err == nil {
-			stats.ActiveToday = n
-		}
-		if n, err := h.loginEventRepo.ActiveUsers(weekStart); err == nil {
-			stats.ActiveThisWeek = n
-		}
-		if n, err := h.loginEventRepo.ActiveUsers(monthStart); err == nil {
-			stats.ActiveThisMonth = n
-		}
-		if m, err := h.loginEventRepo.CountByMethod(monthStart); err == nil {
-			stats.LoginsByMethod = m
-			for _, c := range m {
-				stats.TotalLogins += c
-			}
-		}
-		if daily, err := h.loginEventRepo.DailyLoginCounts(30); err == nil {
-			stats.DailyLogins = daily
-		}
+	paper, err := h.uploadUsecase.Get(userID, paperID)
+	if err == usecase.ErrPaperNotFound {
+		writeError(w, http.StatusNotFound, "Paper not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get paper")
+		return
 	}
 
-	writeJSON(w, http.StatusOK, stats)
+	writeJSON(w, http.StatusOK, paper)
 }
 
-func (h *Handler) AdminGetActivity(w http.ResponseWriter, r *http.Request) {
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+type updateUploadRequest struct {
+	Title         *string  `json:"title"`
+	Abstract      *string  `json:"abstract"`
+	Authors       []string `json:"authors"`
+	PublishedDate *string  `json:"published_date"`
+	DOI           *string  `json:"doi"`
+	Categories    []string `json:"categories"`
+	Visibility    *string  `json:"visibility"`
+}
 
-	if limit == 0 {
-		limit = 50
+func (h *Handler) UpdateUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
 	}
 
-	if h.loginEventRepo == nil {
-		writeJSON(w, http.StatusOK, map[string]interface{}{"events": []interface{}{}, "total": 0})
+	paperID, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid paper ID")
 		return
 	}
 
-	events, total, err := h.loginEventRepo.ListRecent(limit, offset)
+	var req updateUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	upd := usecase.UploadUpdate{
+		Title:      req.Title,
+		Abstract:   req.Abstract,
+		Authors:    req.Authors,
+		DOI:        req.DOI,
+		Categories: req.Categories,
+		Visibility: req.Visibility,
+	}
+	if req.PublishedDate != nil {
+		t, err := time.Parse("2006-01-02", *req.PublishedDate)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "published_date must be YYYY-MM-DD")
+			return
+		}
+		upd.PublishedDate = &t
+	}
+
+	paper, err := h.uploadUsecase.Update(userID, paperID, upd)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to get activity")
+		switch err {
+		case usecase.ErrPaperNotFound:
+			writeError(w, http.StatusNotFound, "Paper not found")
+		case usecase.ErrNotPaperOwner:
+			writeError(w, http.StatusForbidden, err.Error())
+		case usecase.ErrInvalidVisibility, usecase.ErrUploadNoTitle:
+			writeError(w, http.StatusBadRequest, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "Failed to update paper")
+		}
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"events": events,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-	})
+	writeJSON(w, http.StatusOK, paper)
 }
 
-func (h *Handler) AdminGetUserActivity(w http.ResponseWriter, r *http.Request) {
-	userIDStr := chi.URLParam(r, "userId")
-	userID, err := uuid.Parse(userIDStr)
+func (h *Handler) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	paperID, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		writeError(w, http.StatusBadRequest, "Invalid paper ID")
 		return
 	}
 
-	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
-	if limit == 0 {
-		limit = 20
+	err = h.uploadUsecase.Delete(userID, paperID)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Paper deleted"})
+	case usecase.ErrPaperNotFound:
+		writeError(w, http.StatusNotFound, "Paper not found")
+	case usecase.ErrNotPaperOwner:
+		writeError(w, http.StatusForbidden, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to delete paper")
 	}
+}
 
-	if h.loginEventRepo == nil {
-		writeJSON(w, http.StatusOK, map[string]interface{}{"events": []interface{}{}})
+// Digest handlers
+
+func (h *Handler) GetDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	events, err := h.loginEventRepo.ListByUser(userID, limit, 0)
+	settings, err := h.digestUsecase.GetSettings(userID)
+	if err == usecase.ErrUserNotFound {
+		writeError(w, http.StatusNotFound, "User not found")
+		return
+	}
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, "Failed to get user activity")
+		writeError(w, http.StatusInternalServerError, "Failed to get digest settings")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]interface{}{
-		"events": events,
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (h *Handler) UpdateDigestSettings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req domain.DigestSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settings, err := h.digestUsecase.UpdateSettings(userID, req.Frequency)
+	if err == usecase.ErrInvalidDigestFrequency {
+		writeError(w, http.StatusBadRequest, "Frequency must be one of: off, daily, weekly")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update digest settings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// ConfirmUnsubscribeDigest serves the link in digest emails. It only asks
+// for confirmation: mail scanners and link prefetchers follow GET links, so
+// unsubscribing takes the POST its form sends.
+func (h *Handler) ConfirmUnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	token := html.EscapeString(r.URL.Query().Get("token"))
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(`<!DOCTYPE html><html><body><form method="post">` +
+		`<p>Stop receiving paper digests?</p>` +
+		`<input type="hidden" name="token" value="` + token + `">` +
+		`<button type="submit">Unsubscribe</button></form></body></html>`))
+}
+
+// UnsubscribeDigest unsubscribes the token's user, from the confirmation
+// form or by RFC 8058 one-click unsubscribe from the mail client.
+func (h *Handler) UnsubscribeDigest(w http.ResponseWriter, r *http.Request) {
+	err := h.digestUsecase.Unsubscribe(r.FormValue("token"))
+	if err == usecase.ErrInvalidUnsubscribe {
+		writeError(w, http.StatusNotFound, "Invalid or expired unsubscribe link")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to unsubscribe")
+		return
+	}
+
+	if r.FormValue("List-Unsubscribe") == "One-Click" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte("<!DOCTYPE html><html><body><p>You have been unsubscribed from paper digests.</p></body></html>"))
+}
+
+// GetFollows lists the authors and topics the user's digest follows.
+func (h *Handler) GetFollows(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	follows, err := h.digestUsecase.ListFollows(userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get follows")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, follows)
+}
+
+// AddFollow follows an author, by name, or a topic, by category code:
+// {"kind": "author"|"topic", "value": "..."}.
+func (h *Handler) AddFollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	follow, err := h.digestUsecase.Follow(userID, req.Kind, req.Value)
+	if err == usecase.ErrInvalidFollow {
+		writeError(w, http.StatusBadRequest, "Kind must be author or topic, with a name or category code as value")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to follow")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, follow)
+}
+
+func (h *Handler) DeleteFollow(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid follow ID")
+		return
+	}
+
+	err = h.digestUsecase.Unfollow(userID, id)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Unfollowed"})
+	case usecase.ErrFollowNotFound:
+		writeError(w, http.StatusNotFound, "Follow not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to unfollow")
+	}
+}
+
+// GetSavedSearches lists the searches the user's digest runs.
+func (h *Handler) GetSavedSearches(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	searches, err := h.digestUsecase.ListSavedSearches(userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get saved searches")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, searches)
+}
+
+// CreateSavedSearch saves {"name", "query", "categories"} for the digest.
+func (h *Handler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req struct {
+		Name       string   `json:"name"`
+		Query      string   `json:"query"`
+		Categories []string `json:"categories"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	search, err := h.digestUsecase.SaveSearch(userID, req.Name, req.Query, req.Categories)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusCreated, search)
+	case usecase.ErrInvalidSavedSearch:
+		writeError(w, http.StatusBadRequest, "A query of up to 500 characters is required")
+	case usecase.ErrTooManySavedSearches:
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to save search")
+	}
+}
+
+func (h *Handler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid saved search ID")
+		return
+	}
+
+	err = h.digestUsecase.DeleteSavedSearch(userID, id)
+	switch err {
+	case nil:
+		writeJSON(w, http.StatusOK, map[string]string{"message": "Saved search deleted"})
+	case usecase.ErrSavedSearchNotFound:
+		writeError(w, http.StatusNotFound, "Saved search not found")
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to delete saved search")
+	}
+}
+
+// Notification handlers
+
+// GetNotifications lists the user's notifications, newest first.
+// ?unread=true limits it to unread ones.
+func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	result, err := h.notifUsecase.List(userID, unreadOnly, limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get notifications")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+type markReadRequest struct {
+	IDs []uuid.UUID `json:"ids"` // empty = all
+}
+
+// MarkNotificationsRead marks notifications read. An empty body or empty
+// ids list marks all of them.
+func (h *Handler) MarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req markReadRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	n, err := h.notifUsecase.MarkRead(userID, req.IDs)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to update notifications")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int64{"updated": n})
+}
+
+// Admin handlers
+
+type adminUserResponse struct {
+	ID           string  `json:"id"`
+	Email        string  `json:"email"`
+	Name         string  `json:"name"`
+	AuthProvider string  `json:"auth_provider"`
+	IsAdmin      bool    `json:"is_admin"`
+	LastLoginAt  *string `json:"last_login_at,omitempty"`
+	CreatedAt    string  `json:"created_at"`
+	UpdatedAt    string  `json:"updated_at"`
+}
+
+func toAdminUser(u *domain.User) adminUserResponse {
+	resp := adminUserResponse{
+		ID:           u.ID.String(),
+		Email:        u.Email,
+		Name:         u.Name,
+		AuthProvider: u.AuthProvider,
+		IsAdmin:      u.IsAdmin,
+		CreatedAt:    u.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:    u.UpdatedAt.Format(time.RFC3339),
+	}
+	if u.LastLoginAt != nil {
+		t := u.LastLoginAt.Format(time.RFC3339)
+		resp.LastLoginAt = &t
+	}
+	return resp
+}
+
+func (h *Handler) AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit == 0 {
+		limit = 50
+	}
+
+	users, total, err := h.userRepo.ListAll(limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list users")
+		return
+	}
+
+	var resp []adminUserResponse
+	for _, u := range users {
+		resp = append(resp, toAdminUser(u))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"users":  resp,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) AdminGetStats(w http.ResponseWriter, r *http.Request) {
+	_, total, err := h.userRepo.ListAll(1, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get stats")
+		return
+	}
+
+	stats := &domain.AdminStats{
+		TotalUsers:     total,
+		LoginsByMethod: make(map[string]int),
+	}
+
+	if h.loginEventRepo != nil {
+		now := time.Now()
+		todayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		weekStart := todayStart.AddDate(0, 0, -7)
+		monthStart := todayStart.AddDate(0, -1, 0)
+
+		if n, err := h.loginEventRepo.ActiveUsers(todayStart); err == nil {
+			stats.ActiveToday = n
+		}
+		if n, err := h.loginEventRepo.ActiveUsers(weekStart); err == nil {
+			stats.ActiveThisWeek = n
+		}
+		if n, err := h.loginEventRepo.ActiveUsers(monthStart); err == nil {
+			stats.ActiveThisMonth = n
+		}
+		if m, err := h.loginEventRepo.CountByMethod(monthStart); err == nil {
+			stats.LoginsByMethod = m
+			for _, c := range m {
+				stats.TotalLogins += c
+			}
+		}
+		if daily, err := h.loginEventRepo.DailyLoginCounts(30); err == nil {
+			stats.DailyLogins = daily
+		}
+	}
+
+	stats.PDFCache = h.pdfUsecase.Stats()
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (h *Handler) AdminGetActivity(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit == 0 {
+		limit = 50
+	}
+
+	if h.loginEventRepo == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"events": []interface{}{}, "total": 0})
+		return
+	}
+
+	events, total, err := h.loginEventRepo.ListRecent(limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get activity")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+func (h *Handler) AdminGetUserActivity(w http.ResponseWriter, r *http.Request) {
+	userIDStr := chi.URLParam(r, "userId")
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit == 0 {
+		limit = 20
+	}
+
+	if h.loginEventRepo == nil {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"events": []interface{}{}})
+		return
+	}
+
+	events, err := h.loginEventRepo.ListByUser(userID, limit, 0)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to get user activity")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"events": events,
+	})
+}
+
+// Job handlers (admin)
+
+type startJobRequest struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// AdminStreamJobLogs checks for new output every jobLogPollInterval and
+// reads it jobLogBatch lines at a time.
+const (
+	jobLogPollInterval = time.Second
+	jobLogBatch        = 500
+)
+
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrJobNotFound):
+		writeError(w, http.StatusNotFound, "Job not found")
+	case errors.Is(err, usecase.ErrUnknownJobType), errors.Is(err, usecase.ErrInvalidJobParams):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrJobActive), errors.Is(err, usecase.ErrJobNotActive), errors.Is(err, usecase.ErrJobNotRetryable):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to process job request")
+	}
+}
+
+func parseJobID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "jobId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid job ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// AdminListJobs returns recent jobs with their progress, newest first.
+// ?type= and ?status= filter them.
+func (h *Handler) AdminListJobs(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	jobs, err := h.jobUsecase.List(r.URL.Query().Get("type"), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list jobs")
+		return
+	}
+	if jobs == nil {
+		jobs = []*ingestjob.Job{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":  jobs,
+		"types": h.jobUsecase.Types(),
+	})
+}
+
+// AdminStartJob queues an importer run for the job workers.
+func (h *Handler) AdminStartJob(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req startJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	job, err := h.jobUsecase.Start(req.Type, req.Params, userID)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (h *Handler) AdminGetJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobUsecase.Get(id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (h *Handler) AdminCancelJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobUsecase.Cancel(id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (h *Handler) AdminRetryJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := h.jobUsecase.Retry(id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// AdminStreamJobLogs streams a job's output as server-sent events: "log"
+// events carry a line (the event ID is the line's, so a reconnecting client
+// continues after Last-Event-ID or ?after=), "job" events the job whenever
+// its status or progress changes, and "done" ends the stream once the job
+// has finished and its output is sent.
+func (h *Handler) AdminStreamJobLogs(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseJobID(w, r)
+	if !ok {
+		return
+	}
+	job, err := h.jobUsecase.Get(id)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+
+	after, _ := strconv.ParseInt(r.URL.Query().Get("after"), 10, 64)
+	if last, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64); err == nil {
+		after = last
+	}
+
+	// The stream outlives the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	var lastJob []byte
+	sendJob := func(job interface{}) error {
+		data, _ := json.Marshal(job)
+		if string(data) == string(lastJob) {
+			return nil
+		}
+		lastJob = data
+		_, err := fmt.Fprintf(w, "event: job\ndata: %s\n\n", data)
+		return err
+	}
+
+	ticker := time.NewTicker(jobLogPollInterval)
+	defer ticker.Stop()
+	idle := 0
+	for {
+		if err := sendJob(job); err != nil {
+			return
+		}
+		finished := job.Finished()
+
+		// Drain the output before looking at the job again
+		for {
+			lines, err := h.jobUsecase.Logs(id, after, jobLogBatch)
+			if err != nil {
+				return
+			}
+			for _, l := range lines {
+				data, _ := json.Marshal(l)
+				if _, err := fmt.Fprintf(w, "id: %d\nevent: log\ndata: %s\n\n", l.ID, data); err != nil {
+					return
+				}
+				after = l.ID
+				idle = 0
+			}
+			if len(lines) < jobLogBatch {
+				break
+			}
+		}
+		if finished {
+			fmt.Fprint(w, "event: done\ndata: {}\n\n")
+			rc.Flush()
+			return
+		}
+		if idle++; idle%15 == 0 {
+			// Keeps proxies from closing a quiet stream
+			fmt.Fprint(w, ": keepalive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+		if job, err = h.jobUsecase.Get(id); err != nil {
+			return
+		}
+	}
+}
+
+// Schedule handlers (admin)
+
+type scheduleRequest struct {
+	Name    string                      `json:"name"`
+	Cron    string                      `json:"cron"`
+	Steps   []usecase.ScheduleStepInput `json:"steps"`
+	Enabled *bool                       `json:"enabled"`
+}
+
+func writeScheduleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrScheduleNotFound):
+		writeError(w, http.StatusNotFound, "Schedule not found")
+	case errors.Is(err, usecase.ErrInvalidSchedule):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, usecase.ErrScheduleNameTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, "Failed to process schedule request")
+	}
+}
+
+func parseScheduleID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(chi.URLParam(r, "scheduleId"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid schedule ID")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) AdminListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.scheduleUsecase.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to list schedules")
+		return
+	}
+	if schedules == nil {
+		schedules = []*scheduler.Schedule{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"schedules": schedules,
+	})
+}
+
+// AdminCreateSchedule adds a schedule: a cron expression (UTC) and the jobs
+// to run one after the other when it comes due.
+func (h *Handler) AdminCreateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r.Context())
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sched, err := h.scheduleUsecase.Create(req.Name, req.Cron, req.Steps, req.Enabled, userID)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sched)
+}
+
+func (h *Handler) AdminGetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseScheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	sched, err := h.scheduleUsecase.Get(id)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sched)
+}
+
+// AdminUpdateSchedule replaces a schedule's name, cron expression and
+// steps; "enabled" turns it on or off.
+func (h *Handler) AdminUpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseScheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	var req scheduleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	sched, err := h.scheduleUsecase.Update(id, req.Name, req.Cron, req.Steps, req.Enabled)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sched)
+}
+
+func (h *Handler) AdminDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseScheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.scheduleUsecase.Delete(id); err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AdminRunSchedule makes a schedule due now; the scheduler starts it at its
+// next check.
+func (h *Handler) AdminRunSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseScheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	sched, err := h.scheduleUsecase.RunNow(id)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, sched)
+}
+
+// AdminListScheduleRuns returns a schedule's run history with the jobs of
+// each run.
+func (h *Handler) AdminListScheduleRuns(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseScheduleID(w, r)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	runs, err := h.scheduleUsecase.Runs(id, limit)
+	if err != nil {
+		writeScheduleError(w, err)
+		return
+	}
+	if runs == nil {
+		runs = []*scheduler.Run{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"runs": runs,
+	})
+}
+
+// Search sync handlers (admin)
+
+// AdminSearchSyncStatus reports the search outbox: pending and
+// dead-lettered changes and lag_seconds, how far OpenSearch trails
+// PostgreSQL.
+func (h *Handler) AdminSearchSyncStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.syncUsecase.Status()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to read search sync status")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+// AdminRetrySearchSync queues the dead-lettered changes again.
+func (h *Handler) AdminRetrySearchSync(w http.ResponseWriter, r *http.Request) {
+	n, err := h.syncUsecase.RetryDead()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "Failed to requeue search sync failures")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"requeued": n,
 	})
 }
@@ -52,9 +52,22 @@ func NewRouter(handler *Handler, authMiddleware *middleware.AuthMiddleware, allo
 			r.Get("/search", handler.SearchPapers)
 			r.Get("/categories", handler.GetCategories)
 			r.Get("/categories/grouped", handler.GetGroupedCategories)
-			r.Get("/{id}", handler.GetPaper)
+
+			// Uploads are visible to their owner (or the workspace) when signed in
+			r.Group(func(r chi.Router) {
+				r.Use(authMiddleware.OptionalAuth)
+				r.Get("/resolve", handler.ResolvePaper)
+				r.Get("/{id}", handler.GetPaper)
+				r.Get("/{id}/pdf", handler.GetPaperPDF)
+				r.Get("/{id}/versions", handler.GetPaperVersions)
+				r.Get("/{id}/work", handler.GetPaperWork)
+			})
 		})
 
+		// Digest unsubscribe (public, authenticated by token)
+		r.Get("/digest/unsubscribe", handler.ConfirmUnsubscribeDigest)
+		r.Post("/digest/unsubscribe", handler.UnsubscribeDigest)
+
 
 
 
@@ -77,9 +90,35 @@ func NewRouter(handler *Handler, authMiddleware *middleware.AuthMiddleware, allo
 				r.Delete("/{paperId}", handler.UnbookmarkPaper)
 			})
 
+			// Store a federated search hit locally
+			r.Post("/papers/import", handler.ImportPaper)
+
+			// Uploaded PDFs (private or shared with the workspace)
+			r.Route("/uploads", func(r chi.Router) {
+				r.Get("/", handler.ListUploads)
+				r.Post("/", handler.UploadPaper)
+				r.Get("/{id}", handler.GetUpload)
+				r.Patch("/{id}", handler.UpdateUpload)
+				r.Delete("/{id}", handler.DeleteUpload)
+			})
+
 			// Discover route
 			r.Get("/discover", handler.GetDiscover)
 
+			// Digest settings, and the follows and saved searches digests are built from
+			r.Get("/digest/settings", handler.GetDigestSettings)
+			r.Put("/digest/settings", handler.UpdateDigestSettings)
+			r.Get("/digest/follows", handler.GetFollows)
+			r.Post("/digest/follows", handler.AddFollow)
+			r.Delete("/digest/follows/{id}", handler.DeleteFollow)
+			r.Get("/digest/saved-searches", handler.GetSavedSearches)
+			r.Post("/digest/saved-searches", handler.CreateSavedSearch)
+			r.Delete("/digest/saved-searches/{id}", handler.DeleteSavedSearch)
+
+			// Notifications (e.g. new versions of papers in the library)
+			r.Get("/notifications", handler.GetNotifications)
+			r.Post("/notifications/read", handler.MarkNotificationsRead)
+
 			// Admin routes (requires auth + admin role)
 			r.Route("/admin", func(r chi.Router) {
 				r.Use(authMiddleware.AdminOnly)
@@ -87,6 +126,25 @@ func NewRouter(handler *Handler, authMiddleware *middleware.AuthMiddleware, allo
 				r.Get("/users/{userId}/activity", handler.AdminGetUserActivity)
 				r.Get("/stats", handler.AdminGetStats)
 				r.Get("/activity", handler.AdminGetActivity)
+				r.Route("/jobs", func(r chi.Router) {
+					r.Get("/", handler.AdminListJobs)
+					r.Post("/", handler.AdminStartJob)
+					r.Get("/{jobId}", handler.AdminGetJob)
+					r.Get("/{jobId}/logs", handler.AdminStreamJobLogs)
+					r.Post("/{jobId}/cancel", handler.AdminCancelJob)
+					r.Post("/{jobId}/retry", handler.AdminRetryJob)
+				})
+				r.Route("/schedules", func(r chi.Router) {
+					r.Get("/", handler.AdminListSchedules)
+					r.Post("/", handler.AdminCreateSchedule)
+					r.Get("/{scheduleId}", handler.AdminGetSchedule)
+					r.Put("/{scheduleId}", handler.AdminUpdateSchedule)
+					r.Delete("/{scheduleId}", handler.AdminDeleteSchedule)
+					r.Get("/{scheduleId}/runs", handler.AdminListScheduleRuns)
+					r.Post("/{scheduleId}/run", handler.AdminRunSchedule)
+				})
+				r.Get("/search/sync", handler.AdminSearchSyncStatus)
+				r.Post("/search/sync/retry", handler.AdminRetrySearchSync)
 			})
 		})
 	})
@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+const (
+	DigestStatusSending = "sending"
+	DigestStatusSent    = "sent"
+	DigestStatusSkipped = "skipped"
+	DigestStatusFailed  = "failed"
+)
+
+// DigestRecipient is a user who has opted into email digests.
+type DigestRecipient struct {
+	UserID           uuid.UUID `json:"user_id"`
+	Email            string    `json:"email"`
+	Name             string    `json:"name"`
+	Frequency        string    `json:"frequency"`
+	UnsubscribeToken string    `json:"-"`
+}
+
+// DigestSend records one digest email for a user and period. It is claimed
+// before the email goes out so a crashed run never sends the same digest twice.
+type DigestSend struct {
+	ID          uuid.UUID  `json:"id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Frequency   string     `json:"frequency"`
+	PeriodStart time.Time  `json:"period_start"`
+	PeriodEnd   time.Time  `json:"period_end"`
+	Status      string     `json:"status"`
+	PaperCount  int        `json:"paper_count"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+}
+
+// DigestSettings is the user-facing digest preference.
+type DigestSettings struct {
+	Frequency string `json:"frequency"`
+}
+
+const (
+	FollowAuthor = "author"
+	FollowTopic  = "topic"
+)
+
+// Follow is an author or topic (a category code) whose new papers go into
+// the user's digest.
+type Follow struct {
+	ID        uuid.UUID `json:"id"`
+	UserID    uuid.UUID `json:"-"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SavedSearch is a query the digest runs against each period's new papers.
+type SavedSearch struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"-"`
+	Name       string    `json:"name"`
+	Query      string    `json:"query"`
+	Categories []string  `json:"categories"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type DigestRepository interface {
+	ListRecipients(frequency string) ([]*DigestRecipient, error)
+	GetSettings(userID uuid.UUID) (*DigestSettings, error)
+	// SetFrequency updates the preference and issues an unsubscribe token if the user has none.
+	SetFrequency(userID uuid.UUID, frequency string) error
+	// Unsubscribe turns digests off for the token's owner. Returns false if the token is unknown.
+	Unsubscribe(token string) (bool, error)
+	// Claim inserts a 'sending' row for the period. Returns false if the period was
+	// already claimed (sent, skipped or in flight); previously failed rows are reclaimed.
+	Claim(send *DigestSend) (bool, error)
+	MarkSent(id uuid.UUID, paperCount int) error
+	MarkSkipped(id uuid.UUID) error
+	MarkFailed(id uuid.UUID, errMsg string) error
+
+	ListFollows(userID uuid.UUID) ([]*Follow, error)
+	// AddFollow fills in f.ID and f.CreatedAt, from the existing row if the user already follows it.
+	AddFollow(f *Follow) error
+	// DeleteFollow returns false if the user has no such follow.
+	DeleteFollow(userID, id uuid.UUID) (bool, error)
+	ListSavedSearches(userID uuid.UUID) ([]*SavedSearch, error)
+	CountSavedSearches(userID uuid.UUID) (int, error)
+	CreateSavedSearch(s *SavedSearch) error
+	// DeleteSavedSearch returns false if the user has no such saved search.
+	DeleteSavedSearch(userID, id uuid.UUID) (bool, error)
+}
@@ -0,0 +1,22 @@
+package domain
+
+import (
+	"encoding/json"
+
+	"github.com/google/uuid"
+)
+
+// FullText is text extracted from a paper's PDF (see cmd/fulltext).
+type FullText struct {
+	PaperID   uuid.UUID
+	BlobKey   string
+	PDFSize   int64
+	PageCount int
+	Text      string
+	Sections  json.RawMessage // [{"title": "...", "text": "..."}]
+}
+
+// FullTextRepository stores extracted PDF text.
+type FullTextRepository interface {
+	Save(ft *FullText) error
+}
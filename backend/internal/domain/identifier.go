@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Identifier schemes in the crosswalk.
+const (
+	SchemeArxiv    = "arxiv"    // 2301.01234, hep-th/9901001 (no version)
+	SchemeDOI      = "doi"      // lowercase, no URL prefix
+	SchemePMID     = "pmid"     // digits
+	SchemePMCID    = "pmcid"    // PMC1234567
+	SchemeS2       = "s2"       // Semantic Scholar corpusId, digits
+	SchemeS2Paper  = "s2paper"  // Semantic Scholar paperId, 40 hex digits
+	SchemeOpenAlex = "openalex" // W1234567890
+)
+
+// Identifier is one normalized ID of a paper.
+type Identifier struct {
+	Scheme string `json:"scheme"`
+	Value  string `json:"value"`
+}
+
+func (id Identifier) String() string { return id.Scheme + ":" + id.Value }
+
+// PreprintSources are the sources whose records are preprints. A preprint
+// and its journal article are different records of one work (see
+// papers.work_id), never duplicates.
+var PreprintSources = []string{"arxiv", "biorxiv", "medrxiv"}
+
+// IsPreprintSource reports whether source is a preprint server.
+func IsPreprintSource(source string) bool {
+	for _, s := range PreprintSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// PaperMerge folds a duplicate into a surviving paper. Survivor holds the
+// merged field values to save; Fields names the ones taken from the duplicate.
+type PaperMerge struct {
+	Survivor            *Paper
+	DuplicateID         uuid.UUID
+	DuplicateExternalID string
+	DuplicateSource     string
+	Fields              []string
+}
+
+// MergeRecord is a past merge, kept so old IDs still resolve.
+type MergeRecord struct {
+	MergedID   uuid.UUID `json:"merged_id"`
+	IntoID     uuid.UUID `json:"into_id"`
+	ExternalID string    `json:"external_id"`
+	Source     string    `json:"source"`
+	Fields     []string  `json:"fields"`
+	MergedAt   time.Time `json:"merged_at"`
+}
+
+// IdentifierRepository is the identifier crosswalk (paper_identifiers) and
+// the merge log (paper_merges).
+type IdentifierRepository interface {
+	// Register records ids for a paper. Identifiers already held by other
+	// papers are left alone; those papers are returned as likely duplicates.
+	Register(paperID uuid.UUID, source string, ids []Identifier) ([]uuid.UUID, error)
+	// Resolve returns the paper holding any of ids (first match in order),
+	// or uuid.Nil.
+	Resolve(ids []Identifier) (uuid.UUID, error)
+	// ListByPaper returns a paper's identifiers.
+	ListByPaper(paperID uuid.UUID) ([]Identifier, error)
+	// ResolveMerged maps the UUID or external ID of a merged-away paper to
+	// the paper it now lives in, or uuid.Nil.
+	ResolveMerged(key string) (uuid.UUID, error)
+	// Merge saves the survivor, moves everything that references the
+	// duplicate (library entries, links, versions, references, identifiers)
+	// to it and deletes the duplicate, in one transaction.
+	Merge(m *PaperMerge) error
+	// CountByScheme returns the number of identifiers per scheme.
+	CountByScheme() (map[string]int64, error)
+}
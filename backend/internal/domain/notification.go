@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification kinds.
+const (
+	// NotificationPaperVersion: a paper in the user's library has a new
+	// version. Data holds version, version_date, title, external_id and
+	// comments.
+	NotificationPaperVersion = "paper_version"
+)
+
+// Notification is an in-app message for one user.
+type Notification struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Kind      string          `json:"kind"`
+	PaperID   *uuid.UUID      `json:"paper_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+}
+
+type NotificationRepository interface {
+	// ListByUser returns a page of the user's notifications, newest first,
+	// and the total count.
+	ListByUser(userID uuid.UUID, unreadOnly bool, limit, offset int) ([]*Notification, int, error)
+	CountUnread(userID uuid.UUID) (int, error)
+	// MarkRead marks the given notifications read, or all of them if ids is
+	// empty. Returns the number changed.
+	MarkRead(userID uuid.UUID, ids []uuid.UUID) (int64, error)
+}
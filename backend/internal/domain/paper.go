@@ -27,6 +27,33 @@ type Paper struct {
 	Comments        string          `json:"comments,omitempty"`
 	License         string          `json:"license,omitempty"`
 	CreatedAt       time.Time       `json:"created_at"`
+	OwnerID         *uuid.UUID      `json:"owner_id,omitempty"`   // set for uploads
+	Visibility      string          `json:"visibility,omitempty"` // public, workspace or private
+	BlobKey         string          `json:"-"`                    // uploaded PDF in the blob store
+	WorkID          string          `json:"work_id,omitempty"`    // shared by a preprint and its published versions
+}
+
+// Paper visibility. Only public papers are indexed in OpenSearch or returned
+// by public search; workspace papers are visible to every signed-in user.
+const (
+	VisibilityPublic    = "public"
+	VisibilityWorkspace = "workspace"
+	VisibilityPrivate   = "private"
+)
+
+// SourceUpload marks papers created from user-uploaded PDFs.
+const SourceUpload = "upload"
+
+// CanView reports whether a user may see the paper. userID is uuid.Nil for anonymous requests.
+func (p *Paper) CanView(userID uuid.UUID) bool {
+	switch p.Visibility {
+	case "", VisibilityPublic:
+		return true
+	case VisibilityWorkspace:
+		return userID != uuid.Nil
+	default:
+		return userID != uuid.Nil && p.OwnerID != nil && *p.OwnerID == userID
+	}
 }
 
 type Author struct {
@@ -40,35 +67,113 @@ type PaperRepository interface {
 	BulkUpsert(papers []*Paper) (int, error)
 	GetByID(id uuid.UUID) (*Paper, error)
 	GetByExternalID(externalID string) (*Paper, error)
-	Search(query string, source string, limit, offset int, sortBy string) ([]*Paper, int, error)
+	// Search matches public papers; collapse hides preprints linked to a published version.
+	Search(query string, source string, limit, offset int, sortBy string, collapse bool) ([]*Paper, int, error)
+	// ListByWork returns the public papers of a work, published versions first.
+	ListByWork(workID string) ([]*Paper, error)
 	Delete(id uuid.UUID) error
 	CountByCategory() ([]CategoryCount, error)
 	StreamAll(ctx context.Context, batchSize int, fn func(papers []*Paper) error) error
 	BackfillCategories() (int64, error)
+	// ListRecentByCategories returns papers added in [since, until) that overlap the given categories.
+	ListRecentByCategories(categories []string, since, until time.Time, limit int) ([]*Paper, error)
+	// ListRecentByAuthors returns papers added in [since, until) with any of the given author names.
+	ListRecentByAuthors(names []string, since, until time.Time, limit int) ([]*Paper, error)
+	// ListRecentByQuery returns papers added in [since, until) that match a full-text query,
+	// within the given categories if any.
+	ListRecentByQuery(query string, categories []string, since, until time.Time, limit int) ([]*Paper, error)
+
+	// Uploads (non-public papers)
+	CreateUpload(paper *Paper) error
+	UpdateUpload(paper *Paper) error
+	// ListUploads returns uploads the user can see (own + workspace), optionally matching query.
+	ListUploads(userID uuid.UUID, query string, limit, offset int) ([]*Paper, int, error)
+	CountByBlobKey(blobKey string) (int, error)
 }
 
-// PaperSearcher handles search operations (OpenSearch).
-// If OpenSearch is not configured, the system falls back to PaperRepository.Search().
+// PaperSearcher is the search index of public papers. OpenSearch, PostgreSQL
+// full-text search and an embedded on-disk index implement it (see
+// internal/search); SEARCH_BACKEND picks one. Docs are keyed by their ID,
+// which is a PG UUID or, for papers bulk-imported straight into the index,
+// the source's ID (an S2 corpus ID).
 type PaperSearcher interface {
 	Search(ctx context.Context, params SearchParams) (*SearchResult, error)
-	Index(ctx context.Context, paper *Paper) error
-	BulkIndex(ctx context.Context, papers []*Paper) error
+	// GetByID and GetByExternalID return nil, nil if no doc matches.
+	GetByID(ctx context.Context, id string) (*SearchDoc, error)
+	GetByExternalID(ctx context.Context, externalID string) (*SearchDoc, error)
+	// ListByWork returns the docs of one work, most cited first.
+	ListByWork(ctx context.Context, workID string, limit int) ([]*SearchDoc, error)
+	// Random returns up to limit docs with at least 10 citations in any of
+	// the categories (all if none), in an order fixed by seed.
+	Random(ctx context.Context, categories, excludeExternalIDs []string, seed string, limit int) ([]*SearchDoc, error)
+	// TopCited returns the most cited doc of each of the numFields primary
+	// categories whose best paper is most cited.
+	TopCited(ctx context.Context, numFields int) ([]*SearchDoc, error)
+	GetCategoryCounts(ctx context.Context) ([]CategoryCount, error)
+
+	Index(ctx context.Context, doc *SearchDoc) error
+	BulkIndex(ctx context.Context, docs []*SearchDoc) error
+	Delete(ctx context.Context, id string) error
 	DeleteIndex(ctx context.Context) error
 	CreateIndex(ctx context.Context) error
-	GetCategoryCounts(ctx context.Context) ([]CategoryCount, error)
+}
+
+// SearchDoc is a paper as the search index holds it and search endpoints
+// return it. Reference counts, venue, publication types, the S2 URL, open
+// access and the TLDR only come from S2 imports.
+type SearchDoc struct {
+	ID                       string      `json:"id"`
+	ExternalID               string      `json:"external_id"`
+	Source                   string      `json:"source"`
+	Title                    string      `json:"title"`
+	Abstract                 string      `json:"abstract"`
+	Authors                  interface{} `json:"authors"`
+	PublishedDate            *string     `json:"published_date,omitempty"` // yyyy-MM-dd, yyyy-MM or yyyy
+	Year                     int         `json:"year,omitempty"`
+	PDFURL                   string      `json:"pdf_url,omitempty"`
+	PrimaryCategory          string      `json:"primary_category,omitempty"`
+	Categories               []string    `json:"categories,omitempty"`
+	DOI                      string      `json:"doi,omitempty"`
+	JournalRef               string      `json:"journal_ref,omitempty"`
+	CitationCount            int         `json:"citation_count"`
+	ReferenceCount           int         `json:"reference_count"`
+	InfluentialCitationCount int         `json:"influential_citation_count"`
+	Venue                    string      `json:"venue,omitempty"`
+	PublicationTypes         []string    `json:"publication_types,omitempty"`
+	S2URL                    string      `json:"s2_url,omitempty"`
+	IsOpenAccess             bool        `json:"is_open_access"`
+	TLDR                     string      `json:"tldr,omitempty"`
+	WorkID                   string      `json:"work_id,omitempty"`
+
+	// Extracted PDF text: indexed, never returned.
+	FullTextSections []FullTextSection `json:"fulltext_sections,omitempty"`
+	// FullTextSnippet is the best full-text match, set by Search with
+	// FullText.
+	FullTextSnippet string `json:"fulltext_snippet,omitempty"`
+	// RemoteSource marks a federated search hit not yet in our store.
+	RemoteSource string `json:"remote_source,omitempty"`
+}
+
+// FullTextSection is one section of a paper's extracted text.
+type FullTextSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
 }
 
 type SearchParams struct {
 	Query      string
+	Source     string   // only papers from this source
 	Categories []string // filter by categories (e.g., ["cs.AI", "cs.LG"])
 	SortBy     string   // "relevance", "citations", "date"
 	Limit      int
 	Offset     int
+	FullText   bool // also match extracted PDF text
+	Collapse   bool // one hit per work; Total counts works
 }
 
 type SearchResult struct {
-	Papers []*Paper `json:"papers"`
-	Total  int      `json:"total"`
+	Papers []*SearchDoc `json:"papers"`
+	Total  int          `json:"total"`
 }
 
 type CategoryCount struct {
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Where a version record came from.
+const (
+	VersionSourceKaggle = "kaggle" // arXiv metadata snapshot: exact version numbers
+	VersionSourceOAI    = "oai"    // OAI-PMH harvest: numbers inferred from dates
+)
+
+// PaperVersion is one revision of a paper. Comments and Abstract are
+// snapshots and only known for the version current when it was imported.
+type PaperVersion struct {
+	PaperID   uuid.UUID `json:"paper_id"`
+	Version   int       `json:"version"`
+	Date      time.Time `json:"date"`
+	Comments  string    `json:"comments,omitempty"`
+	Abstract  string    `json:"abstract,omitempty"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExternalID identifies the paper when recording and PaperID is not
+	// known (bulk imports insert papers by external ID).
+	ExternalID string `json:"-"`
+}
+
+type PaperVersionRepository interface {
+	// GetByPaper returns a paper's versions, oldest first.
+	GetByPaper(paperID uuid.UUID) ([]*PaperVersion, error)
+	// Record stores versions that aren't on record yet and notifies users
+	// with the paper in their library of versions posted after they saved
+	// it. Version 0 means "the next version": numbered one past the latest
+	// on record, and skipped if a version from the same day exists. Returns
+	// the number of new versions.
+	Record(versions []*PaperVersion) (int, error)
+}
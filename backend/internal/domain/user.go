@@ -72,4 +72,19 @@ type AdminStats struct {
 	DailyLogins      []DailyCount   `json:"daily_logins"`
 	NewUsersToday    int            `json:"new_users_today"`
 	NewUsersThisWeek int            `json:"new_users_this_week"`
+	PDFCache         *PDFCacheStats `json:"pdf_cache,omitempty"`
+}
+
+// PDFCacheStats reports the PDF proxy cache since server start
+type PDFCacheStats struct {
+	Hits        int64   `json:"hits"`
+	Misses      int64   `json:"misses"`
+	Revalidated int64   `json:"revalidated"`
+	StaleServed int64   `json:"stale_served"`
+	Errors      int64   `json:"errors"`
+	Evictions   int64   `json:"evictions"`
+	HitRate     float64 `json:"hit_rate"`
+	Entries     int     `json:"entries"`
+	Bytes       int64   `json:"bytes"`
+	MaxBytes    int64   `json:"max_bytes"`
 }
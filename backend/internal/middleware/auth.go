@@ -47,6 +47,21 @@ func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	})
 }
 
+// OptionalAuth sets the user ID when a valid bearer token is present and
+// otherwise lets the request through anonymously. Used by public routes that
+// also serve private content to its owner.
+func (m *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(r.Header.Get("Authorization"), " ")
+		if len(parts) == 2 && parts[0] == "Bearer" {
+			if claims, err := m.authUsecase.ValidateAccessToken(parts[1]); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), UserIDKey, claims.UserID))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // AdminOnly middleware must be used after Authenticate. It checks that the
 // authenticated user has the is_admin flag set.
 func (m *AuthMiddleware) AdminOnly(next http.Handler) http.Handler {
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/domain"
+)
+
+type DigestRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewDigestRepository(db *pgxpool.Pool) *DigestRepository {
+	return &DigestRepository{db: db}
+}
+
+func (r *DigestRepository) ListRecipients(frequency string) ([]*domain.DigestRecipient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, email, COALESCE(name, ''), digest_frequency, COALESCE(digest_unsubscribe_token, '')
+		FROM users
+		WHERE digest_frequency = $1
+		ORDER BY created_at
+	`, frequency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*domain.DigestRecipient
+	for rows.Next() {
+		rc := &domain.DigestRecipient{}
+		if err := rows.Scan(&rc.UserID, &rc.Email, &rc.Name, &rc.Frequency, &rc.UnsubscribeToken); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, rc)
+	}
+	return recipients, rows.Err()
+}
+
+func (r *DigestRepository) GetSettings(userID uuid.UUID) (*domain.DigestSettings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings := &domain.DigestSettings{}
+	err := r.db.QueryRow(ctx, `SELECT digest_frequency FROM users WHERE id = $1`, userID).Scan(&settings.Frequency)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (r *DigestRepository) SetFrequency(userID uuid.UUID, frequency string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	token, err := newDigestToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, `
+		UPDATE users
+		SET digest_frequency = $2,
+			digest_unsubscribe_token = COALESCE(digest_unsubscribe_token, $3),
+			updated_at = NOW()
+		WHERE id = $1
+	`, userID, frequency, token)
+	return err
+}
+
+func (r *DigestRepository) Unsubscribe(token string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ct, err := r.db.Exec(ctx, `
+		UPDATE users SET digest_frequency = 'off', updated_at = NOW()
+		WHERE digest_unsubscribe_token = $1
+	`, token)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func (r *DigestRepository) Claim(send *domain.DigestSend) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if send.ID == uuid.Nil {
+		send.ID = uuid.New()
+	}
+
+	// Only 'failed' rows may be reclaimed: those are known not to have been delivered.
+	// A leftover 'sending' row means a previous run died mid-send, so it is never retried.
+	err := r.db.QueryRow(ctx, `
+		INSERT INTO digest_sends (id, user_id, frequency, period_start, period_end, status)
+		VALUES ($1, $2, $3, $4, $5, 'sending')
+		ON CONFLICT (user_id, frequency, period_start) DO UPDATE
+			SET status = 'sending', error = NULL
+			WHERE digest_sends.status = 'failed'
+		RETURNING id, status, created_at
+	`, send.ID, send.UserID, send.Frequency, send.PeriodStart, send.PeriodEnd).Scan(&send.ID, &send.Status, &send.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *DigestRepository) MarkSent(id uuid.UUID, paperCount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, `
+		UPDATE digest_sends SET status = 'sent', paper_count = $2, sent_at = NOW() WHERE id = $1
+	`, id, paperCount)
+	return err
+}
+
+func (r *DigestRepository) MarkSkipped(id uuid.UUID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, `UPDATE digest_sends SET status = 'skipped' WHERE id = $1`, id)
+	return err
+}
+
+func (r *DigestRepository) MarkFailed(id uuid.UUID, errMsg string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, `UPDATE digest_sends SET status = 'failed', error = $2 WHERE id = $1`, id, errMsg)
+	return err
+}
+
+func (r *DigestRepository) ListFollows(userID uuid.UUID) ([]*domain.Follow, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, kind, value, created_at
+		FROM user_follows
+		WHERE user_id = $1
+		ORDER BY kind, value
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	follows := []*domain.Follow{}
+	for rows.Next() {
+		f := &domain.Follow{}
+		if err := rows.Scan(&f.ID, &f.UserID, &f.Kind, &f.Value, &f.CreatedAt); err != nil {
+			return nil, err
+		}
+		follows = append(follows, f)
+	}
+	return follows, rows.Err()
+}
+
+func (r *DigestRepository) AddFollow(f *domain.Follow) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if f.ID == uuid.Nil {
+		f.ID = uuid.New()
+	}
+	// The no-op update makes RETURNING yield the existing row on conflict
+	return r.db.QueryRow(ctx, `
+		INSERT INTO user_follows (id, user_id, kind, value)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, kind, value) DO UPDATE SET value = EXCLUDED.value
+		RETURNING id, created_at
+	`, f.ID, f.UserID, f.Kind, f.Value).Scan(&f.ID, &f.CreatedAt)
+}
+
+func (r *DigestRepository) DeleteFollow(userID, id uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ct, err := r.db.Exec(ctx, `DELETE FROM user_follows WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func (r *DigestRepository) ListSavedSearches(userID uuid.UUID) ([]*domain.SavedSearch, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, user_id, name, query, categories, created_at
+		FROM saved_searches
+		WHERE user_id = $1
+		ORDER BY created_at
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	searches := []*domain.SavedSearch{}
+	for rows.Next() {
+		s := &domain.SavedSearch{}
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Name, &s.Query, &s.Categories, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		searches = append(searches, s)
+	}
+	return searches, rows.Err()
+}
+
+func (r *DigestRepository) CountSavedSearches(userID uuid.UUID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM saved_searches WHERE user_id = $1`, userID).Scan(&n)
+	return n, err
+}
+
+func (r *DigestRepository) CreateSavedSearch(s *domain.SavedSearch) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	if s.Categories == nil {
+		s.Categories = []string{}
+	}
+	return r.db.QueryRow(ctx, `
+		INSERT INTO saved_searches (id, user_id, name, query, categories)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at
+	`, s.ID, s.UserID, s.Name, s.Query, s.Categories).Scan(&s.CreatedAt)
+}
+
+func (r *DigestRepository) DeleteSavedSearch(userID, id uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	ct, err := r.db.Exec(ctx, `DELETE FROM saved_searches WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, err
+	}
+	return ct.RowsAffected() > 0, nil
+}
+
+func newDigestToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
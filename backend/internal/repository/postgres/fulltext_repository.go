@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/domain"
+)
+
+type FullTextRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewFullTextRepository(db *pgxpool.Pool) *FullTextRepository {
+	return &FullTextRepository{db: db}
+}
+
+// Save upserts extracted text. The search outbox trigger queues the paper,
+// so its document picks the text up.
+func (r *FullTextRepository) Save(ft *domain.FullText) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO paper_fulltext (paper_id, blob_key, pdf_size, page_count, text, sections, extracted_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (paper_id) DO UPDATE SET
+			blob_key = EXCLUDED.blob_key,
+			pdf_size = EXCLUDED.pdf_size,
+			page_count = EXCLUDED.page_count,
+			text = EXCLUDED.text,
+			sections = EXCLUDED.sections,
+			extracted_at = NOW()
+	`, ft.PaperID, ft.BlobKey, ft.PDFSize, ft.PageCount, ft.Text, ft.Sections)
+	return err
+}
@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/domain"
+)
+
+type IdentifierRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewIdentifierRepository(db *pgxpool.Pool) *IdentifierRepository {
+	return &IdentifierRepository{db: db}
+}
+
+func splitIdentifiers(ids []domain.Identifier) (schemes, values []string) {
+	schemes = make([]string, len(ids))
+	values = make([]string, len(ids))
+	for i, id := range ids {
+		schemes[i], values[i] = id.Scheme, id.Value
+	}
+	return schemes, values
+}
+
+func (r *IdentifierRepository) Register(paperID uuid.UUID, source string, ids []domain.Identifier) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	schemes, values := splitIdentifiers(ids)
+	batch := &pgx.Batch{}
+	batch.Queue(`
+		INSERT INTO paper_identifiers (scheme, value, paper_id, source)
+		SELECT u.scheme, u.value, $1::uuid, $4::text
+		FROM unnest($2::text[], $3::text[]) AS u(scheme, value)
+		ON CONFLICT (scheme, value) DO NOTHING
+	`, paperID, schemes, values, source)
+	batch.Queue(`
+		SELECT DISTINCT pi.paper_id
+		FROM paper_identifiers pi
+		JOIN unnest($2::text[], $3::text[]) AS u(scheme, value) ON pi.scheme = u.scheme AND pi.value = u.value
+		WHERE pi.paper_id <> $1::uuid
+	`, paperID, schemes, values)
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	if _, err := br.Exec(); err != nil {
+		return nil, err
+	}
+	rows, err := br.Query()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		owners = append(owners, id)
+	}
+	return owners, rows.Err()
+}
+
+func (r *IdentifierRepository) Resolve(ids []domain.Identifier) (uuid.UUID, error) {
+	if len(ids) == 0 {
+		return uuid.Nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	schemes, values := splitIdentifiers(ids)
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `
+		SELECT pi.paper_id
+		FROM unnest($1::text[], $2::text[]) WITH ORDINALITY AS u(scheme, value, n)
+		JOIN paper_identifiers pi ON pi.scheme = u.scheme AND pi.value = u.value
+		ORDER BY u.n
+		LIMIT 1
+	`, schemes, values).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return id, err
+}
+
+func (r *IdentifierRepository) ListByPaper(paperID uuid.UUID) ([]domain.Identifier, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT scheme, value FROM paper_identifiers WHERE paper_id = $1 ORDER BY scheme, value
+	`, paperID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []domain.Identifier
+	for rows.Next() {
+		var id domain.Identifier
+		if err := rows.Scan(&id.Scheme, &id.Value); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *IdentifierRepository) ResolveMerged(key string) (uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := `SELECT into_id FROM paper_merges WHERE external_id = $1 ORDER BY merged_at DESC LIMIT 1`
+	var arg interface{} = key
+	if id, err := uuid.Parse(key); err == nil {
+		query = `SELECT into_id FROM paper_merges WHERE merged_id = $1`
+		arg = id
+	}
+
+	var into uuid.UUID
+	err := r.db.QueryRow(ctx, query, arg).Scan(&into)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, nil
+	}
+	return into, err
+}
+
+// mergeSQL moves rows referencing the duplicate ($2) to the survivor ($1).
+// Rows that would collide with the survivor's own (the same user's library
+// entry, an existing full text) stay and are deleted with the duplicate.
+var mergeSQL = []string{
+	`UPDATE user_papers SET paper_id = $1
+	 WHERE paper_id = $2
+	   AND user_id NOT IN (SELECT user_id FROM user_papers WHERE paper_id = $1 AND user_id IS NOT NULL)`,
+	`UPDATE reading_sessions SET paper_id = $1 WHERE paper_id = $2`,
+	`UPDATE paper_fulltext SET paper_id = $1
+	 WHERE paper_id = $2 AND NOT EXISTS (SELECT 1 FROM paper_fulltext WHERE paper_id = $1)`,
+	`UPDATE fulltext_failures SET paper_id = $1
+	 WHERE paper_id = $2 AND NOT EXISTS (SELECT 1 FROM fulltext_failures WHERE paper_id = $1)`,
+	`UPDATE paper_references SET citing_paper_id = $1
+	 WHERE citing_paper_id = $2 AND NOT EXISTS (SELECT 1 FROM paper_references WHERE citing_paper_id = $1)`,
+	`UPDATE paper_references SET cited_paper_id = $1 WHERE cited_paper_id = $2`,
+	`INSERT INTO paper_links (paper_id, linked_paper_id, relation, method, created_at)
+	 SELECT l.from_id, l.to_id, l.relation, l.method, l.created_at
+	 FROM (
+		SELECT CASE WHEN paper_id = $2 THEN $1 ELSE paper_id END AS from_id,
+			CASE WHEN linked_paper_id = $2 THEN $1 ELSE linked_paper_id END AS to_id,
+			relation, method, created_at
+		FROM paper_links WHERE paper_id = $2 OR linked_paper_id = $2
+	 ) l
+	 WHERE l.from_id <> l.to_id
+	 ON CONFLICT DO NOTHING`,
+	`INSERT INTO paper_versions (paper_id, version, version_date, comments, abstract, source, created_at)
+	 SELECT $1, version, version_date, comments, abstract, source, created_at
+	 FROM paper_versions WHERE paper_id = $2
+	 ON CONFLICT DO NOTHING`,
+	`UPDATE notifications SET paper_id = $1 WHERE paper_id = $2`,
+	`UPDATE paper_identifiers SET paper_id = $1 WHERE paper_id = $2`,
+	`UPDATE paper_merges SET into_id = $1 WHERE into_id = $2`,
+}
+
+func (r *IdentifierRepository) Merge(m *domain.PaperMerge) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	p := m.Survivor
+	_, err = tx.Exec(ctx, `
+		UPDATE papers SET title = $2, abstract = $3, authors = $4, published_date = $5, pdf_url = $6,
+			metadata = $7, citation_count = $8, primary_category = NULLIF($9, ''), categories = $10,
+			doi = NULLIF($11, ''), journal_ref = NULLIF($12, ''), comments = NULLIF($13, ''), license = NULLIF($14, '')
+		WHERE id = $1
+	`, p.ID, p.Title, p.Abstract, p.Authors, p.PublishedDate, p.PDFURL,
+		p.Metadata, p.CitationCount, p.PrimaryCategory, p.Categories,
+		p.DOI, p.JournalRef, p.Comments, p.License)
+	if err != nil {
+		return err
+	}
+
+	for _, stmt := range mergeSQL {
+		if _, err := tx.Exec(ctx, stmt, p.ID, m.DuplicateID); err != nil {
+			return err
+		}
+	}
+
+	fields := m.Fields
+	if fields == nil {
+		fields = []string{}
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO paper_merges (merged_id, into_id, external_id, source, fields)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (merged_id) DO UPDATE SET into_id = EXCLUDED.into_id
+	`, m.DuplicateID, p.ID, m.DuplicateExternalID, m.DuplicateSource, fields)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM papers WHERE id = $1`, m.DuplicateID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *IdentifierRepository) CountByScheme() (map[string]int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `SELECT scheme, COUNT(*) FROM paper_identifiers GROUP BY scheme`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[string]int64{}
+	for rows.Next() {
+		var scheme string
+		var n int64
+		if err := rows.Scan(&scheme, &n); err != nil {
+			return nil, err
+		}
+		counts[scheme] = n
+	}
+	return counts, rows.Err()
+}
@@ -121,7 +121,7 @@ func (r *PaperRepository) GetByID(id uuid.UUID) (*domain.Paper, error) {
 			pdf_url, metadata, COALESCE(citation_count, 0),
 			COALESCE(primary_category, ''), categories,
 			COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
-			created_at
+			created_at, owner_id, visibility, COALESCE(blob_key, ''), COALESCE(work_id, '')
 		FROM papers WHERE id = $1
 	`
 
@@ -131,7 +131,7 @@ func (r *PaperRepository) GetByID(id uuid.UUID) (*domain.Paper, error) {
 		&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
 		&paper.PrimaryCategory, &paper.Categories,
 		&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
-		&paper.CreatedAt,
+		&paper.CreatedAt, &paper.OwnerID, &paper.Visibility, &paper.BlobKey, &paper.WorkID,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -151,7 +151,7 @@ func (r *PaperRepository) GetByExternalID(externalID string) (*domain.Paper, err
 			pdf_url, metadata, COALESCE(citation_count, 0),
 			COALESCE(primary_category, ''), categories,
 			COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
-			created_at
+			created_at, owner_id, visibility, COALESCE(blob_key, ''), COALESCE(work_id, '')
 		FROM papers WHERE external_id = $1
 	`
 
@@ -161,7 +161,7 @@ func (r *PaperRepository) GetByExternalID(externalID string) (*domain.Paper, err
 		&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
 		&paper.PrimaryCategory, &paper.Categories,
 		&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
-		&paper.CreatedAt,
+		&paper.CreatedAt, &paper.OwnerID, &paper.Visibility, &paper.BlobKey, &paper.WorkID,
 	)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil
@@ -172,7 +172,7 @@ func (r *PaperRepository) GetByExternalID(externalID string) (*domain.Paper, err
 	return paper, nil
 }
 
-func (r *PaperRepository) Search(query string, source string, limit, offset int, sortBy string) ([]*domain.Paper, int, error) {
+func (r *PaperRepository) Search(query string, source string, limit, offset int, sortBy string, collapse bool) ([]*domain.Paper, int, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
@@ -181,9 +181,14 @@ func (r *PaperRepository) Search(query string, source string, limit, offset int,
 	}
 
 	whereClause := `
-		WHERE ($1 = '' OR search_vector @@ plainto_tsquery('english', $1) OR title ILIKE '%' || $1 || '%')
+		WHERE visibility = 'public'
+		AND ($1 = '' OR search_vector @@ plainto_tsquery('english', $1) OR title ILIKE '%' || $1 || '%')
 		AND ($2 = '' OR source = $2)
 	`
+	if collapse {
+		// Linked preprints are shown through their published version
+		whereClause += ` AND work_primary`
+	}
 
 	var orderClause string
 	switch sortBy {
@@ -221,7 +226,7 @@ func (r *PaperRepository) Search(query string, source string, limit, offset int,
 			pdf_url, metadata, COALESCE(citation_count, 0),
 			COALESCE(primary_category, ''), categories,
 			COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
-			created_at
+			created_at, COALESCE(work_id, '')
 		FROM papers %s %s LIMIT $3 OFFSET $4
 	`, whereClause, orderClause)
 
@@ -247,7 +252,7 @@ func (r *PaperRepository) Search(query string, source string, limit, offset int,
 			&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
 			&paper.PrimaryCategory, &paper.Categories,
 			&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
-			&paper.CreatedAt,
+			&paper.CreatedAt, &paper.WorkID,
 		)
 		if err != nil {
 			return nil, 0, err
@@ -275,6 +280,7 @@ func (r *PaperRepository) CountByCategory() ([]domain.CategoryCount, error) {
 		SELECT COALESCE(primary_category, 'unknown'), COUNT(*)
 		FROM papers
 		WHERE primary_category IS NOT NULL AND primary_category != ''
+		  AND visibility = 'public'
 		GROUP BY primary_category
 		ORDER BY COUNT(*) DESC
 	`)
@@ -348,9 +354,9 @@ func (r *PaperRepository) StreamAll(ctx context.Context, batchSize int, fn func(
 				pdf_url, metadata, COALESCE(citation_count, 0),
 				COALESCE(primary_category, ''), categories,
 				COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
-				created_at
+				created_at, COALESCE(work_id, '')
 			FROM papers
-			WHERE title IS NOT NULL AND title != ''
+			WHERE title IS NOT NULL AND title != '' AND visibility = 'public'
 			ORDER BY external_id
 			LIMIT $1 OFFSET $2
 		`, batchSize, offset)
@@ -366,7 +372,7 @@ func (r *PaperRepository) StreamAll(ctx context.Context, batchSize int, fn func(
 				&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
 				&paper.PrimaryCategory, &paper.Categories,
 				&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
-				&paper.CreatedAt,
+				&paper.CreatedAt, &paper.WorkID,
 			)
 			if err != nil {
 				rows.Close()
@@ -388,3 +394,217 @@ func (r *PaperRepository) StreamAll(ctx context.Context, batchSize int, fn func(
 	}
 	return nil
 }
+
+// ListByWork returns the public papers sharing a work ID: the published
+// version(s) first, then preprints, oldest first within each.
+func (r *PaperRepository) ListByWork(workID string) ([]*domain.Paper, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, external_id, source, title, abstract, authors, published_date, updated_date,
+			pdf_url, metadata, COALESCE(citation_count, 0),
+			COALESCE(primary_category, ''), categories,
+			COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
+			created_at, COALESCE(work_id, '')
+		FROM papers
+		WHERE work_id = $1 AND visibility = 'public'
+		ORDER BY work_primary DESC, published_date ASC NULLS LAST
+		LIMIT 50
+	`, workID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var papers []*domain.Paper
+	for rows.Next() {
+		paper := &domain.Paper{}
+		err := rows.Scan(
+			&paper.ID, &paper.ExternalID, &paper.Source, &paper.Title, &paper.Abstract, &paper.Authors,
+			&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
+			&paper.PrimaryCategory, &paper.Categories,
+			&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
+			&paper.CreatedAt, &paper.WorkID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		papers = append(papers, paper)
+	}
+	return papers, rows.Err()
+}
+
+// ListRecentByCategories returns papers ingested in [since, until) whose categories
+// overlap the given list, most cited first.
+func (r *PaperRepository) ListRecentByCategories(categories []string, since, until time.Time, limit int) ([]*domain.Paper, error) {
+	return r.listRecent(`categories && $4`, since, until, limit, categories)
+}
+
+// ListRecentByAuthors returns papers ingested in [since, until) by any of the
+// given authors, most cited first. Names match whole author entries, case
+// insensitively, whether stored as objects with a name or as plain strings.
+func (r *PaperRepository) ListRecentByAuthors(names []string, since, until time.Time, limit int) ([]*domain.Paper, error) {
+	return r.listRecent(`EXISTS (
+			SELECT 1 FROM unnest($4::text[]) n
+			WHERE authors::text ILIKE '%"' || n || '"%'
+		)`, since, until, limit, names)
+}
+
+// ListRecentByQuery returns papers ingested in [since, until) that match a
+// full-text query on title and abstract, in any of the categories if given,
+// most cited first.
+func (r *PaperRepository) ListRecentByQuery(query string, categories []string, since, until time.Time, limit int) ([]*domain.Paper, error) {
+	if len(categories) == 0 {
+		categories = nil
+	}
+	return r.listRecent(`search_vector @@ plainto_tsquery('english', $4)
+		  AND ($5::text[] IS NULL OR categories && $5)`, since, until, limit, query, categories)
+}
+
+// listRecent lists public papers ingested in [$1, $2) that match filter,
+// whose own arguments start at $4; $3 is the limit.
+func (r *PaperRepository) listRecent(filter string, since, until time.Time, limit int, args ...interface{}) ([]*domain.Paper, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT id, external_id, source, title, abstract, authors, published_date, updated_date,
+			pdf_url, metadata, COALESCE(citation_count, 0),
+			COALESCE(primary_category, ''), categories,
+			COALESCE(doi, ''), COALESCE(journal_ref, ''), COALESCE(comments, ''), COALESCE(license, ''),
+			created_at
+		FROM papers
+		WHERE `+filter+`
+		  AND visibility = 'public'
+		  AND created_at >= $1 AND created_at < $2
+		ORDER BY citation_count DESC, published_date DESC NULLS LAST
+		LIMIT $3
+	`, append([]interface{}{since, until, limit}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var papers []*domain.Paper
+	for rows.Next() {
+		paper := &domain.Paper{}
+		err := rows.Scan(
+			&paper.ID, &paper.ExternalID, &paper.Source, &paper.Title, &paper.Abstract, &paper.Authors,
+			&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
+			&paper.PrimaryCategory, &paper.Categories,
+			&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
+			&paper.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		papers = append(papers, paper)
+	}
+	return papers, rows.Err()
+}
+
+// CreateUpload inserts a user-uploaded paper. Unlike Create it never merges
+// into an existing row.
+func (r *PaperRepository) CreateUpload(paper *domain.Paper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if paper.ID == uuid.Nil {
+		paper.ID = uuid.New()
+	}
+	paper.CreatedAt = time.Now()
+
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO papers (id, external_id, source, title, abstract, authors, published_date,
+			pdf_url, metadata, primary_category, categories, doi, created_at, owner_id, visibility, blob_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, '', $8, $9, $10, $11, $12, $13, $14, $15)
+	`,
+		paper.ID, paper.ExternalID, paper.Source, paper.Title, paper.Abstract, paper.Authors, paper.PublishedDate,
+		paper.Metadata, paper.PrimaryCategory, paper.Categories, paper.DOI, paper.CreatedAt,
+		paper.OwnerID, paper.Visibility, paper.BlobKey,
+	)
+	return err
+}
+
+// UpdateUpload saves edited metadata and visibility of an upload.
+func (r *PaperRepository) UpdateUpload(paper *domain.Paper) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.db.Exec(ctx, `
+		UPDATE papers SET title = $2, abstract = $3, authors = $4, published_date = $5,
+			primary_category = $6, categories = $7, doi = $8, visibility = $9, updated_date = CURRENT_DATE
+		WHERE id = $1 AND source = 'upload'
+	`,
+		paper.ID, paper.Title, paper.Abstract, paper.Authors, paper.PublishedDate,
+		paper.PrimaryCategory, paper.Categories, paper.DOI, paper.Visibility,
+	)
+	return err
+}
+
+// ListUploads returns uploads owned by userID or shared with the workspace,
+// newest first. A query matches title/abstract or the extracted PDF text.
+func (r *PaperRepository) ListUploads(userID uuid.UUID, query string, limit, offset int) ([]*domain.Paper, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	whereClause := `
+		WHERE p.source = 'upload'
+		AND (p.owner_id = $1 OR p.visibility = 'workspace')
+		AND ($2 = ''
+			OR p.search_vector @@ plainto_tsquery('english', $2)
+			OR p.title ILIKE '%' || $2 || '%'
+			OR EXISTS (
+				SELECT 1 FROM paper_fulltext ft
+				WHERE ft.paper_id = p.id AND to_tsvector('english', ft.text) @@ plainto_tsquery('english', $2)
+			))
+	`
+
+	var total int
+	if err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM papers p `+whereClause, userID, query).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT p.id, p.external_id, p.source, p.title, p.abstract, p.authors, p.published_date, p.updated_date,
+			p.pdf_url, p.metadata, COALESCE(p.citation_count, 0),
+			COALESCE(p.primary_category, ''), p.categories,
+			COALESCE(p.doi, ''), COALESCE(p.journal_ref, ''), COALESCE(p.comments, ''), COALESCE(p.license, ''),
+			p.created_at, p.owner_id, p.visibility, COALESCE(p.blob_key, '')
+		FROM papers p `+whereClause+`
+		ORDER BY p.created_at DESC
+		LIMIT $3 OFFSET $4
+	`, userID, query, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var papers []*domain.Paper
+	for rows.Next() {
+		paper := &domain.Paper{}
+		err := rows.Scan(
+			&paper.ID, &paper.ExternalID, &paper.Source, &paper.Title, &paper.Abstract, &paper.Authors,
+			&paper.PublishedDate, &paper.UpdatedDate, &paper.PDFURL, &paper.Metadata, &paper.CitationCount,
+			&paper.PrimaryCategory, &paper.Categories,
+			&paper.DOI, &paper.JournalRef, &paper.Comments, &paper.License,
+			&paper.CreatedAt, &paper.OwnerID, &paper.Visibility, &paper.BlobKey,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		papers = append(papers, paper)
+	}
+	return papers, total, nil
+}
+
+// CountByBlobKey returns how many papers reference an uploaded PDF.
+func (r *PaperRepository) CountByBlobKey(blobKey string) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var n int
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM papers WHERE blob_key = $1`, blobKey).Scan(&n)
+	return n, err
+}
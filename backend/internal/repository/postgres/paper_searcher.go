@@ -0,0 +1,303 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// PaperSearcher searches public papers with PostgreSQL full-text search,
+// for running without a separate index. Docs are read straight from the
+// papers table, so the index methods do nothing, and only what PG has is
+// returned: none of the S2-only fields.
+type PaperSearcher struct {
+	db *pgxpool.Pool
+}
+
+func NewPaperSearcher(db *pgxpool.Pool) *PaperSearcher {
+	return &PaperSearcher{db: db}
+}
+
+// searchDocColumns are the columns scanSearchDoc reads, from papers p.
+const searchDocColumns = `
+	COALESCE(p.metadata->>'search_doc_id', p.id::text), p.external_id, p.source, p.title,
+	COALESCE(p.abstract, ''), p.authors, p.published_date, COALESCE(p.pdf_url, ''),
+	COALESCE(p.primary_category, ''), p.categories, COALESCE(p.doi, ''),
+	COALESCE(p.journal_ref, ''), COALESCE(p.citation_count, 0), COALESCE(p.work_id, '')
+`
+
+// rankExpr is the relevance of a paper to the query in $1.
+const rankExpr = `
+	CASE WHEN $1 != '' AND p.search_vector @@ plainto_tsquery('english', $1)
+		THEN ts_rank(p.search_vector, plainto_tsquery('english', $1))
+		ELSE 0
+	END
+`
+
+func (s *PaperSearcher) Search(ctx context.Context, params domain.SearchParams) (*domain.SearchResult, error) {
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+	var categories []string
+	if len(params.Categories) > 0 {
+		categories = params.Categories
+	}
+
+	// search_vector holds title and abstract; author names are matched as
+	// substrings, and $4 also matches extracted PDF text. Neither is
+	// indexed, which is slow on large tables.
+	from := `
+		FROM papers p
+		LEFT JOIN paper_fulltext ft ON $4 AND ft.paper_id = p.id
+		WHERE p.visibility = 'public' AND p.title != ''
+		AND ($1 = '' OR p.search_vector @@ plainto_tsquery('english', $1) OR p.title ILIKE '%' || $1 || '%'
+			OR p.authors::text ILIKE '%' || $1 || '%'
+			OR ($4 AND to_tsvector('english', ft.text) @@ plainto_tsquery('english', $1)))
+		AND ($2 = '' OR p.source = $2)
+		AND ($3::text[] IS NULL OR p.categories && $3)
+	`
+	if params.Collapse {
+		// Linked preprints are shown through their published version
+		from += ` AND p.work_primary`
+	}
+
+	var order string
+	switch params.SortBy {
+	case "citations":
+		order = `ORDER BY p.citation_count DESC NULLS LAST, ` + rankExpr + ` DESC, p.published_date DESC NULLS LAST`
+	case "date":
+		order = `ORDER BY p.published_date DESC NULLS LAST, ` + rankExpr + ` DESC`
+	default:
+		if params.Query != "" {
+			order = `ORDER BY ` + rankExpr + ` DESC, p.citation_count DESC NULLS LAST, p.published_date DESC NULLS LAST`
+		} else {
+			order = `ORDER BY p.published_date DESC NULLS LAST`
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	var total int
+	if err := s.db.QueryRow(ctx, `SELECT COUNT(*) `+from, params.Query, params.Source, categories, params.FullText).Scan(&total); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, fmt.Sprintf(`
+		SELECT %s,
+			CASE WHEN $4 AND $1 != '' AND to_tsvector('english', ft.text) @@ plainto_tsquery('english', $1)
+				THEN ts_headline('english', ft.text, plainto_tsquery('english', $1),
+					'StartSel=<mark>, StopSel=</mark>, MaxFragments=1, MaxWords=35, MinWords=15')
+				ELSE ''
+			END
+		%s %s, p.id
+		LIMIT $5 OFFSET $6
+	`, searchDocColumns, from, order), params.Query, params.Source, categories, params.FullText, params.Limit, params.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := &domain.SearchResult{Papers: []*domain.SearchDoc{}, Total: total}
+	for rows.Next() {
+		var snippet string
+		doc, err := scanSearchDoc(rows, &snippet)
+		if err != nil {
+			return nil, err
+		}
+		doc.FullTextSnippet = snippet
+		result.Papers = append(result.Papers, doc)
+	}
+	return result, rows.Err()
+}
+
+// GetByID finds a doc by PG UUID or by the search index ID kept in
+// metadata.search_doc_id.
+func (s *PaperSearcher) GetByID(ctx context.Context, id string) (*domain.SearchDoc, error) {
+	where := `p.metadata->>'search_doc_id' = $1`
+	if _, err := uuid.Parse(id); err == nil {
+		where = `p.id = $1::uuid`
+	}
+	return s.getOne(ctx, where, id)
+}
+
+func (s *PaperSearcher) GetByExternalID(ctx context.Context, externalID string) (*domain.SearchDoc, error) {
+	return s.getOne(ctx, `p.external_id = $1`, externalID)
+}
+
+func (s *PaperSearcher) getOne(ctx context.Context, where string, arg interface{}) (*domain.SearchDoc, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	row := s.db.QueryRow(ctx, `SELECT `+searchDocColumns+` FROM papers p WHERE p.visibility = 'public' AND `+where+` LIMIT 1`, arg)
+	doc, err := scanSearchDoc(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return doc, err
+}
+
+func (s *PaperSearcher) ListByWork(ctx context.Context, workID string, limit int) ([]*domain.SearchDoc, error) {
+	return s.list(ctx, `
+		SELECT `+searchDocColumns+` FROM papers p
+		WHERE p.work_id = $1 AND p.visibility = 'public'
+		ORDER BY p.citation_count DESC NULLS LAST, p.published_date ASC NULLS LAST, p.id
+		LIMIT $2
+	`, workID, limit)
+}
+
+// Random orders candidates by a hash of their ID and the seed. That reads
+// every candidate, which is fine for the tables this backend is meant for.
+func (s *PaperSearcher) Random(ctx context.Context, categories, excludeExternalIDs []string, seed string, limit int) ([]*domain.SearchDoc, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	if len(categories) == 0 {
+		categories = nil
+	}
+	if excludeExternalIDs == nil {
+		excludeExternalIDs = []string{} // NULL would exclude everything
+	}
+	if seed == "" {
+		seed = time.Now().String()
+	}
+	return s.list(ctx, `
+		SELECT `+searchDocColumns+` FROM papers p
+		WHERE p.visibility = 'public' AND p.title != '' AND p.citation_count >= 10
+		AND ($1::text[] IS NULL OR p.categories && $1)
+		AND NOT (p.external_id = ANY($2))
+		ORDER BY md5(p.id::text || $3), p.id
+		LIMIT $4
+	`, categories, excludeExternalIDs, seed, limit)
+}
+
+func (s *PaperSearcher) TopCited(ctx context.Context, numFields int) ([]*domain.SearchDoc, error) {
+	if numFields <= 0 {
+		numFields = 5
+	}
+	return s.list(ctx, `
+		SELECT * FROM (
+			SELECT DISTINCT ON (p.primary_category) `+searchDocColumns+` FROM papers p
+			WHERE p.visibility = 'public' AND p.title != '' AND p.primary_category != ''
+			ORDER BY p.primary_category, p.citation_count DESC NULLS LAST, p.id
+		) best
+		ORDER BY 13 DESC, 9
+		LIMIT $1
+	`, numFields)
+}
+
+// GetCategoryCounts counts public papers per category, as the index does:
+// a paper counts towards each of its categories.
+func (s *PaperSearcher) GetCategoryCounts(ctx context.Context) ([]domain.CategoryCount, error) {
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		SELECT c, COUNT(*)
+		FROM papers p, unnest(p.categories) c
+		WHERE p.visibility = 'public'
+		GROUP BY c
+		ORDER BY COUNT(*) DESC, c
+		LIMIT 200
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []domain.CategoryCount
+	for rows.Next() {
+		var cc domain.CategoryCount
+		if err := rows.Scan(&cc.Category, &cc.Count); err != nil {
+			return nil, err
+		}
+		counts = append(counts, cc)
+	}
+	return counts, rows.Err()
+}
+
+// Index does nothing: papers are searched where they are stored.
+func (s *PaperSearcher) Index(ctx context.Context, doc *domain.SearchDoc) error {
+	return nil
+}
+
+// BulkIndex does nothing, as Index.
+func (s *PaperSearcher) BulkIndex(ctx context.Context, docs []*domain.SearchDoc) error {
+	return nil
+}
+
+// Delete does nothing: deleting the paper removes it from search.
+func (s *PaperSearcher) Delete(ctx context.Context, id string) error {
+	return nil
+}
+
+// DeleteIndex does nothing, as Index.
+func (s *PaperSearcher) DeleteIndex(ctx context.Context) error {
+	return nil
+}
+
+// CreateIndex does nothing, as Index.
+func (s *PaperSearcher) CreateIndex(ctx context.Context) error {
+	return nil
+}
+
+func (s *PaperSearcher) list(ctx context.Context, query string, args ...interface{}) ([]*domain.SearchDoc, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	docs := []*domain.SearchDoc{}
+	for rows.Next() {
+		doc, err := scanSearchDoc(rows)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, rows.Err()
+}
+
+// scanSearchDoc reads searchDocColumns, then any extra columns into dest.
+func scanSearchDoc(row pgx.Row, dest ...interface{}) (*domain.SearchDoc, error) {
+	var (
+		doc           = &domain.SearchDoc{}
+		authors       []byte
+		publishedDate *time.Time
+	)
+	fields := []interface{}{
+		&doc.ID, &doc.ExternalID, &doc.Source, &doc.Title, &doc.Abstract, &authors, &publishedDate,
+		&doc.PDFURL, &doc.PrimaryCategory, &doc.Categories, &doc.DOI, &doc.JournalRef,
+		&doc.CitationCount, &doc.WorkID,
+	}
+	if err := row.Scan(append(fields, dest...)...); err != nil {
+		return nil, err
+	}
+	if len(authors) > 0 {
+		doc.Authors = json.RawMessage(authors)
+	}
+	if publishedDate != nil {
+		date := publishedDate.Format("2006-01-02")
+		doc.PublishedDate = &date
+		doc.Year = publishedDate.Year()
+	}
+	if doc.WorkID == "" {
+		doc.WorkID = paperid.WorkKey(doc.Source, doc.ExternalID, doc.DOI)
+	}
+	return doc, nil
+}
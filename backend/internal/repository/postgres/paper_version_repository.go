@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/internal/domain"
+)
+
+type PaperVersionRepository struct {
+	db *pgxpool.Pool
+}
+
+func NewPaperVersionRepository(db *pgxpool.Pool) *PaperVersionRepository {
+	return &PaperVersionRepository{db: db}
+}
+
+func (r *PaperVersionRepository) GetByPaper(paperID uuid.UUID) ([]*domain.PaperVersion, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := r.db.Query(ctx, `
+		SELECT paper_id, version, version_date, COALESCE(comments, ''), COALESCE(abstract, ''), source, created_at
+		FROM paper_versions
+		WHERE paper_id = $1
+		ORDER BY version
+	`, paperID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var versions []*domain.PaperVersion
+	for rows.Next() {
+		v := &domain.PaperVersion{}
+		if err := rows.Scan(&v.PaperID, &v.Version, &v.Date, &v.Comments, &v.Abstract, &v.Source, &v.CreatedAt); err != nil {
+			return nil, err
+		}
+		versions = append(versions, v)
+	}
+	return versions, rows.Err()
+}
+
+// recordVersionSQL inserts one version and, in the same statement, the
+// notifications for it. $1/$2 identify the paper by id or external_id.
+const recordVersionSQL = `
+	WITH paper AS (
+		SELECT id, title, external_id FROM papers
+		WHERE CASE WHEN $1::uuid IS NULL THEN external_id = $2::text ELSE id = $1::uuid END
+	), inserted AS (
+		INSERT INTO paper_versions (paper_id, version, version_date, comments, abstract, source)
+		SELECT p.id,
+			CASE WHEN $3::int > 0 THEN $3::int
+				ELSE COALESCE((SELECT MAX(version) FROM paper_versions WHERE paper_id = p.id), 0) + 1 END,
+			$4::timestamp, NULLIF($5::text, ''), NULLIF($6::text, ''), $7::text
+		FROM paper p
+		WHERE $3::int > 0 OR NOT EXISTS (
+			SELECT 1 FROM paper_versions WHERE paper_id = p.id AND version_date::date = $4::timestamp::date
+		)
+		ON CONFLICT (paper_id, version) DO NOTHING
+		RETURNING paper_id, version, version_date, comments
+	), notified AS (
+		INSERT INTO notifications (user_id, kind, paper_id, data, dedupe_key)
+		SELECT up.user_id, 'paper_version', i.paper_id,
+			jsonb_build_object('version', i.version, 'version_date', i.version_date, 'title', p.title,
+				'external_id', p.external_id, 'comments', COALESCE(i.comments, '')),
+			'paper_version:' || i.paper_id || ':' || i.version
+		FROM inserted i
+		JOIN paper p ON p.id = i.paper_id
+		JOIN user_papers up ON up.paper_id = i.paper_id
+		WHERE i.version > 1 AND i.version_date > up.saved_at
+		ON CONFLICT (user_id, dedupe_key) DO NOTHING
+		RETURNING id
+	)
+	SELECT (SELECT COUNT(*) FROM inserted), (SELECT COUNT(*) FROM notified)
+`
+
+func (r *PaperVersionRepository) Record(versions []*domain.PaperVersion) (int, error) {
+	if len(versions) == 0 {
+		return 0, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	batch := &pgx.Batch{}
+	for _, v := range versions {
+		var paperID *uuid.UUID
+		if v.PaperID != uuid.Nil {
+			paperID = &v.PaperID
+		}
+		source := v.Source
+		if source == "" {
+			source = domain.VersionSourceOAI
+		}
+		batch.Queue(recordVersionSQL, paperID, v.ExternalID, v.Version, v.Date, v.Comments, v.Abstract, source)
+	}
+
+	br := r.db.SendBatch(ctx, batch)
+	defer br.Close()
+
+	recorded := 0
+	for range versions {
+		var inserted, notified int
+		if err := br.QueryRow().Scan(&inserted, &notified); err != nil {
+			return recorded, err
+		}
+		recorded += inserted
+	}
+	return recorded, nil
+}
@@ -129,6 +129,7 @@ func (r *UserPaperRepository) GetByUser(userID uuid.UUID, status string, bookmar
 		FROM user_papers up
 		JOIN papers p ON up.paper_id = p.id
 		WHERE up.user_id = $1
+		AND (p.visibility != 'private' OR p.owner_id = up.user_id)
 		AND ($2 = '' OR up.status = $2)
 		AND ($3::boolean IS NULL OR up.is_bookmarked = $3)
 		ORDER BY %s
@@ -138,7 +139,9 @@ func (r *UserPaperRepository) GetByUser(userID uuid.UUID, status string, bookmar
 	countQuery := `
 		SELECT COUNT(*)
 		FROM user_papers up
+		JOIN papers p ON up.paper_id = p.id
 		WHERE up.user_id = $1
+		AND (p.visibility != 'private' OR p.owner_id = up.user_id)
 		AND ($2 = '' OR up.status = $2)
 		AND ($3::boolean IS NULL OR up.is_bookmarked = $3)
 	`
@@ -0,0 +1,386 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/localsearch"
+	"github.com/paper-app/backend/pkg/opensearch"
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// Field names in the local index.
+const (
+	fieldTitle     = "title"
+	fieldAbstract  = "abstract"
+	fieldAuthors   = "authors"
+	fieldVenue     = "venue"
+	fieldFullText  = "fulltext"
+	fieldCitations = "citation_count"
+	fieldPublished = "published" // days since 1970
+)
+
+// Local searches an embedded index in a directory, for running without
+// OpenSearch. Its query mirrors the OpenSearch one: the same fields and
+// boosts, minus fuzziness.
+type Local struct {
+	index *localsearch.Index
+}
+
+// OpenLocal opens, or creates, the index in dir.
+func OpenLocal(dir string) (*Local, error) {
+	index, err := localsearch.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Local{index: index}, nil
+}
+
+// Len returns the number of indexed docs.
+func (s *Local) Len() int {
+	return s.index.Len()
+}
+
+// Close closes the index.
+func (s *Local) Close() error {
+	return s.index.Close()
+}
+
+func (s *Local) Search(ctx context.Context, params domain.SearchParams) (*domain.SearchResult, error) {
+	if params.Limit <= 0 {
+		params.Limit = 20
+	}
+	if params.Limit > 100 {
+		params.Limit = 100
+	}
+	q := localsearch.Query{
+		Text: params.Query,
+		Fields: map[string]float64{
+			fieldTitle:    3,
+			fieldAbstract: 1,
+			fieldAuthors:  2,
+			fieldVenue:    1.5,
+		},
+		Phrases: map[string]float64{fieldTitle: 10},
+		Filter:  map[string][]string{},
+		Offset:  params.Offset,
+		Limit:   params.Limit,
+	}
+	if params.FullText {
+		q.Fields[fieldFullText] = 0.5
+		q.Highlight = fieldFullText
+	}
+	if len(params.Categories) > 0 {
+		q.Filter["categories"] = params.Categories
+	}
+	if params.Source != "" {
+		q.Filter["source"] = []string{params.Source}
+	}
+	if params.Collapse {
+		q.Collapse = "work_id"
+	}
+
+	score := localsearch.Sort{Field: localsearch.ScoreField, Desc: true}
+	citations := localsearch.Sort{Field: fieldCitations, Desc: true}
+	published := localsearch.Sort{Field: fieldPublished, Desc: true}
+	switch {
+	case params.SortBy == "citations":
+		q.Sort = []localsearch.Sort{citations, score, published}
+	case params.SortBy == "date":
+		q.Sort = []localsearch.Sort{published, score}
+	case params.Query != "":
+		q.Sort = []localsearch.Sort{score, citations, published}
+	default:
+		q.Sort = []localsearch.Sort{published}
+	}
+
+	res := s.index.Search(q)
+	result := &domain.SearchResult{Papers: make([]*domain.SearchDoc, 0, len(res.Hits)), Total: res.Total}
+	for _, hit := range res.Hits {
+		doc, err := decodeDoc(hit.Doc)
+		if err != nil {
+			return nil, err
+		}
+		doc.FullTextSnippet = hit.Snippet
+		result.Papers = append(result.Papers, doc)
+	}
+	return result, nil
+}
+
+func (s *Local) GetByID(ctx context.Context, id string) (*domain.SearchDoc, error) {
+	d, ok := s.index.Get(id)
+	if !ok {
+		return nil, nil
+	}
+	return decodeDoc(d)
+}
+
+func (s *Local) GetByExternalID(ctx context.Context, externalID string) (*domain.SearchDoc, error) {
+	docs, err := s.find(localsearch.Query{
+		Filter: map[string][]string{"external_id": {externalID}},
+		Limit:  1,
+	})
+	if err != nil || len(docs) == 0 {
+		return nil, err
+	}
+	return docs[0], nil
+}
+
+func (s *Local) ListByWork(ctx context.Context, workID string, limit int) ([]*domain.SearchDoc, error) {
+	return s.find(localsearch.Query{
+		Filter: map[string][]string{"work_id": {workID}},
+		Sort: []localsearch.Sort{
+			{Field: fieldCitations, Desc: true},
+			{Field: fieldPublished},
+		},
+		Limit: limit,
+	})
+}
+
+func (s *Local) Random(ctx context.Context, categories, excludeExternalIDs []string, seed string, limit int) ([]*domain.SearchDoc, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+	q := localsearch.Query{
+		Filter:  map[string][]string{},
+		Exclude: map[string][]string{"external_id": excludeExternalIDs},
+		Min:     map[string]float64{fieldCitations: 10},
+		Seed:    seed,
+		Limit:   limit,
+	}
+	if q.Seed == "" {
+		q.Seed = time.Now().String()
+	}
+	if len(categories) > 0 {
+		q.Filter["categories"] = categories
+	}
+	return s.find(q)
+}
+
+func (s *Local) TopCited(ctx context.Context, numFields int) ([]*domain.SearchDoc, error) {
+	if numFields <= 0 {
+		numFields = 5
+	}
+	var best []*domain.SearchDoc
+	for category := range s.index.Terms("primary_category") {
+		docs, err := s.find(localsearch.Query{
+			Filter: map[string][]string{"primary_category": {category}},
+			Sort:   []localsearch.Sort{{Field: fieldCitations, Desc: true}},
+			Limit:  1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		best = append(best, docs...)
+	}
+	sort.Slice(best, func(i, j int) bool {
+		if best[i].CitationCount != best[j].CitationCount {
+			return best[i].CitationCount > best[j].CitationCount
+		}
+		return best[i].PrimaryCategory < best[j].PrimaryCategory
+	})
+	if len(best) > numFields {
+		best = best[:numFields]
+	}
+	return best, nil
+}
+
+func (s *Local) GetCategoryCounts(ctx context.Context) ([]domain.CategoryCount, error) {
+	counts := sortedCounts(s.index.Terms("categories"))
+	if len(counts) > 200 {
+		counts = counts[:200]
+	}
+	return counts, nil
+}
+
+func (s *Local) Index(ctx context.Context, doc *domain.SearchDoc) error {
+	d, err := encodeDoc(doc)
+	if err != nil {
+		return err
+	}
+	return s.index.Put(d)
+}
+
+func (s *Local) BulkIndex(ctx context.Context, docs []*domain.SearchDoc) error {
+	batch := make([]*localsearch.Doc, 0, len(docs))
+	for _, doc := range docs {
+		d, err := encodeDoc(doc)
+		if err != nil {
+			return err
+		}
+		batch = append(batch, d)
+	}
+	return s.index.Put(batch...)
+}
+
+func (s *Local) Delete(ctx context.Context, id string) error {
+	return s.index.Delete(id)
+}
+
+func (s *Local) DeleteIndex(ctx context.Context) error {
+	return s.index.Clear()
+}
+
+// CreateIndex does nothing: the index exists once it is open.
+func (s *Local) CreateIndex(ctx context.Context) error {
+	return nil
+}
+
+// BulkSync writes PostgreSQL's view of papers for searchsync. As with
+// OpenSearch, the S2 fields are kept from the indexed doc unless
+// PostgreSQL has them, and so is its full text if the paper has none.
+func (s *Local) BulkSync(ctx context.Context, docs []*opensearch.PaperDoc, deletes []string) ([]opensearch.BulkFailure, error) {
+	var failures []opensearch.BulkFailure
+	batch := make([]*localsearch.Doc, 0, len(docs))
+	for _, pd := range docs {
+		doc := FromPaperDoc(pd)
+		var fulltext string
+		if old, ok := s.index.Get(doc.ID); ok {
+			prev, err := decodeDoc(old)
+			if err == nil && !pd.S2Fields {
+				doc.ReferenceCount = prev.ReferenceCount
+				doc.InfluentialCitationCount = prev.InfluentialCitationCount
+				doc.Venue = prev.Venue
+				doc.PublicationTypes = prev.PublicationTypes
+				doc.S2URL = prev.S2URL
+				doc.IsOpenAccess = prev.IsOpenAccess
+				doc.TLDR = prev.TLDR
+			}
+			fulltext = old.Text[fieldFullText]
+		}
+		d, err := encodeDoc(doc)
+		if err != nil {
+			failures = append(failures, opensearch.BulkFailure{ID: doc.ID, Status: 400, Reason: err.Error()})
+			continue
+		}
+		if len(doc.FullTextSections) == 0 && fulltext != "" {
+			d.Text[fieldFullText] = fulltext
+		}
+		batch = append(batch, d)
+	}
+	if err := s.index.Put(batch...); err != nil {
+		return nil, err
+	}
+	if err := s.index.Delete(deletes...); err != nil {
+		return nil, err
+	}
+	return failures, nil
+}
+
+// find runs a query and decodes its hits.
+func (s *Local) find(q localsearch.Query) ([]*domain.SearchDoc, error) {
+	res := s.index.Search(q)
+	docs := make([]*domain.SearchDoc, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		doc, err := decodeDoc(hit.Doc)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// encodeDoc builds the index's doc. The full text is indexed but, as in
+// OpenSearch, not kept in the source that hits return.
+func encodeDoc(doc *domain.SearchDoc) (*localsearch.Doc, error) {
+	stored := *doc
+	if stored.WorkID == "" {
+		stored.WorkID = paperid.WorkKey(doc.Source, doc.ExternalID, doc.DOI)
+	}
+	stored.FullTextSections = nil
+	stored.FullTextSnippet = ""
+	stored.RemoteSource = ""
+	source, err := json.Marshal(&stored)
+	if err != nil {
+		return nil, err
+	}
+
+	var sections []string
+	for _, s := range doc.FullTextSections {
+		sections = append(sections, s.Text)
+	}
+	d := &localsearch.Doc{
+		ID: doc.ID,
+		Text: map[string]string{
+			fieldTitle:    doc.Title,
+			fieldAbstract: doc.Abstract,
+			fieldAuthors:  strings.Join(authorNames(doc.Authors), ", "),
+			fieldVenue:    doc.Venue,
+			fieldFullText: strings.Join(sections, "\n\n"),
+		},
+		Keywords: map[string][]string{
+			"categories":       keywords(doc.Categories...),
+			"primary_category": keywords(doc.PrimaryCategory),
+			"external_id":      keywords(doc.ExternalID),
+			"source":           keywords(doc.Source),
+			"work_id":          keywords(stored.WorkID),
+		},
+		Numbers: map[string]float64{fieldCitations: float64(doc.CitationCount)},
+		Source:  source,
+	}
+	if days, ok := publishedDays(doc); ok {
+		d.Numbers[fieldPublished] = days
+	}
+	return d, nil
+}
+
+// keywords drops empty values, which aren't indexed.
+func keywords(values ...string) []string {
+	var kept []string
+	for _, v := range values {
+		if v != "" {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func decodeDoc(d *localsearch.Doc) (*domain.SearchDoc, error) {
+	doc := &domain.SearchDoc{}
+	if err := json.Unmarshal(d.Source, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// authorNames reads the names out of an authors field, which holds
+// objects with a name or, from some importers, plain strings.
+func authorNames(authors interface{}) []string {
+	raw, err := json.Marshal(authors)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	if json.Unmarshal(raw, &names) == nil {
+		return names
+	}
+	var objs []struct {
+		Name string `json:"name"`
+	}
+	json.Unmarshal(raw, &objs)
+	for _, a := range objs {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// publishedDays is a doc's publication date as days since 1970, from its
+// published_date (yyyy-MM-dd, yyyy-MM or yyyy) or else its year.
+func publishedDays(doc *domain.SearchDoc) (float64, bool) {
+	if doc.PublishedDate != nil {
+		for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+			if t, err := time.Parse(layout, *doc.PublishedDate); err == nil {
+				return float64(t.Unix() / 86400), true
+			}
+		}
+	}
+	if doc.Year != 0 {
+		return float64(time.Date(doc.Year, 1, 1, 0, 0, 0, 0, time.UTC).Unix() / 86400), true
+	}
+	return 0, false
+}
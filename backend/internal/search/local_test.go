@@ -0,0 +1,40 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/internal/search"
+	"github.com/paper-app/backend/internal/search/searchtest"
+)
+
+// TestLocalContract runs the searchtest contract against the local index
+// in a temporary directory. cmd/conformance runs the same suite and can
+// also check the postgres and opensearch backends.
+func TestLocalContract(t *testing.T) {
+	local, err := search.OpenLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer local.Close()
+
+	load := func(ctx context.Context, docs []*domain.SearchDoc) error {
+		if err := local.DeleteIndex(ctx); err != nil {
+			return err
+		}
+		if err := local.CreateIndex(ctx); err != nil {
+			return err
+		}
+		return local.BulkIndex(ctx, docs)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, r := range searchtest.Run(ctx, &searchtest.Backend{Name: "local", Searcher: local, Load: load}) {
+		if r.Err != nil {
+			t.Errorf("%s: %v", r.Check, r.Err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+// Package search implements domain.PaperSearcher on OpenSearch and on an
+// embedded on-disk index (pkg/localsearch); the PostgreSQL full-text
+// implementation is postgres.PaperSearcher. searchtest holds the
+// behaviour they share, which cmd/conformance checks.
+package search
+
+import (
+	"context"
+	"sort"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/opensearch"
+)
+
+// OpenSearch searches an OpenSearch index.
+type OpenSearch struct {
+	client *opensearch.Client
+}
+
+// NewOpenSearch wraps a client.
+func NewOpenSearch(client *opensearch.Client) *OpenSearch {
+	return &OpenSearch{client: client}
+}
+
+// Client returns the wrapped client.
+func (s *OpenSearch) Client() *opensearch.Client {
+	return s.client
+}
+
+func (s *OpenSearch) Search(ctx context.Context, params domain.SearchParams) (*domain.SearchResult, error) {
+	res, err := s.client.Search(ctx, opensearch.SearchParams{
+		Query:           params.Query,
+		Source:          params.Source,
+		Categories:      params.Categories,
+		SortBy:          params.SortBy,
+		Limit:           params.Limit,
+		Offset:          params.Offset,
+		IncludeFullText: params.FullText,
+		Collapse:        params.Collapse,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &domain.SearchResult{Papers: make([]*domain.SearchDoc, 0, len(res.Hits)), Total: res.Total}
+	for _, hit := range res.Hits {
+		result.Papers = append(result.Papers, FromPaperDoc(&hit.Doc))
+	}
+	return result, nil
+}
+
+func (s *OpenSearch) GetByID(ctx context.Context, id string) (*domain.SearchDoc, error) {
+	doc, err := s.client.GetByID(ctx, id)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return FromPaperDoc(doc), nil
+}
+
+func (s *OpenSearch) GetByExternalID(ctx context.Context, externalID string) (*domain.SearchDoc, error) {
+	doc, err := s.client.SearchByExternalID(ctx, externalID)
+	if err != nil || doc == nil {
+		return nil, err
+	}
+	return FromPaperDoc(doc), nil
+}
+
+func (s *OpenSearch) ListByWork(ctx context.Context, workID string, limit int) ([]*domain.SearchDoc, error) {
+	docs, err := s.client.SearchByWorkID(ctx, workID, limit)
+	return fromPaperDocs(docs), err
+}
+
+func (s *OpenSearch) Random(ctx context.Context, categories, excludeExternalIDs []string, seed string, limit int) ([]*domain.SearchDoc, error) {
+	docs, err := s.client.GetRandomPapers(ctx, categories, excludeExternalIDs, seed, limit)
+	return fromPaperDocs(docs), err
+}
+
+func (s *OpenSearch) TopCited(ctx context.Context, numFields int) ([]*domain.SearchDoc, error) {
+	docs, err := s.client.GetTopCitedDiverseFields(ctx, numFields)
+	return fromPaperDocs(docs), err
+}
+
+func (s *OpenSearch) GetCategoryCounts(ctx context.Context) ([]domain.CategoryCount, error) {
+	counts, err := s.client.GetCategoryCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sortedCounts(counts), nil
+}
+
+func (s *OpenSearch) Index(ctx context.Context, doc *domain.SearchDoc) error {
+	return s.client.IndexDoc(ctx, ToPaperDoc(doc))
+}
+
+func (s *OpenSearch) BulkIndex(ctx context.Context, docs []*domain.SearchDoc) error {
+	paperDocs := make([]*opensearch.PaperDoc, 0, len(docs))
+	for _, d := range docs {
+		paperDocs = append(paperDocs, ToPaperDoc(d))
+	}
+	_, err := s.client.BulkIndex(ctx, paperDocs)
+	return err
+}
+
+func (s *OpenSearch) Delete(ctx context.Context, id string) error {
+	_, err := s.client.DeleteDoc(ctx, id)
+	return err
+}
+
+func (s *OpenSearch) DeleteIndex(ctx context.Context) error {
+	return s.client.DeleteIndex(ctx)
+}
+
+func (s *OpenSearch) CreateIndex(ctx context.Context) error {
+	return s.client.CreateIndex(ctx)
+}
+
+// FromPaperDoc converts an OpenSearch document.
+func FromPaperDoc(d *opensearch.PaperDoc) *domain.SearchDoc {
+	doc := &domain.SearchDoc{
+		ID:                       d.ID,
+		ExternalID:               d.ExternalID,
+		Source:                   d.Source,
+		Title:                    d.Title,
+		Abstract:                 d.Abstract,
+		Authors:                  d.Authors,
+		PublishedDate:            d.PublishedDate,
+		Year:                     d.Year,
+		PDFURL:                   d.PDFURL,
+		PrimaryCategory:          d.PrimaryCategory,
+		Categories:               d.Categories,
+		DOI:                      d.DOI,
+		JournalRef:               d.JournalRef,
+		CitationCount:            d.CitationCount,
+		ReferenceCount:           d.ReferenceCount,
+		InfluentialCitationCount: d.InfluentialCitationCount,
+		Venue:                    d.Venue,
+		PublicationTypes:         d.PublicationTypes,
+		S2URL:                    d.S2URL,
+		IsOpenAccess:             d.IsOpenAccess,
+		TLDR:                     d.TLDR,
+		WorkID:                   d.WorkID,
+		FullTextSnippet:          d.FullTextSnippet,
+		RemoteSource:             d.RemoteSource,
+	}
+	for _, s := range d.FullTextSections {
+		doc.FullTextSections = append(doc.FullTextSections, domain.FullTextSection{Title: s.Title, Text: s.Text})
+	}
+	return doc
+}
+
+// ToPaperDoc converts a doc for OpenSearch.
+func ToPaperDoc(d *domain.SearchDoc) *opensearch.PaperDoc {
+	doc := &opensearch.PaperDoc{
+		ID:                       d.ID,
+		ExternalID:               d.ExternalID,
+		Source:                   d.Source,
+		Title:                    d.Title,
+		Abstract:                 d.Abstract,
+		Authors:                  d.Authors,
+		PublishedDate:            d.PublishedDate,
+		Year:                     d.Year,
+		PDFURL:                   d.PDFURL,
+		PrimaryCategory:          d.PrimaryCategory,
+		Categories:               d.Categories,
+		DOI:                      d.DOI,
+		JournalRef:               d.JournalRef,
+		CitationCount:            d.CitationCount,
+		ReferenceCount:           d.ReferenceCount,
+		InfluentialCitationCount: d.InfluentialCitationCount,
+		Venue:                    d.Venue,
+		PublicationTypes:         d.PublicationTypes,
+		S2URL:                    d.S2URL,
+		IsOpenAccess:             d.IsOpenAccess,
+		TLDR:                     d.TLDR,
+		WorkID:                   d.WorkID,
+		FullTextSnippet:          d.FullTextSnippet,
+		RemoteSource:             d.RemoteSource,
+	}
+	for _, s := range d.FullTextSections {
+		doc.FullTextSections = append(doc.FullTextSections, opensearch.FullTextSection{Title: s.Title, Text: s.Text})
+	}
+	return doc
+}
+
+func fromPaperDocs(docs []*opensearch.PaperDoc) []*domain.SearchDoc {
+	out := make([]*domain.SearchDoc, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, FromPaperDoc(d))
+	}
+	return out
+}
+
+// sortedCounts lists category counts, largest first.
+func sortedCounts(counts map[string]int64) []domain.CategoryCount {
+	out := make([]domain.CategoryCount, 0, len(counts))
+	for category, n := range counts {
+		out = append(out, domain.CategoryCount{Category: category, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Category < out[j].Category
+	})
+	return out
+}
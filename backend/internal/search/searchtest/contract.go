@@ -0,0 +1,423 @@
+// Package searchtest is the behaviour every domain.PaperSearcher shares,
+// checked against a small fixed corpus. go test ./internal/search runs it
+// against the local index; cmd/conformance against any search backend.
+package searchtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/paper-app/backend/internal/domain"
+)
+
+// Result is the outcome of one contract check.
+type Result struct {
+	Check string
+	Err   error // nil = passed
+}
+
+// Backend is a searcher under test.
+type Backend struct {
+	Name     string
+	Searcher domain.PaperSearcher
+	// Load replaces everything the searcher finds with docs, and returns
+	// once they are searchable.
+	Load func(ctx context.Context, docs []*domain.SearchDoc) error
+}
+
+// Fixture docs, by role
+const (
+	idAttention = "00000000-0000-4000-8000-000000000001"
+	idResNet    = "00000000-0000-4000-8000-000000000002"
+	idPreprint  = "00000000-0000-4000-8000-000000000003" // of workGraph
+	idJournal   = "00000000-0000-4000-8000-000000000004" // of workGraph
+	idFolding   = "00000000-0000-4000-8000-000000000005" // few citations, has full text
+	idGAT       = "00000000-0000-4000-8000-000000000006"
+	idBayes     = "00000000-0000-4000-8000-000000000007" // no date
+
+	workGraph = "doi:10.1000/graph.2021.1"
+)
+
+// Docs returns the corpus the checks expect a backend to hold.
+func Docs() []*domain.SearchDoc {
+	date := func(s string) *string { return &s }
+	author := func(names ...string) []map[string]string {
+		var authors []map[string]string
+		for _, n := range names {
+			authors = append(authors, map[string]string{"name": n, "affiliation": ""})
+		}
+		return authors
+	}
+	return []*domain.SearchDoc{
+		{
+			ID: idAttention, ExternalID: "1706.03762", Source: "arxiv",
+			Title:    "Attention Is All You Need",
+			Abstract: "The dominant sequence transduction models are based on recurrent or convolutional neural networks. We propose the Transformer, based solely on attention mechanisms.",
+			Authors:  author("Ashish Vaswani", "Noam Shazeer"), PublishedDate: date("2017-06-12"), Year: 2017,
+			PrimaryCategory: "cs.CL", Categories: []string{"cs.CL", "cs.LG"}, CitationCount: 90000,
+			WorkID: "arxiv:1706.03762",
+		},
+		{
+			ID: idResNet, ExternalID: "1512.03385", Source: "arxiv",
+			Title:    "Deep Residual Learning for Image Recognition",
+			Abstract: "Deeper neural networks are more difficult to train. We present a residual learning framework to ease the training of very deep networks.",
+			Authors:  author("Kaiming He", "Xiangyu Zhang"), PublishedDate: date("2015-12-10"), Year: 2015,
+			PrimaryCategory: "cs.CV", Categories: []string{"cs.CV"}, CitationCount: 150000,
+			WorkID: "arxiv:1512.03385",
+		},
+		{
+			ID: idPreprint, ExternalID: "2001.00001", Source: "arxiv",
+			Title:    "Message Passing Networks for Molecules",
+			Abstract: "We learn molecular properties with message passing on molecular graphs.",
+			Authors:  author("Ada Lovelace"), PublishedDate: date("2020-01-01"), Year: 2020,
+			PrimaryCategory: "cs.LG", Categories: []string{"cs.LG"}, CitationCount: 500,
+			WorkID: workGraph,
+		},
+		{
+			ID: idJournal, ExternalID: "33000001", Source: "pubmed",
+			Title:    "Message Passing Networks for Molecules",
+			Abstract: "We learn molecular properties with message passing on molecular graphs.",
+			Authors:  author("Ada Lovelace"), PublishedDate: date("2021-05-01"), Year: 2021,
+			PrimaryCategory: "cs.LG", Categories: []string{"cs.LG"}, CitationCount: 800,
+			DOI: "10.1000/graph.2021.1", WorkID: workGraph,
+		},
+		{
+			ID: idFolding, ExternalID: "2107.00001", Source: "arxiv",
+			Title:    "Protein Structure Prediction",
+			Abstract: "We predict the three-dimensional structure of proteins from their sequence.",
+			Authors:  author("Grace Hopper"), PublishedDate: date("2021-07-15"), Year: 2021,
+			PrimaryCategory: "q-bio.BM", Categories: []string{"q-bio.BM"}, CitationCount: 5,
+			WorkID: "arxiv:2107.00001",
+			FullTextSections: []domain.FullTextSection{
+				{Title: "Methods", Text: "Our model builds on the Evoformer block and recycles its predictions three times before the structure module."},
+			},
+		},
+		{
+			ID: idGAT, ExternalID: "1710.10903", Source: "arxiv",
+			Title:    "Graph Attention Networks",
+			Abstract: "We present graph attention networks, novel neural network architectures that operate on graph-structured data.",
+			Authors:  author("Petar Velickovic"), PublishedDate: date("2017-10-30"), Year: 2017,
+			PrimaryCategory: "cs.LG", Categories: []string{"cs.LG", "stat.ML"}, CitationCount: 12000,
+			WorkID: "arxiv:1710.10903",
+		},
+		{
+			ID: idBayes, ExternalID: "W2000000001", Source: "openalex",
+			Title:           "Notes on Bayesian Inference",
+			Abstract:        "A short introduction to priors, posteriors and Markov chain Monte Carlo.",
+			Authors:         author("Thomas Bayes"),
+			PrimaryCategory: "stat.ML", Categories: []string{"stat.ML"}, CitationCount: 20,
+			WorkID: "openalex:W2000000001",
+		},
+	}
+}
+
+// Run loads the corpus into b and checks that:
+//   - queries match on title, abstract and authors, plural or not, and rank
+//     a title holding the whole query first
+//   - category and source filters, sorts, paging and collapsing by work
+//     behave as the OpenSearch query does
+//   - full text is only matched when asked for, with a <mark>ed snippet
+//   - lookups return the doc asked for, and nil, nil for unknown IDs
+//   - Random, TopCited and GetCategoryCounts follow their documented rules
+func Run(ctx context.Context, b *Backend) []Result {
+	var results []Result
+	check := func(name string, fn func() error) {
+		results = append(results, Result{Check: name, Err: fn()})
+	}
+	s := b.Searcher
+
+	if err := b.Load(ctx, Docs()); err != nil {
+		return []Result{{Check: "load", Err: err}}
+	}
+
+	search := func(p domain.SearchParams) (*domain.SearchResult, error) {
+		if p.Limit == 0 {
+			p.Limit = 20
+		}
+		return s.Search(ctx, p)
+	}
+
+	check("search/relevance", func() error {
+		res, err := search(domain.SearchParams{Query: "residual learning image recognition"})
+		if err != nil {
+			return err
+		}
+		return expectFirst(res.Papers, idResNet)
+	})
+	check("search/title_phrase", func() error {
+		res, err := search(domain.SearchParams{Query: "graph attention networks"})
+		if err != nil {
+			return err
+		}
+		return expectFirst(res.Papers, idGAT)
+	})
+	check("search/plural", func() error {
+		res, err := search(domain.SearchParams{Query: "molecule"})
+		if err != nil {
+			return err
+		}
+		return expectIDs(res.Papers, idPreprint, idJournal)
+	})
+	check("search/author", func() error {
+		res, err := search(domain.SearchParams{Query: "Vaswani"})
+		if err != nil {
+			return err
+		}
+		return expectIDs(res.Papers, idAttention)
+	})
+	check("search/no_match", func() error {
+		res, err := search(domain.SearchParams{Query: "zyxwvutsrq"})
+		if err != nil {
+			return err
+		}
+		if res.Total != 0 || len(res.Papers) != 0 {
+			return fmt.Errorf("got %d papers (total %d), want none", len(res.Papers), res.Total)
+		}
+		return nil
+	})
+	check("search/all", func() error {
+		res, err := search(domain.SearchParams{})
+		if err != nil {
+			return err
+		}
+		if res.Total != len(Docs()) || len(res.Papers) != len(Docs()) {
+			return fmt.Errorf("got %d papers (total %d), want %d", len(res.Papers), res.Total, len(Docs()))
+		}
+		return nil
+	})
+	check("search/categories", func() error {
+		res, err := search(domain.SearchParams{Categories: []string{"cs.CV", "q-bio.BM"}})
+		if err != nil {
+			return err
+		}
+		return expectIDs(res.Papers, idResNet, idFolding)
+	})
+	check("search/source", func() error {
+		res, err := search(domain.SearchParams{Source: "pubmed"})
+		if err != nil {
+			return err
+		}
+		return expectIDs(res.Papers, idJournal)
+	})
+	check("search/sort_citations", func() error {
+		res, err := search(domain.SearchParams{SortBy: "citations"})
+		if err != nil {
+			return err
+		}
+		return expectOrder(res.Papers, idResNet, idAttention, idGAT, idJournal, idPreprint, idBayes, idFolding)
+	})
+	check("search/sort_date", func() error {
+		res, err := search(domain.SearchParams{SortBy: "date"})
+		if err != nil {
+			return err
+		}
+		return expectOrder(res.Papers, idFolding, idJournal, idPreprint, idGAT, idAttention, idResNet, idBayes)
+	})
+	check("search/paging", func() error {
+		res, err := search(domain.SearchParams{SortBy: "citations", Offset: 2, Limit: 2})
+		if err != nil {
+			return err
+		}
+		if res.Total != len(Docs()) {
+			return fmt.Errorf("total = %d, want %d", res.Total, len(Docs()))
+		}
+		return expectOrder(res.Papers, idGAT, idJournal)
+	})
+	check("search/collapse", func() error {
+		res, err := search(domain.SearchParams{Collapse: true})
+		if err != nil {
+			return err
+		}
+		if res.Total != len(Docs())-1 || len(res.Papers) != len(Docs())-1 {
+			return fmt.Errorf("got %d papers (total %d), want %d works", len(res.Papers), res.Total, len(Docs())-1)
+		}
+		seen := map[string]bool{}
+		for _, p := range res.Papers {
+			if seen[p.WorkID] {
+				return fmt.Errorf("work %s returned twice", p.WorkID)
+			}
+			seen[p.WorkID] = true
+		}
+		return nil
+	})
+	check("search/fulltext", func() error {
+		res, err := search(domain.SearchParams{Query: "evoformer"})
+		if err != nil {
+			return err
+		}
+		if len(res.Papers) != 0 {
+			return fmt.Errorf("full text matched without FullText: %s", ids(res.Papers))
+		}
+		res, err = search(domain.SearchParams{Query: "evoformer", FullText: true})
+		if err != nil {
+			return err
+		}
+		if err := expectIDs(res.Papers, idFolding); err != nil {
+			return err
+		}
+		if snippet := res.Papers[0].FullTextSnippet; !strings.Contains(snippet, "<mark>") {
+			return fmt.Errorf("snippet %q has no <mark>", snippet)
+		}
+		if len(res.Papers[0].FullTextSections) > 0 {
+			return fmt.Errorf("full text sections returned")
+		}
+		return nil
+	})
+
+	check("get_by_id", func() error {
+		doc, err := s.GetByID(ctx, idAttention)
+		if err != nil {
+			return err
+		}
+		if doc == nil || doc.ExternalID != "1706.03762" {
+			return fmt.Errorf("GetByID(%s) = %v, want 1706.03762", idAttention, describe(doc))
+		}
+		if doc.Title != "Attention Is All You Need" || doc.CitationCount != 90000 || len(doc.Categories) != 2 {
+			return fmt.Errorf("GetByID(%s) lost fields: %+v", idAttention, doc)
+		}
+		return nil
+	})
+	check("get_by_id/not_found", func() error {
+		for _, id := range []string{"00000000-0000-4000-8000-0000000000ff", "no-such-doc"} {
+			doc, err := s.GetByID(ctx, id)
+			if err != nil || doc != nil {
+				return fmt.Errorf("GetByID(%s) = %v, %v; want nil, nil", id, describe(doc), err)
+			}
+		}
+		return nil
+	})
+	check("get_by_external_id", func() error {
+		doc, err := s.GetByExternalID(ctx, "33000001")
+		if err != nil {
+			return err
+		}
+		if doc == nil || doc.ID != idJournal {
+			return fmt.Errorf("GetByExternalID(33000001) = %v, want %s", describe(doc), idJournal)
+		}
+		doc, err = s.GetByExternalID(ctx, "0000.00000")
+		if err != nil || doc != nil {
+			return fmt.Errorf("unknown external ID: got %v, %v; want nil, nil", describe(doc), err)
+		}
+		return nil
+	})
+	check("list_by_work", func() error {
+		docs, err := s.ListByWork(ctx, workGraph, 10)
+		if err != nil {
+			return err
+		}
+		return expectOrder(docs, idJournal, idPreprint)
+	})
+
+	check("random", func() error {
+		first, err := s.Random(ctx, nil, []string{"1706.03762"}, "seed-1", 10)
+		if err != nil {
+			return err
+		}
+		// Everything with 10+ citations but the excluded one
+		if err := expectIDs(first, idResNet, idPreprint, idJournal, idGAT, idBayes); err != nil {
+			return err
+		}
+		again, err := s.Random(ctx, nil, []string{"1706.03762"}, "seed-1", 10)
+		if err != nil {
+			return err
+		}
+		if ids(first) != ids(again) {
+			return fmt.Errorf("same seed, different order: %s then %s", ids(first), ids(again))
+		}
+		return nil
+	})
+	check("random/categories", func() error {
+		docs, err := s.Random(ctx, []string{"stat.ML"}, nil, "seed-2", 10)
+		if err != nil {
+			return err
+		}
+		return expectIDs(docs, idGAT, idBayes)
+	})
+	check("random/limit", func() error {
+		docs, err := s.Random(ctx, nil, nil, "seed-3", 2)
+		if err != nil {
+			return err
+		}
+		if len(docs) != 2 {
+			return fmt.Errorf("got %d docs for limit 2", len(docs))
+		}
+		return nil
+	})
+	check("top_cited", func() error {
+		docs, err := s.TopCited(ctx, 3)
+		if err != nil {
+			return err
+		}
+		return expectOrder(docs, idResNet, idAttention, idGAT)
+	})
+	check("category_counts", func() error {
+		counts, err := s.GetCategoryCounts(ctx)
+		if err != nil {
+			return err
+		}
+		want := map[string]int64{"cs.LG": 4, "stat.ML": 2, "cs.CL": 1, "cs.CV": 1, "q-bio.BM": 1}
+		if len(counts) != len(want) {
+			return fmt.Errorf("got %d categories, want %d: %v", len(counts), len(want), counts)
+		}
+		for i, c := range counts {
+			if want[c.Category] != c.Count {
+				return fmt.Errorf("%s: count %d, want %d", c.Category, c.Count, want[c.Category])
+			}
+			if i > 0 && c.Count > counts[i-1].Count {
+				return fmt.Errorf("counts not sorted: %v", counts)
+			}
+		}
+		return nil
+	})
+
+	return results
+}
+
+func ids(docs []*domain.SearchDoc) string {
+	var out []string
+	for _, d := range docs {
+		out = append(out, d.ID)
+	}
+	return "[" + strings.Join(out, " ") + "]"
+}
+
+func describe(doc *domain.SearchDoc) string {
+	if doc == nil {
+		return "nil"
+	}
+	return doc.ID
+}
+
+// expectFirst checks the top hit.
+func expectFirst(docs []*domain.SearchDoc, id string) error {
+	if len(docs) == 0 || docs[0].ID != id {
+		return fmt.Errorf("got %s, want %s first", ids(docs), id)
+	}
+	return nil
+}
+
+// expectIDs checks the hits are exactly these docs, in any order.
+func expectIDs(docs []*domain.SearchDoc, want ...string) error {
+	got := map[string]bool{}
+	for _, d := range docs {
+		got[d.ID] = true
+	}
+	if len(got) != len(docs) || len(docs) != len(want) {
+		return fmt.Errorf("got %s, want %v", ids(docs), want)
+	}
+	for _, id := range want {
+		if !got[id] {
+			return fmt.Errorf("got %s, want %v", ids(docs), want)
+		}
+	}
+	return nil
+}
+
+// expectOrder checks the hits are exactly these docs, in this order.
+func expectOrder(docs []*domain.SearchDoc, want ...string) error {
+	if got := ids(docs); got != "["+strings.Join(want, " ")+"]" {
+		return fmt.Errorf("got %s, want %v", got, want)
+	}
+	return nil
+}
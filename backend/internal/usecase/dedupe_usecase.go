@@ -0,0 +1,492 @@
+package usecase
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// MinMergeTitleSimilarity is the title similarity two records sharing an
+// identifier need before they are merged. Lower scores point at a bad
+// identifier upstream (a DOI reused for an erratum, a wrong PMID) and are
+// reported instead.
+const MinMergeTitleSimilarity = 0.6
+
+// Reasons a pair of records sharing an identifier is not merged.
+const (
+	SkipCrossKind     = "preprint and published record" // linked as one work instead
+	SkipTitleMismatch = "titles differ"
+	SkipNotPublic     = "not a public paper"
+)
+
+// survivorRank orders sources by whose record survives a merge: the
+// preprint servers own their IDs and version history, PubMed its MeSH terms.
+var survivorRank = []string{"arxiv", "biorxiv", "medrxiv", "pubmed", "crossref", "openalex", "semanticscholar"}
+
+// fieldRank overrides survivorRank for fields some sources know better:
+// publication details come from the publisher side.
+var fieldRank = map[string][]string{
+	"published_date": {"crossref", "pubmed", "openalex", "semanticscholar", "arxiv", "biorxiv", "medrxiv"},
+	"journal_ref":    {"crossref", "pubmed", "openalex", "semanticscholar", "arxiv", "biorxiv", "medrxiv"},
+	"license":        {"crossref", "arxiv", "biorxiv", "medrxiv", "openalex", "pubmed", "semanticscholar"},
+	"pdf_url":        {"arxiv", "biorxiv", "medrxiv", "openalex", "semanticscholar", "pubmed", "crossref"},
+}
+
+var (
+	pmidRe     = regexp.MustCompile(`^\d{1,9}$`)
+	pmcidRe    = regexp.MustCompile(`(?i)\b(PMC\d+)\b`)
+	openalexRe = regexp.MustCompile(`(?i)(?:^|openalex\.org/(?:works/)?)(W\d+)/?$`)
+	corpusIDRe = regexp.MustCompile(`^\d+$`)
+	s2PaperRe  = regexp.MustCompile(`(?i)^[0-9a-f]{40}$`)
+	s2HashRe   = regexp.MustCompile(`(?i)\b([0-9a-f]{40})\b`)
+	// "PMID: 123", pubmed.ncbi.nlm.nih.gov/123/, ncbi.nlm.nih.gov/pubmed/123
+	pubmedRe = regexp.MustCompile(`(?i)(?:^pmid:?\s*|pubmed\.ncbi\.nlm\.nih\.gov/|ncbi\.nlm\.nih\.gov/pubmed/)(\d{1,9})/?$`)
+	// "CorpusId:123", api.semanticscholar.org/CorpusID:123
+	corpusIDRefRe = regexp.MustCompile(`(?i)corpusid:\s*(\d+)/?$`)
+)
+
+// DedupeUsecase keeps the identifier crosswalk and merges records of one
+// paper that arrived from different sources.
+type DedupeUsecase struct {
+	paperRepo domain.PaperRepository
+	idRepo    domain.IdentifierRepository
+}
+
+func NewDedupeUsecase(paperRepo domain.PaperRepository, idRepo domain.IdentifierRepository) *DedupeUsecase {
+	return &DedupeUsecase{paperRepo: paperRepo, idRepo: idRepo}
+}
+
+// ---------- Identifiers ----------
+
+// ExtractIdentifiers returns the normalized identifiers of a paper.
+// Preprint records only claim their own identity (arXiv ID, preprint DOI):
+// the journal DOI or PMID a preprint carries belongs to the published
+// record, which is a separate paper of the same work.
+func ExtractIdentifiers(p *domain.Paper) []domain.Identifier {
+	var ids []domain.Identifier
+	seen := map[string]bool{}
+	add := func(scheme, value string) {
+		if value == "" || seen[scheme+":"+value] {
+			return
+		}
+		seen[scheme+":"+value] = true
+		ids = append(ids, domain.Identifier{Scheme: scheme, Value: value})
+	}
+
+	meta := metadataMap(p.Metadata)
+	metaString := func(key string) string {
+		switch v := meta[key].(type) {
+		case string:
+			return strings.TrimSpace(v)
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+		return ""
+	}
+
+	doi := paperDOI(p, meta)
+	switch p.Source {
+	case "arxiv":
+		add(domain.SchemeArxiv, paperid.NormalizeArxivID(p.ExternalID))
+	case "biorxiv", "medrxiv":
+		add(domain.SchemeDOI, paperid.NormalizeDOI(p.ExternalID))
+		return ids
+	}
+	if arxivID := paperid.NormalizeArxivID(doi); arxivID != "" {
+		// Another source's copy of an arXiv preprint
+		add(domain.SchemeArxiv, arxivID)
+		add(domain.SchemeDOI, doi)
+	}
+	if isPreprint(p) {
+		return ids
+	}
+
+	add(domain.SchemeDOI, doi)
+	if p.Source == "pubmed" && pmidRe.MatchString(p.ExternalID) {
+		add(domain.SchemePMID, p.ExternalID)
+	}
+	if m := pmcidRe.FindStringSubmatch(metaString("pmc_id")); m != nil {
+		add(domain.SchemePMCID, strings.ToUpper(m[1]))
+	}
+	if m := openalexRe.FindStringSubmatch(metaString("openalex_id")); m != nil {
+		add(domain.SchemeOpenAlex, strings.ToUpper(m[1]))
+	} else if p.Source == "openalex" {
+		if m := openalexRe.FindStringSubmatch(p.ExternalID); m != nil {
+			add(domain.SchemeOpenAlex, strings.ToUpper(m[1]))
+		}
+	}
+	if corpusID := metaString("corpus_id"); corpusIDRe.MatchString(corpusID) {
+		add(domain.SchemeS2, corpusID)
+	}
+	switch {
+	case s2PaperRe.MatchString(metaString("s2_paper_id")):
+		add(domain.SchemeS2Paper, strings.ToLower(metaString("s2_paper_id")))
+	case p.Source == "semanticscholar" && s2PaperRe.MatchString(p.ExternalID):
+		add(domain.SchemeS2Paper, strings.ToLower(p.ExternalID))
+	default:
+		if m := s2HashRe.FindStringSubmatch(metaString("s2_url")); m != nil {
+			add(domain.SchemeS2Paper, strings.ToLower(m[1]))
+		}
+	}
+	return ids
+}
+
+// isPreprint reports whether a record is a preprint: one from a preprint
+// server, or another source's copy of an arXiv preprint.
+func isPreprint(p *domain.Paper) bool {
+	return domain.IsPreprintSource(p.Source) || paperid.NormalizeArxivID(paperDOI(p, metadataMap(p.Metadata))) != ""
+}
+
+// paperDOI returns the normalized DOI of a record; some importers only keep
+// it in metadata.
+func paperDOI(p *domain.Paper, meta map[string]interface{}) string {
+	if doi := paperid.NormalizeDOI(p.DOI); doi != "" {
+		return doi
+	}
+	s, _ := meta["doi"].(string)
+	return paperid.NormalizeDOI(s)
+}
+
+// ParseIdentifier returns the identifiers a user-supplied key could be.
+// It accepts bare IDs, prefixed IDs and landing-page URLs: arXiv IDs with or
+// without version ("arXiv:2101.00001v2", arxiv.org/abs/...), DOIs and
+// doi.org URLs, PMIDs ("PMID: 123", PubMed URLs), PMCIDs, Semantic Scholar
+// paper URLs and CorpusIds, and OpenAlex work IDs and URLs. A bare number is
+// tried as PMID and as S2 corpusId. Returns nil if nothing is recognized.
+func ParseIdentifier(s string) []domain.Identifier {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+
+	// Forms that name their scheme
+	if m := pubmedRe.FindStringSubmatch(s); m != nil {
+		return []domain.Identifier{{Scheme: domain.SchemePMID, Value: m[1]}}
+	}
+	if m := corpusIDRefRe.FindStringSubmatch(s); m != nil {
+		return []domain.Identifier{{Scheme: domain.SchemeS2, Value: m[1]}}
+	}
+	if strings.Contains(strings.ToLower(s), "semanticscholar.org") {
+		if m := s2HashRe.FindStringSubmatch(s); m != nil {
+			return []domain.Identifier{{Scheme: domain.SchemeS2Paper, Value: strings.ToLower(m[1])}}
+		}
+		return nil
+	}
+	if scheme, value, ok := strings.Cut(s, ":"); ok {
+		switch strings.ToLower(scheme) {
+		case domain.SchemeS2:
+			if corpusIDRe.MatchString(value) {
+				return []domain.Identifier{{Scheme: domain.SchemeS2, Value: value}}
+			}
+		case domain.SchemeS2Paper, domain.SchemePMCID, domain.SchemeOpenAlex:
+			s = strings.TrimSpace(value)
+		}
+	}
+
+	var ids []domain.Identifier
+	if arxivID := paperid.NormalizeArxivID(s); arxivID != "" {
+		ids = append(ids, domain.Identifier{Scheme: domain.SchemeArxiv, Value: arxivID})
+	}
+	if doi := paperid.NormalizeDOI(s); doi != "" {
+		ids = append(ids, domain.Identifier{Scheme: domain.SchemeDOI, Value: doi})
+	}
+	if m := pmcidRe.FindStringSubmatch(s); m != nil {
+		ids = append(ids, domain.Identifier{Scheme: domain.SchemePMCID, Value: strings.ToUpper(m[1])})
+	}
+	if m := openalexRe.FindStringSubmatch(s); m != nil {
+		ids = append(ids, domain.Identifier{Scheme: domain.SchemeOpenAlex, Value: strings.ToUpper(m[1])})
+	}
+	if s2PaperRe.MatchString(s) {
+		ids = append(ids, domain.Identifier{Scheme: domain.SchemeS2Paper, Value: strings.ToLower(s)})
+	}
+	if corpusIDRe.MatchString(s) {
+		ids = append(ids,
+			domain.Identifier{Scheme: domain.SchemePMID, Value: s},
+			domain.Identifier{Scheme: domain.SchemeS2, Value: s})
+	}
+	return ids
+}
+
+// ---------- Lookup ----------
+
+// Resolve finds a paper by a key that isn't its UUID or external ID: the
+// UUID or external ID of a record merged into it, or any identifier in
+// the crosswalk. Returns nil, nil when nothing matches.
+func (d *DedupeUsecase) Resolve(key string) (*domain.Paper, error) {
+	id, err := d.idRepo.ResolveMerged(key)
+	if err != nil {
+		return nil, err
+	}
+	if id == uuid.Nil {
+		if id, err = d.idRepo.Resolve(ParseIdentifier(key)); err != nil {
+			return nil, err
+		}
+	}
+	if id == uuid.Nil {
+		return nil, nil
+	}
+	return d.paperRepo.GetByID(id)
+}
+
+// ResolveIdentifiers returns the paper holding the first of ids found in the
+// crosswalk, or nil.
+func (d *DedupeUsecase) ResolveIdentifiers(ids []domain.Identifier) (*domain.Paper, error) {
+	id, err := d.idRepo.Resolve(ids)
+	if err != nil || id == uuid.Nil {
+		return nil, err
+	}
+	return d.paperRepo.GetByID(id)
+}
+
+// FindExisting returns the stored record a not-yet-saved paper duplicates:
+// one sharing an identifier and of the same kind (preprint or published),
+// or nil.
+func (d *DedupeUsecase) FindExisting(p *domain.Paper) (*domain.Paper, error) {
+	id, err := d.idRepo.Resolve(ExtractIdentifiers(p))
+	if err != nil || id == uuid.Nil {
+		return nil, err
+	}
+	existing, err := d.paperRepo.GetByID(id)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+	if isPreprint(existing) != isPreprint(p) {
+		return nil, nil
+	}
+	return existing, nil
+}
+
+// ---------- Ingest ----------
+
+// Register adds a stored paper's identifiers to the crosswalk and returns
+// the other papers already holding one of them.
+func (d *DedupeUsecase) Register(p *domain.Paper) ([]uuid.UUID, error) {
+	if p.Visibility != "" && p.Visibility != domain.VisibilityPublic {
+		return nil, nil
+	}
+	return d.idRepo.Register(p.ID, p.Source, ExtractIdentifiers(p))
+}
+
+// Ingest registers a freshly stored paper and merges it with the records it
+// duplicates. It returns the ID the paper lives under afterwards, which is
+// another record's when that one wins the merge.
+func (d *DedupeUsecase) Ingest(p *domain.Paper) (uuid.UUID, []*domain.PaperMerge, error) {
+	owners, err := d.Register(p)
+	if err != nil || len(owners) == 0 {
+		return p.ID, nil, err
+	}
+
+	current, err := d.paperRepo.GetByID(p.ID)
+	if err != nil || current == nil {
+		return p.ID, nil, err
+	}
+	var merges []*domain.PaperMerge
+	for _, ownerID := range owners {
+		other, err := d.paperRepo.GetByID(ownerID)
+		if err != nil {
+			return current.ID, merges, err
+		}
+		if other == nil {
+			continue
+		}
+		m, _ := PlanMerge(current, other, time.Now())
+		if m == nil {
+			continue
+		}
+		if err := d.idRepo.Merge(m); err != nil {
+			return current.ID, merges, err
+		}
+		merges = append(merges, m)
+		current = m.Survivor
+	}
+	return current.ID, merges, nil
+}
+
+// Apply saves a planned merge.
+func (d *DedupeUsecase) Apply(m *domain.PaperMerge) error {
+	return d.idRepo.Merge(m)
+}
+
+// ---------- Merge rules ----------
+
+// PlanMerge decides whether a and b are one paper and, if so, which one
+// survives and what it looks like afterwards. Neither argument is changed.
+// When they must stay apart it returns nil and one of the Skip reasons.
+func PlanMerge(a, b *domain.Paper, now time.Time) (*domain.PaperMerge, string) {
+	for _, p := range []*domain.Paper{a, b} {
+		if p.Visibility != "" && p.Visibility != domain.VisibilityPublic {
+			return nil, SkipNotPublic
+		}
+	}
+	if isPreprint(a) != isPreprint(b) {
+		return nil, SkipCrossKind
+	}
+	if paperid.TitleSimilarity(a.Title, b.Title) < MinMergeTitleSimilarity {
+		return nil, SkipTitleMismatch
+	}
+
+	survivor, dup := a, b
+	if ra, rb := sourceRank(survivorRank, a.Source), sourceRank(survivorRank, b.Source); rb < ra || (rb == ra && b.CreatedAt.Before(a.CreatedAt)) {
+		survivor, dup = b, a
+	}
+
+	merged := *survivor
+	fields := mergeFields(&merged, dup, now)
+	return &domain.PaperMerge{
+		Survivor:            &merged,
+		DuplicateID:         dup.ID,
+		DuplicateExternalID: dup.ExternalID,
+		DuplicateSource:     dup.Source,
+		Fields:              fields,
+	}, ""
+}
+
+// mergeFields copies values from dup into p where dup's source ranks higher
+// for the field, or p has none. A field's source is the one recorded in
+// metadata.provenance (set by enrichers) and the record's own otherwise.
+// Citation counts take the higher value, categories are combined, and
+// metadata keys p lacks are filled in. Taken fields are tagged in
+// metadata.provenance with via "dedupe". Returns the taken fields.
+func mergeFields(p, dup *domain.Paper, now time.Time) []string {
+	meta, dupMeta := metadataMap(p.Metadata), metadataMap(dup.Metadata)
+	prov, _ := meta["provenance"].(map[string]interface{})
+	if prov == nil {
+		prov = map[string]interface{}{}
+	}
+	dupProv, _ := dupMeta["provenance"].(map[string]interface{})
+
+	fieldSource := func(pr map[string]interface{}, field, fallback string) string {
+		if entry, ok := pr[field].(map[string]interface{}); ok {
+			if s, ok := entry["source"].(string); ok && s != "" {
+				return s
+			}
+		}
+		return fallback
+	}
+
+	var taken []string
+	take := func(field string, empty, dupEmpty bool) bool {
+		if dupEmpty {
+			return false
+		}
+		if !empty {
+			ranks := fieldRank[field]
+			if ranks == nil {
+				ranks = survivorRank
+			}
+			if sourceRank(ranks, fieldSource(dupProv, field, dup.Source)) >= sourceRank(ranks, fieldSource(prov, field, p.Source)) {
+				return false
+			}
+		}
+		taken = append(taken, field)
+		prov[field] = map[string]interface{}{
+			"source": fieldSource(dupProv, field, dup.Source),
+			"via":    "dedupe",
+			"at":     now.Format(time.RFC3339),
+		}
+		return true
+	}
+	takeString := func(field string, dst *string, value string) {
+		if take(field, *dst == "", value == "") {
+			*dst = value
+		}
+	}
+
+	takeString("title", &p.Title, dup.Title)
+	takeString("abstract", &p.Abstract, dup.Abstract)
+	if take("authors", len(p.Authors) == 0 || string(p.Authors) == "[]" || string(p.Authors) == "null",
+		len(dup.Authors) == 0 || string(dup.Authors) == "[]" || string(dup.Authors) == "null") {
+		p.Authors = dup.Authors
+	}
+	if take("published_date", p.PublishedDate == nil, dup.PublishedDate == nil) {
+		p.PublishedDate = dup.PublishedDate
+	}
+	takeString("pdf_url", &p.PDFURL, dup.PDFURL)
+	takeString("journal_ref", &p.JournalRef, dup.JournalRef)
+	takeString("license", &p.License, dup.License)
+	if p.DOI == "" && dup.DOI != "" && !isPreprint(p) {
+		p.DOI = dup.DOI
+		taken = append(taken, "doi")
+	}
+	if p.Comments == "" && dup.Comments != "" {
+		p.Comments = dup.Comments
+		taken = append(taken, "comments")
+	}
+	if p.PrimaryCategory == "" && dup.PrimaryCategory != "" {
+		p.PrimaryCategory = dup.PrimaryCategory
+		taken = append(taken, "primary_category")
+	}
+	if dup.CitationCount > p.CitationCount {
+		p.CitationCount = dup.CitationCount
+		taken = append(taken, "citation_count")
+	}
+	if categories := unionStrings(p.Categories, dup.Categories); len(categories) > len(p.Categories) {
+		p.Categories = categories
+		taken = append(taken, "categories")
+	}
+
+	var metaKeys []string
+	for k, v := range dupMeta {
+		if k == "provenance" {
+			continue
+		}
+		if current, ok := meta[k]; !ok || current == nil || current == "" {
+			meta[k] = v
+			metaKeys = append(metaKeys, k)
+		}
+	}
+	sort.Strings(metaKeys)
+	for _, k := range metaKeys {
+		taken = append(taken, "metadata."+k)
+	}
+
+	if len(prov) > 0 {
+		meta["provenance"] = prov
+	}
+	p.Metadata, _ = json.Marshal(meta)
+	return taken
+}
+
+// sourceRank returns the position of source in ranks; unknown sources rank last.
+func sourceRank(ranks []string, source string) int {
+	for i, s := range ranks {
+		if s == source {
+			return i
+		}
+	}
+	return len(ranks)
+}
+
+func unionStrings(a, b []string) []string {
+	seen := make(map[string]bool, len(a))
+	out := append([]string(nil), a...)
+	for _, s := range a {
+		seen[s] = true
+	}
+	for _, s := range b {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// metadataMap decodes paper metadata; anything but a JSON object yields an
+// empty map.
+func metadataMap(raw json.RawMessage) map[string]interface{} {
+	meta := map[string]interface{}{}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &meta); err != nil || meta == nil {
+			meta = map[string]interface{}{}
+		}
+	}
+	return meta
+}
@@ -0,0 +1,550 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/internal/config"
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/mailer"
+)
+
+var (
+	ErrInvalidDigestFrequency = errors.New("invalid digest frequency")
+	ErrInvalidUnsubscribe     = errors.New("invalid unsubscribe token")
+	ErrInvalidFollow          = errors.New("invalid follow")
+	ErrFollowNotFound         = errors.New("follow not found")
+	ErrInvalidSavedSearch     = errors.New("invalid saved search")
+	ErrSavedSearchNotFound    = errors.New("saved search not found")
+	ErrTooManySavedSearches   = errors.New("too many saved searches")
+)
+
+// maxSavedSearches caps saved searches per user: the digest runs each one.
+const maxSavedSearches = 20
+
+//go:embed templates/digest.html.tmpl templates/digest.txt.tmpl
+var digestTemplateFS embed.FS
+
+var (
+	digestHTMLTemplate = htmltemplate.Must(htmltemplate.ParseFS(digestTemplateFS, "templates/digest.html.tmpl"))
+	digestTextTemplate = texttemplate.Must(texttemplate.ParseFS(digestTemplateFS, "templates/digest.txt.tmpl"))
+)
+
+type DigestUsecase struct {
+	digestRepo    domain.DigestRepository
+	userPaperRepo domain.UserPaperRepository
+	paperRepo     domain.PaperRepository
+	sender        mailer.Sender
+	from          string
+	cfg           *config.DigestConfig
+}
+
+func NewDigestUsecase(digestRepo domain.DigestRepository, userPaperRepo domain.UserPaperRepository, paperRepo domain.PaperRepository, sender mailer.Sender, from string, cfg *config.DigestConfig) *DigestUsecase {
+	return &DigestUsecase{
+		digestRepo:    digestRepo,
+		userPaperRepo: userPaperRepo,
+		paperRepo:     paperRepo,
+		sender:        sender,
+		from:          from,
+		cfg:           cfg,
+	}
+}
+
+// ---------- Settings ----------
+
+func (u *DigestUsecase) GetSettings(userID uuid.UUID) (*domain.DigestSettings, error) {
+	settings, err := u.digestRepo.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return nil, ErrUserNotFound
+	}
+	return settings, nil
+}
+
+func (u *DigestUsecase) UpdateSettings(userID uuid.UUID, frequency string) (*domain.DigestSettings, error) {
+	switch frequency {
+	case domain.DigestOff, domain.DigestDaily, domain.DigestWeekly:
+	default:
+		return nil, ErrInvalidDigestFrequency
+	}
+	if err := u.digestRepo.SetFrequency(userID, frequency); err != nil {
+		return nil, err
+	}
+	return &domain.DigestSettings{Frequency: frequency}, nil
+}
+
+func (u *DigestUsecase) Unsubscribe(token string) error {
+	if token == "" {
+		return ErrInvalidUnsubscribe
+	}
+	ok, err := u.digestRepo.Unsubscribe(token)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidUnsubscribe
+	}
+	return nil
+}
+
+// ---------- Follows and saved searches ----------
+
+func (u *DigestUsecase) ListFollows(userID uuid.UUID) ([]*domain.Follow, error) {
+	return u.digestRepo.ListFollows(userID)
+}
+
+// Follow adds an author (by name) or a topic (a category code) to the user's digest.
+func (u *DigestUsecase) Follow(userID uuid.UUID, kind, value string) (*domain.Follow, error) {
+	value = strings.Join(strings.Fields(value), " ")
+	if value == "" || len(value) > 200 {
+		return nil, ErrInvalidFollow
+	}
+	switch kind {
+	case domain.FollowAuthor:
+	case domain.FollowTopic:
+		if strings.ContainsAny(value, " ,") {
+			return nil, ErrInvalidFollow
+		}
+	default:
+		return nil, ErrInvalidFollow
+	}
+	f := &domain.Follow{UserID: userID, Kind: kind, Value: value}
+	if err := u.digestRepo.AddFollow(f); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (u *DigestUsecase) Unfollow(userID, id uuid.UUID) error {
+	ok, err := u.digestRepo.DeleteFollow(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrFollowNotFound
+	}
+	return nil
+}
+
+func (u *DigestUsecase) ListSavedSearches(userID uuid.UUID) ([]*domain.SavedSearch, error) {
+	return u.digestRepo.ListSavedSearches(userID)
+}
+
+// SaveSearch stores a query whose new matches go into the user's digest.
+// The name defaults to the query.
+func (u *DigestUsecase) SaveSearch(userID uuid.UUID, name, query string, categories []string) (*domain.SavedSearch, error) {
+	query = strings.TrimSpace(query)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = truncateText(query, 100)
+	}
+	if query == "" || len(query) > 500 || len(name) > 200 || len(categories) > 20 {
+		return nil, ErrInvalidSavedSearch
+	}
+	n, err := u.digestRepo.CountSavedSearches(userID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxSavedSearches {
+		return nil, ErrTooManySavedSearches
+	}
+	search := &domain.SavedSearch{UserID: userID, Name: name, Query: query, Categories: categories}
+	if err := u.digestRepo.CreateSavedSearch(search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+func (u *DigestUsecase) DeleteSavedSearch(userID, id uuid.UUID) error {
+	ok, err := u.digestRepo.DeleteSavedSearch(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSavedSearchNotFound
+	}
+	return nil
+}
+
+// ---------- Sending ----------
+
+// DigestRunStats summarises one digest run.
+type DigestRunStats struct {
+	Recipients int
+	Sent       int
+	Skipped    int // nothing new for the user
+	Claimed    int // already handled by an earlier (or concurrent) run
+	Failed     int
+}
+
+// DigestPeriod returns the [start, end) window a digest sent at now covers:
+// the previous UTC day for daily digests, the previous Monday-to-Monday week for weekly.
+func DigestPeriod(frequency string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	end := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch frequency {
+	case domain.DigestDaily:
+		return end.AddDate(0, 0, -1), end, nil
+	case domain.DigestWeekly:
+		daysSinceMonday := (int(end.Weekday()) + 6) % 7
+		end = end.AddDate(0, 0, -daysSinceMonday)
+		return end.AddDate(0, 0, -7), end, nil
+	}
+	return time.Time{}, time.Time{}, ErrInvalidDigestFrequency
+}
+
+// Run sends the digest for the period ending before now to every subscribed user.
+// With dryRun the emails are rendered and passed to the sender but no send state is recorded.
+func (u *DigestUsecase) Run(ctx context.Context, frequency string, now time.Time, dryRun bool) (*DigestRunStats, error) {
+	start, end, err := DigestPeriod(frequency, now)
+	if err != nil {
+		return nil, err
+	}
+
+	recipients, err := u.digestRepo.ListRecipients(frequency)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &DigestRunStats{Recipients: len(recipients)}
+	for _, rc := range recipients {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+
+		send := &domain.DigestSend{
+			UserID:      rc.UserID,
+			Frequency:   frequency,
+			PeriodStart: start,
+			PeriodEnd:   end,
+		}
+		if !dryRun {
+			claimed, err := u.digestRepo.Claim(send)
+			if err != nil {
+				log.Printf("DIGEST: claim failed for %s: %v", rc.Email, err)
+				stats.Failed++
+				continue
+			}
+			if !claimed {
+				stats.Claimed++
+				continue
+			}
+		}
+
+		msg, count, err := u.buildMessage(rc, start, end)
+		if err != nil {
+			log.Printf("DIGEST: build failed for %s: %v", rc.Email, err)
+			stats.Failed++
+			if !dryRun {
+				u.digestRepo.MarkFailed(send.ID, err.Error())
+			}
+			continue
+		}
+		if msg == nil {
+			stats.Skipped++
+			if !dryRun {
+				u.digestRepo.MarkSkipped(send.ID)
+			}
+			continue
+		}
+
+		if err := u.sender.Send(ctx, msg); err != nil {
+			log.Printf("DIGEST: send failed for %s: %v", rc.Email, err)
+			stats.Failed++
+			if !dryRun {
+				u.digestRepo.MarkFailed(send.ID, err.Error())
+			}
+			continue
+		}
+		stats.Sent++
+		if !dryRun {
+			if err := u.digestRepo.MarkSent(send.ID, count); err != nil {
+				log.Printf("DIGEST: sent to %s but failed to record it: %v", rc.Email, err)
+			}
+		}
+	}
+	return stats, nil
+}
+
+// digestPaper and digestSection are the template view models.
+type digestPaper struct {
+	Title   string
+	Authors string
+	Snippet string
+	URL     string
+}
+
+type digestSection struct {
+	Name   string
+	Papers []digestPaper
+}
+
+type digestView struct {
+	Subject        string
+	Name           string
+	FrequencyLabel string
+	PeriodLabel    string
+	PaperCount     int
+	PapersLabel    string // "1 new paper" / "N new papers"
+	Sections       []digestSection
+	UnsubscribeURL string
+}
+
+// buildMessage collects the user's new papers and renders the email: those
+// by followed authors, then saved search matches, then papers in followed
+// topics and the categories of the user's library. Each paper is listed
+// once, in the first section it fits. Returns a nil message when there is
+// nothing to send.
+func (u *DigestUsecase) buildMessage(rc *domain.DigestRecipient, start, end time.Time) (*mailer.Message, int, error) {
+	follows, err := u.digestRepo.ListFollows(rc.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+	searches, err := u.digestRepo.ListSavedSearches(rc.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+	categories, err := u.userPaperRepo.GetUserCategories(rc.UserID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var authors []string
+	topics := map[string]bool{}
+	for _, c := range categories {
+		topics[c] = true
+	}
+	for _, f := range follows {
+		switch f.Kind {
+		case domain.FollowAuthor:
+			authors = append(authors, f.Value)
+		case domain.FollowTopic:
+			topics[f.Value] = true
+		}
+	}
+	if len(authors) == 0 && len(searches) == 0 && len(topics) == 0 {
+		return nil, 0, nil
+	}
+
+	maxPapers := u.cfg.MaxPapers
+	if maxPapers <= 0 {
+		maxPapers = 20
+	}
+	// Over-fetch so papers already in the library can be dropped without starving the digest.
+	fetch := maxPapers * 2
+
+	inLibrary := map[string]bool{}
+	if ids, err := u.userPaperRepo.GetUserPaperExternalIDs(rc.UserID); err == nil {
+		for _, id := range ids {
+			inLibrary[id] = true
+		}
+	}
+
+	d := &digestBuilder{max: maxPapers, appURL: u.cfg.AppURL, inLibrary: inLibrary, listed: map[uuid.UUID]bool{}}
+
+	if len(authors) > 0 {
+		papers, err := u.paperRepo.ListRecentByAuthors(authors, start, end, fetch)
+		if err != nil {
+			return nil, 0, err
+		}
+		followed := map[string]string{}
+		for _, a := range authors {
+			followed[strings.ToLower(a)] = a
+		}
+		group := d.group()
+		for _, p := range papers {
+			for _, name := range digestAuthorNames(p.Authors) {
+				if a, ok := followed[strings.ToLower(name)]; ok {
+					d.add(group, a, p)
+					break
+				}
+			}
+		}
+		d.sort(group)
+	}
+
+	for _, search := range searches {
+		papers, err := u.paperRepo.ListRecentByQuery(search.Query, search.Categories, start, end, fetch)
+		if err != nil {
+			return nil, 0, err
+		}
+		group := d.group()
+		for _, p := range papers {
+			d.add(group, "Saved search: "+search.Name, p)
+		}
+	}
+
+	if len(topics) > 0 {
+		list := make([]string, 0, len(topics))
+		for c := range topics {
+			list = append(list, c)
+		}
+		papers, err := u.paperRepo.ListRecentByCategories(list, start, end, fetch)
+		if err != nil {
+			return nil, 0, err
+		}
+		group := d.group()
+		for _, p := range papers {
+			for _, c := range p.Categories {
+				if topics[c] {
+					d.add(group, domain.GetCategoryInfo(c).Name, p)
+					break
+				}
+			}
+		}
+		d.sort(group)
+	}
+
+	count := d.count
+	if count == 0 {
+		return nil, 0, nil
+	}
+	ordered := make([]digestSection, 0, len(d.sections))
+	for _, sec := range d.sections {
+		ordered = append(ordered, *sec)
+	}
+
+	label := "daily"
+	periodLabel := start.Format("Jan 2, 2006")
+	if rc.Frequency == domain.DigestWeekly {
+		label = "weekly"
+		periodLabel = fmt.Sprintf("the week of %s", start.Format("Jan 2, 2006"))
+	}
+
+	papersLabel := fmt.Sprintf("%d new papers", count)
+	if count == 1 {
+		papersLabel = "1 new paper"
+	}
+
+	unsubscribeURL := fmt.Sprintf("%s/api/v1/digest/unsubscribe?token=%s", u.cfg.APIURL, url.QueryEscape(rc.UnsubscribeToken))
+	view := digestView{
+		Subject:        papersLabel + " for you",
+		Name:           rc.Name,
+		FrequencyLabel: label,
+		PeriodLabel:    periodLabel,
+		PaperCount:     count,
+		PapersLabel:    papersLabel,
+		Sections:       ordered,
+		UnsubscribeURL: unsubscribeURL,
+	}
+
+	var htmlBuf, textBuf bytes.Buffer
+	if err := digestHTMLTemplate.Execute(&htmlBuf, view); err != nil {
+		return nil, 0, err
+	}
+	if err := digestTextTemplate.Execute(&textBuf, view); err != nil {
+		return nil, 0, err
+	}
+
+	return &mailer.Message{
+		From:    u.from,
+		To:      rc.Email,
+		Subject: view.Subject,
+		Text:    textBuf.String(),
+		HTML:    htmlBuf.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribeURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, count, nil
+}
+
+// digestBuilder fills the digest's sections, up to max papers in all.
+// Sections come in groups, one per kind of input, kept in order.
+type digestBuilder struct {
+	max       int
+	appURL    string
+	inLibrary map[string]bool
+	listed    map[uuid.UUID]bool
+	count     int
+	sections  []*digestSection
+	groups    []map[string]*digestSection
+}
+
+// group starts the next group of sections and returns its index.
+func (d *digestBuilder) group() int {
+	d.groups = append(d.groups, map[string]*digestSection{})
+	return len(d.groups) - 1
+}
+
+// add lists p under the named section of a group, unless it is already
+// listed or in the user's library, or the digest is full.
+func (d *digestBuilder) add(group int, name string, p *domain.Paper) {
+	if d.count >= d.max || d.listed[p.ID] || d.inLibrary[p.ExternalID] {
+		return
+	}
+	sec, ok := d.groups[group][name]
+	if !ok {
+		sec = &digestSection{Name: name}
+		d.groups[group][name] = sec
+		d.sections = append(d.sections, sec)
+	}
+	sec.Papers = append(sec.Papers, digestPaper{
+		Title:   p.Title,
+		Authors: formatDigestAuthors(p.Authors),
+		Snippet: truncateText(p.Abstract, 240),
+		URL:     fmt.Sprintf("%s/paper/%s", d.appURL, p.ID),
+	})
+	d.listed[p.ID] = true
+	d.count++
+}
+
+// sort orders a group's sections by name.
+func (d *digestBuilder) sort(group int) {
+	first := len(d.sections) - len(d.groups[group])
+	tail := d.sections[first:]
+	sort.Slice(tail, func(i, j int) bool { return tail[i].Name < tail[j].Name })
+}
+
+// digestAuthorNames reads the author names from the stored JSON, which
+// holds objects with a name or, from some importers, plain strings.
+func digestAuthorNames(raw json.RawMessage) []string {
+	if len(raw) == 0 {
+		return nil
+	}
+	var names []string
+	var objs []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &objs); err == nil {
+		for _, a := range objs {
+			if a.Name != "" {
+				names = append(names, a.Name)
+			}
+		}
+	} else {
+		json.Unmarshal(raw, &names)
+	}
+	return names
+}
+
+// formatDigestAuthors renders the first few author names from the stored JSON.
+func formatDigestAuthors(raw json.RawMessage) string {
+	names := digestAuthorNames(raw)
+	if len(names) > 3 {
+		return strings.Join(names[:3], ", ") + " et al."
+	}
+	return strings.Join(names, ", ")
+}
+
+func truncateText(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) <= n {
+		return s
+	}
+	return string([]rune(s)[:n]) + "…"
+}
@@ -0,0 +1,416 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/paperid"
+	"github.com/paper-app/backend/pkg/ratelimit"
+	"github.com/paper-app/backend/pkg/sources"
+)
+
+const (
+	federatedHitTTL     = time.Hour
+	federatedMaxHits    = 5000
+	defaultFederatedTTL = 4 * time.Second
+)
+
+// FederatedSourceStatus reports how one source did for a query.
+type FederatedSourceStatus struct {
+	Count  int    `json:"count"`
+	TookMs int64  `json:"took_ms"`
+	Error  string `json:"error,omitempty"`
+}
+
+type federatedHit struct {
+	paper   *domain.Paper
+	expires time.Time
+}
+
+// FederatedSearch fans a query out to external paper APIs (arXiv, PubMed,
+// OpenAlex, Semantic Scholar) when the local index has too few results.
+// Hits are remembered for an hour so a selected one can be imported into
+// PostgreSQL without querying the API again.
+type FederatedSearch struct {
+	sources []sources.Source
+	timeout time.Duration
+	limiter *ratelimit.HostLimiter
+
+	mu   sync.Mutex
+	hits map[string]federatedHit // keyed by FederatedKey
+}
+
+// NewFederatedSearch builds a searcher over srcs, in priority order. Sources
+// that can't search are skipped. timeout bounds each source separately.
+func NewFederatedSearch(srcs []sources.Source, timeout time.Duration) *FederatedSearch {
+	if timeout <= 0 {
+		timeout = defaultFederatedTTL
+	}
+	// Pace each API as its capabilities ask. Searches that would wait longer
+	// than the timeout are dropped, not queued.
+	limiter := ratelimit.NewHostLimiter(0)
+	f := &FederatedSearch{
+		timeout: timeout,
+		limiter: limiter,
+		hits:    make(map[string]federatedHit),
+	}
+	for _, src := range srcs {
+		if caps := src.Capabilities(); caps.Search {
+			limiter.SetInterval(src.Name(), caps.MinInterval)
+			f.sources = append(f.sources, src)
+		}
+	}
+	return f
+}
+
+// Sources returns the enabled source names.
+func (f *FederatedSearch) Sources() []string {
+	names := make([]string, len(f.sources))
+	for i, s := range f.sources {
+		names[i] = s.Name()
+	}
+	return names
+}
+
+// FederatedKey is the ID given to a remote hit: "<source>:<external_id>",
+// e.g. "arxiv:2301.00001" or "openalex:W2741809807".
+func FederatedKey(p *domain.Paper) string {
+	return p.Source + ":" + p.ExternalID
+}
+
+// Search queries every source concurrently and returns the deduplicated hits,
+// ranked by sort ("citations", "date", otherwise interleaved by source rank).
+func (f *FederatedSearch) Search(query string, limit int, sortBy string) ([]*domain.Paper, map[string]FederatedSourceStatus) {
+	type result struct {
+		papers []*domain.Paper
+		status FederatedSourceStatus
+	}
+	results := make([]result, len(f.sources))
+
+	var wg sync.WaitGroup
+	for i, src := range f.sources {
+		wg.Add(1)
+		go func(i int, src sources.Source) {
+			defer wg.Done()
+			start := time.Now()
+			papers, err := f.searchOne(src, query, limit, sortBy)
+			results[i].status.TookMs = time.Since(start).Milliseconds()
+			if err != nil {
+				results[i].status.Error = err.Error()
+				return
+			}
+			results[i].papers = papers
+			results[i].status.Count = len(papers)
+		}(i, src)
+	}
+	wg.Wait()
+
+	statuses := make(map[string]FederatedSourceStatus, len(f.sources))
+	var lists [][]*domain.Paper
+	for i, src := range f.sources {
+		statuses[src.Name()] = results[i].status
+		lists = append(lists, results[i].papers)
+	}
+
+	// Round-robin by rank so every source's best hits come first; on a
+	// duplicate the first copy wins and later copies fill in missing fields.
+	var merged []*domain.Paper
+	seen := newDedupeIndex()
+	for rank := 0; ; rank++ {
+		more := false
+		for _, list := range lists {
+			if rank >= len(list) {
+				continue
+			}
+			more = true
+			p := list[rank]
+			normalizeFederated(p)
+			if first := seen.find(p); first != nil {
+				fillMissing(first, p)
+				continue
+			}
+			seen.add(p)
+			merged = append(merged, p)
+		}
+		if !more {
+			break
+		}
+	}
+
+	switch sortBy {
+	case "citations":
+		sort.SliceStable(merged, func(i, j int) bool { return merged[i].CitationCount > merged[j].CitationCount })
+	case "date":
+		sort.SliceStable(merged, func(i, j int) bool {
+			a, b := merged[i].PublishedDate, merged[j].PublishedDate
+			return a != nil && (b == nil || a.After(*b))
+		})
+	}
+
+	f.remember(merged)
+	return merged, statuses
+}
+
+// searchOne runs one source under the per-source timeout.
+func (f *FederatedSearch) searchOne(src sources.Source, query string, limit int, sortBy string) ([]*domain.Paper, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	if err := f.limiter.Wait(ctx, src.Name()); err != nil {
+		return nil, fmt.Errorf("rate limited")
+	}
+	page, err := src.Search(ctx, sources.Query{Text: query, Limit: limit, Sort: sortBy})
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, fmt.Errorf("timed out after %v", f.timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return page.Papers, nil
+}
+
+// Lookup returns a remote paper by FederatedKey: from recent search hits, or
+// by fetching it again from sources that support lookup by ID.
+func (f *FederatedSearch) Lookup(key string) (*domain.Paper, error) {
+	f.mu.Lock()
+	hit, ok := f.hits[key]
+	f.mu.Unlock()
+	if ok && time.Now().Before(hit.expires) {
+		return hit.paper, nil
+	}
+
+	name, externalID, ok := strings.Cut(key, ":")
+	if !ok || externalID == "" {
+		return nil, nil
+	}
+	for _, src := range f.sources {
+		if src.Name() != name || !src.Capabilities().GetByID {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		defer cancel()
+		if err := f.limiter.Wait(ctx, src.Name()); err != nil {
+			return nil, fmt.Errorf("%s: rate limited", name)
+		}
+		p, err := src.GetByID(ctx, externalID)
+		if err != nil || p == nil {
+			return nil, err
+		}
+		normalizeFederated(p)
+		return p, nil
+	}
+	return nil, nil
+}
+
+// Fetch looks a paper up live by identifier, trying ids in order against
+// each source that accepts that kind of ID. Returns nil, nil if no source
+// has it; an error only if every source that was asked failed.
+func (f *FederatedSearch) Fetch(ids []domain.Identifier) (*domain.Paper, error) {
+	var lastErr error
+	asked, failed := 0, 0
+	for _, id := range ids {
+		for _, src := range f.sources {
+			caps := src.Capabilities()
+			sourceID, ok := sourceIDFor(id, caps.IDTypes)
+			if !caps.GetByID || !ok {
+				continue
+			}
+			asked++
+			p, err := f.getOne(src, sourceID)
+			if err != nil {
+				failed++
+				lastErr = fmt.Errorf("%s: %w", src.Name(), err)
+				continue
+			}
+			if p != nil {
+				normalizeFederated(p)
+				return p, nil
+			}
+		}
+	}
+	if asked > 0 && failed == asked {
+		return nil, lastErr
+	}
+	return nil, nil
+}
+
+// getOne fetches one paper from a source under the per-source timeout.
+func (f *FederatedSearch) getOne(src sources.Source, id string) (*domain.Paper, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	if err := f.limiter.Wait(ctx, src.Name()); err != nil {
+		return nil, fmt.Errorf("rate limited")
+	}
+	return src.GetByID(ctx, id)
+}
+
+// sourceIDFor formats an identifier the way sources.Source.GetByID expects
+// it, if the source accepts that kind of ID (Capabilities.IDTypes).
+func sourceIDFor(id domain.Identifier, idTypes []string) (string, bool) {
+	idType, value := id.Scheme, id.Value
+	switch id.Scheme {
+	case domain.SchemePMID:
+		value = "pmid:" + id.Value
+	case domain.SchemeS2:
+		value = "corpusid:" + id.Value
+	case domain.SchemeS2Paper:
+		idType = "s2"
+	}
+	for _, t := range idTypes {
+		if t == idType {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+func (f *FederatedSearch) remember(papers []*domain.Paper) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := time.Now()
+	if len(f.hits)+len(papers) > federatedMaxHits {
+		for k, h := range f.hits {
+			if now.After(h.expires) {
+				delete(f.hits, k)
+			}
+		}
+		// Still full: drop arbitrary entries, they can be searched again
+		for k := range f.hits {
+			if len(f.hits)+len(papers) <= federatedMaxHits {
+				break
+			}
+			delete(f.hits, k)
+		}
+	}
+	for _, p := range papers {
+		f.hits[FederatedKey(p)] = federatedHit{paper: p, expires: now.Add(federatedHitTTL)}
+	}
+}
+
+// normalizeFederated fills the typed fields the API clients leave in metadata.
+func normalizeFederated(p *domain.Paper) {
+	var meta struct {
+		DOI           string   `json:"doi"`
+		CitationCount int      `json:"citation_count"`
+		Categories    []string `json:"categories"`
+		Venue         string   `json:"venue"`
+		Journal       string   `json:"journal"`
+	}
+	json.Unmarshal(p.Metadata, &meta)
+
+	if p.DOI == "" {
+		p.DOI = paperid.NormalizeDOI(meta.DOI)
+	}
+	if p.CitationCount == 0 {
+		p.CitationCount = meta.CitationCount
+	}
+	if len(p.Categories) == 0 && len(meta.Categories) > 0 {
+		p.Categories = meta.Categories
+		p.PrimaryCategory = meta.Categories[0]
+	}
+	if p.JournalRef == "" {
+		p.JournalRef = meta.Venue
+		if p.JournalRef == "" {
+			p.JournalRef = meta.Journal
+		}
+	}
+}
+
+// fillMissing copies fields from a duplicate hit into the one being kept.
+func fillMissing(dst, src *domain.Paper) {
+	if dst.DOI == "" {
+		dst.DOI = src.DOI
+	}
+	if dst.Abstract == "" {
+		dst.Abstract = src.Abstract
+	}
+	if dst.PDFURL == "" || strings.HasPrefix(dst.PDFURL, "https://doi.org/") {
+		if src.PDFURL != "" && !strings.HasPrefix(src.PDFURL, "https://doi.org/") {
+			dst.PDFURL = src.PDFURL
+		}
+	}
+	if src.CitationCount > dst.CitationCount {
+		dst.CitationCount = src.CitationCount
+	}
+	if dst.PublishedDate == nil {
+		dst.PublishedDate = src.PublishedDate
+	}
+	if dst.JournalRef == "" {
+		dst.JournalRef = src.JournalRef
+	}
+}
+
+// dedupeIndex finds papers already seen by DOI, arXiv ID or normalized title.
+type dedupeIndex struct {
+	keys map[string]*domain.Paper
+}
+
+func newDedupeIndex() *dedupeIndex {
+	return &dedupeIndex{keys: make(map[string]*domain.Paper)}
+}
+
+func dedupeKeys(doi, source, externalID, title string) []string {
+	var keys []string
+	arxivID := ""
+	if d := paperid.NormalizeDOI(doi); d != "" {
+		keys = append(keys, "doi:"+d)
+		// arXiv's own DOIs (10.48550/arXiv.XXXX) identify the preprint
+		if strings.HasPrefix(d, "10.48550/arxiv.") {
+			arxivID = strings.TrimPrefix(d, "10.48550/arxiv.")
+		}
+	}
+	if source == "arxiv" {
+		arxivID = externalID
+	}
+	if a := paperid.NormalizeArxivID(arxivID); a != "" {
+		keys = append(keys, "arxiv:"+a)
+	}
+	if t := paperid.NormalizeTitle(title); len(t) >= 10 {
+		keys = append(keys, "title:"+t)
+	}
+	return keys
+}
+
+func (d *dedupeIndex) find(p *domain.Paper) *domain.Paper {
+	for _, k := range dedupeKeys(p.DOI, p.Source, p.ExternalID, p.Title) {
+		if first, ok := d.keys[k]; ok {
+			return first
+		}
+	}
+	return nil
+}
+
+func (d *dedupeIndex) add(p *domain.Paper) {
+	for _, k := range dedupeKeys(p.DOI, p.Source, p.ExternalID, p.Title) {
+		if _, ok := d.keys[k]; !ok {
+			d.keys[k] = p
+		}
+	}
+}
+
+// addDoc registers a local result so remote copies of it are dropped.
+func (d *dedupeIndex) addDoc(doc *domain.SearchDoc) {
+	for _, k := range dedupeKeys(doc.DOI, doc.Source, doc.ExternalID, doc.Title) {
+		if _, ok := d.keys[k]; !ok {
+			d.keys[k] = nil
+		}
+	}
+}
+
+func (d *dedupeIndex) seen(p *domain.Paper) bool {
+	for _, k := range dedupeKeys(p.DOI, p.Source, p.ExternalID, p.Title) {
+		if _, ok := d.keys[k]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/pkg/ingestjob"
+)
+
+var (
+	ErrUnknownJobType   = errors.New("unknown job type")
+	ErrInvalidJobParams = errors.New("invalid job parameters")
+	ErrJobNotFound      = errors.New("job not found")
+	ErrJobActive        = errors.New("a job with this type and name is already queued or running")
+	ErrJobNotActive     = errors.New("job is not queued or running")
+	ErrJobNotRetryable  = errors.New("only failed or cancelled jobs can be retried")
+)
+
+// JobType describes an importer the admin API can start.
+type JobType struct {
+	Type     string   `json:"type"`
+	Params   []string `json:"params"`
+	Required []string `json:"required,omitempty"`
+}
+
+// JobUsecase queues importer jobs for the job workers and reports on them.
+// Jobs started from the command line show up here as well.
+type JobUsecase struct {
+	store *ingestjob.Store
+}
+
+func NewJobUsecase(store *ingestjob.Store) *JobUsecase {
+	return &JobUsecase{store: store}
+}
+
+// Types lists the importers that can be started.
+func (u *JobUsecase) Types() []JobType {
+	types := make([]JobType, 0, len(ingestjob.Commands))
+	for _, t := range ingestjob.CommandTypes() {
+		cmd := ingestjob.Commands[t]
+		types = append(types, JobType{Type: t, Params: cmd.Flags, Required: cmd.Required})
+	}
+	return types
+}
+
+// Start queues a job. Params are the importer's flags by name.
+func (u *JobUsecase) Start(jobType string, params map[string]interface{}, userID uuid.UUID) (*ingestjob.Job, error) {
+	cmd := ingestjob.Commands[jobType]
+	if cmd == nil {
+		return nil, ErrUnknownJobType
+	}
+	flags, err := cmd.Params(params)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJobParams, err)
+	}
+	name := cmd.Name(flags)
+
+	ctx := context.Background()
+	active, err := u.store.Latest(ctx, jobType, name, ingestjob.StatusPending, ingestjob.StatusRunning)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrJobActive
+	}
+	return u.store.Enqueue(ctx, jobType, name, flags, &userID)
+}
+
+// List returns the most recent jobs, optionally of one type and status.
+func (u *JobUsecase) List(jobType, status string, limit int) ([]*ingestjob.Job, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return u.store.List(context.Background(), jobType, status, limit)
+}
+
+func (u *JobUsecase) Get(id uuid.UUID) (*ingestjob.Job, error) {
+	job, err := u.store.Get(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Cancel stops a queued job right away and asks a running one to stop; it
+// ends cancelled once its importer has saved its progress.
+func (u *JobUsecase) Cancel(id uuid.UUID) (*ingestjob.Job, error) {
+	ok, err := u.store.RequestCancel(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		if _, err := u.Get(id); err != nil {
+			return nil, err
+		}
+		return nil, ErrJobNotActive
+	}
+	return u.Get(id)
+}
+
+// Retry queues a failed or cancelled job again; the importer continues at
+// the job's cursor.
+func (u *JobUsecase) Retry(id uuid.UUID) (*ingestjob.Job, error) {
+	job, err := u.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if ingestjob.Commands[job.Type] == nil {
+		return nil, ErrUnknownJobType
+	}
+	job, err = u.store.Retry(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, ErrJobNotRetryable
+	}
+	return job, nil
+}
+
+// Logs returns up to limit lines of a job's output after the line with ID
+// after.
+func (u *JobUsecase) Logs(id uuid.UUID, after int64, limit int) ([]ingestjob.LogLine, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 500
+	}
+	return u.store.Logs(context.Background(), id, after, limit)
+}
@@ -84,7 +84,7 @@ func (u *LibraryUsecase) SavePaper(userID, paperID uuid.UUID) (*domain.UserPaper
 	if err != nil {
 		return nil, err
 	}
-	if paper == nil {
+	if paper == nil || !paper.CanView(userID) {
 		return nil, ErrPaperNotFound
 	}
 
@@ -182,7 +182,7 @@ func (u *LibraryUsecase) BookmarkPaper(userID, paperID uuid.UUID) (*domain.UserP
 		if err != nil {
 			return nil, err
 		}
-		if paper == nil {
+		if paper == nil || !paper.CanView(userID) {
 			return nil, ErrPaperNotFound
 		}
 
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/internal/domain"
+)
+
+type NotificationUsecase struct {
+	notificationRepo domain.NotificationRepository
+}
+
+func NewNotificationUsecase(notificationRepo domain.NotificationRepository) *NotificationUsecase {
+	return &NotificationUsecase{notificationRepo: notificationRepo}
+}
+
+type NotificationList struct {
+	Notifications []*domain.Notification `json:"notifications"`
+	Total         int                    `json:"total"`
+	Unread        int                    `json:"unread"`
+	Offset        int                    `json:"offset"`
+	Limit         int                    `json:"limit"`
+}
+
+func (u *NotificationUsecase) List(userID uuid.UUID, unreadOnly bool, limit, offset int) (*NotificationList, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	notifications, total, err := u.notificationRepo.ListByUser(userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := u.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	if notifications == nil {
+		notifications = []*domain.Notification{}
+	}
+
+	return &NotificationList{
+		Notifications: notifications,
+		Total:         total,
+		Unread:        unread,
+		Offset:        offset,
+		Limit:         limit,
+	}, nil
+}
+
+// MarkRead marks the given notifications (or all, if ids is empty) as read
+// and returns how many changed.
+func (u *NotificationUsecase) MarkRead(userID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	return u.notificationRepo.MarkRead(userID, ids)
+}
@@ -10,20 +10,26 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/paper-app/backend/internal/domain"
-	"github.com/paper-app/backend/pkg/opensearch"
+	"github.com/paper-app/backend/pkg/paperid"
 )
 
 var ErrPaperNotFoundOS = errors.New("paper not found in search index")
 
 type PaperUsecase struct {
-	paperRepo domain.PaperRepository // PG — only used for library operations
-	osClient  *opensearch.Client     // OpenSearch — primary source for search + detail
+	paperRepo   domain.PaperRepository        // PG — only used for library operations
+	versionRepo domain.PaperVersionRepository // PG — revision history
+	searcher    domain.PaperSearcher          // Search index — primary source for search + detail
+	federated   *FederatedSearch              // External APIs, queried on ?federated=true (optional)
+	dedupe      *DedupeUsecase                // Identifier crosswalk and merged-ID redirects (optional)
 }
 
-func NewPaperUsecase(paperRepo domain.PaperRepository, osClient *opensearch.Client) *PaperUsecase {
+func NewPaperUsecase(paperRepo domain.PaperRepository, versionRepo domain.PaperVersionRepository, searcher domain.PaperSearcher, federated *FederatedSearch, dedupe *DedupeUsecase) *PaperUsecase {
 	return &PaperUsecase{
-		paperRepo: paperRepo,
-		osClient:  osClient,
+		paperRepo:   paperRepo,
+		versionRepo: versionRepo,
+		searcher:    searcher,
+		federated:   federated,
+		dedupe:      dedupe,
 	}
 }
 
@@ -31,13 +37,22 @@ func NewPaperUsecase(paperRepo domain.PaperRepository, osClient *opensearch.Clie
 
 // SearchResult is the API response for paper search.
 type SearchResult struct {
-	Papers []*opensearch.PaperDoc `json:"papers"`
-	Total  int                    `json:"total"`
-	Offset int                    `json:"offset"`
-	Limit  int                    `json:"limit"`
+	Papers []*domain.SearchDoc `json:"papers"`
+	Total  int                 `json:"total"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+	// Per-source outcome when external APIs were queried
+	Federated map[string]FederatedSourceStatus `json:"federated,omitempty"`
 }
 
-func (u *PaperUsecase) SearchPapers(query, source string, limit, offset int, sort string, categories []string) (*SearchResult, error) {
+// SearchOptions are optional search behaviours toggled per request.
+type SearchOptions struct {
+	FullText  bool // also match extracted PDF text
+	Federated bool // fill a short local page with hits from external APIs
+	Collapse  bool // one hit per work: hide preprints of a published paper
+}
+
+func (u *PaperUsecase) SearchPapers(query, source string, limit, offset int, sort string, categories []string, opts SearchOptions) (*SearchResult, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -48,19 +63,32 @@ func (u *PaperUsecase) SearchPapers(query, source string, limit, offset int, sor
 		sort = "relevance"
 	}
 
-	// Use OpenSearch as the primary search engine
-	if u.osClient != nil {
-		return u.searchOpenSearch(query, categories, limit, offset, sort)
+	result, err := u.searchLocal(query, source, categories, limit, offset, sort, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// The local index missed (short page): top up from external APIs
+	if opts.Federated && u.federated != nil && strings.TrimSpace(query) != "" && len(result.Papers) < limit {
+		u.addFederated(result, query, limit, sort)
+	}
+	return result, nil
+}
+
+func (u *PaperUsecase) searchLocal(query, source string, categories []string, limit, offset int, sort string, opts SearchOptions) (*SearchResult, error) {
+	// Use the search index as the primary search engine
+	if u.searcher != nil {
+		return u.searchIndex(query, source, categories, limit, offset, sort, opts)
 	}
 
 	// Fallback to PostgreSQL search (legacy)
-	papers, total, err := u.paperRepo.Search(query, source, limit, offset, sort)
+	papers, total, err := u.paperRepo.Search(query, source, limit, offset, sort, opts.Collapse)
 	if err != nil {
 		return nil, err
 	}
 
-	// Convert domain.Paper to opensearch.PaperDoc for consistent API response
-	docs := make([]*opensearch.PaperDoc, 0, len(papers))
+	// Convert domain.Paper to domain.SearchDoc for consistent API response
+	docs := make([]*domain.SearchDoc, 0, len(papers))
 	for _, p := range papers {
 		docs = append(docs, domainPaperToDoc(p))
 	}
@@ -73,27 +101,58 @@ func (u *PaperUsecase) SearchPapers(query, source string, limit, offset int, sor
 	}, nil
 }
 
-func (u *PaperUsecase) searchOpenSearch(query string, categories []string, limit, offset int, sort string) (*SearchResult, error) {
+// addFederated appends external hits not already in the local results.
+// Remote docs carry their FederatedKey as ID; saving one to the library or
+// POST /papers/import stores it in PostgreSQL.
+func (u *PaperUsecase) addFederated(result *SearchResult, query string, limit int, sort string) {
+	remote, statuses := u.federated.Search(query, limit, sort)
+	result.Federated = statuses
+
+	local := newDedupeIndex()
+	for _, doc := range result.Papers {
+		local.addDoc(doc)
+	}
+	added := 0
+	for _, p := range remote {
+		if len(result.Papers) >= limit {
+			break
+		}
+		if local.seen(p) {
+			continue
+		}
+		doc := domainPaperToDoc(p)
+		doc.ID = FederatedKey(p)
+		doc.RemoteSource = p.Source
+		result.Papers = append(result.Papers, doc)
+		added++
+	}
+	result.Total += added
+}
+
+func (u *PaperUsecase) searchIndex(query, source string, categories []string, limit, offset int, sort string, opts SearchOptions) (*SearchResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	osResult, err := u.osClient.Search(ctx, opensearch.SearchParams{
+	indexResult, err := u.searcher.Search(ctx, domain.SearchParams{
 		Query:      query,
+		Source:     source,
 		Categories: categories,
 		SortBy:     sort,
 		Limit:      limit,
 		Offset:     offset,
+		FullText:   opts.FullText,
+		Collapse:   opts.Collapse,
 	})
 	if err != nil {
-		log.Printf("OpenSearch search failed: %v", err)
+		log.Printf("Search index query failed: %v", err)
 
 		// Fallback to PostgreSQL if available
 		if u.paperRepo != nil {
-			papers, total, pgErr := u.paperRepo.Search(query, "", limit, offset, sort)
+			papers, total, pgErr := u.paperRepo.Search(query, source, limit, offset, sort, opts.Collapse)
 			if pgErr != nil {
 				return nil, pgErr
 			}
-			docs := make([]*opensearch.PaperDoc, 0, len(papers))
+			docs := make([]*domain.SearchDoc, 0, len(papers))
 			for _, p := range papers {
 				docs = append(docs, domainPaperToDoc(p))
 			}
@@ -102,16 +161,9 @@ func (u *PaperUsecase) searchOpenSearch(query string, categories []string, limit
 		return nil, err
 	}
 
-	// Extract PaperDocs from hits
-	papers := make([]*opensearch.PaperDoc, 0, len(osResult.Hits))
-	for _, hit := range osResult.Hits {
-		doc := hit.Doc
-		papers = append(papers, &doc)
-	}
-
 	return &SearchResult{
-		Papers: papers,
-		Total:  osResult.Total,
+		Papers: indexResult.Papers,
+		Total:  indexResult.Total,
 		Offset: offset,
 		Limit:  limit,
 	}, nil
@@ -119,9 +171,9 @@ func (u *PaperUsecase) searchOpenSearch(query string, categories []string, limit
 
 // ---------- Paper Detail ----------
 
-// GetPaperFromOS retrieves a paper by its S2 corpusid or external ID from OpenSearch.
-func (u *PaperUsecase) GetPaperFromOS(id string) (*opensearch.PaperDoc, error) {
-	if u.osClient == nil {
+// GetPaperFromIndex retrieves a paper by its S2 corpusid or external ID from the search index.
+func (u *PaperUsecase) GetPaperFromIndex(id string) (*domain.SearchDoc, error) {
+	if u.searcher == nil {
 		return nil, ErrPaperNotFoundOS
 	}
 
@@ -129,20 +181,53 @@ func (u *PaperUsecase) GetPaperFromOS(id string) (*opensearch.PaperDoc, error) {
 	defer cancel()
 
 	// Try direct lookup by _id (corpusid)
-	doc, err := u.osClient.GetByID(ctx, id)
+	doc, err := u.searcher.GetByID(ctx, id)
 	if err == nil && doc != nil {
 		return doc, nil
 	}
 
 	// Try by external_id (arXiv ID)
-	doc, err = u.osClient.SearchByExternalID(ctx, id)
+	doc, err = u.searcher.GetByExternalID(ctx, id)
 	if err == nil && doc != nil {
 		return doc, nil
 	}
 
+	// Another identifier of the paper (DOI, PMID, ...) or a merged-away
+	// record: find the PG paper it belongs to, then its doc
+	if u.dedupe != nil {
+		paper, err := u.dedupe.Resolve(id)
+		if err == nil && paper != nil && paper.CanView(uuid.Nil) {
+			doc, err = u.searcher.GetByID(ctx, paper.ID.String())
+			if err != nil || doc == nil {
+				doc, err = u.searcher.GetByExternalID(ctx, paper.ExternalID)
+			}
+			if err == nil && doc != nil {
+				return doc, nil
+			}
+			return domainPaperToDoc(paper), nil
+		}
+	}
+
 	return nil, ErrPaperNotFoundOS
 }
 
+// findPaper looks a paper up in PG by UUID, external ID, or — through the
+// crosswalk — any other identifier or the ID of a record merged into it.
+// Returns nil, nil when nothing matches.
+func (u *PaperUsecase) findPaper(idStr string) (*domain.Paper, error) {
+	var paper *domain.Paper
+	var err error
+	if id, parseErr := uuid.Parse(idStr); parseErr == nil {
+		paper, err = u.paperRepo.GetByID(id)
+	} else {
+		paper, err = u.paperRepo.GetByExternalID(idStr)
+	}
+	if err != nil || paper != nil || u.dedupe == nil {
+		return paper, err
+	}
+	return u.dedupe.Resolve(idStr)
+}
+
 // GetPaper retrieves a paper by UUID from PostgreSQL (legacy, for library).
 func (u *PaperUsecase) GetPaper(id uuid.UUID) (*domain.Paper, error) {
 	if u.paperRepo == nil {
@@ -159,10 +244,247 @@ func (u *PaperUsecase) GetPaperByExternalID(externalID string) (*domain.Paper, e
 	return u.paperRepo.GetByExternalID(externalID)
 }
 
+// VersionsResult is the revision history of one paper.
+type VersionsResult struct {
+	PaperID    uuid.UUID              `json:"paper_id"`
+	ExternalID string                 `json:"external_id"`
+	Versions   []*domain.PaperVersion `json:"versions"`
+}
+
+// GetVersions returns a paper's versions by UUID or external ID (arXiv ID).
+// Papers the viewer can't see are reported as not found.
+func (u *PaperUsecase) GetVersions(idStr string, viewer uuid.UUID) (*VersionsResult, error) {
+	if u.paperRepo == nil || u.versionRepo == nil {
+		return nil, ErrPaperNotFound
+	}
+
+	paper, err := u.findPaper(idStr)
+	if err != nil {
+		return nil, err
+	}
+	if paper == nil || !paper.CanView(viewer) {
+		return nil, ErrPaperNotFound
+	}
+
+	versions, err := u.versionRepo.GetByPaper(paper.ID)
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []*domain.PaperVersion{}
+	}
+	return &VersionsResult{PaperID: paper.ID, ExternalID: paper.ExternalID, Versions: versions}, nil
+}
+
+// WorkResult lists every record of one work: a preprint and its published
+// versions, possibly imported from different sources.
+type WorkResult struct {
+	WorkID string              `json:"work_id"`
+	Papers []*domain.SearchDoc `json:"papers"`
+}
+
+// GetWork returns all versions of the work a paper belongs to. id is a PG
+// UUID, an external ID or a search index doc ID. A paper that was never linked
+// is a work of its own.
+func (u *PaperUsecase) GetWork(idStr string, viewer uuid.UUID) (*WorkResult, error) {
+	var self *domain.SearchDoc
+	var workID string
+
+	var paper *domain.Paper
+	if u.paperRepo != nil {
+		var err error
+		if paper, err = u.findPaper(idStr); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case paper != nil:
+		if !paper.CanView(viewer) {
+			return nil, ErrPaperNotFound
+		}
+		self = domainPaperToDoc(paper)
+		workID = self.WorkID
+		if paper.Visibility != "" && paper.Visibility != domain.VisibilityPublic {
+			// Uploads are never linked to other records
+			return &WorkResult{WorkID: workID, Papers: []*domain.SearchDoc{self}}, nil
+		}
+	default:
+		doc, err := u.GetPaperFromIndex(idStr)
+		if err != nil {
+			return nil, ErrPaperNotFound
+		}
+		self = doc
+		workID = doc.WorkID
+		if workID == "" {
+			workID = paperid.WorkKey(doc.Source, doc.ExternalID, doc.DOI)
+		}
+	}
+
+	var papers []*domain.SearchDoc
+	if u.searcher != nil && workID != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		docs, err := u.searcher.ListByWork(ctx, workID, 50)
+		if err != nil {
+			log.Printf("Search index work lookup failed: %v", err)
+		}
+		papers = docs
+	}
+	if len(papers) == 0 && u.paperRepo != nil && workID != "" {
+		linked, err := u.paperRepo.ListByWork(workID)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range linked {
+			papers = append(papers, domainPaperToDoc(p))
+		}
+	}
+	if len(papers) == 0 {
+		papers = []*domain.SearchDoc{self}
+	}
+	return &WorkResult{WorkID: workID, Papers: papers}, nil
+}
+
+// ---------- Resolve ----------
+
+// ErrUnrecognizedIdentifier is returned by ResolveIdentifier for input that
+// isn't any known identifier form.
+var ErrUnrecognizedIdentifier = errors.New("unrecognized identifier")
+
+// ResolveResult is a paper found by identifier.
+type ResolveResult struct {
+	Paper       *domain.SearchDoc   `json:"paper"`
+	Identifiers []domain.Identifier `json:"identifiers"` // what the input was read as
+	MatchedBy   string              `json:"matched_by"`  // "database", "search_index", or the source it was fetched from
+	Imported    bool                `json:"imported"`    // fetched live and stored just now
+}
+
+// ResolveIdentifier finds a paper by anything a user might paste: a DOI,
+// arXiv ID or URL, PMID, PMCID, Semantic Scholar or OpenAlex URL (see
+// ParseIdentifier), or one of our own IDs. It checks PostgreSQL (external
+// IDs and the identifier crosswalk), then the search index. With fetch, a
+// paper found nowhere is looked up live in the external APIs that accept
+// the identifier and imported.
+func (u *PaperUsecase) ResolveIdentifier(input string, fetch bool, viewer uuid.UUID) (*ResolveResult, error) {
+	input = strings.TrimSpace(input)
+	ids := ParseIdentifier(input)
+	result := &ResolveResult{Identifiers: ids}
+	if result.Identifiers == nil {
+		result.Identifiers = []domain.Identifier{}
+	}
+
+	// Our own IDs (UUID, external ID, merged-away records)
+	if u.paperRepo != nil {
+		paper, err := u.findPaper(input)
+		if err != nil {
+			return nil, err
+		}
+		if paper != nil {
+			if !paper.CanView(viewer) {
+				return nil, ErrPaperNotFound
+			}
+			result.Paper, result.MatchedBy = domainPaperToDoc(paper), "database"
+			return result, nil
+		}
+	}
+	if len(ids) == 0 {
+		if _, err := uuid.Parse(input); err == nil {
+			return nil, ErrPaperNotFound
+		}
+		return nil, ErrUnrecognizedIdentifier
+	}
+
+	if paper, err := u.findByIdentifiers(ids); err != nil {
+		return nil, err
+	} else if paper != nil {
+		result.Paper, result.MatchedBy = domainPaperToDoc(paper), "database"
+		return result, nil
+	}
+
+	if doc := u.findDocByIdentifiers(ids); doc != nil {
+		result.Paper, result.MatchedBy = doc, "search_index"
+		return result, nil
+	}
+
+	if !fetch || u.federated == nil || u.paperRepo == nil {
+		return nil, ErrPaperNotFound
+	}
+	remote, err := u.federated.Fetch(ids)
+	if err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		return nil, ErrPaperNotFound
+	}
+	paper, created, err := u.importRemote(remote)
+	if err != nil {
+		return nil, err
+	}
+	result.Paper, result.MatchedBy, result.Imported = domainPaperToDoc(paper), remote.Source, created
+	return result, nil
+}
+
+// findByIdentifiers looks parsed identifiers up in PostgreSQL: through the
+// crosswalk, then as external IDs (for records the dedupe job hasn't
+// registered yet). Only public papers are returned.
+func (u *PaperUsecase) findByIdentifiers(ids []domain.Identifier) (*domain.Paper, error) {
+	if u.paperRepo == nil {
+		return nil, nil
+	}
+	if u.dedupe != nil {
+		paper, err := u.dedupe.ResolveIdentifiers(ids)
+		if err != nil {
+			return nil, err
+		}
+		if paper != nil && paper.CanView(uuid.Nil) {
+			return paper, nil
+		}
+	}
+	for _, id := range ids {
+		if id.Scheme == domain.SchemeS2 || id.Scheme == domain.SchemePMCID {
+			continue // never used as external IDs
+		}
+		paper, err := u.paperRepo.GetByExternalID(id.Value)
+		if err != nil {
+			return nil, err
+		}
+		if paper != nil && paper.CanView(uuid.Nil) {
+			return paper, nil
+		}
+	}
+	return nil, nil
+}
+
+// findDocByIdentifiers looks parsed identifiers up in the search index,
+// where bulk imports keep papers that aren't in PostgreSQL: S2 corpusIds
+// and OpenAlex IDs are document IDs, arXiv IDs external IDs.
+func (u *PaperUsecase) findDocByIdentifiers(ids []domain.Identifier) *domain.SearchDoc {
+	if u.searcher == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	for _, id := range ids {
+		var doc *domain.SearchDoc
+		var err error
+		switch id.Scheme {
+		case domain.SchemeS2, domain.SchemeOpenAlex:
+			doc, err = u.searcher.GetByID(ctx, id.Value)
+		case domain.SchemeArxiv, domain.SchemePMID, domain.SchemeDOI, domain.SchemeS2Paper:
+			doc, err = u.searcher.GetByExternalID(ctx, id.Value)
+		}
+		if err == nil && doc != nil {
+			return doc
+		}
+	}
+	return nil
+}
+
 // ---------- Library Support ----------
 
 // EnsurePaperInDB makes sure a paper exists in PostgreSQL (for library operations).
-// If the paper is not in PG, fetches it from OpenSearch and creates a record.
+// If the paper is not in PG, fetches it from the search index and creates a record.
 // Returns the PG UUID for the paper.
 func (u *PaperUsecase) EnsurePaperInDB(idStr string) (uuid.UUID, error) {
 	// Try parsing as UUID first (existing PG paper)
@@ -175,31 +497,42 @@ func (u *PaperUsecase) EnsurePaperInDB(idStr string) (uuid.UUID, error) {
 		}
 	}
 
-	// Try as external_id in PG
+	// Try as external_id in PG, then as any identifier in the crosswalk
 	if u.paperRepo != nil {
-		paper, err := u.paperRepo.GetByExternalID(idStr)
+		paper, err := u.findPaper(idStr)
 		if err == nil && paper != nil {
 			return paper.ID, nil
 		}
 	}
 
-	// Not in PG — fetch from OpenSearch and create a PG record
-	if u.osClient != nil {
+	// External search hit ("arxiv:2301.00001", "openalex:W...")
+	if u.federated != nil && strings.Contains(idStr, ":") {
+		if paper, err := u.ImportFederated(idStr); err == nil {
+			return paper.ID, nil
+		}
+	}
+
+	// Not in PG — fetch from the search index and create a PG record
+	if u.searcher != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		// Try by _id first (corpusid)
-		doc, err := u.osClient.GetByID(ctx, idStr)
+		doc, err := u.searcher.GetByID(ctx, idStr)
 		if err != nil || doc == nil {
 			// Try by external_id
-			doc, err = u.osClient.SearchByExternalID(ctx, idStr)
+			doc, err = u.searcher.GetByExternalID(ctx, idStr)
 			if err != nil || doc == nil {
 				return uuid.Nil, ErrPaperNotFound
 			}
 		}
 
-		// Create PG record from OS data
-		newPaper := osPaperDocToDomain(doc)
+		// Create PG record from the indexed doc, unless the crosswalk knows
+		// it under another source's ID
+		newPaper := searchDocToDomain(doc)
+		if existing := u.findExisting(newPaper); existing != nil {
+			return existing.ID, nil
+		}
 		if u.paperRepo != nil {
 			if err := u.paperRepo.Create(newPaper); err != nil {
 				// If create fails (e.g., duplicate), try to find existing
@@ -209,6 +542,7 @@ func (u *PaperUsecase) EnsurePaperInDB(idStr string) (uuid.UUID, error) {
 				}
 				return uuid.Nil, err
 			}
+			u.registerIdentifiers(newPaper)
 		}
 		return newPaper.ID, nil
 	}
@@ -216,33 +550,109 @@ func (u *PaperUsecase) EnsurePaperInDB(idStr string) (uuid.UUID, error) {
 	return uuid.Nil, ErrPaperNotFound
 }
 
+// ImportFederated stores an external search hit (by FederatedKey) in
+// PostgreSQL, or returns the existing row if the paper is already there.
+func (u *PaperUsecase) ImportFederated(key string) (*domain.Paper, error) {
+	if u.federated == nil || u.paperRepo == nil {
+		return nil, ErrPaperNotFound
+	}
+	_, externalID, ok := strings.Cut(key, ":")
+	if !ok || externalID == "" {
+		return nil, ErrPaperNotFound
+	}
+
+	if existing, err := u.paperRepo.GetByExternalID(externalID); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return existing, nil
+	}
+
+	remote, err := u.federated.Lookup(key)
+	if err != nil {
+		return nil, err
+	}
+	if remote == nil {
+		return nil, ErrPaperNotFound
+	}
+
+	paper, _, err := u.importRemote(remote)
+	return paper, err
+}
+
+// importRemote stores a paper fetched from an external API, unless it is
+// already here under its external ID or another source's identifier.
+// Reports whether a new row was created.
+func (u *PaperUsecase) importRemote(remote *domain.Paper) (*domain.Paper, bool, error) {
+	if existing, err := u.paperRepo.GetByExternalID(remote.ExternalID); err != nil {
+		return nil, false, err
+	} else if existing != nil {
+		return existing, false, nil
+	}
+	if existing := u.findExisting(remote); existing != nil {
+		return existing, false, nil
+	}
+
+	paper := *remote // the cached hit is shared; don't write IDs into it
+	paper.ID = uuid.Nil
+	if err := u.paperRepo.Create(&paper); err != nil {
+		return nil, false, err
+	}
+	u.registerIdentifiers(&paper)
+	return &paper, true, nil
+}
+
+// findExisting returns the stored record an unsaved paper duplicates
+// according to the crosswalk, or nil.
+func (u *PaperUsecase) findExisting(p *domain.Paper) *domain.Paper {
+	if u.dedupe == nil {
+		return nil
+	}
+	existing, err := u.dedupe.FindExisting(p)
+	if err != nil {
+		log.Printf("Crosswalk lookup failed for %s: %v", p.ExternalID, err)
+		return nil
+	}
+	return existing
+}
+
+// registerIdentifiers adds a newly created paper to the crosswalk. Failures
+// are logged; the batch dedupe job registers anything missed.
+func (u *PaperUsecase) registerIdentifiers(p *domain.Paper) {
+	if u.dedupe == nil {
+		return
+	}
+	if _, err := u.dedupe.Register(p); err != nil {
+		log.Printf("Failed to register identifiers of %s: %v", p.ExternalID, err)
+	}
+}
+
 // ---------- Discover ----------
 
 // DiscoverResult is the response for the discover/suggestion endpoint.
 type DiscoverResult struct {
-	PaperOfTheDay *opensearch.PaperDoc   `json:"paper_of_the_day"`
-	Suggestions   []*opensearch.PaperDoc `json:"suggestions"`
-	Categories    []string               `json:"based_on_categories"`
-	TopCited      []*opensearch.PaperDoc `json:"top_cited,omitempty"`
+	PaperOfTheDay *domain.SearchDoc   `json:"paper_of_the_day"`
+	Suggestions   []*domain.SearchDoc `json:"suggestions"`
+	Categories    []string            `json:"based_on_categories"`
+	TopCited      []*domain.SearchDoc `json:"top_cited,omitempty"`
 }
 
 // Discover returns random paper suggestions based on user interest categories.
 // Uses a seed for deterministic randomness (same result within a seed value, e.g. daily).
 func (u *PaperUsecase) Discover(categories []string, excludeExternalIDs []string, seed string) (*DiscoverResult, error) {
-	if u.osClient == nil {
+	if u.searcher == nil {
 		return &DiscoverResult{}, nil
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	papers, err := u.osClient.GetRandomPapers(ctx, categories, excludeExternalIDs, seed, 6)
+	papers, err := u.searcher.Random(ctx, categories, excludeExternalIDs, seed, 6)
 	if err != nil || len(papers) == 0 {
 		if err != nil {
 			log.Printf("Discover search failed: %v", err)
 		}
 		// Try without categories as fallback (popular random papers)
-		papers, err = u.osClient.GetRandomPapers(ctx, nil, excludeExternalIDs, seed, 6)
+		papers, err = u.searcher.Random(ctx, nil, excludeExternalIDs, seed, 6)
 		if err != nil {
 			return nil, err
 		}
@@ -260,7 +670,7 @@ func (u *PaperUsecase) Discover(categories []string, excludeExternalIDs []string
 	}
 
 	// Fetch top-cited papers of all time from diverse fields
-	topCited, err := u.osClient.GetTopCitedDiverseFields(ctx, 5)
+	topCited, err := u.searcher.TopCited(ctx, 5)
 	if err != nil {
 		log.Printf("Failed to fetch top-cited papers: %v", err)
 		// Non-fatal — the section simply won't appear
@@ -277,13 +687,17 @@ func (u *PaperUsecase) Discover(categories []string, excludeExternalIDs []string
 func (u *PaperUsecase) GetCategories() ([]domain.CategoryInfo, error) {
 	var counts map[string]int64
 
-	if u.osClient != nil {
+	if u.searcher != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		var err error
-		counts, err = u.osClient.GetCategoryCounts(ctx)
+		indexCounts, err := u.searcher.GetCategoryCounts(ctx)
 		if err != nil {
-			log.Printf("OpenSearch category counts failed: %v", err)
+			log.Printf("Search index category counts failed: %v", err)
+		} else {
+			counts = make(map[string]int64, len(indexCounts))
+			for _, c := range indexCounts {
+				counts[c.Category] = c.Count
+			}
 		}
 	}
 
@@ -375,15 +789,20 @@ func ParseCategories(s string) []string {
 
 // ---------- Converters ----------
 
-// domainPaperToDoc converts a PG domain.Paper to an opensearch.PaperDoc for API responses.
-func domainPaperToDoc(p *domain.Paper) *opensearch.PaperDoc {
+// domainPaperToDoc converts a PG domain.Paper to a domain.SearchDoc for API responses.
+func domainPaperToDoc(p *domain.Paper) *domain.SearchDoc {
 	var pubDate *string
 	if p.PublishedDate != nil {
 		s := p.PublishedDate.Format("2006-01-02")
 		pubDate = &s
 	}
 
-	return &opensearch.PaperDoc{
+	workID := p.WorkID
+	if workID == "" {
+		workID = paperid.WorkKey(p.Source, p.ExternalID, p.DOI)
+	}
+
+	return &domain.SearchDoc{
 		ID:              p.ID.String(),
 		ExternalID:      p.ExternalID,
 		Source:          p.Source,
@@ -397,11 +816,12 @@ func domainPaperToDoc(p *domain.Paper) *opensearch.PaperDoc {
 		DOI:             p.DOI,
 		JournalRef:      p.JournalRef,
 		CitationCount:   p.CitationCount,
+		WorkID:          workID,
 	}
 }
 
-// osPaperDocToDomain converts an OS PaperDoc to a PG domain.Paper.
-func osPaperDocToDomain(doc *opensearch.PaperDoc) *domain.Paper {
+// searchDocToDomain converts an indexed doc to a PG domain.Paper.
+func searchDocToDomain(doc *domain.SearchDoc) *domain.Paper {
 	authorsJSON, _ := json.Marshal(doc.Authors)
 
 	var pubDate *time.Time
@@ -415,8 +835,17 @@ func osPaperDocToDomain(doc *opensearch.PaperDoc) *domain.Paper {
 		}
 	}
 
+	// Keep the paper on its document: the search outbox syncs a paper to
+	// its UUID unless metadata names another document
+	id, err := uuid.Parse(doc.ID)
+	var metadata json.RawMessage
+	if err != nil {
+		id = uuid.New()
+		metadata, _ = json.Marshal(map[string]string{"search_doc_id": doc.ID})
+	}
+
 	return &domain.Paper{
-		ID:              uuid.New(),
+		ID:              id,
 		ExternalID:      doc.ExternalID,
 		Source:          doc.Source,
 		Title:           doc.Title,
@@ -429,6 +858,7 @@ func osPaperDocToDomain(doc *opensearch.PaperDoc) *domain.Paper {
 		DOI:             doc.DOI,
 		JournalRef:      doc.JournalRef,
 		CitationCount:   doc.CitationCount,
+		Metadata:        metadata,
 		CreatedAt:       time.Now(),
 	}
 }
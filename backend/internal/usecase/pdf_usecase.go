@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/pdfcache"
+)
+
+var (
+	ErrNoPDF            = errors.New("paper has no PDF")
+	ErrPDFCacheDisabled = errors.New("PDF cache not configured")
+)
+
+// PDFUsecase serves paper PDFs through the local cache.
+type PDFUsecase struct {
+	papers *PaperUsecase
+	cache  *pdfcache.Cache
+}
+
+func NewPDFUsecase(papers *PaperUsecase, cache *pdfcache.Cache) *PDFUsecase {
+	return &PDFUsecase{
+		papers: papers,
+		cache:  cache,
+	}
+}
+
+// PDFURL returns the upstream PDF URL for a paper ID (corpus ID, external ID or UUID).
+func (u *PDFUsecase) PDFURL(idStr string) (string, error) {
+	if doc, err := u.papers.GetPaperFromIndex(idStr); err == nil && doc != nil {
+		if doc.PDFURL == "" {
+			return "", ErrNoPDF
+		}
+		return doc.PDFURL, nil
+	}
+
+	var paper *domain.Paper
+	if id, err := uuid.Parse(idStr); err == nil {
+		paper, _ = u.papers.GetPaper(id)
+	}
+	if paper == nil {
+		paper, _ = u.papers.GetPaperByExternalID(idStr)
+	}
+	if paper == nil {
+		return "", ErrPaperNotFound
+	}
+	if paper.PDFURL == "" {
+		return "", ErrNoPDF
+	}
+	return paper.PDFURL, nil
+}
+
+// Open returns the cached PDF for a paper along with its upstream URL.
+func (u *PDFUsecase) Open(ctx context.Context, idStr string) (*pdfcache.Object, string, error) {
+	pdfURL, err := u.PDFURL(idStr)
+	if err != nil {
+		return nil, "", err
+	}
+	if u.cache == nil {
+		return nil, pdfURL, ErrPDFCacheDisabled
+	}
+	obj, err := u.cache.Open(ctx, pdfURL)
+	return obj, pdfURL, err
+}
+
+// Stats returns cache counters for the admin dashboard, or nil if caching is off.
+func (u *PDFUsecase) Stats() *domain.PDFCacheStats {
+	if u == nil || u.cache == nil {
+		return nil
+	}
+	s := u.cache.Stats()
+	stats := &domain.PDFCacheStats{
+		Hits:        s.Hits,
+		Misses:      s.Misses,
+		Revalidated: s.Revalidated,
+		StaleServed: s.StaleServed,
+		Errors:      s.Errors,
+		Evictions:   s.Evictions,
+		Entries:     s.Entries,
+		Bytes:       s.Bytes,
+		MaxBytes:    s.MaxBytes,
+	}
+	if total := s.Hits + s.Misses + s.Revalidated + s.StaleServed; total > 0 {
+		stats.HitRate = float64(s.Hits+s.Revalidated+s.StaleServed) / float64(total)
+	}
+	return stats
+}
@@ -0,0 +1,157 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/pkg/ingestjob"
+	"github.com/paper-app/backend/pkg/scheduler"
+)
+
+var (
+	ErrScheduleNotFound  = errors.New("schedule not found")
+	ErrScheduleNameTaken = errors.New("a schedule with this name already exists")
+	ErrInvalidSchedule   = errors.New("invalid schedule")
+)
+
+// ScheduleStepInput is one step of a schedule as submitted: a job type
+// and the importer's flags as params. Steps run one after the other.
+type ScheduleStepInput struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params"`
+}
+
+// ScheduleUsecase manages the cron schedules the scheduler queues jobs
+// from.
+type ScheduleUsecase struct {
+	store *scheduler.Store
+}
+
+func NewScheduleUsecase(store *scheduler.Store) *ScheduleUsecase {
+	return &ScheduleUsecase{store: store}
+}
+
+func (u *ScheduleUsecase) List() ([]*scheduler.Schedule, error) {
+	return u.store.List(context.Background())
+}
+
+func (u *ScheduleUsecase) Get(id uuid.UUID) (*scheduler.Schedule, error) {
+	sched, err := u.store.Get(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	if sched == nil {
+		return nil, ErrScheduleNotFound
+	}
+	return sched, nil
+}
+
+// Create adds a schedule, enabled unless the input says otherwise.
+func (u *ScheduleUsecase) Create(name, cron string, steps []ScheduleStepInput, enabled *bool, userID uuid.UUID) (*scheduler.Schedule, error) {
+	sched := &scheduler.Schedule{Enabled: enabled == nil || *enabled, CreatedBy: &userID}
+	if err := u.apply(sched, name, cron, steps); err != nil {
+		return nil, err
+	}
+	created, err := u.store.Create(context.Background(), sched)
+	if errors.Is(err, scheduler.ErrNameTaken) {
+		return nil, ErrScheduleNameTaken
+	}
+	return created, err
+}
+
+// Update replaces a schedule's name, cron expression and steps, and enables
+// or disables it if enabled is set. The next run is recomputed.
+func (u *ScheduleUsecase) Update(id uuid.UUID, name, cron string, steps []ScheduleStepInput, enabled *bool) (*scheduler.Schedule, error) {
+	sched, err := u.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled != nil {
+		sched.Enabled = *enabled
+	}
+	if err := u.apply(sched, name, cron, steps); err != nil {
+		return nil, err
+	}
+	return u.save(sched)
+}
+
+// RunNow makes the schedule due at the scheduler's next check.
+func (u *ScheduleUsecase) RunNow(id uuid.UUID) (*scheduler.Schedule, error) {
+	sched, err := u.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	sched.NextRunAt = &now
+	sched.Enabled = true
+	return u.save(sched)
+}
+
+func (u *ScheduleUsecase) Delete(id uuid.UUID) error {
+	ok, err := u.store.Delete(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrScheduleNotFound
+	}
+	return nil
+}
+
+// Runs returns a schedule's run history, newest first.
+func (u *ScheduleUsecase) Runs(id uuid.UUID, limit int) ([]*scheduler.Run, error) {
+	if _, err := u.Get(id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	return u.store.Runs(context.Background(), id, limit)
+}
+
+// apply validates the input into sched and sets its next run time.
+func (u *ScheduleUsecase) apply(sched *scheduler.Schedule, name, cron string, steps []ScheduleStepInput) error {
+	name, cron = strings.TrimSpace(name), strings.TrimSpace(cron)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidSchedule)
+	}
+	if len(steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidSchedule)
+	}
+	next, err := scheduler.NextRun(cron, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSchedule, err)
+	}
+
+	sched.Name, sched.Cron, sched.NextRunAt = name, cron, next
+	sched.Steps = make([]scheduler.Step, len(steps))
+	for i, step := range steps {
+		cmd := ingestjob.Commands[step.Type]
+		if cmd == nil {
+			return fmt.Errorf("%w: step %d: unknown job type %q", ErrInvalidSchedule, i+1, step.Type)
+		}
+		params, err := cmd.Params(step.Params)
+		if err != nil {
+			return fmt.Errorf("%w: step %d: %v", ErrInvalidSchedule, i+1, err)
+		}
+		sched.Steps[i] = scheduler.Step{Type: step.Type, Params: params}
+	}
+	return nil
+}
+
+func (u *ScheduleUsecase) save(sched *scheduler.Schedule) (*scheduler.Schedule, error) {
+	saved, err := u.store.Update(context.Background(), sched)
+	switch {
+	case errors.Is(err, scheduler.ErrNameTaken):
+		return nil, ErrScheduleNameTaken
+	case err != nil:
+		return nil, err
+	case saved == nil:
+		return nil, ErrScheduleNotFound
+	}
+	return saved, nil
+}
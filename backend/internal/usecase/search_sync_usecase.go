@@ -0,0 +1,42 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/paper-app/backend/pkg/searchsync"
+)
+
+// SearchSyncStatus is the search outbox's backlog with the most recently
+// dead-lettered rows.
+type SearchSyncStatus struct {
+	*searchsync.Status
+	RecentDead []*searchsync.DeadEntry `json:"recent_dead"`
+}
+
+// SearchSyncUsecase reports how far the search index trails PostgreSQL and
+// requeues the changes the sync gave up on.
+type SearchSyncUsecase struct {
+	syncer *searchsync.Syncer
+}
+
+func NewSearchSyncUsecase(syncer *searchsync.Syncer) *SearchSyncUsecase {
+	return &SearchSyncUsecase{syncer: syncer}
+}
+
+func (u *SearchSyncUsecase) Status() (*SearchSyncStatus, error) {
+	ctx := context.Background()
+	st, err := u.syncer.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dead, err := u.syncer.Dead(ctx, 20)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchSyncStatus{Status: st, RecentDead: dead}, nil
+}
+
+// RetryDead queues the dead-lettered changes again and returns how many.
+func (u *SearchSyncUsecase) RetryDead() (int64, error) {
+	return u.syncer.RequeueDead(context.Background())
+}
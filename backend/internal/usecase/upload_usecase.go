@@ -0,0 +1,319 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/blobstore"
+	"github.com/paper-app/backend/pkg/pdftext"
+)
+
+var (
+	ErrUploadNotPDF      = errors.New("uploaded file is not a PDF")
+	ErrUploadTooLarge    = errors.New("uploaded file is too large")
+	ErrUploadNoTitle     = errors.New("title is required")
+	ErrInvalidVisibility = errors.New("visibility must be private or workspace")
+	ErrNotPaperOwner     = errors.New("only the owner can modify this paper")
+	ErrNotUpload         = errors.New("paper is not an upload")
+)
+
+// UploadUsecase manages user-uploaded PDFs. Uploads are never public: they are
+// visible to their owner, or to every signed-in user when shared with the workspace.
+type UploadUsecase struct {
+	paperRepo    domain.PaperRepository
+	fullTextRepo domain.FullTextRepository
+	store        *blobstore.Store
+	maxSize      int64
+	// extractTimeout bounds text extraction, which runs on arbitrary input
+	extractTimeout time.Duration
+}
+
+func NewUploadUsecase(paperRepo domain.PaperRepository, fullTextRepo domain.FullTextRepository, store *blobstore.Store, maxSize int64, extractTimeout time.Duration) *UploadUsecase {
+	if extractTimeout <= 0 {
+		extractTimeout = 30 * time.Second
+	}
+	return &UploadUsecase{
+		paperRepo:      paperRepo,
+		fullTextRepo:   fullTextRepo,
+		store:          store,
+		maxSize:        maxSize,
+		extractTimeout: extractTimeout,
+	}
+}
+
+// MaxSize is the largest PDF Upload accepts.
+func (u *UploadUsecase) MaxSize() int64 {
+	return u.maxSize
+}
+
+// UploadInput is the metadata submitted with an uploaded PDF.
+type UploadInput struct {
+	Title         string
+	Abstract      string
+	Authors       []string
+	PublishedDate *time.Time
+	DOI           string
+	Categories    []string
+	Visibility    string // private (default) or workspace
+	Filename      string
+}
+
+// UploadList is the response for listing uploads.
+type UploadList struct {
+	Papers []*domain.Paper `json:"papers"`
+	Total  int             `json:"total"`
+	Offset int             `json:"offset"`
+	Limit  int             `json:"limit"`
+}
+
+// Upload stores the PDF, extracts its text and creates the paper.
+func (u *UploadUsecase) Upload(ownerID uuid.UUID, file io.Reader, in UploadInput) (*domain.Paper, error) {
+	if in.Visibility == "" {
+		in.Visibility = domain.VisibilityPrivate
+	}
+	if in.Visibility != domain.VisibilityPrivate && in.Visibility != domain.VisibilityWorkspace {
+		return nil, ErrInvalidVisibility
+	}
+	in.Title = strings.TrimSpace(in.Title)
+	if in.Title == "" {
+		return nil, ErrUploadNoTitle
+	}
+
+	key, size, err := u.store.Put(file, u.maxSize)
+	if err == blobstore.ErrTooLarge {
+		return nil, ErrUploadTooLarge
+	}
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.store.Read(key)
+	if err != nil {
+		return nil, err
+	}
+	// The header may follow a little junk (PDF spec allows it within the first 1KB)
+	if !strings.Contains(string(data[:min(len(data), 1024)]), "%PDF-") {
+		u.deleteBlobIfUnused(key)
+		return nil, ErrUploadNotPDF
+	}
+
+	id := uuid.New()
+	authors := make([]domain.Author, 0, len(in.Authors))
+	for _, name := range in.Authors {
+		if name = strings.TrimSpace(name); name != "" {
+			authors = append(authors, domain.Author{Name: name})
+		}
+	}
+	authorsJSON, _ := json.Marshal(authors)
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"original_filename": in.Filename,
+		"file_size":         size,
+	})
+
+	paper := &domain.Paper{
+		ID:            id,
+		ExternalID:    "upload:" + id.String(),
+		Source:        domain.SourceUpload,
+		Title:         in.Title,
+		Abstract:      strings.TrimSpace(in.Abstract),
+		Authors:       authorsJSON,
+		PublishedDate: in.PublishedDate,
+		Metadata:      metadata,
+		Categories:    in.Categories,
+		DOI:           strings.TrimSpace(in.DOI),
+		OwnerID:       &ownerID,
+		Visibility:    in.Visibility,
+		BlobKey:       key,
+	}
+	if len(in.Categories) > 0 {
+		paper.PrimaryCategory = in.Categories[0]
+	}
+	if err := u.paperRepo.CreateUpload(paper); err != nil {
+		u.deleteBlobIfUnused(key)
+		return nil, err
+	}
+
+	// Text extraction is best-effort: scanned or encrypted PDFs, and those
+	// that take too long, are still stored.
+	ctx, cancel := context.WithTimeout(context.Background(), u.extractTimeout)
+	defer cancel()
+	if res, err := pdftext.ExtractContext(ctx, data); err == nil {
+		sections, _ := json.Marshal(pdftext.SplitSections(res.Text))
+		ft := &domain.FullText{
+			PaperID:   id,
+			BlobKey:   key,
+			PDFSize:   size,
+			PageCount: len(res.Pages),
+			Text:      res.Text,
+			Sections:  sections,
+		}
+		if err := u.fullTextRepo.Save(ft); err != nil {
+			log.Printf("WARN: save text of upload %s: %v", id, err)
+		}
+	} else {
+		log.Printf("Upload %s: no text extracted (%v)", id, err)
+	}
+
+	return paper, nil
+}
+
+// Get returns an upload the user can see.
+func (u *UploadUsecase) Get(userID, paperID uuid.UUID) (*domain.Paper, error) {
+	paper, err := u.paperRepo.GetByID(paperID)
+	if err != nil {
+		return nil, err
+	}
+	if paper == nil || paper.Source != domain.SourceUpload || !paper.CanView(userID) {
+		return nil, ErrPaperNotFound
+	}
+	return paper, nil
+}
+
+// List returns uploads visible to the user, optionally filtered by a text query.
+func (u *UploadUsecase) List(userID uuid.UUID, query string, limit, offset int) (*UploadList, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	papers, total, err := u.paperRepo.ListUploads(userID, strings.TrimSpace(query), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return &UploadList{Papers: papers, Total: total, Offset: offset, Limit: limit}, nil
+}
+
+// UploadUpdate holds the fields to change; nil fields are left as is.
+type UploadUpdate struct {
+	Title         *string
+	Abstract      *string
+	Authors       []string
+	PublishedDate *time.Time
+	DOI           *string
+	Categories    []string
+	Visibility    *string
+}
+
+// Update edits an upload's metadata or visibility. Only the owner may do this.
+func (u *UploadUsecase) Update(userID, paperID uuid.UUID, upd UploadUpdate) (*domain.Paper, error) {
+	paper, err := u.owned(userID, paperID)
+	if err != nil {
+		return nil, err
+	}
+
+	if upd.Title != nil {
+		if strings.TrimSpace(*upd.Title) == "" {
+			return nil, ErrUploadNoTitle
+		}
+		paper.Title = strings.TrimSpace(*upd.Title)
+	}
+	if upd.Abstract != nil {
+		paper.Abstract = strings.TrimSpace(*upd.Abstract)
+	}
+	if upd.Authors != nil {
+		authors := make([]domain.Author, 0, len(upd.Authors))
+		for _, name := range upd.Authors {
+			if name = strings.TrimSpace(name); name != "" {
+				authors = append(authors, domain.Author{Name: name})
+			}
+		}
+		paper.Authors, _ = json.Marshal(authors)
+	}
+	if upd.PublishedDate != nil {
+		paper.PublishedDate = upd.PublishedDate
+	}
+	if upd.DOI != nil {
+		paper.DOI = strings.TrimSpace(*upd.DOI)
+	}
+	if upd.Categories != nil {
+		paper.Categories = upd.Categories
+		paper.PrimaryCategory = ""
+		if len(upd.Categories) > 0 {
+			paper.PrimaryCategory = upd.Categories[0]
+		}
+	}
+	if upd.Visibility != nil {
+		if *upd.Visibility != domain.VisibilityPrivate && *upd.Visibility != domain.VisibilityWorkspace {
+			return nil, ErrInvalidVisibility
+		}
+		paper.Visibility = *upd.Visibility
+	}
+
+	if err := u.paperRepo.UpdateUpload(paper); err != nil {
+		return nil, err
+	}
+	return paper, nil
+}
+
+// Delete removes an upload and, if no other paper uses it, its PDF.
+func (u *UploadUsecase) Delete(userID, paperID uuid.UUID) error {
+	paper, err := u.owned(userID, paperID)
+	if err != nil {
+		return err
+	}
+	if err := u.paperRepo.Delete(paper.ID); err != nil {
+		return err
+	}
+	u.deleteBlobIfUnused(paper.BlobKey)
+	return nil
+}
+
+// OpenPDF opens the stored PDF of an upload. It returns ErrNotUpload for
+// papers that are not uploads so callers can fall back to the PDF proxy.
+func (u *UploadUsecase) OpenPDF(userID uuid.UUID, idStr string) (*os.File, *domain.Paper, error) {
+	paperID, err := uuid.Parse(idStr)
+	if err != nil {
+		if !strings.HasPrefix(idStr, "upload:") {
+			return nil, nil, ErrNotUpload
+		}
+		if paperID, err = uuid.Parse(strings.TrimPrefix(idStr, "upload:")); err != nil {
+			return nil, nil, ErrPaperNotFound
+		}
+	}
+	paper, err := u.paperRepo.GetByID(paperID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if paper == nil || paper.Source != domain.SourceUpload {
+		return nil, nil, ErrNotUpload
+	}
+	if !paper.CanView(userID) || paper.BlobKey == "" {
+		return nil, nil, ErrPaperNotFound
+	}
+	f, err := u.store.Open(paper.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, paper, nil
+}
+
+func (u *UploadUsecase) owned(userID, paperID uuid.UUID) (*domain.Paper, error) {
+	paper, err := u.Get(userID, paperID)
+	if err != nil {
+		return nil, err
+	}
+	if paper.OwnerID == nil || *paper.OwnerID != userID {
+		return nil, ErrNotPaperOwner
+	}
+	return paper, nil
+}
+
+func (u *UploadUsecase) deleteBlobIfUnused(key string) {
+	if key == "" {
+		return
+	}
+	if n, err := u.paperRepo.CountByBlobKey(key); err != nil || n > 0 {
+		return
+	}
+	if err := u.store.Delete(key); err != nil {
+		log.Printf("WARN: delete blob %s: %v", key, err)
+	}
+}
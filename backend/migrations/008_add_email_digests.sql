@@ -0,0 +1,29 @@
+-- Email digest preferences and send log
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_frequency VARCHAR(10) NOT NULL DEFAULT 'off';  -- 'off', 'daily', 'weekly'
+ALTER TABLE users ADD COLUMN IF NOT EXISTS digest_unsubscribe_token VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_digest_unsubscribe_token
+    ON users(digest_unsubscribe_token) WHERE digest_unsubscribe_token IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_users_digest_frequency ON users(digest_frequency) WHERE digest_frequency != 'off';
+
+-- One row per (user, frequency, period). The row is claimed with status 'sending'
+-- BEFORE the SMTP call, so a crash mid-send leaves a 'sending' row behind and the
+-- next run skips it instead of sending the same digest twice.
+CREATE TABLE IF NOT EXISTS digest_sends (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    frequency VARCHAR(10) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'sending',  -- 'sending', 'sent', 'skipped', 'failed'
+    paper_count INT NOT NULL DEFAULT 0,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    sent_at TIMESTAMPTZ,
+    UNIQUE (user_id, frequency, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_digest_sends_status ON digest_sends(status);
+
+-- Digests select papers by ingest time
+CREATE INDEX IF NOT EXISTS idx_papers_created_at ON papers(created_at DESC);
@@ -0,0 +1,28 @@
+-- Full-text extraction from open-access PDFs (cmd/fulltext)
+CREATE TABLE IF NOT EXISTS paper_fulltext (
+    paper_id UUID PRIMARY KEY REFERENCES papers(id) ON DELETE CASCADE,
+    blob_key VARCHAR(64) NOT NULL,       -- SHA-256 of the PDF in the blob store
+    pdf_size BIGINT NOT NULL DEFAULT 0,
+    page_count INT NOT NULL DEFAULT 0,
+    text TEXT NOT NULL,
+    sections JSONB,                      -- [{"title": "...", "text": "..."}]
+    extracted_at TIMESTAMPTZ DEFAULT NOW(),
+    indexed_at TIMESTAMPTZ               -- last push to OpenSearch; NULL = pending
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_fulltext_blob_key ON paper_fulltext(blob_key);
+CREATE INDEX IF NOT EXISTS idx_paper_fulltext_unindexed ON paper_fulltext(extracted_at) WHERE indexed_at IS NULL;
+
+-- Download/extraction failures, retried with backoff until permanent
+CREATE TABLE IF NOT EXISTS fulltext_failures (
+    paper_id UUID PRIMARY KEY REFERENCES papers(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    http_status INT,
+    last_error TEXT,
+    permanent BOOLEAN NOT NULL DEFAULT FALSE,  -- 404/410, not a PDF, encrypted, too many attempts
+    next_attempt_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_fulltext_failures_next_attempt ON fulltext_failures(next_attempt_at) WHERE NOT permanent;
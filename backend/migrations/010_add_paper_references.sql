@@ -0,0 +1,27 @@
+-- Citation edges parsed from PDF bibliographies (cmd/refextract)
+CREATE TABLE IF NOT EXISTS paper_references (
+    id BIGSERIAL PRIMARY KEY,
+    citing_paper_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE,
+    position INT NOT NULL,                -- order in the bibliography, from 1
+    raw TEXT NOT NULL,                    -- entry as printed, kept even when unresolved
+    title TEXT,
+    authors JSONB,
+    year INT,
+    venue TEXT,
+    doi VARCHAR(255),
+    arxiv_id VARCHAR(50),
+    cited_paper_id UUID REFERENCES papers(id) ON DELETE SET NULL,  -- NULL = not in our index (yet)
+    match_method VARCHAR(10),             -- doi, arxiv, title
+    match_score REAL,
+    source VARCHAR(20) NOT NULL DEFAULT 'pdf',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(citing_paper_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_references_cited ON paper_references(cited_paper_id) WHERE cited_paper_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_paper_references_unresolved ON paper_references(id) WHERE cited_paper_id IS NULL;
+
+ALTER TABLE paper_fulltext ADD COLUMN IF NOT EXISTS references_extracted_at TIMESTAMPTZ;
+
+-- DOI lookups from paperid.Matcher are case-insensitive
+CREATE INDEX IF NOT EXISTS idx_papers_doi_lower ON papers(lower(doi));
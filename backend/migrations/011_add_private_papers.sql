@@ -0,0 +1,14 @@
+-- User-uploaded PDFs (source = 'upload') are private to their owner or shared
+-- with the workspace (every signed-in user of this deployment). Only 'public'
+-- papers are indexed in OpenSearch or returned by /papers/search.
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS owner_id UUID REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'public';
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS blob_key VARCHAR(64);  -- uploaded PDF in the blob store
+
+DO $$ BEGIN
+    ALTER TABLE papers ADD CONSTRAINT papers_visibility_check CHECK (visibility IN ('public', 'workspace', 'private'));
+EXCEPTION WHEN duplicate_object THEN NULL;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_papers_owner ON papers(owner_id) WHERE owner_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_papers_non_public ON papers(visibility, created_at DESC) WHERE visibility != 'public';
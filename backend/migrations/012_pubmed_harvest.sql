@@ -0,0 +1,9 @@
+-- PubMed harvesting (cmd/pubmed_harvest) stores MeSH descriptors as categories.
+-- Descriptor names run longer than arXiv category codes, e.g.
+-- "Antineoplastic Combined Chemotherapy Protocols". Widening a VARCHAR does
+-- not rewrite the table.
+ALTER TABLE papers ALTER COLUMN primary_category TYPE VARCHAR(255);
+
+-- Harvest checkpoints are keyed "pubmed" or "pubmed:<query>" alongside the
+-- OAI-PMH sets; E-utilities queries can be long.
+ALTER TABLE harvest_checkpoints ALTER COLUMN set_name TYPE VARCHAR(255);
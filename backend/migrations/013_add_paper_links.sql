@@ -0,0 +1,17 @@
+-- Preprint → journal article linkage. published_doi is the DOI of the
+-- journal version as reported by the preprint server (bioRxiv/medRxiv
+-- "published" field); paper_links records the edge once both records exist.
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS published_doi VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_papers_published_doi ON papers(lower(published_doi)) WHERE published_doi IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS paper_links (
+    paper_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE,        -- the preprint
+    linked_paper_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE, -- the journal article
+    relation VARCHAR(20) NOT NULL DEFAULT 'published_as',
+    method VARCHAR(20) NOT NULL,  -- how the link was found: published_doi, ...
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (paper_id, linked_paper_id, relation),
+    CHECK (paper_id <> linked_paper_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_links_linked ON paper_links(linked_paper_id);
@@ -0,0 +1,31 @@
+-- Revision history of arXiv papers. cmd/ingest records exact versions from
+-- the Kaggle snapshot; cmd/harvest infers them from OAI-PMH created/updated
+-- dates. comments/abstract are snapshots, known only for the version that
+-- was current when the record was read.
+CREATE TABLE IF NOT EXISTS paper_versions (
+    paper_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE,
+    version INT NOT NULL,
+    version_date TIMESTAMP NOT NULL,
+    comments TEXT,
+    abstract TEXT,
+    source VARCHAR(20) NOT NULL,  -- kaggle or oai
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (paper_id, version)
+);
+
+-- In-app notifications, e.g. a new version of a paper in the user's library.
+-- dedupe_key makes re-running an import idempotent.
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(30) NOT NULL,
+    paper_id UUID REFERENCES papers(id) ON DELETE CASCADE,
+    data JSONB NOT NULL DEFAULT '{}',
+    dedupe_key VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMP,
+    UNIQUE (user_id, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications(user_id) WHERE read_at IS NULL;
@@ -0,0 +1,9 @@
+-- Works: a preprint and its journal version(s) are one "work". work_id is a
+-- string key shared by every record of the work (see paperid.WorkKey);
+-- cmd/linkworks assigns it from paper_links. work_primary marks the record
+-- shown when search collapses a work to one hit (the published version).
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS work_id VARCHAR(300);
+ALTER TABLE papers ADD COLUMN IF NOT EXISTS work_primary BOOLEAN NOT NULL DEFAULT true;
+CREATE INDEX IF NOT EXISTS idx_papers_work_id ON papers(work_id) WHERE work_id IS NOT NULL;
+
+-- paper_links.method gains doi, journal_ref and title_author (cmd/linkworks)
@@ -0,0 +1,27 @@
+-- Identifier crosswalk: every known ID of a paper (arXiv, DOI, PMID, PMCID,
+-- S2 corpusId, OpenAlex work) points at one papers row. Two rows claiming
+-- the same identifier are duplicates, and the dedupe engine merges them.
+CREATE TABLE IF NOT EXISTS paper_identifiers (
+    scheme VARCHAR(20) NOT NULL,   -- arxiv, doi, pmid, pmcid, s2, openalex
+    value VARCHAR(255) NOT NULL,   -- normalized (see usecase.ExtractIdentifiers)
+    paper_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE,
+    source VARCHAR(30) NOT NULL,   -- source of the record the identifier was read from
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (scheme, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_identifiers_paper ON paper_identifiers(paper_id);
+
+-- Merged duplicates. Old UUIDs and external IDs keep resolving to the
+-- surviving record; into_id follows later merges of the survivor.
+CREATE TABLE IF NOT EXISTS paper_merges (
+    merged_id UUID PRIMARY KEY,
+    into_id UUID NOT NULL REFERENCES papers(id) ON DELETE CASCADE,
+    external_id VARCHAR(255) NOT NULL,
+    source VARCHAR(30) NOT NULL,
+    fields TEXT[] NOT NULL DEFAULT '{}',  -- survivor fields taken from the duplicate
+    merged_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_paper_merges_into ON paper_merges(into_id);
+CREATE INDEX IF NOT EXISTS idx_paper_merges_external ON paper_merges(external_id);
@@ -0,0 +1,26 @@
+-- Ingestion jobs (pkg/ingestjob): one row per importer run. type + name is
+-- the resume key (e.g. harvest + cs); cursor is the importer's position,
+-- counters its progress. A running job whose heartbeat stops is from a
+-- crashed process and is failed by the next runner that looks.
+CREATE TABLE IF NOT EXISTS jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    type VARCHAR(50) NOT NULL,                 -- harvest, oaimport, s2import, ingest, enrich, ...
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    params JSONB NOT NULL DEFAULT '{}',
+    cursor TEXT NOT NULL DEFAULT '',
+    counters JSONB NOT NULL DEFAULT '{}',
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, completed, failed, cancelled
+    error TEXT,                                -- why it failed
+    errors JSONB NOT NULL DEFAULT '[]',        -- recent non-fatal errors, newest last
+    cancel_requested BOOLEAN NOT NULL DEFAULT false,
+    worker VARCHAR(255),                       -- host:pid of the process running it
+    heartbeat_at TIMESTAMPTZ,
+    started_at TIMESTAMPTZ,
+    finished_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_key ON jobs(type, name, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status, created_at DESC);
+-- One live run per key
+CREATE UNIQUE INDEX IF NOT EXISTS idx_jobs_running ON jobs(type, name) WHERE status = 'running';
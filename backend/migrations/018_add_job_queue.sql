@@ -0,0 +1,15 @@
+-- Jobs launched from the admin API (/api/v1/admin/jobs) are queued as
+-- 'pending' and picked up by a worker (the server's pool or cmd/worker),
+-- which runs the importer command and stores its output in job_logs.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs(created_at) WHERE status = 'pending';
+
+CREATE TABLE IF NOT EXISTS job_logs (
+    id BIGSERIAL PRIMARY KEY,
+    job_id UUID NOT NULL REFERENCES jobs(id) ON DELETE CASCADE,
+    line TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_logs_job ON job_logs(job_id, id);
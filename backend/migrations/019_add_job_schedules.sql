@@ -0,0 +1,46 @@
+-- Job schedules (pkg/scheduler): each schedule queues its steps as jobs
+-- (pkg/ingestjob) when its cron expression comes due, one step after the
+-- previous one completed. Schedulers in several processes take turns under
+-- a Postgres advisory lock, and (schedule_id, scheduled_for) is unique, so
+-- each due time runs once.
+CREATE TABLE IF NOT EXISTS job_schedules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL UNIQUE,
+    cron VARCHAR(100) NOT NULL,                -- 5-field cron expression in UTC, or @daily etc.
+    steps JSONB NOT NULL DEFAULT '[]',         -- [{"type": "harvest", "params": {"set": "cs"}}, ...]
+    enabled BOOLEAN NOT NULL DEFAULT true,
+    next_run_at TIMESTAMPTZ,
+    last_run_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_job_schedules_due ON job_schedules(next_run_at) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS schedule_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    schedule_id UUID NOT NULL REFERENCES job_schedules(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMPTZ NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'running', -- running, completed, failed, cancelled, skipped
+    step INT NOT NULL DEFAULT 0,               -- index of the step running
+    job_ids UUID[] NOT NULL DEFAULT '{}',      -- jobs queued so far, one per step
+    error TEXT,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ,
+    UNIQUE (schedule_id, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_schedule ON schedule_runs(schedule_id, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_schedule_runs_running ON schedule_runs(started_at) WHERE status = 'running';
+
+-- cmd/index --since selects papers by updated_at
+CREATE INDEX IF NOT EXISTS idx_papers_updated_at ON papers(updated_at);
+
+-- arXiv publishes daily: harvest cs from the last datestamp at 02:00 UTC,
+-- then index the papers that changed. Disabled until an admin turns it on.
+INSERT INTO job_schedules (name, cron, steps, enabled)
+VALUES ('arxiv-cs-daily', '0 2 * * *',
+        '[{"type": "harvest", "params": {"set": "cs"}}, {"type": "index", "params": {"since": "last"}}]',
+        false)
+ON CONFLICT (name) DO NOTHING;
@@ -0,0 +1,88 @@
+-- Search outbox: every write to a paper's searchable columns queues the
+-- paper here in the same transaction, whichever importer or API made it.
+-- A sync worker (cmd/searchsync or the server's SEARCH_SYNC loop) drains
+-- it into OpenSearch, retrying failures with backoff and moving rows that
+-- keep failing to search_outbox_dead.
+CREATE TABLE IF NOT EXISTS search_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    paper_id UUID NOT NULL,              -- no FK: deletes are queued too
+    doc_id TEXT NOT NULL,                -- OpenSearch _id
+    op VARCHAR(10) NOT NULL CHECK (op IN ('upsert', 'delete')),
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_outbox_available ON search_outbox(available_at, id);
+
+CREATE TABLE IF NOT EXISTS search_outbox_dead (
+    id BIGINT PRIMARY KEY,
+    paper_id UUID NOT NULL,
+    doc_id TEXT NOT NULL,
+    op VARCHAR(10) NOT NULL,
+    attempts INT NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMPTZ NOT NULL,
+    dead_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A paper's document is its UUID, except for papers created from documents
+-- indexed under another ID (S2 corpus IDs), which keep it in
+-- metadata.search_doc_id.
+CREATE OR REPLACE FUNCTION search_outbox_papers() RETURNS trigger AS $$
+DECLARE
+    old_doc TEXT;
+    new_doc TEXT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO search_outbox (paper_id, doc_id, op)
+        VALUES (OLD.id, COALESCE(OLD.metadata->>'search_doc_id', OLD.id::text), 'delete');
+        RETURN NULL;
+    END IF;
+
+    new_doc := COALESCE(NEW.metadata->>'search_doc_id', NEW.id::text);
+    IF TG_OP = 'UPDATE' THEN
+        -- Only the columns cmd/index puts in the document
+        IF ROW(OLD.external_id, OLD.source, OLD.title, OLD.abstract, OLD.authors, OLD.published_date,
+               OLD.pdf_url, OLD.primary_category, OLD.categories, OLD.doi, OLD.journal_ref,
+               OLD.citation_count, OLD.work_id, OLD.visibility, OLD.metadata->>'search_doc_id')
+           IS NOT DISTINCT FROM
+           ROW(NEW.external_id, NEW.source, NEW.title, NEW.abstract, NEW.authors, NEW.published_date,
+               NEW.pdf_url, NEW.primary_category, NEW.categories, NEW.doi, NEW.journal_ref,
+               NEW.citation_count, NEW.work_id, NEW.visibility, NEW.metadata->>'search_doc_id') THEN
+            RETURN NULL;
+        END IF;
+        old_doc := COALESCE(OLD.metadata->>'search_doc_id', OLD.id::text);
+        IF old_doc <> new_doc THEN
+            INSERT INTO search_outbox (paper_id, doc_id, op) VALUES (OLD.id, old_doc, 'delete');
+        END IF;
+    END IF;
+
+    INSERT INTO search_outbox (paper_id, doc_id, op) VALUES (NEW.id, new_doc, 'upsert');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_search_outbox_papers ON papers;
+CREATE TRIGGER trg_search_outbox_papers
+    AFTER INSERT OR UPDATE OR DELETE ON papers
+    FOR EACH ROW EXECUTE FUNCTION search_outbox_papers();
+
+-- Extracted full text is part of the document too
+CREATE OR REPLACE FUNCTION search_outbox_fulltext() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.sections IS NOT DISTINCT FROM NEW.sections THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO search_outbox (paper_id, doc_id, op)
+    SELECT p.id, COALESCE(p.metadata->>'search_doc_id', p.id::text), 'upsert'
+    FROM papers p WHERE p.id = NEW.paper_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_search_outbox_fulltext ON paper_fulltext;
+CREATE TRIGGER trg_search_outbox_fulltext
+    AFTER INSERT OR UPDATE ON paper_fulltext
+    FOR EACH ROW EXECUTE FUNCTION search_outbox_fulltext();
@@ -0,0 +1,22 @@
+-- What digests are built from besides library categories: followed authors
+-- and topics (category codes), and saved searches, which are run against
+-- the papers added during each digest period.
+CREATE TABLE IF NOT EXISTS user_follows (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    kind VARCHAR(10) NOT NULL CHECK (kind IN ('author', 'topic')),
+    value TEXT NOT NULL,                 -- author name, or category code such as cs.LG
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (user_id, kind, value)
+);
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    query TEXT NOT NULL,
+    categories TEXT[] NOT NULL DEFAULT '{}',  -- empty: any category
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_saved_searches_user ON saved_searches(user_id, created_at);
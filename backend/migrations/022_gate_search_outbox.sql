@@ -0,0 +1,78 @@
+-- The search outbox is only filled while a syncer consumes it. Without one
+-- (PostgreSQL search, or no SEARCH_SYNC / cmd/searchsync) the trigger used
+-- to queue every change forever. A syncer registers in search_sync_state
+-- when it starts; the first one queues every paper, since the changes made
+-- before it were not recorded (see searchsync.Syncer).
+CREATE TABLE IF NOT EXISTS search_sync_state (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),  -- one row
+    enabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- A backlog queued before any syncer ran is replaced by that full queue
+DELETE FROM search_outbox WHERE NOT EXISTS (SELECT 1 FROM search_sync_state);
+
+-- Importers no longer write to OpenSearch, so the S2 fields kept in
+-- papers.metadata are part of the document too.
+CREATE OR REPLACE FUNCTION search_outbox_papers() RETURNS trigger AS $$
+DECLARE
+    old_doc TEXT;
+    new_doc TEXT;
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM search_sync_state) THEN
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        INSERT INTO search_outbox (paper_id, doc_id, op)
+        VALUES (OLD.id, COALESCE(OLD.metadata->>'search_doc_id', OLD.id::text), 'delete');
+        RETURN NULL;
+    END IF;
+
+    new_doc := COALESCE(NEW.metadata->>'search_doc_id', NEW.id::text);
+    IF TG_OP = 'UPDATE' THEN
+        -- Only the columns and metadata keys searchsync puts in the document
+        IF ROW(OLD.external_id, OLD.source, OLD.title, OLD.abstract, OLD.authors, OLD.published_date,
+               OLD.pdf_url, OLD.primary_category, OLD.categories, OLD.doi, OLD.journal_ref,
+               OLD.citation_count, OLD.work_id, OLD.visibility, OLD.metadata->>'search_doc_id',
+               OLD.metadata->'s2_url', OLD.metadata->'venue', OLD.metadata->'is_open_access',
+               OLD.metadata->'reference_count', OLD.metadata->'influential_citation_count',
+               OLD.metadata->'publication_types', OLD.metadata->'tldr')
+           IS NOT DISTINCT FROM
+           ROW(NEW.external_id, NEW.source, NEW.title, NEW.abstract, NEW.authors, NEW.published_date,
+               NEW.pdf_url, NEW.primary_category, NEW.categories, NEW.doi, NEW.journal_ref,
+               NEW.citation_count, NEW.work_id, NEW.visibility, NEW.metadata->>'search_doc_id',
+               NEW.metadata->'s2_url', NEW.metadata->'venue', NEW.metadata->'is_open_access',
+               NEW.metadata->'reference_count', NEW.metadata->'influential_citation_count',
+               NEW.metadata->'publication_types', NEW.metadata->'tldr') THEN
+            RETURN NULL;
+        END IF;
+        old_doc := COALESCE(OLD.metadata->>'search_doc_id', OLD.id::text);
+        IF old_doc <> new_doc THEN
+            INSERT INTO search_outbox (paper_id, doc_id, op) VALUES (OLD.id, old_doc, 'delete');
+        END IF;
+    END IF;
+
+    INSERT INTO search_outbox (paper_id, doc_id, op) VALUES (NEW.id, new_doc, 'upsert');
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_outbox_fulltext() RETURNS trigger AS $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM search_sync_state) THEN
+        RETURN NULL;
+    END IF;
+    IF TG_OP = 'UPDATE' AND OLD.sections IS NOT DISTINCT FROM NEW.sections THEN
+        RETURN NULL;
+    END IF;
+    INSERT INTO search_outbox (paper_id, doc_id, op)
+    SELECT p.id, COALESCE(p.metadata->>'search_doc_id', p.id::text), 'upsert'
+    FROM papers p WHERE p.id = NEW.paper_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- cmd/fulltext tracked its own pushes to OpenSearch here; the outbox
+-- trigger on paper_fulltext replaces them.
+DROP INDEX IF EXISTS idx_paper_fulltext_unindexed;
+ALTER TABLE paper_fulltext DROP COLUMN IF EXISTS indexed_at;
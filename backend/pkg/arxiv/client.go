@@ -27,6 +27,11 @@ func NewClient() *Client {
 	}
 }
 
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
 type SearchResult struct {
 	Papers       []*domain.Paper
 	TotalResults int
@@ -0,0 +1,331 @@
+// Package biorxiv is a client for the bioRxiv/medRxiv API
+// (https://api.biorxiv.org). Both servers share one API: "details" lists
+// preprint versions posted in a date range, and "pubs" lists preprints
+// whose journal version appeared in a date range.
+package biorxiv
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+)
+
+const (
+	baseURL = "https://api.biorxiv.org"
+
+	ServerBioRxiv = "biorxiv"
+	ServerMedRxiv = "medrxiv"
+
+	// PageSize is the fixed number of records the API returns per call.
+	PageSize = 100
+)
+
+// Servers lists the preprint servers the API covers.
+var Servers = []string{ServerBioRxiv, ServerMedRxiv}
+
+type Client struct {
+	httpClient *http.Client
+	baseURL    string
+}
+
+func NewClient() *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		baseURL:    baseURL,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// Preprint is one version of a preprint as returned by the details endpoint.
+// Each version is a separate record with the same DOI.
+type Preprint struct {
+	DOI                            string `json:"doi"`
+	Title                          string `json:"title"`
+	Authors                        string `json:"authors"` // "Smith, J.; Doe, A. B."
+	AuthorCorresponding            string `json:"author_corresponding"`
+	AuthorCorrespondingInstitution string `json:"author_corresponding_institution"`
+	Date                           string `json:"date"` // YYYY-MM-DD this version was posted
+	Version                        string `json:"version"`
+	Type                           string `json:"type"`
+	License                        string `json:"license"`
+	Category                       string `json:"category"`
+	JATSXML                        string `json:"jatsxml"`
+	Abstract                       string `json:"abstract"`
+	Published                      string `json:"published"` // journal DOI, or "NA"
+	Server                         string `json:"server"`
+}
+
+// Publication links a preprint to its journal version (pubs endpoint).
+type Publication struct {
+	BioRxivDOI       string `json:"biorxiv_doi"`
+	MedRxivDOI       string `json:"medrxiv_doi"`
+	PublishedDOI     string `json:"published_doi"`
+	PublishedJournal string `json:"published_journal"`
+	PreprintTitle    string `json:"preprint_title"`
+	PreprintCategory string `json:"preprint_category"`
+	PreprintDate     string `json:"preprint_date"`
+	PublishedDate    string `json:"published_date"`
+}
+
+// PreprintDOI returns the preprint's DOI whichever server it is on.
+func (p *Publication) PreprintDOI() string {
+	if p.BioRxivDOI != "" {
+		return p.BioRxivDOI
+	}
+	return p.MedRxivDOI
+}
+
+// Page is one page of a date-range listing. Cursor is the offset of this
+// page; the next page starts at Cursor+Count while that is below Total.
+type Page[T any] struct {
+	Items  []T
+	Cursor int
+	Count  int
+	Total  int
+}
+
+// Next returns the cursor of the following page, or -1 after the last one.
+func (p *Page[T]) Next() int {
+	if p.Count == 0 || p.Cursor+p.Count >= p.Total {
+		return -1
+	}
+	return p.Cursor + p.Count
+}
+
+type message struct {
+	Status string  `json:"status"`
+	Cursor flexInt `json:"cursor"`
+	Count  flexInt `json:"count"`
+	Total  flexInt `json:"total"`
+}
+
+type response[T any] struct {
+	Messages   []message `json:"messages"`
+	Collection []T       `json:"collection"`
+}
+
+// flexInt accepts both 100 and "100"; the API uses either, by endpoint.
+type flexInt int
+
+func (n *flexInt) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("biorxiv: bad number %s", b)
+	}
+	*n = flexInt(v)
+	return nil
+}
+
+// Details lists preprint versions posted on server between from and to
+// (inclusive days), starting at cursor.
+func (c *Client) Details(ctx context.Context, server string, from, to time.Time, cursor int) (*Page[Preprint], error) {
+	path := fmt.Sprintf("/details/%s/%s/%s/%d/json", server, from.Format("2006-01-02"), to.Format("2006-01-02"), cursor)
+	return get[Preprint](ctx, c, path, cursor)
+}
+
+// Versions returns every version of one preprint, oldest first. Returns an
+// empty slice if the server has no such DOI.
+func (c *Client) Versions(ctx context.Context, server, doi string) ([]Preprint, error) {
+	page, err := get[Preprint](ctx, c, fmt.Sprintf("/details/%s/%s/na/json", server, doi), 0)
+	if err != nil {
+		return nil, err
+	}
+	return page.Items, nil
+}
+
+// Published lists preprints on server whose journal version was published
+// between from and to, starting at cursor.
+func (c *Client) Published(ctx context.Context, server string, from, to time.Time, cursor int) (*Page[Publication], error) {
+	path := fmt.Sprintf("/pubs/%s/%s/%s/%d", server, from.Format("2006-01-02"), to.Format("2006-01-02"), cursor)
+	return get[Publication](ctx, c, path, cursor)
+}
+
+func get[T any](ctx context.Context, c *Client, path string, cursor int) (*Page[T], error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "PaperApp/1.0 (academic-reader)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("biorxiv API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("biorxiv API returned status %d: %s", resp.StatusCode, truncate(string(body), 300))
+	}
+
+	var r response[T]
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	page := &Page[T]{Items: r.Collection, Cursor: cursor, Count: len(r.Collection)}
+	if len(r.Messages) > 0 {
+		m := r.Messages[0]
+		// An empty range reports "no posts found" rather than ok
+		if m.Status != "ok" && len(r.Collection) > 0 {
+			return nil, fmt.Errorf("biorxiv API: %s", m.Status)
+		}
+		page.Total = int(m.Total)
+	}
+	if page.Total < page.Cursor+page.Count {
+		page.Total = page.Cursor + page.Count
+	}
+	return page, nil
+}
+
+// PublishedDOI returns the journal DOI, or "" while the preprint is
+// unpublished.
+func (p *Preprint) PublishedDOI() string {
+	d := strings.TrimSpace(p.Published)
+	if d == "" || strings.EqualFold(d, "NA") {
+		return ""
+	}
+	return d
+}
+
+// VersionNumber parses Version, 0 if missing.
+func (p *Preprint) VersionNumber() int {
+	n, _ := strconv.Atoi(strings.TrimSpace(p.Version))
+	return n
+}
+
+// PDFURL is the full-text PDF of one version.
+func PDFURL(server, doi string, version int) string {
+	host := "www.biorxiv.org"
+	if strings.EqualFold(server, ServerMedRxiv) {
+		host = "www.medrxiv.org"
+	}
+	return fmt.Sprintf("https://%s/content/%sv%d.full.pdf", host, doi, version)
+}
+
+// ToPaper converts one preprint version. Source is the server name
+// ("biorxiv" or "medrxiv") and ExternalID the preprint DOI, which is stable
+// across versions. PublishedDate is this version's posting date; callers
+// merging versions should keep the earliest.
+func (p *Preprint) ToPaper(server string) *domain.Paper {
+	title := strings.TrimSpace(p.Title)
+	doi := strings.ToLower(strings.TrimSpace(p.DOI))
+	if title == "" || doi == "" {
+		return nil
+	}
+	if p.Server != "" {
+		server = strings.ToLower(p.Server)
+	}
+
+	authors := ParseAuthors(p.Authors)
+	if len(authors) > 0 && p.AuthorCorrespondingInstitution != "" {
+		// Only the corresponding author's affiliation is given; attach it
+		// when we can tell which author that is.
+		for i := range authors {
+			if sameAuthor(authors[i].Name, p.AuthorCorresponding) {
+				authors[i].Affiliation = strings.TrimSpace(p.AuthorCorrespondingInstitution)
+			}
+		}
+	}
+	authorsJSON, _ := json.Marshal(authors)
+
+	var published *time.Time
+	if t, err := time.Parse("2006-01-02", p.Date); err == nil {
+		published = &t
+	}
+
+	version := p.VersionNumber()
+	if version == 0 {
+		version = 1
+	}
+	category := strings.ToLower(strings.TrimSpace(p.Category))
+
+	metadata := map[string]interface{}{
+		"version":  version,
+		"type":     p.Type,
+		"html_url": fmt.Sprintf("https://doi.org/%s", doi),
+		// Both servers are open access; search filters on it
+		"is_open_access": true,
+	}
+	if p.JATSXML != "" {
+		metadata["jats_xml"] = p.JATSXML
+	}
+	if pub := p.PublishedDOI(); pub != "" {
+		metadata["published_doi"] = strings.ToLower(pub)
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	paper := &domain.Paper{
+		ExternalID:      doi,
+		Source:          server,
+		Title:           title,
+		Abstract:        strings.TrimSpace(p.Abstract),
+		Authors:         authorsJSON,
+		PublishedDate:   published,
+		UpdatedDate:     published,
+		PDFURL:          PDFURL(server, doi, version),
+		Metadata:        metadataJSON,
+		DOI:             doi,
+		License:         strings.TrimSpace(p.License),
+		PrimaryCategory: category,
+	}
+	if category != "" {
+		paper.Categories = []string{category}
+	}
+	return paper
+}
+
+// ParseAuthors splits the API's "Last, F.; Last2, F. M." list into authors
+// named "F. Last".
+func ParseAuthors(s string) []domain.Author {
+	var authors []domain.Author
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name := part
+		if last, first, ok := strings.Cut(part, ","); ok && strings.TrimSpace(first) != "" {
+			name = strings.TrimSpace(first) + " " + strings.TrimSpace(last)
+		}
+		authors = append(authors, domain.Author{Name: strings.TrimSpace(name)})
+	}
+	return authors
+}
+
+// sameAuthor compares "F. Last" with the corresponding author's full name
+// ("First Last") by last name and first initial.
+func sameAuthor(short, full string) bool {
+	sf, ff := strings.Fields(short), strings.Fields(full)
+	if len(sf) == 0 || len(ff) == 0 {
+		return false
+	}
+	return strings.EqualFold(sf[len(sf)-1], ff[len(ff)-1]) &&
+		strings.EqualFold(sf[0][:1], ff[0][:1])
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
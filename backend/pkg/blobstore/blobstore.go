@@ -0,0 +1,128 @@
+// Package blobstore is a content-addressed file store on local disk.
+//
+// Blobs are keyed by the hex SHA-256 of their contents and laid out as
+// <root>/ab/cd/abcd...; writing the same bytes twice stores them once.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrNotFound   = errors.New("blob not found")
+	ErrInvalidKey = errors.New("invalid blob key")
+	ErrTooLarge   = errors.New("blob exceeds size limit")
+)
+
+// Store is a content-addressed blob store rooted at a directory.
+type Store struct {
+	root string
+}
+
+// New creates the root directory if needed and returns a Store.
+func New(root string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(root, "tmp"), 0o755); err != nil {
+		return nil, fmt.Errorf("create blob root: %w", err)
+	}
+	return &Store{root: root}, nil
+}
+
+// Put streams r into the store and returns its key and size.
+// If maxSize > 0 and the content is larger, nothing is stored and ErrTooLarge is returned.
+func (s *Store) Put(r io.Reader, maxSize int64) (string, int64, error) {
+	tmp, err := os.CreateTemp(filepath.Join(s.root, "tmp"), "put-*")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	h := sha256.New()
+	src := r
+	if maxSize > 0 {
+		src = io.LimitReader(r, maxSize+1)
+	}
+	n, err := io.Copy(io.MultiWriter(tmp, h), src)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	if maxSize > 0 && n > maxSize {
+		return "", 0, ErrTooLarge
+	}
+
+	key := hex.EncodeToString(h.Sum(nil))
+	dst := s.path(key)
+	if _, err := os.Stat(dst); err == nil {
+		return key, n, nil // already stored
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", 0, err
+	}
+	return key, n, nil
+}
+
+// Open returns a reader for the blob.
+func (s *Store) Open(key string) (*os.File, error) {
+	if !validKey(key) {
+		return nil, ErrInvalidKey
+	}
+	f, err := os.Open(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Read returns the whole blob.
+func (s *Store) Read(key string) ([]byte, error) {
+	f, err := s.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// Has reports whether the blob exists.
+func (s *Store) Has(key string) bool {
+	if !validKey(key) {
+		return false
+	}
+	_, err := os.Stat(s.path(key))
+	return err == nil
+}
+
+// Delete removes a blob. Deleting a missing blob is not an error.
+func (s *Store) Delete(key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.root, key[0:2], key[2:4], key)
+}
+
+func validKey(key string) bool {
+	if len(key) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(key)
+	return err == nil
+}
@@ -0,0 +1,534 @@
+// Package crossref is a client for the Crossref REST API
+// (https://api.crossref.org), the registry behind most journal DOIs. It
+// resolves DOIs to publication metadata (venue, volume/pages, license,
+// dates, funders) and pages through /works with deep-paging cursors.
+package crossref
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+)
+
+const (
+	baseURL = "https://api.crossref.org"
+
+	// MaxRows is the largest page /works returns.
+	MaxRows = 1000
+
+	// Crossref asks for no more than 10 req/s in the polite pool (mailto
+	// set) and 5 req/s otherwise.
+	minInterval       = 200 * time.Millisecond
+	minIntervalPolite = 100 * time.Millisecond
+)
+
+// Client is a Crossref API client. A mailto address puts requests in the
+// "polite pool", which Crossref serves from more reliable capacity.
+type Client struct {
+	httpClient *http.Client
+	mailto     string
+
+	mu       sync.Mutex
+	interval time.Duration
+	lastCall time.Time
+}
+
+// NewClient creates a Crossref client. mailto is optional but recommended.
+func NewClient(mailto string) *Client {
+	interval := minInterval
+	if mailto != "" {
+		interval = minIntervalPolite
+	}
+	return &Client{
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		mailto:     mailto,
+		interval:   interval,
+	}
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+// Work is a Crossref work record (the subset we use).
+type Work struct {
+	DOI                 string     `json:"DOI"`
+	Type                string     `json:"type"`
+	Title               []string   `json:"title"`
+	ContainerTitle      []string   `json:"container-title"`
+	ShortContainerTitle []string   `json:"short-container-title"`
+	Publisher           string     `json:"publisher"`
+	Volume              string     `json:"volume"`
+	Issue               string     `json:"issue"`
+	Page                string     `json:"page"`
+	ISSN                []string   `json:"ISSN"`
+	Subject             []string   `json:"subject"`
+	Abstract            string     `json:"abstract"` // JATS markup
+	URL                 string     `json:"URL"`
+	ReferencedByCount   int        `json:"is-referenced-by-count"`
+	Author              []Author   `json:"author"`
+	License             []License  `json:"license"`
+	Funder              []Funder   `json:"funder"`
+	Issued              DateParts  `json:"issued"`
+	PublishedPrint      DateParts  `json:"published-print"`
+	PublishedOnline     DateParts  `json:"published-online"`
+	Created             DateParts  `json:"created"`
+	Relation            Relations  `json:"relation"`
+	Link                []FullText `json:"link"`
+}
+
+type Author struct {
+	Given       string `json:"given"`
+	Family      string `json:"family"`
+	Name        string `json:"name"` // organisations
+	ORCID       string `json:"ORCID"`
+	Affiliation []struct {
+		Name string `json:"name"`
+	} `json:"affiliation"`
+}
+
+type License struct {
+	URL            string    `json:"URL"`
+	ContentVersion string    `json:"content-version"` // vor, am, tdm, unspecified
+	Start          DateParts `json:"start"`
+}
+
+type Funder struct {
+	Name  string   `json:"name"`
+	DOI   string   `json:"DOI"`
+	Award []string `json:"award"`
+}
+
+type FullText struct {
+	URL         string `json:"URL"`
+	ContentType string `json:"content-type"`
+}
+
+// Relations maps a relation type ("is-preprint-of", "has-preprint", ...)
+// to the related identifiers.
+type Relations map[string][]struct {
+	IDType string `json:"id-type"`
+	ID     string `json:"id"`
+}
+
+// DateParts is Crossref's partial date: [[year, month, day]], where month
+// and day may be missing.
+type DateParts struct {
+	Parts [][]int `json:"date-parts"`
+}
+
+// Time returns the date, with missing month/day as 1. ok is false when no
+// year is present.
+func (d DateParts) Time() (t time.Time, ok bool) {
+	if len(d.Parts) == 0 || len(d.Parts[0]) == 0 || d.Parts[0][0] == 0 {
+		return time.Time{}, false
+	}
+	p := d.Parts[0]
+	month, day := 1, 1
+	if len(p) > 1 && p[1] > 0 {
+		month = p[1]
+	}
+	if len(p) > 2 && p[2] > 0 {
+		day = p[2]
+	}
+	return time.Date(p[0], time.Month(month), day, 0, 0, 0, 0, time.UTC), true
+}
+
+// Precision is the number of date parts present: 1 = year, 2 = month, 3 = day.
+func (d DateParts) Precision() int {
+	if len(d.Parts) == 0 {
+		return 0
+	}
+	return len(d.Parts[0])
+}
+
+// Query selects works from /works. Filter keys may repeat (e.g. several
+// "doi" values are OR-ed), so values are lists.
+type Query struct {
+	Query  string              // free-text query.bibliographic
+	Filter map[string][]string // e.g. {"from-pub-date": {"2024-01-01"}}
+	Select []string            // fields to return (default: all)
+	Rows   int                 // page size, up to MaxRows
+	Cursor string              // "" for the first page
+}
+
+// WorksPage is one page of /works results.
+type WorksPage struct {
+	Items        []Work
+	TotalResults int
+	NextCursor   string // "" after the last page
+}
+
+type worksResponse struct {
+	Status  string `json:"status"`
+	Message struct {
+		TotalResults int    `json:"total-results"`
+		NextCursor   string `json:"next-cursor"`
+		Items        []Work `json:"items"`
+	} `json:"message"`
+}
+
+type workResponse struct {
+	Status  string `json:"status"`
+	Message Work   `json:"message"`
+}
+
+// Work fetches one work by DOI. Returns nil, nil if Crossref has no such DOI.
+func (c *Client) Work(ctx context.Context, doi string) (*Work, error) {
+	doi = NormalizeDOI(doi)
+	if doi == "" {
+		return nil, nil
+	}
+	body, status, err := c.get(ctx, "/works/"+url.PathEscape(doi), url.Values{})
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("Crossref API returned status %d: %s", status, truncate(string(body), 300))
+	}
+
+	var r workResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &r.Message, nil
+}
+
+// Works lists works matching q. Deep paging uses cursors: the first call
+// starts a cursor, and each page's NextCursor continues it. Crossref keeps
+// returning the cursor on the last page, so NextCursor is cleared once a
+// page comes back short.
+func (c *Client) Works(ctx context.Context, q Query) (*WorksPage, error) {
+	rows := q.Rows
+	if rows <= 0 {
+		rows = 100
+	}
+	if rows > MaxRows {
+		rows = MaxRows
+	}
+	cursor := q.Cursor
+	if cursor == "" {
+		cursor = "*"
+	}
+
+	params := url.Values{}
+	params.Set("rows", strconv.Itoa(rows))
+	params.Set("cursor", cursor)
+	if q.Query != "" {
+		params.Set("query.bibliographic", q.Query)
+	}
+	if len(q.Filter) > 0 {
+		keys := make([]string, 0, len(q.Filter))
+		for key := range q.Filter {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var filters []string
+		for _, key := range keys {
+			for _, v := range q.Filter[key] {
+				filters = append(filters, key+":"+v)
+			}
+		}
+		params.Set("filter", strings.Join(filters, ","))
+	}
+	if len(q.Select) > 0 {
+		params.Set("select", strings.Join(q.Select, ","))
+	}
+
+	body, status, err := c.get(ctx, "/works", params)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("Crossref API returned status %d: %s", status, truncate(string(body), 300))
+	}
+
+	var r worksResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	page := &WorksPage{
+		Items:        r.Message.Items,
+		TotalResults: r.Message.TotalResults,
+		NextCursor:   r.Message.NextCursor,
+	}
+	if len(page.Items) < rows {
+		page.NextCursor = ""
+	}
+	return page, nil
+}
+
+// WorksByDOI looks up several DOIs in one /works call. Missing DOIs are
+// absent from the result, which is keyed by normalized DOI.
+func (c *Client) WorksByDOI(ctx context.Context, dois []string) (map[string]*Work, error) {
+	var filter []string
+	for _, d := range dois {
+		if d = NormalizeDOI(d); d != "" {
+			filter = append(filter, d)
+		}
+	}
+	works := make(map[string]*Work, len(filter))
+	if len(filter) == 0 {
+		return works, nil
+	}
+
+	page, err := c.Works(ctx, Query{Filter: map[string][]string{"doi": filter}, Rows: len(filter)})
+	if err != nil {
+		return nil, err
+	}
+	for i := range page.Items {
+		w := &page.Items[i]
+		works[NormalizeDOI(w.DOI)] = w
+	}
+	return works, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byte, int, error) {
+	if c.mailto != "" {
+		params.Set("mailto", c.mailto)
+	}
+	reqURL := baseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	ua := "PaperApp/1.0 (academic-reader)"
+	if c.mailto != "" {
+		ua = fmt.Sprintf("PaperApp/1.0 (academic-reader; mailto:%s)", c.mailto)
+	}
+	req.Header.Set("User-Agent", ua)
+
+	if err := c.throttle(ctx); err != nil {
+		return nil, 0, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("Crossref API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, 0, fmt.Errorf("rate limited (429)")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
+// throttle spaces requests by the client's minimum interval.
+func (c *Client) throttle(ctx context.Context) error {
+	c.mu.Lock()
+	wait := c.interval - time.Since(c.lastCall)
+	if wait < 0 {
+		wait = 0
+	}
+	c.lastCall = time.Now().Add(wait)
+	c.mu.Unlock()
+
+	if wait == 0 {
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// NormalizeDOI strips URL/"doi:" prefixes and lowercases a DOI.
+func NormalizeDOI(doi string) string {
+	doi = strings.TrimSpace(doi)
+	lower := strings.ToLower(doi)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if strings.HasPrefix(lower, prefix) {
+			lower = lower[len(prefix):]
+			break
+		}
+	}
+	if !strings.HasPrefix(lower, "10.") {
+		return ""
+	}
+	return lower
+}
+
+// --- Derived fields ---
+
+// Venue is the journal, proceedings or book title.
+func (w *Work) Venue() string {
+	return first(w.ContainerTitle)
+}
+
+// PublishedDate is the earliest publication date Crossref knows (print,
+// online, or the "issued" summary of both), with its precision.
+func (w *Work) PublishedDate() (time.Time, int, bool) {
+	var best time.Time
+	precision := 0
+	for _, d := range []DateParts{w.PublishedPrint, w.PublishedOnline, w.Issued} {
+		t, ok := d.Time()
+		if !ok {
+			continue
+		}
+		if best.IsZero() || t.Before(best) || (t.Equal(best) && d.Precision() > precision) {
+			best, precision = t, d.Precision()
+		}
+	}
+	return best, precision, !best.IsZero()
+}
+
+// JournalRef formats the citation, e.g. "Nature 500(7463):415-418 (2013)".
+func (w *Work) JournalRef() string {
+	venue := w.Venue()
+	if venue == "" {
+		return ""
+	}
+	ref := venue
+	if w.Volume != "" {
+		ref += " " + w.Volume
+		if w.Issue != "" {
+			ref += "(" + w.Issue + ")"
+		}
+	}
+	if w.Page != "" {
+		if w.Volume != "" {
+			ref += ":" + w.Page
+		} else {
+			ref += ", " + w.Page
+		}
+	}
+	if t, _, ok := w.PublishedDate(); ok {
+		ref += fmt.Sprintf(" (%d)", t.Year())
+	}
+	return ref
+}
+
+// LicenseURL picks the license of the published version ("vor"), falling
+// back to any license listed.
+func (w *Work) LicenseURL() string {
+	for _, l := range w.License {
+		if l.ContentVersion == "vor" {
+			return l.URL
+		}
+	}
+	if len(w.License) > 0 {
+		return w.License[0].URL
+	}
+	return ""
+}
+
+// PreprintDOIs lists the preprints this work says it was published from.
+func (w *Work) PreprintDOIs() []string {
+	var dois []string
+	for _, r := range w.Relation["has-preprint"] {
+		if r.IDType == "doi" {
+			if d := NormalizeDOI(r.ID); d != "" {
+				dois = append(dois, d)
+			}
+		}
+	}
+	return dois
+}
+
+var jatsTag = regexp.MustCompile(`<[^>]+>`)
+
+// ToPaper converts a work to a Paper with Source "crossref" and the DOI as
+// ExternalID. Returns nil for works without a title.
+func (w *Work) ToPaper() *domain.Paper {
+	title := strings.TrimSpace(first(w.Title))
+	doi := NormalizeDOI(w.DOI)
+	if title == "" || doi == "" {
+		return nil
+	}
+
+	authors := make([]domain.Author, 0, len(w.Author))
+	for _, a := range w.Author {
+		name := strings.TrimSpace(strings.TrimSpace(a.Given) + " " + strings.TrimSpace(a.Family))
+		if name == "" {
+			name = strings.TrimSpace(a.Name)
+		}
+		if name == "" {
+			continue
+		}
+		author := domain.Author{Name: name}
+		if len(a.Affiliation) > 0 {
+			author.Affiliation = a.Affiliation[0].Name
+		}
+		authors = append(authors, author)
+	}
+	authorsJSON, _ := json.Marshal(authors)
+
+	metadata := map[string]interface{}{
+		"type":     w.Type,
+		"html_url": "https://doi.org/" + doi,
+	}
+	if v := w.Venue(); v != "" {
+		metadata["venue"] = v
+	}
+	if w.Publisher != "" {
+		metadata["publisher"] = w.Publisher
+	}
+	if len(w.ISSN) > 0 {
+		metadata["issn"] = w.ISSN
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	paper := &domain.Paper{
+		ExternalID:    doi,
+		Source:        "crossref",
+		Title:         title,
+		Abstract:      strings.TrimSpace(jatsTag.ReplaceAllString(w.Abstract, " ")),
+		Authors:       authorsJSON,
+		Metadata:      metadataJSON,
+		CitationCount: w.ReferencedByCount,
+		DOI:           doi,
+		JournalRef:    w.JournalRef(),
+		License:       w.LicenseURL(),
+		Categories:    w.Subject,
+	}
+	paper.Abstract = strings.Join(strings.Fields(paper.Abstract), " ")
+	if t, _, ok := w.PublishedDate(); ok {
+		paper.PublishedDate = &t
+	}
+	for _, l := range w.Link {
+		if l.ContentType == "application/pdf" {
+			paper.PDFURL = l.URL
+			break
+		}
+	}
+	return paper
+}
+
+func first(s []string) string {
+	if len(s) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(s[0])
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
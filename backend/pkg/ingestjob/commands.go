@@ -0,0 +1,190 @@
+package ingestjob
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Command is an importer a Worker can run for a job of its type. Job params
+// are the command's flags by name; connection settings (database,
+// OpenSearch, API keys) come from the worker's environment and can't be
+// set per job.
+type Command struct {
+	Type    string // job type, as the command's Runner uses it
+	Binary  string // executable in the worker's bin dir
+	Package string // ./cmd/<Package>, for `go run` without a bin dir
+	// Flags are the flags params may set.
+	Flags []string
+	// Required flags must be set.
+	Required []string
+	// Resume is the flag that makes the command continue its last run; a
+	// Worker adds it when retrying a job that made progress.
+	Resume string
+	// Name returns the job name for these params, as the command's Runner
+	// would name it; the command may resolve it further when it attaches.
+	Name func(params map[string]string) string
+}
+
+// Commands are the importers that can be run as queued jobs.
+var Commands = map[string]*Command{
+	"harvest": {
+		Type: "harvest", Binary: "harvest", Package: "harvest",
+		Flags:  []string{"set", "from", "max", "batch"},
+		Resume: "resume",
+		Name:   func(p map[string]string) string { return orDefault(p["set"], "_all") },
+	},
+	"pubmed_harvest": {
+		Type: "pubmed_harvest", Binary: "pubmed_harvest", Package: "pubmed_harvest",
+		Flags:  []string{"query", "from", "until", "datetype", "window", "batch", "max", "name"},
+		Resume: "resume",
+		Name: func(p map[string]string) string {
+			switch {
+			case p["name"] != "":
+				return p["name"]
+			case p["query"] != "":
+				return "pubmed:" + p["query"]
+			}
+			return "pubmed"
+		},
+	},
+	"biorxiv_harvest": {
+		Type: "biorxiv_harvest", Binary: "biorxiv_harvest", Package: "biorxiv_harvest",
+		Flags:  []string{"server", "from", "until", "window", "max", "link-only"},
+		Resume: "resume",
+		// Each server is a job of its own; the first one's run takes over
+		// the queued job
+		Name: func(p map[string]string) string { return orDefault(p["server"], "biorxiv,medrxiv") },
+	},
+	"oaimport": {
+		Type: "oaimport", Binary: "oaimport", Package: "oaimport",
+		Flags: []string{"index", "recreate-index", "keep", "min-doc-ratio", "batch-size", "per-page", "cursor", "max-docs", "mailto"},
+		Name:  func(p map[string]string) string { return orDefault(p["index"], "papers") },
+	},
+	"s2import": {
+		Type: "s2import", Binary: "s2import", Package: "s2import",
+		Flags: []string{"index", "recreate-index", "keep", "min-doc-ratio", "batch-size", "index-workers", "start-query", "max-pages", "query"},
+		Name: func(p map[string]string) string {
+			name := orDefault(p["index"], "papers")
+			if p["query"] != "" {
+				name += "/" + p["query"]
+			}
+			return name
+		},
+	},
+	"s2datasets": {
+		Type: "s2datasets", Binary: "s2datasets", Package: "s2datasets",
+		Flags: []string{"release", "datasets", "concurrency", "batch", "max-shards", "arxiv-only", "fields",
+			"min-year", "min-citations", "restart", "full"},
+		Name: func(p map[string]string) string { return orDefault(p["release"], "latest") },
+	},
+	"ingest": {
+		Type: "ingest", Binary: "ingest", Package: "ingest",
+		Flags:    []string{"file", "batch", "categories", "limit", "drop-indexes"},
+		Required: []string{"file"},
+		Resume:   "resume",
+		Name:     func(p map[string]string) string { return p["file"] },
+	},
+	"enrich": {
+		Type: "enrich", Binary: "enrich", Package: "enrich",
+		Flags: []string{"mode", "batch", "limit", "rate", "mailto", "force"},
+		Name:  func(p map[string]string) string { return orDefault(p["mode"], "citations") },
+	},
+	"index": {
+		Type: "index", Binary: "indexer", Package: "index",
+		Flags: []string{"index", "recreate", "keep", "min-doc-ratio", "batch", "category", "limit", "fulltext", "since"},
+		Name:  func(p map[string]string) string { return orDefault(p["index"], "papers") },
+	},
+}
+
+// CommandTypes lists the job types of Commands, sorted.
+func CommandTypes() []string {
+	types := make([]string, 0, len(Commands))
+	for t := range Commands {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Params checks job params against the command's flags and formats their
+// values as flag values.
+func (c *Command) Params(params map[string]interface{}) (map[string]string, error) {
+	out := make(map[string]string, len(params))
+	for name, v := range params {
+		if !contains(c.Flags, name) {
+			return nil, fmt.Errorf("%s has no parameter %q", c.Type, name)
+		}
+		s, err := flagValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		out[name] = s
+	}
+	for _, name := range c.Required {
+		if out[name] == "" {
+			return nil, fmt.Errorf("%s requires parameter %q", c.Type, name)
+		}
+	}
+	return out, nil
+}
+
+// Args returns the command line flags for params, in a stable order.
+func (c *Command) Args(params map[string]string, resume bool) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	args := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		args = append(args, "--"+name+"="+params[name])
+	}
+	if resume && c.Resume != "" {
+		args = append(args, "--"+c.Resume)
+	}
+	return args
+}
+
+// jobParams decodes the params a job was stored with.
+func jobParams(j *Job) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+	if len(j.Params) == 0 {
+		return params, nil
+	}
+	d := json.NewDecoder(strings.NewReader(string(j.Params)))
+	d.UseNumber()
+	if err := d.Decode(&params); err != nil {
+		return nil, fmt.Errorf("decode params: %w", err)
+	}
+	return params, nil
+}
+
+func flagValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case json.Number:
+		return v.String(), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("unsupported value %v", v)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,379 @@
+package ingestjob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EnvJobID is the environment variable through which a Worker hands the job
+// it claimed to the command it starts. The first Runner.Start of that type
+// attaches to the job instead of creating one.
+const EnvJobID = "INGESTJOB_ID"
+
+// Defaults for Runner.
+const (
+	DefaultHeartbeatInterval = 15 * time.Second
+	DefaultStaleAfter        = 2 * time.Minute
+)
+
+// Runner starts and resumes jobs.
+type Runner struct {
+	Store *Store
+	// Worker identifies this process in jobs.worker (default host:pid).
+	Worker string
+	// HeartbeatInterval is how often a run saves its progress and checks for
+	// a cancel request.
+	HeartbeatInterval time.Duration
+	// StaleAfter is how long a running job may go without a heartbeat before
+	// it is taken to be from a crashed process.
+	StaleAfter time.Duration
+
+	attached bool // the EnvJobID job was taken
+}
+
+// NewRunner creates a Runner on the given pool.
+func NewRunner(db *pgxpool.Pool) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		Store:             NewStore(db),
+		Worker:            fmt.Sprintf("%s:%d", host, os.Getpid()),
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		StaleAfter:        DefaultStaleAfter,
+	}
+}
+
+// Options describe the job to start.
+type Options struct {
+	Type   string      // importer, e.g. "harvest"
+	Name   string      // resume key within the type, e.g. the OAI set; may be empty
+	Params interface{} // the command's flags by name, so a Worker can rerun it
+	// Resume continues the latest job of Type and Name at its cursor if it
+	// stopped unfinished (failed, cancelled or crashed). Otherwise, and
+	// without Resume, a new job starts at Cursor.
+	Resume bool
+	Cursor string
+}
+
+// Start begins a run. Jobs of any key whose heartbeat went stale are failed
+// first, so a crashed run of this key can be resumed. Returns ErrRunning if
+// a live run of the same key exists.
+func (r *Runner) Start(ctx context.Context, opts Options) (*Run, error) {
+	stale, err := r.Store.FailStale(ctx, r.StaleAfter)
+	if err != nil {
+		return nil, fmt.Errorf("check stale jobs: %w", err)
+	}
+	for _, j := range stale {
+		log.Printf("Job %s (%s %s) had no heartbeat since %s; marked failed", j.ID, j.Type, j.Name, formatTime(j.HeartbeatAt))
+	}
+
+	previous, err := r.Store.Latest(ctx, opts.Type, opts.Name, StatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := r.attach(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	resumed := job != nil && job.Cursor != ""
+	if job != nil && job.Cursor == "" {
+		job.Cursor = opts.Cursor
+	}
+	if job == nil && opts.Resume {
+		last, err := r.Store.Latest(ctx, opts.Type, opts.Name)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case last == nil || last.Status == StatusCompleted || last.Status == StatusPending:
+			// Nothing to resume
+		case last.Status == StatusRunning:
+			return nil, ErrRunning
+		default:
+			if job, err = r.Store.Claim(ctx, last.ID, r.Worker); err != nil {
+				return nil, err
+			}
+			if job != nil {
+				log.Printf("Resuming job %s (%s) at cursor %q", job.ID, last.Status, truncate(job.Cursor, 60))
+				resumed = true
+			}
+		}
+	}
+	if job == nil {
+		if job, err = r.Store.Create(ctx, opts.Type, opts.Name, opts.Params, opts.Cursor, r.Worker); err != nil {
+			return nil, err
+		}
+		log.Printf("Started job %s (%s %s)", job.ID, job.Type, orDefault(job.Name, "-"))
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	run := &Run{
+		Job:      job,
+		Previous: previous,
+		Resumed:  resumed,
+		ctx:      runCtx,
+		cancel:   cancel,
+		store:    r.Store,
+		cursor:   job.Cursor,
+		counters: job.Counters,
+		errs:     job.Errors,
+		stop:     make(chan struct{}),
+	}
+	run.wg.Add(1)
+	go run.heartbeat(r.HeartbeatInterval)
+	return run, nil
+}
+
+// attach takes the job named by EnvJobID if it is of this type. Returns
+// nil, nil if there is none to take.
+func (r *Runner) attach(ctx context.Context, opts Options) (*Job, error) {
+	raw := os.Getenv(EnvJobID)
+	if raw == "" || r.attached {
+		return nil, nil
+	}
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("bad %s %q: %w", EnvJobID, raw, err)
+	}
+	j, err := r.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if j == nil || j.Type != opts.Type {
+		// The worker tracks the job from outside; this run gets its own
+		return nil, nil
+	}
+	if j, err = r.Store.Attach(ctx, id, opts.Name, r.Worker); err != nil || j == nil {
+		return nil, err
+	}
+	r.attached = true
+	log.Printf("Attached to job %s (%s %s)", j.ID, j.Type, orDefault(j.Name, "-"))
+	return j, nil
+}
+
+// Do starts a run, calls fn with it and finishes it with fn's error.
+func (r *Runner) Do(ctx context.Context, opts Options, fn func(run *Run) error) (*Job, error) {
+	run, err := r.Start(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	err = fn(run)
+	if ferr := run.Finish(err); ferr != nil && err == nil {
+		err = ferr
+	}
+	return run.Job, err
+}
+
+// Run is a job being worked on. Its methods are safe for concurrent use.
+type Run struct {
+	Job      *Job // as it was when the run started; Status is set by Finish
+	Previous *Job // the last completed job of the same type and name, or nil
+	Resumed  bool // continuing a stopped job rather than starting a new one
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	store  *Store
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	mu              sync.Mutex
+	cursor          string
+	counters        map[string]int64
+	errs            []ErrorEntry
+	cancelRequested bool
+	finished        bool
+}
+
+// Context is cancelled when the parent context is or when the job is asked
+// to stop (see Store.RequestCancel). Importers should use it for all work.
+func (r *Run) Context() context.Context {
+	return r.ctx
+}
+
+// Cursor returns the resume position.
+func (r *Run) Cursor() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cursor
+}
+
+// SetCursor moves the resume position. It is saved with the next heartbeat
+// or Checkpoint; set it only once the work before it is stored.
+func (r *Run) SetCursor(cursor string) {
+	r.mu.Lock()
+	r.cursor = cursor
+	r.mu.Unlock()
+}
+
+// Add adds delta to a progress counter.
+func (r *Run) Add(counter string, delta int64) {
+	r.mu.Lock()
+	r.counters[counter] += delta
+	r.mu.Unlock()
+}
+
+// Set sets a progress counter.
+func (r *Run) Set(counter string, value int64) {
+	r.mu.Lock()
+	r.counters[counter] = value
+	r.mu.Unlock()
+}
+
+// Count returns a progress counter.
+func (r *Run) Count(counter string) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counters[counter]
+}
+
+// Error records a non-fatal error; the job keeps the most recent ones.
+func (r *Run) Error(err error) {
+	if err == nil {
+		return
+	}
+	r.mu.Lock()
+	r.errs = append(r.errs, ErrorEntry{At: time.Now().UTC(), Message: err.Error()})
+	if len(r.errs) > maxErrors {
+		r.errs = r.errs[len(r.errs)-maxErrors:]
+	}
+	r.mu.Unlock()
+}
+
+// Checkpoint saves the cursor and counters now.
+func (r *Run) Checkpoint() error {
+	cursor, counters, errs := r.snapshot()
+	cancelRequested, err := r.store.Heartbeat(context.Background(), r.Job.ID, cursor, counters, errs)
+	if err != nil {
+		return err
+	}
+	if cancelRequested {
+		r.requestCancel()
+	}
+	return nil
+}
+
+// Finish stops the heartbeat and records how the run ended: completed if
+// err is nil and the run wasn't stopped, cancelled if its context was
+// cancelled (shutdown signal or cancel request), failed otherwise. It
+// returns the error saving the status, if any.
+func (r *Run) Finish(err error) error {
+	r.mu.Lock()
+	if r.finished {
+		r.mu.Unlock()
+		return nil
+	}
+	r.finished = true
+	r.mu.Unlock()
+
+	stopped := r.ctx.Err() != nil
+	close(r.stop)
+	r.wg.Wait()
+	r.cancel()
+
+	status, msg := StatusCompleted, ""
+	switch {
+	case err != nil && !(stopped && errors.Is(err, context.Canceled)):
+		status, msg = StatusFailed, err.Error()
+	case stopped:
+		status = StatusCancelled
+		if r.isCancelRequested() {
+			msg = "cancel requested"
+		}
+	}
+	r.Job.Status = status
+	r.Job.Error = msg
+
+	cursor, counters, errs := r.snapshot()
+	r.Job.Cursor, r.Job.Counters, r.Job.Errors = cursor, counters, errs
+	log.Printf("Job %s %s | %s", r.Job.ID, status, r.Summary())
+	return r.store.Finish(context.Background(), r.Job.ID, status, msg, cursor, counters, errs)
+}
+
+// Summary formats the counters for logs: "a=1 b=2", sorted by name.
+func (r *Run) Summary() string {
+	_, counters, _ := r.snapshot()
+	names := make([]string, 0, len(counters))
+	for name := range counters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s=%d", name, counters[name])
+	}
+	return orDefault(strings.Join(parts, " "), "no progress")
+}
+
+func (r *Run) heartbeat(interval time.Duration) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			if err := r.Checkpoint(); err != nil {
+				log.Printf("WARN: job %s heartbeat: %v", r.Job.ID, err)
+			}
+		}
+	}
+}
+
+func (r *Run) requestCancel() {
+	r.mu.Lock()
+	first := !r.cancelRequested
+	r.cancelRequested = true
+	r.mu.Unlock()
+	if first {
+		log.Printf("Job %s: cancel requested, stopping...", r.Job.ID)
+		r.cancel()
+	}
+}
+
+func (r *Run) isCancelRequested() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cancelRequested
+}
+
+func (r *Run) snapshot() (string, map[string]int64, []ErrorEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counters := make(map[string]int64, len(r.counters))
+	for k, v := range r.counters {
+		counters[k] = v
+	}
+	return r.cursor, counters, append([]ErrorEntry(nil), r.errs...)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "never"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
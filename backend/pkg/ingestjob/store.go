@@ -0,0 +1,464 @@
+// Package ingestjob keeps the state of importer runs in the jobs table:
+// parameters, a resume cursor, progress counters, status, a heartbeat and
+// recent errors. The cmd/ importers share one Runner, which gives them the
+// same resume, cancellation, progress reporting and crash detection.
+//
+// Jobs can also be queued (from the admin API) and run by a Worker, which
+// starts the importer command for the job and keeps its output in job_logs.
+package ingestjob
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Job statuses.
+const (
+	StatusPending   = "pending" // queued for a Worker
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// maxErrors is how many recent errors a job keeps.
+const maxErrors = 20
+
+// ErrRunning is returned when a live job already runs under the same key.
+var ErrRunning = errors.New("a job with this type and name is already running")
+
+// Job is one importer run.
+type Job struct {
+	ID              uuid.UUID        `json:"id"`
+	Type            string           `json:"type"`
+	Name            string           `json:"name"`
+	Params          json.RawMessage  `json:"params"`
+	Cursor          string           `json:"cursor"`
+	Counters        map[string]int64 `json:"counters"`
+	Status          string           `json:"status"`
+	Error           string           `json:"error,omitempty"`
+	Errors          []ErrorEntry     `json:"errors"`
+	CancelRequested bool             `json:"cancel_requested"`
+	Worker          string           `json:"worker,omitempty"`
+	HeartbeatAt     *time.Time       `json:"heartbeat_at,omitempty"`
+	StartedAt       *time.Time       `json:"started_at,omitempty"`
+	FinishedAt      *time.Time       `json:"finished_at,omitempty"`
+	CreatedBy       *uuid.UUID       `json:"created_by,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+// LogLine is a line of output of a job run by a Worker.
+type LogLine struct {
+	ID        int64     `json:"id"`
+	Line      string    `json:"line"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ErrorEntry is a non-fatal error a job ran into.
+type ErrorEntry struct {
+	At      time.Time `json:"at"`
+	Message string    `json:"message"`
+}
+
+// Finished reports whether the job has stopped.
+func (j *Job) Finished() bool {
+	return j.Status != StatusRunning && j.Status != StatusPending
+}
+
+// Store reads and writes the jobs table.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store on the given pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const jobColumns = `id, type, name, params, cursor, counters, status, COALESCE(error, ''), errors,
+	cancel_requested, COALESCE(worker, ''), heartbeat_at, started_at, finished_at, created_by, created_at`
+
+func scanJob(row pgx.Row) (*Job, error) {
+	j := &Job{}
+	var counters, errs []byte
+	err := row.Scan(&j.ID, &j.Type, &j.Name, &j.Params, &j.Cursor, &counters, &j.Status, &j.Error, &errs,
+		&j.CancelRequested, &j.Worker, &j.HeartbeatAt, &j.StartedAt, &j.FinishedAt, &j.CreatedBy, &j.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	json.Unmarshal(counters, &j.Counters)
+	json.Unmarshal(errs, &j.Errors)
+	if j.Counters == nil {
+		j.Counters = map[string]int64{}
+	}
+	return j, nil
+}
+
+func queryJobs(ctx context.Context, db *pgxpool.Pool, query string, args ...interface{}) ([]*Job, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, j)
+	}
+	return jobs, rows.Err()
+}
+
+// isUniqueViolation reports whether err is the one-live-run-per-key index.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func marshalParams(params interface{}) ([]byte, error) {
+	if params == nil {
+		return []byte("{}"), nil
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("marshal params: %w", err)
+	}
+	return paramsJSON, nil
+}
+
+// Create starts a new running job.
+func (s *Store) Create(ctx context.Context, jobType, name string, params interface{}, cursor, worker string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	paramsJSON, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	j, err := scanJob(s.db.QueryRow(ctx, `
+		INSERT INTO jobs (type, name, params, cursor, status, worker, heartbeat_at, started_at)
+		VALUES ($1, $2, $3, $4, 'running', $5, NOW(), NOW())
+		RETURNING `+jobColumns,
+		jobType, name, paramsJSON, cursor, worker))
+	if isUniqueViolation(err) {
+		return nil, ErrRunning
+	}
+	return j, err
+}
+
+// Enqueue queues a job for a Worker.
+func (s *Store) Enqueue(ctx context.Context, jobType, name string, params interface{}, createdBy *uuid.UUID) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	paramsJSON, err := marshalParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return scanJob(s.db.QueryRow(ctx, `
+		INSERT INTO jobs (type, name, params, status, created_by)
+		VALUES ($1, $2, $3, 'pending', $4)
+		RETURNING `+jobColumns,
+		jobType, name, paramsJSON, createdBy))
+}
+
+// ClaimNext starts the oldest pending job whose key has no live run, under
+// this worker. Returns nil, nil if there is none.
+func (s *Store) ClaimNext(ctx context.Context, worker string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRow(ctx, `
+		UPDATE jobs SET status = 'running', worker = $1, heartbeat_at = NOW(), started_at = NOW()
+		WHERE id = (
+			SELECT p.id FROM jobs p
+			WHERE p.status = 'pending' AND NOT EXISTS (
+				SELECT 1 FROM jobs r WHERE r.type = p.type AND r.name = p.name AND r.status = 'running'
+			)
+			ORDER BY p.created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns,
+		worker))
+	// A run of the same key may have started between the check and the update
+	if errors.Is(err, pgx.ErrNoRows) || isUniqueViolation(err) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// Attach hands a running job to the process that does its work (a Worker
+// starts the importer command, which attaches to the job it was given).
+// The job takes the name the process runs it under, which may be resolved
+// from the params (e.g. the "latest" release). Returns nil, nil if the job
+// isn't running, ErrRunning if another job of that name is.
+func (s *Store) Attach(ctx context.Context, id uuid.UUID, name, worker string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRow(ctx, `
+		UPDATE jobs SET name = $2, worker = $3, heartbeat_at = NOW()
+		WHERE id = $1 AND status = 'running'
+		RETURNING `+jobColumns,
+		id, name, worker))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrRunning
+	}
+	return j, err
+}
+
+// Retry queues a failed or cancelled job again. It keeps its cursor and
+// counters, so the importer resumes where the job stopped. Returns nil, nil
+// if the job isn't stopped.
+func (s *Store) Retry(ctx context.Context, id uuid.UUID) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRow(ctx, `
+		UPDATE jobs SET status = 'pending', error = NULL, cancel_requested = false, finished_at = NULL
+		WHERE id = $1 AND status IN ('failed', 'cancelled')
+		RETURNING `+jobColumns,
+		id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// Claim restarts a stopped job under this worker, keeping its cursor and
+// counters. Returns nil, nil if the job is running or no longer exists.
+func (s *Store) Claim(ctx context.Context, id uuid.UUID, worker string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRow(ctx, `
+		UPDATE jobs SET status = 'running', worker = $2, heartbeat_at = NOW(), started_at = NOW(),
+			finished_at = NULL, error = NULL, cancel_requested = false
+		WHERE id = $1 AND status <> 'running'
+		RETURNING `+jobColumns,
+		id, worker))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if isUniqueViolation(err) {
+		return nil, ErrRunning
+	}
+	return j, err
+}
+
+// Get returns a job by ID, or nil if there is none.
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	j, err := scanJob(s.db.QueryRow(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// Latest returns the most recent job of a type and name, or nil. With
+// statuses, only jobs in one of them are considered.
+func (s *Store) Latest(ctx context.Context, jobType, name string, statuses ...string) (*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	query := `SELECT ` + jobColumns + ` FROM jobs WHERE type = $1 AND name = $2`
+	args := []interface{}{jobType, name}
+	if len(statuses) > 0 {
+		query += ` AND status = ANY($3)`
+		args = append(args, statuses)
+	}
+	j, err := scanJob(s.db.QueryRow(ctx, query+` ORDER BY created_at DESC LIMIT 1`, args...))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return j, err
+}
+
+// List returns jobs newest first, optionally of one type and/or status.
+func (s *Store) List(ctx context.Context, jobType, status string, limit int) ([]*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	return queryJobs(ctx, s.db, `
+		SELECT `+jobColumns+` FROM jobs
+		WHERE ($1 = '' OR type = $1) AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`, jobType, status, limit)
+}
+
+// Heartbeat saves a running job's progress and reports whether it was asked
+// to stop. A job that is no longer running (failed as stale by another
+// process) reports cancel as well.
+func (s *Store) Heartbeat(ctx context.Context, id uuid.UUID, cursor string, counters map[string]int64, errs []ErrorEntry) (cancelRequested bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	countersJSON, errsJSON := marshalProgress(counters, errs)
+	err = s.db.QueryRow(ctx, `
+		UPDATE jobs SET cursor = $2, counters = $3, errors = $4, heartbeat_at = NOW()
+		WHERE id = $1 AND status = 'running'
+		RETURNING cancel_requested
+	`, id, cursor, countersJSON, errsJSON).Scan(&cancelRequested)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return true, nil
+	}
+	return cancelRequested, err
+}
+
+// Finish records a job's final status and progress.
+func (s *Store) Finish(ctx context.Context, id uuid.UUID, status, errMsg, cursor string, counters map[string]int64, errs []ErrorEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	countersJSON, errsJSON := marshalProgress(counters, errs)
+	_, err := s.db.Exec(ctx, `
+		UPDATE jobs SET status = $2, error = NULLIF($3, ''), cursor = $4, counters = $5, errors = $6,
+			heartbeat_at = NOW(), finished_at = NOW()
+		WHERE id = $1
+	`, id, status, errMsg, cursor, countersJSON, errsJSON)
+	return err
+}
+
+// RequestCancel asks a running job to stop; its runner notices on the next
+// heartbeat. A pending job is cancelled right away. Returns false if the job
+// has already finished.
+func (s *Store) RequestCancel(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE jobs SET cancel_requested = true,
+			status = CASE WHEN status = 'pending' THEN 'cancelled' ELSE status END,
+			finished_at = CASE WHEN status = 'pending' THEN NOW() ELSE finished_at END
+		WHERE id = $1 AND status IN ('pending', 'running')
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Touch renews a running job's heartbeat without changing its progress, for
+// a process watching over the one doing the work. Reports whether the job
+// was asked to stop and whether it is still running.
+func (s *Store) Touch(ctx context.Context, id uuid.UUID) (cancelRequested, running bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	err = s.db.QueryRow(ctx, `
+		UPDATE jobs SET heartbeat_at = NOW() WHERE id = $1 AND status = 'running'
+		RETURNING cancel_requested
+	`, id).Scan(&cancelRequested)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, false, nil
+	}
+	return cancelRequested, err == nil, err
+}
+
+// FinishRunning sets the final status of a job that is still running, for
+// when the process doing the work exits without finishing it. Reports
+// whether the job was still running.
+func (s *Store) FinishRunning(ctx context.Context, id uuid.UUID, status, errMsg string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.db.Exec(ctx, `
+		UPDATE jobs SET status = $2, error = NULLIF($3, ''), finished_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`, id, status, errMsg)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// AppendLogs stores lines of a job's output.
+func (s *Store) AppendLogs(ctx context.Context, id uuid.UUID, lines []string) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO job_logs (job_id, line)
+		SELECT $1, line FROM unnest($2::text[]) WITH ORDINALITY AS l(line, n) ORDER BY n
+	`, id, lines)
+	return err
+}
+
+// Logs returns up to limit lines of a job's output after the line with ID
+// after (0 = from the start).
+func (s *Store) Logs(ctx context.Context, id uuid.UUID, after int64, limit int) ([]LogLine, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, line, created_at FROM job_logs
+		WHERE job_id = $1 AND id > $2
+		ORDER BY id
+		LIMIT $3
+	`, id, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []LogLine
+	for rows.Next() {
+		var l LogLine
+		if err := rows.Scan(&l.ID, &l.Line, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// FailStale fails running jobs whose heartbeat is older than staleAfter:
+// their process died without finishing them. Returns the jobs failed.
+func (s *Store) FailStale(ctx context.Context, staleAfter time.Duration) ([]*Job, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return queryJobs(ctx, s.db, `
+		UPDATE jobs SET status = 'failed', finished_at = NOW(),
+			error = 'stale: no heartbeat from ' || COALESCE(worker, 'unknown worker') || ' since ' || to_char(heartbeat_at, 'YYYY-MM-DD HH24:MI:SS TZ')
+		WHERE status = 'running' AND heartbeat_at < NOW() - make_interval(secs => $1)
+		RETURNING `+jobColumns,
+		staleAfter.Seconds())
+}
+
+func marshalProgress(counters map[string]int64, errs []ErrorEntry) ([]byte, []byte) {
+	if counters == nil {
+		counters = map[string]int64{}
+	}
+	if errs == nil {
+		errs = []ErrorEntry{}
+	}
+	countersJSON, _ := json.Marshal(counters)
+	errsJSON, _ := json.Marshal(errs)
+	return countersJSON, errsJSON
+}
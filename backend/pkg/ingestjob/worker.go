@@ -0,0 +1,336 @@
+package ingestjob
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxLogLine is the longest output line kept in job_logs.
+const maxLogLine = 4096
+
+// Worker runs queued jobs: it claims pending jobs and runs their Command
+// with the job's params as flags and the job's ID in EnvJobID, so that the
+// command's Runner reports progress into the same job. The worker stores
+// the command's output in job_logs, keeps the job's heartbeat while the
+// command runs, stops it on a cancel request and sets the final status
+// unless the command did.
+type Worker struct {
+	Store *Store
+	// Name identifies this worker in jobs.worker until the command attaches
+	// (default host:pid).
+	Name string
+	// Concurrency is how many jobs run at once.
+	Concurrency int
+	// BinDir holds the command binaries; empty runs `go run ./cmd/<pkg>`
+	// from the working directory instead (for development).
+	BinDir string
+	// PollInterval is how often the queue is checked while there is room.
+	PollInterval time.Duration
+	// HeartbeatInterval is how often a running job's heartbeat is renewed
+	// and its cancel flag checked.
+	HeartbeatInterval time.Duration
+	// StaleAfter is passed to Store.FailStale, which the worker runs on
+	// every poll so that jobs of crashed processes don't stay running.
+	StaleAfter time.Duration
+	// StopTimeout is how long a command gets to exit after SIGTERM before
+	// it is killed.
+	StopTimeout time.Duration
+}
+
+// NewWorker creates a Worker on the given pool.
+func NewWorker(db *pgxpool.Pool, concurrency int, binDir string) *Worker {
+	host, _ := os.Hostname()
+	return &Worker{
+		Store:             NewStore(db),
+		Name:              fmt.Sprintf("%s:%d", host, os.Getpid()),
+		Concurrency:       max(concurrency, 1),
+		BinDir:            binDir,
+		PollInterval:      5 * time.Second,
+		HeartbeatInterval: DefaultHeartbeatInterval,
+		StaleAfter:        DefaultStaleAfter,
+		StopTimeout:       time.Minute,
+	}
+}
+
+// Run claims and runs jobs until ctx is cancelled. It then stops the
+// commands still running (their jobs end cancelled, to be retried) and
+// returns once they have exited.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("Job worker %s started (%d slots, commands from %s)", w.Name, w.Concurrency, orDefault(w.BinDir, "go run"))
+	slots := make(chan struct{}, w.Concurrency)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+		job := w.claim(ctx)
+		if job == nil {
+			<-slots
+			select {
+			case <-time.After(w.PollInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			w.runJob(ctx, job)
+		}()
+	}
+}
+
+func (w *Worker) claim(ctx context.Context) *Job {
+	if stale, err := w.Store.FailStale(ctx, w.StaleAfter); err != nil {
+		log.Printf("WARN: job worker: check stale jobs: %v", err)
+	} else {
+		for _, j := range stale {
+			log.Printf("Job %s (%s %s) had no heartbeat since %s; marked failed", j.ID, j.Type, j.Name, formatTime(j.HeartbeatAt))
+		}
+	}
+	job, err := w.Store.ClaimNext(ctx, w.Name)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("WARN: job worker: claim: %v", err)
+		}
+		return nil
+	}
+	return job
+}
+
+// runJob runs one claimed job's command to the end.
+func (w *Worker) runJob(ctx context.Context, job *Job) {
+	logs := newLogWriter(w.Store, job)
+	defer logs.Close()
+
+	fail := func(err error) {
+		logs.Line("ERROR: " + err.Error())
+		logs.Close()
+		if _, ferr := w.Store.FinishRunning(context.Background(), job.ID, StatusFailed, err.Error()); ferr != nil {
+			log.Printf("WARN: job %s: %v", job.ID, ferr)
+		}
+		log.Printf("Job %s (%s) failed: %v", job.ID, job.Type, err)
+	}
+
+	command := Commands[job.Type]
+	if command == nil {
+		fail(fmt.Errorf("no command for job type %q", job.Type))
+		return
+	}
+	raw, err := jobParams(job)
+	if err != nil {
+		fail(err)
+		return
+	}
+	params, err := command.Params(raw)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	// A retried job that made progress continues where it stopped
+	args := command.Args(params, job.Cursor != "")
+	var cmd *exec.Cmd
+	if w.BinDir != "" {
+		cmd = exec.Command(filepath.Join(w.BinDir, command.Binary), args...)
+	} else {
+		cmd = exec.Command("go", append([]string{"run", "./cmd/" + command.Package}, args...)...)
+	}
+	cmd.Env = append(os.Environ(), EnvJobID+"="+job.ID.String())
+	cmd.Stdout = logs
+	cmd.Stderr = logs
+
+	logs.Line("$ " + strings.Join(append([]string{filepath.Base(cmd.Path)}, cmd.Args[1:]...), " "))
+	log.Printf("Job %s (%s %s) starting", job.ID, job.Type, orDefault(job.Name, "-"))
+	if err := cmd.Start(); err != nil {
+		fail(fmt.Errorf("start %s: %w", command.Binary, err))
+		return
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	var (
+		stopping  bool
+		cancelled bool
+		killAt    <-chan time.Time
+		ticker    = time.NewTicker(w.HeartbeatInterval)
+		waitErr   error
+	)
+	defer ticker.Stop()
+	stop := func(reason string) {
+		if stopping {
+			return
+		}
+		stopping = true
+		logs.Line("Stopping: " + reason)
+		cmd.Process.Signal(syscall.SIGTERM)
+		killAt = time.After(w.StopTimeout)
+	}
+wait:
+	for {
+		select {
+		case waitErr = <-done:
+			break wait
+		case <-ctx.Done():
+			stop("worker shutting down")
+		case <-killAt:
+			logs.Line(fmt.Sprintf("Command did not exit %s after SIGTERM; killing it", w.StopTimeout))
+			cmd.Process.Kill()
+		case <-ticker.C:
+			// Once the command has finished the job there's nothing to renew;
+			// it may still be cleaning up, so it isn't stopped
+			cancelRequested, _, err := w.Store.Touch(context.Background(), job.ID)
+			if err != nil {
+				log.Printf("WARN: job %s heartbeat: %v", job.ID, err)
+				continue
+			}
+			if cancelRequested {
+				cancelled = true
+				stop("cancel requested")
+			}
+		}
+	}
+	logs.Close()
+
+	// Commands with a Runner attached to the job have set its status
+	status, msg := StatusCompleted, ""
+	switch {
+	case stopping:
+		status = StatusCancelled
+		if !cancelled {
+			msg = "worker shut down"
+		}
+	case waitErr != nil:
+		status, msg = StatusFailed, waitErr.Error()
+		if last := logs.Last(); last != "" {
+			msg += ": " + last
+		}
+	}
+	if _, err := w.Store.FinishRunning(context.Background(), job.ID, status, msg); err != nil {
+		log.Printf("WARN: job %s: %v", job.ID, err)
+	}
+	if j, err := w.Store.Get(context.Background(), job.ID); err == nil && j != nil {
+		status = j.Status
+	}
+	log.Printf("Job %s (%s %s) %s", job.ID, job.Type, orDefault(job.Name, "-"), status)
+}
+
+// logWriter splits a command's output into lines and stores them in
+// job_logs, flushing every second.
+type logWriter struct {
+	store *Store
+	job   *Job
+	pw    *io.PipeWriter
+	done  chan struct{}
+
+	flushMu sync.Mutex // keeps stored lines in order
+	mu      sync.Mutex
+	pending []string
+	last    string
+	closed  bool
+}
+
+func newLogWriter(store *Store, job *Job) *logWriter {
+	pr, pw := io.Pipe()
+	l := &logWriter{store: store, job: job, pw: pw, done: make(chan struct{})}
+	go l.read(pr)
+	return l
+}
+
+func (l *logWriter) Write(p []byte) (int, error) {
+	return l.pw.Write(p)
+}
+
+// Line adds a line of the worker's own.
+func (l *logWriter) Line(line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, line)
+}
+
+// Last returns the last non-empty line of output.
+func (l *logWriter) Last() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// Close flushes the remaining output. Safe to call more than once.
+func (l *logWriter) Close() {
+	l.mu.Lock()
+	closed := l.closed
+	l.closed = true
+	l.mu.Unlock()
+	if !closed {
+		l.pw.Close()
+	}
+	<-l.done
+}
+
+func (l *logWriter) read(r io.Reader) {
+	defer close(l.done)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.flush()
+			case <-l.done:
+				return
+			}
+		}
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > maxLogLine {
+			line = line[:maxLogLine] + "..."
+		}
+		l.mu.Lock()
+		l.pending = append(l.pending, line)
+		if strings.TrimSpace(line) != "" {
+			l.last = line
+		}
+		l.mu.Unlock()
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.ErrClosedPipe) {
+		l.Line("WARN: reading output: " + err.Error())
+	}
+	// Drain what's left if lines got too long to scan
+	io.Copy(io.Discard, r)
+	l.flush()
+}
+
+func (l *logWriter) flush() {
+	l.flushMu.Lock()
+	defer l.flushMu.Unlock()
+	l.mu.Lock()
+	lines := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if err := l.store.AppendLogs(context.Background(), l.job.ID, lines); err != nil {
+		log.Printf("WARN: job %s: store %d log lines: %v", l.job.ID, len(lines), err)
+	}
+}
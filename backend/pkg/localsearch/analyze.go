@@ -0,0 +1,128 @@
+package localsearch
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// stopwords are the English stop words OpenSearch's "stop" filter drops.
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "if": true, "in": true, "into": true, "is": true,
+	"it": true, "no": true, "not": true, "of": true, "on": true, "or": true, "such": true,
+	"that": true, "the": true, "their": true, "then": true, "there": true, "these": true,
+	"they": true, "this": true, "to": true, "was": true, "will": true, "with": true,
+}
+
+// token is a word of a text and where it is.
+type token struct {
+	term       string // analyzed form; "" for a stop word
+	start, end int    // byte offsets in the text
+}
+
+// analyze returns the terms of a text: lowercased words and numbers,
+// stemmed, without stop words.
+func analyze(text string) []string {
+	var terms []string
+	for _, t := range tokenize(text) {
+		if t.term != "" {
+			terms = append(terms, t.term)
+		}
+	}
+	return terms
+}
+
+// tokenize splits a text into words and numbers.
+func tokenize(text string) []token {
+	var tokens []token
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, newToken(text, start, i))
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, newToken(text, start, len(text)))
+	}
+	return tokens
+}
+
+func newToken(text string, start, end int) token {
+	word := strings.ToLower(text[start:end])
+	t := token{start: start, end: end}
+	if !stopwords[word] {
+		t.term = stem(word)
+	}
+	return t
+}
+
+// stem strips English plural endings, so that "networks" matches "network".
+// It is much lighter than OpenSearch's snowball stemmer.
+func stem(word string) string {
+	n := utf8.RuneCountInString(word)
+	switch {
+	case n <= 3:
+		return word
+	case strings.HasSuffix(word, "ies") && n > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "ss"), strings.HasSuffix(word, "us"), strings.HasSuffix(word, "is"):
+		return word
+	case strings.HasSuffix(word, "s"):
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// snippet returns the part of a text around its first match of terms,
+// with every match wrapped in <mark>, or "" if nothing matches.
+func snippet(text string, terms map[string]bool, size int) string {
+	tokens := tokenize(text)
+	first := -1
+	for i, t := range tokens {
+		if terms[t.term] {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return ""
+	}
+
+	// Start a little before the match, on a word boundary
+	from, to := tokens[first].start, len(text)
+	for i := first; i >= 0 && tokens[first].start-tokens[i].start < size/4; i-- {
+		from = tokens[i].start
+	}
+	if limit := from + size; limit < to {
+		to = tokens[first].end
+		for _, t := range tokens[first:] {
+			if t.end > limit {
+				break
+			}
+			to = t.end
+		}
+	}
+
+	var b strings.Builder
+	pos := from
+	for _, t := range tokens {
+		if t.start < from || t.end > to || !terms[t.term] {
+			continue
+		}
+		b.WriteString(text[pos:t.start])
+		b.WriteString("<mark>")
+		b.WriteString(text[t.start:t.end])
+		b.WriteString("</mark>")
+		pos = t.end
+	}
+	b.WriteString(text[pos:to])
+	return b.String()
+}
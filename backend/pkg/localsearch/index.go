@@ -0,0 +1,365 @@
+// Package localsearch is an embedded full-text index kept on disk, for
+// running search without an OpenSearch cluster: on a single box or in
+// development. Documents and an inverted index over their text fields are
+// held in memory; every change is appended to a log in the index directory,
+// which Open replays and which is rewritten once most of it is superseded.
+//
+// Text fields are scored with BM25 per field, keyword fields filter and
+// count, and number fields sort. Only one process may open a directory at
+// a time.
+package localsearch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// logFile is the change log inside the index directory.
+const logFile = "docs.log"
+
+// Doc is an indexed document.
+type Doc struct {
+	ID string `json:"id"`
+	// Text fields are analyzed for full-text queries.
+	Text map[string]string `json:"text,omitempty"`
+	// Keywords are exact values to filter, exclude, collapse and count on.
+	Keywords map[string][]string `json:"keywords,omitempty"`
+	// Numbers sort and filter by range.
+	Numbers map[string]float64 `json:"numbers,omitempty"`
+	// Source is returned with hits as is.
+	Source json.RawMessage `json:"source,omitempty"`
+}
+
+// record is one line of the log.
+type record struct {
+	Op  string `json:"op"` // put, delete or clear
+	Doc *Doc   `json:"doc,omitempty"`
+	ID  string `json:"id,omitempty"`
+}
+
+// entry is a document with the token count of each text field.
+type entry struct {
+	doc  *Doc
+	lens map[string]int
+}
+
+// Index is an open index directory. It is safe for concurrent use.
+type Index struct {
+	mu      sync.RWMutex
+	dir     string
+	log     *os.File
+	records int // lines in the log
+
+	docs     map[string]*entry
+	postings map[string]map[string]map[*entry]int      // field → term → doc → frequency
+	tokens   map[string]int64                          // field → tokens in all docs
+	keywords map[string]map[string]map[*entry]struct{} // field → value → docs
+}
+
+// Open opens the index in dir, creating it if needed.
+func Open(dir string) (*Index, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	x := &Index{dir: dir}
+	x.reset()
+
+	path := filepath.Join(dir, logFile)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := x.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("replay %s: %w", path, err)
+	}
+	// Drop a record cut short by a crash
+	if err := f.Truncate(valid); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if _, err := f.Seek(valid, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	x.log = f
+	return x, nil
+}
+
+// replay applies the log and returns the length of its complete records.
+func (x *Index) replay(f *os.File) (int64, error) {
+	r := bufio.NewReader(f)
+	var valid int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return valid, nil // a partial last line is ignored
+		}
+		if err != nil {
+			return 0, err
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return 0, fmt.Errorf("record at offset %d: %w", valid, err)
+		}
+		x.apply(&rec)
+		x.records++
+		valid += int64(len(line))
+	}
+}
+
+// Put adds or replaces documents.
+func (x *Index) Put(docs ...*Doc) error {
+	recs := make([]*record, 0, len(docs))
+	for _, d := range docs {
+		if d.ID == "" {
+			return errors.New("localsearch: document without ID")
+		}
+		recs = append(recs, &record{Op: "put", Doc: d})
+	}
+	return x.write(recs)
+}
+
+// Delete removes documents; unknown IDs are ignored.
+func (x *Index) Delete(ids ...string) error {
+	recs := make([]*record, 0, len(ids))
+	for _, id := range ids {
+		recs = append(recs, &record{Op: "delete", ID: id})
+	}
+	return x.write(recs)
+}
+
+// Clear removes every document.
+func (x *Index) Clear() error {
+	return x.write([]*record{{Op: "clear"}})
+}
+
+// Get returns a document by ID.
+func (x *Index) Get(id string) (*Doc, bool) {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	e, ok := x.docs[id]
+	if !ok {
+		return nil, false
+	}
+	return e.doc, true
+}
+
+// Len returns the number of documents.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.docs)
+}
+
+// Terms counts the documents holding each value of a keyword field.
+func (x *Index) Terms(field string) map[string]int64 {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	counts := map[string]int64{}
+	for value, docs := range x.keywords[field] {
+		counts[value] = int64(len(docs))
+	}
+	return counts
+}
+
+// Close closes the log.
+func (x *Index) Close() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.log == nil {
+		return nil
+	}
+	err := x.log.Close()
+	x.log = nil
+	return err
+}
+
+// write appends records to the log, syncs it and applies them. A failed
+// write is cut from the log again, so that it can't leave a broken record
+// between later ones for Open to stop at.
+func (x *Index) write(recs []*record) error {
+	if len(recs) == 0 {
+		return nil
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.log == nil {
+		return errors.New("localsearch: index closed")
+	}
+
+	var buf bytes.Buffer
+	for _, rec := range recs {
+		line, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	offset, err := x.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := x.log.Write(buf.Bytes()); err != nil {
+		return x.rollback(offset, err)
+	}
+	if err := x.log.Sync(); err != nil {
+		return x.rollback(offset, err)
+	}
+	for _, rec := range recs {
+		x.apply(rec)
+	}
+	x.records += len(recs)
+
+	// The records are applied whatever happens here; a failed compaction
+	// keeps the current log and is tried again on the next write.
+	if x.records > 2*len(x.docs)+1024 {
+		if err := x.compact(); err != nil {
+			log.Printf("[localsearch] Compacting %s: %v", x.dir, err)
+		}
+	}
+	return nil
+}
+
+// rollback cuts the log back to offset after a failed write and returns
+// err; x.mu must be held.
+func (x *Index) rollback(offset int64, err error) error {
+	if terr := x.log.Truncate(offset); terr != nil {
+		return fmt.Errorf("%w (truncating the log: %v)", err, terr)
+	}
+	if _, serr := x.log.Seek(offset, io.SeekStart); serr != nil {
+		return fmt.Errorf("%w (seeking in the log: %v)", err, serr)
+	}
+	return err
+}
+
+// compact rewrites the log with one put per document; x.mu must be held.
+func (x *Index) compact() error {
+	path := filepath.Join(x.dir, logFile)
+	tmp, err := os.Create(path + ".tmp")
+	if err != nil {
+		return err
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	w := bufio.NewWriter(tmp)
+	for _, e := range x.docs {
+		line, err := json.Marshal(&record{Op: "put", Doc: e.doc})
+		if err != nil {
+			return fail(err)
+		}
+		w.Write(line)
+		w.WriteByte('\n')
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fail(err)
+	}
+	x.log.Close()
+	x.log = tmp
+	x.records = len(x.docs)
+	return nil
+}
+
+// apply changes the in-memory index; x.mu must be held.
+func (x *Index) apply(rec *record) {
+	switch rec.Op {
+	case "put":
+		x.remove(rec.Doc.ID)
+		x.add(rec.Doc)
+	case "delete":
+		x.remove(rec.ID)
+	case "clear":
+		x.reset()
+	}
+}
+
+func (x *Index) reset() {
+	x.docs = map[string]*entry{}
+	x.postings = map[string]map[string]map[*entry]int{}
+	x.tokens = map[string]int64{}
+	x.keywords = map[string]map[string]map[*entry]struct{}{}
+}
+
+func (x *Index) add(d *Doc) {
+	e := &entry{doc: d, lens: map[string]int{}}
+	x.docs[d.ID] = e
+	for field, text := range d.Text {
+		terms := analyze(text)
+		e.lens[field] = len(terms)
+		x.tokens[field] += int64(len(terms))
+		byTerm := x.postings[field]
+		if byTerm == nil {
+			byTerm = map[string]map[*entry]int{}
+			x.postings[field] = byTerm
+		}
+		for _, t := range terms {
+			if byTerm[t] == nil {
+				byTerm[t] = map[*entry]int{}
+			}
+			byTerm[t][e]++
+		}
+	}
+	for field, values := range d.Keywords {
+		byValue := x.keywords[field]
+		if byValue == nil {
+			byValue = map[string]map[*entry]struct{}{}
+			x.keywords[field] = byValue
+		}
+		for _, v := range values {
+			if byValue[v] == nil {
+				byValue[v] = map[*entry]struct{}{}
+			}
+			byValue[v][e] = struct{}{}
+		}
+	}
+}
+
+func (x *Index) remove(id string) {
+	e, ok := x.docs[id]
+	if !ok {
+		return
+	}
+	delete(x.docs, id)
+	for field, text := range e.doc.Text {
+		x.tokens[field] -= int64(e.lens[field])
+		byTerm := x.postings[field]
+		for _, t := range analyze(text) {
+			if docs := byTerm[t]; docs != nil {
+				delete(docs, e)
+				if len(docs) == 0 {
+					delete(byTerm, t)
+				}
+			}
+		}
+	}
+	for field, values := range e.doc.Keywords {
+		byValue := x.keywords[field]
+		for _, v := range values {
+			if docs := byValue[v]; docs != nil {
+				delete(docs, e)
+				if len(docs) == 0 {
+					delete(byValue, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package localsearch
+
+import (
+	"hash/fnv"
+	"math"
+	"sort"
+	"strings"
+)
+
+// BM25 parameters, as OpenSearch's defaults.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// ScoreField sorts by relevance.
+const ScoreField = "_score"
+
+// Query is a search.
+type Query struct {
+	// Text is matched against Fields: a doc with any of its terms is a hit.
+	// Empty Text matches every doc.
+	Text string
+	// Fields are the text fields Text is matched against, with their
+	// boosts.
+	Fields map[string]float64
+	// Phrases are text fields that add their boost for each term when they
+	// hold all of Text's terms in order.
+	Phrases map[string]float64
+	// Filter keeps docs with any of the values of each keyword field.
+	Filter map[string][]string
+	// Exclude drops docs with any of the values.
+	Exclude map[string][]string
+	// Min keeps docs whose number field is at least the value.
+	Min map[string]float64
+	// Sort orders hits, by score if empty. Docs without a number field
+	// sort after those with it; ties are broken by ID.
+	Sort []Sort
+	// Seed, if set, orders hits randomly instead, the same way each time
+	// for the same seed.
+	Seed string
+	// Collapse keeps the first hit per value of a keyword field, and Total
+	// counts values. Docs without the field are each their own value.
+	Collapse string
+	// Highlight is the text field Hit.Snippet is taken from.
+	Highlight string
+	Offset    int
+	Limit     int // default 10
+}
+
+// Sort is a sort key: ScoreField or a number field.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// Hit is a matching doc.
+type Hit struct {
+	Doc   *Doc
+	Score float64
+	// Snippet is a fragment of the Highlight field with the matching terms
+	// wrapped in <mark>, or "" if it has none.
+	Snippet string
+}
+
+// Result is a page of hits.
+type Result struct {
+	Hits  []*Hit
+	Total int
+}
+
+// Search runs a query.
+func (x *Index) Search(q Query) *Result {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+
+	terms := analyze(q.Text)
+	var candidates []*entry
+	scores := map[*entry]float64{}
+	if strings.TrimSpace(q.Text) == "" {
+		for _, e := range x.docs {
+			candidates = append(candidates, e)
+		}
+	} else {
+		x.score(terms, q, scores)
+		for e := range scores {
+			candidates = append(candidates, e)
+		}
+	}
+
+	matched := candidates[:0]
+	for _, e := range candidates {
+		if x.matches(e, q) {
+			matched = append(matched, e)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return x.less(matched[i], matched[j], q, scores) })
+
+	if q.Collapse != "" {
+		seen := map[string]bool{}
+		kept := matched[:0]
+		for _, e := range matched {
+			values := e.doc.Keywords[q.Collapse]
+			if len(values) > 0 {
+				if seen[values[0]] {
+					continue
+				}
+				seen[values[0]] = true
+			}
+			kept = append(kept, e)
+		}
+		matched = kept
+	}
+
+	result := &Result{Total: len(matched)}
+	termSet := map[string]bool{}
+	for _, t := range terms {
+		termSet[t] = true
+	}
+	for i := q.Offset; i < len(matched) && i < q.Offset+q.Limit; i++ {
+		e := matched[i]
+		hit := &Hit{Doc: e.doc, Score: scores[e]}
+		if q.Highlight != "" && len(termSet) > 0 {
+			hit.Snippet = snippet(e.doc.Text[q.Highlight], termSet, 200)
+		}
+		result.Hits = append(result.Hits, hit)
+	}
+	return result
+}
+
+// score adds the BM25 score of each doc matching any term to scores.
+func (x *Index) score(terms []string, q Query, scores map[*entry]float64) {
+	n := float64(len(x.docs))
+	if n == 0 || len(terms) == 0 {
+		return
+	}
+	unique := map[string]bool{}
+	for _, t := range terms {
+		unique[t] = true
+	}
+	for field, boost := range q.Fields {
+		byTerm := x.postings[field]
+		avgLen := float64(x.tokens[field]) / n
+		if avgLen == 0 {
+			continue
+		}
+		for t := range unique {
+			docs := byTerm[t]
+			df := float64(len(docs))
+			if df == 0 {
+				continue
+			}
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+			for e, freq := range docs {
+				tf := float64(freq)
+				norm := tf + k1*(1-b+b*float64(e.lens[field])/avgLen)
+				scores[e] += boost * idf * tf * (k1 + 1) / norm
+			}
+		}
+	}
+	for field, boost := range q.Phrases {
+		for e := range scores {
+			if hasPhrase(analyze(e.doc.Text[field]), terms) {
+				scores[e] += boost * float64(len(terms))
+			}
+		}
+	}
+}
+
+// hasPhrase reports whether terms occur in order in text.
+func hasPhrase(text, terms []string) bool {
+	for i := 0; i+len(terms) <= len(text); i++ {
+		match := true
+		for j, t := range terms {
+			if text[i+j] != t {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// matches applies the query's filters.
+func (x *Index) matches(e *entry, q Query) bool {
+	for field, values := range q.Filter {
+		if !hasAny(e.doc.Keywords[field], values) {
+			return false
+		}
+	}
+	for field, values := range q.Exclude {
+		if hasAny(e.doc.Keywords[field], values) {
+			return false
+		}
+	}
+	for field, min := range q.Min {
+		v, ok := e.doc.Numbers[field]
+		if !ok || v < min {
+			return false
+		}
+	}
+	return true
+}
+
+func hasAny(have, want []string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// less orders two hits.
+func (x *Index) less(a, c *entry, q Query, scores map[*entry]float64) bool {
+	if q.Seed != "" {
+		ha, hc := seededHash(q.Seed, a.doc.ID), seededHash(q.Seed, c.doc.ID)
+		if ha != hc {
+			return ha < hc
+		}
+		return a.doc.ID < c.doc.ID
+	}
+	keys := q.Sort
+	if len(keys) == 0 {
+		keys = []Sort{{Field: ScoreField, Desc: true}}
+	}
+	for _, k := range keys {
+		av, aok := sortValue(a, k.Field, scores)
+		cv, cok := sortValue(c, k.Field, scores)
+		if aok != cok {
+			return aok
+		}
+		if av != cv {
+			if k.Desc {
+				return av > cv
+			}
+			return av < cv
+		}
+	}
+	return a.doc.ID < c.doc.ID
+}
+
+func sortValue(e *entry, field string, scores map[*entry]float64) (float64, bool) {
+	if field == ScoreField {
+		return scores[e], true
+	}
+	v, ok := e.doc.Numbers[field]
+	return v, ok
+}
+
+func seededHash(seed, id string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(seed))
+	h.Write([]byte{0})
+	h.Write([]byte(id))
+	return h.Sum64()
+}
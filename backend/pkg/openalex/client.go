@@ -37,15 +37,17 @@ func NewClient(email string) *Client {
 type SearchResult struct {
 	Papers       []*domain.Paper
 	TotalResults int
+	NextCursor   string // set by ListSince; empty on the last page
 }
 
 // --- OpenAlex API response types ---
 
 type searchResponse struct {
 	Meta struct {
-		Count   int `json:"count"`
-		Page    int `json:"page"`
-		PerPage int `json:"per_page"`
+		Count      int    `json:"count"`
+		Page       int    `json:"page"`
+		PerPage    int    `json:"per_page"`
+		NextCursor string `json:"next_cursor"`
 	} `json:"meta"`
 	Results []workResult `json:"results"`
 }
@@ -142,33 +144,68 @@ func (c *Client) Search(query, sourceFilter, sortBy string, limit, offset int) (
 		params.Set("mailto", c.email)
 	}
 
-	reqURL := fmt.Sprintf("%s/works?%s", baseURL, params.Encode())
+	return c.listWorks(params)
+}
 
-	req, err := http.NewRequest("GET", reqURL, nil)
+// GetWork fetches one work by OpenAlex ID ("W2741809807"), DOI ("doi:10.1038/nature12373")
+// or PubMed ID ("pmid:12345"). Returns nil, nil if OpenAlex has no such work.
+func (c *Client) GetWork(id string) (*domain.Paper, error) {
+	params := url.Values{}
+	if c.email != "" {
+		params.Set("mailto", c.email)
+	}
+	body, status, err := c.get("/works/"+url.PathEscape(strings.TrimPrefix(id, "https://openalex.org/")), params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OpenAlex API returned status %d: %s", status, string(body))
 	}
 
-	ua := "PaperApp/1.0 (academic-reader)"
-	if c.email != "" {
-		ua = fmt.Sprintf("PaperApp/1.0 (mailto:%s)", c.email)
+	var w workResult
+	if err := json.Unmarshal(body, &w); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-	req.Header.Set("User-Agent", ua)
+	return workToPaper(&w), nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("OpenAlex API request failed: %w", err)
+// ListSince pages through works published on or after since, newest first.
+// Pass cursor "" for the first page; an empty NextCursor means there are no more.
+func (c *Client) ListSince(since time.Time, cursor string, limit int) (*SearchResult, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 200
+	}
+	if cursor == "" {
+		cursor = "*"
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("OpenAlex API returned status %d: %s", resp.StatusCode, string(body))
+	params := url.Values{}
+	params.Set("filter", "from_publication_date:"+since.Format("2006-01-02"))
+	params.Set("sort", "publication_date:desc")
+	params.Set("per_page", fmt.Sprintf("%d", limit))
+	params.Set("cursor", cursor)
+	if c.email != "" {
+		params.Set("mailto", c.email)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	return c.listWorks(params)
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+func (c *Client) listWorks(params url.Values) (*SearchResult, error) {
+	body, status, err := c.get("/works", params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OpenAlex API returned status %d: %s", status, string(body))
 	}
 
 	var searchResp searchResponse
@@ -187,9 +224,37 @@ func (c *Client) Search(query, sourceFilter, sortBy string, limit, offset int) (
 	return &SearchResult{
 		Papers:       papers,
 		TotalResults: searchResp.Meta.Count,
+		NextCursor:   searchResp.Meta.NextCursor,
 	}, nil
 }
 
+func (c *Client) get(path string, params url.Values) ([]byte, int, error) {
+	reqURL := fmt.Sprintf("%s%s?%s", baseURL, path, params.Encode())
+
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	ua := "PaperApp/1.0 (academic-reader)"
+	if c.email != "" {
+		ua = fmt.Sprintf("PaperApp/1.0 (mailto:%s)", c.email)
+	}
+	req.Header.Set("User-Agent", ua)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("OpenAlex API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	return body, resp.StatusCode, nil
+}
+
 // workToPaper converts an OpenAlex work result to our domain Paper model
 func workToPaper(w *workResult) *domain.Paper {
 	title := w.Title
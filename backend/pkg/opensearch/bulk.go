@@ -0,0 +1,435 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ---------- Bulk indexer ----------
+
+// ErrIndexerClosed is returned by Add after Close.
+var ErrIndexerClosed = errors.New("bulk indexer closed")
+
+// BulkIndexerConfig tunes a BulkIndexer. Zero values take the defaults.
+type BulkIndexerConfig struct {
+	Workers       int           // concurrent bulk requests (default 2)
+	FlushDocs     int           // docs per request (default 500)
+	FlushBytes    int           // request size that triggers a flush (default 5 MB)
+	FlushInterval time.Duration // longest a doc waits in a partial batch (default 5s)
+	// MaxAttempts is how often a doc is sent before a retryable error
+	// (429, 503, a failed request) counts as its failure (default 6).
+	MaxAttempts int
+	MinBackoff  time.Duration // first retry delay, doubled per retry (default 1s)
+	MaxBackoff  time.Duration // default 1m
+	// OnResult, if set, is called with each doc's outcome once it is
+	// final. It is called from the worker goroutines.
+	OnResult func(BulkResult)
+}
+
+// BulkResult is the outcome of one doc.
+type BulkResult struct {
+	ID       string
+	Status   int   // item status; 0 if the request itself failed
+	Err      error // nil if the doc was indexed
+	Attempts int
+}
+
+// BulkIndexerStats counts a BulkIndexer's work so far.
+type BulkIndexerStats struct {
+	Added     int64 `json:"added"`
+	Indexed   int64 `json:"indexed"`
+	Failed    int64 `json:"failed"`
+	Retried   int64 `json:"retried"`   // docs sent again after a retryable error
+	Throttled int64 `json:"throttled"` // requests and docs rejected with 429 or 503
+	Requests  int64 `json:"requests"`
+	Bytes     int64 `json:"bytes"`
+}
+
+// BulkIndexer indexes docs in the background: Add batches them and workers
+// send the batches with _bulk. Add blocks while all workers are busy and
+// the queue is full, so a fast producer is held to the cluster's pace.
+// Docs the cluster rejects as overloaded are sent again after a backoff.
+type BulkIndexer struct {
+	client *Client
+	cfg    BulkIndexerConfig
+	ctx    context.Context
+
+	mu           sync.Mutex
+	batch        []*bulkItem
+	batchBytes   int
+	batchStarted time.Time
+	closed       bool
+	pending      int        // batches queued or being sent
+	idle         *sync.Cond // signalled when pending drops to 0
+
+	queue   chan []*bulkItem
+	workers sync.WaitGroup
+	stop    chan struct{}
+	stopped chan struct{}
+
+	added, indexed, failed, retried, throttled, requests, bytes atomic.Int64
+}
+
+// bulkItem is a serialized doc waiting to be indexed.
+type bulkItem struct {
+	id       string
+	body     []byte // action and source lines
+	attempts int
+	status   int
+	err      error
+}
+
+// NewBulkIndexer starts an indexer writing to the client's write index.
+// Cancelling ctx aborts the requests in flight; their docs fail. Close
+// must be called to flush the last batch and stop the workers.
+func (c *Client) NewBulkIndexer(ctx context.Context, cfg BulkIndexerConfig) *BulkIndexer {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 2
+	}
+	if cfg.FlushDocs <= 0 {
+		cfg.FlushDocs = 500
+	}
+	if cfg.FlushBytes <= 0 {
+		cfg.FlushBytes = 5 << 20
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 6
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+
+	b := &BulkIndexer{
+		client:  c,
+		cfg:     cfg,
+		ctx:     ctx,
+		queue:   make(chan []*bulkItem, cfg.Workers),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	b.idle = sync.NewCond(&b.mu)
+	for i := 0; i < cfg.Workers; i++ {
+		b.workers.Add(1)
+		go func() {
+			defer b.workers.Done()
+			for batch := range b.queue {
+				b.send(batch)
+				b.batchDone()
+			}
+		}()
+	}
+	go b.flushOnInterval()
+	return b
+}
+
+// Add queues a doc. It returns once the doc is batched, which may wait for
+// a worker; the doc's outcome is reported to OnResult and in Stats. ctx
+// bounds the wait.
+func (b *BulkIndexer) Add(ctx context.Context, doc *PaperDoc) error {
+	doc.setWorkID()
+	action, err := json.Marshal(map[string]interface{}{"index": map[string]string{"_id": doc.ID}})
+	if err != nil {
+		return fmt.Errorf("marshal action for doc %s: %w", doc.ID, err)
+	}
+	source, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("marshal doc %s: %w", doc.ID, err)
+	}
+	body := make([]byte, 0, len(action)+len(source)+2)
+	body = append(append(append(append(body, action...), '\n'), source...), '\n')
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return ErrIndexerClosed
+	}
+	b.added.Add(1)
+	if len(b.batch) == 0 {
+		b.batchStarted = time.Now()
+	}
+	b.batch = append(b.batch, &bulkItem{id: doc.ID, body: body})
+	b.batchBytes += len(body)
+	var full []*bulkItem
+	if len(b.batch) >= b.cfg.FlushDocs || b.batchBytes >= b.cfg.FlushBytes {
+		full = b.takeLocked()
+	}
+	b.mu.Unlock()
+
+	return b.enqueue(ctx, full)
+}
+
+// Flush sends the current batch and waits until every doc added so far
+// has its outcome.
+func (b *BulkIndexer) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.takeLocked()
+	b.mu.Unlock()
+	if err := b.enqueue(ctx, batch); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	for b.pending > 0 {
+		b.idle.Wait()
+	}
+	b.mu.Unlock()
+	return ctx.Err()
+}
+
+// Close flushes the last batch, waits for the workers and stops them. It
+// returns an error if any doc failed.
+func (b *BulkIndexer) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	batch := b.takeLocked()
+	b.mu.Unlock()
+
+	close(b.stop)
+	<-b.stopped
+	b.enqueue(context.Background(), batch)
+
+	// Batches taken before closed was set may still be on their way to
+	// the queue
+	b.mu.Lock()
+	for b.pending > 0 {
+		b.idle.Wait()
+	}
+	b.mu.Unlock()
+	close(b.queue)
+	b.workers.Wait()
+
+	if failed := b.failed.Load(); failed > 0 {
+		return fmt.Errorf("bulk index: %d/%d docs failed", failed, b.added.Load())
+	}
+	return nil
+}
+
+// Stats returns the counts so far.
+func (b *BulkIndexer) Stats() BulkIndexerStats {
+	return BulkIndexerStats{
+		Added:     b.added.Load(),
+		Indexed:   b.indexed.Load(),
+		Failed:    b.failed.Load(),
+		Retried:   b.retried.Load(),
+		Throttled: b.throttled.Load(),
+		Requests:  b.requests.Load(),
+		Bytes:     b.bytes.Load(),
+	}
+}
+
+// takeLocked removes the current batch; b.mu must be held. A batch taken
+// must be passed to enqueue.
+func (b *BulkIndexer) takeLocked() []*bulkItem {
+	if len(b.batch) == 0 {
+		return nil
+	}
+	batch := b.batch
+	b.batch, b.batchBytes = nil, 0
+	b.pending++
+	return batch
+}
+
+// enqueue hands a batch to the workers, or fails its docs if ctx ends
+// first.
+func (b *BulkIndexer) enqueue(ctx context.Context, batch []*bulkItem) error {
+	if len(batch) == 0 {
+		return nil
+	}
+	select {
+	case b.queue <- batch:
+		return nil
+	case <-ctx.Done():
+		for _, item := range batch {
+			b.finish(item, 0, ctx.Err())
+		}
+		b.batchDone()
+		return ctx.Err()
+	}
+}
+
+// batchDone marks a taken batch as finished.
+func (b *BulkIndexer) batchDone() {
+	b.mu.Lock()
+	b.pending--
+	if b.pending == 0 {
+		b.idle.Broadcast()
+	}
+	b.mu.Unlock()
+}
+
+// flushOnInterval sends a partial batch once its first doc has waited
+// FlushInterval.
+func (b *BulkIndexer) flushOnInterval() {
+	defer close(b.stopped)
+	ticker := time.NewTicker(b.cfg.FlushInterval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-ticker.C:
+		}
+		b.mu.Lock()
+		var batch []*bulkItem
+		if len(b.batch) > 0 && time.Since(b.batchStarted) >= b.cfg.FlushInterval {
+			batch = b.takeLocked()
+		}
+		b.mu.Unlock()
+		b.enqueue(b.ctx, batch)
+	}
+}
+
+// send indexes a batch, sending the docs that hit a retryable error again
+// after a backoff until they succeed or run out of attempts.
+func (b *BulkIndexer) send(items []*bulkItem) {
+	backoff := b.cfg.MinBackoff
+	for len(items) > 0 {
+		var next []*bulkItem
+		for _, item := range b.sendOnce(items) {
+			if item.attempts >= b.cfg.MaxAttempts || b.ctx.Err() != nil {
+				b.finish(item, item.status, item.err)
+			} else {
+				next = append(next, item)
+			}
+		}
+		items = next
+		if len(items) == 0 {
+			return
+		}
+
+		// Jittered, so workers throttled together don't retry together
+		wait := time.Duration(rand.Int63n(int64(backoff))) + backoff/2
+		log.Printf("[BulkIndexer] %d docs not indexed (%v), retrying in %s", len(items), items[0].err, wait.Round(time.Millisecond))
+		b.retried.Add(int64(len(items)))
+		select {
+		case <-b.ctx.Done():
+			for _, item := range items {
+				b.finish(item, item.status, b.ctx.Err())
+			}
+			return
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > b.cfg.MaxBackoff {
+			backoff = b.cfg.MaxBackoff
+		}
+	}
+}
+
+// sendOnce sends one _bulk request and returns the docs to retry, with
+// their error set. The others are finished.
+func (b *BulkIndexer) sendOnce(items []*bulkItem) []*bulkItem {
+	var buf bytes.Buffer
+	for _, item := range items {
+		item.attempts++
+		buf.Write(item.body)
+	}
+	retryAll := func(status int, err error) []*bulkItem {
+		for _, item := range items {
+			item.status, item.err = status, err
+		}
+		return items
+	}
+
+	url := fmt.Sprintf("%s/%s/_bulk", b.client.cfg.Endpoint, b.client.writeIndex(b.ctx))
+	b.requests.Add(1)
+	b.bytes.Add(int64(buf.Len()))
+	resp, err := b.client.doRequest(b.ctx, "POST", url, buf.Bytes())
+	if err != nil {
+		return retryAll(0, fmt.Errorf("bulk index: %w", err))
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return retryAll(0, fmt.Errorf("read bulk response: %w", err))
+	}
+	switch {
+	case resp.StatusCode == http.StatusOK:
+	case retryableStatus(resp.StatusCode) || resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout:
+		b.throttled.Add(1)
+		return retryAll(resp.StatusCode, fmt.Errorf("bulk index: status %d", resp.StatusCode))
+	default:
+		err := fmt.Errorf("bulk index failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+		for _, item := range items {
+			b.finish(item, resp.StatusCode, err)
+		}
+		return nil
+	}
+
+	var bulkResp struct {
+		Items []map[string]struct {
+			Status int `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	// Indexing a doc twice is harmless, so an unreadable response is retried
+	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+		return retryAll(0, fmt.Errorf("bulk index: cannot parse response (sent %d docs): %w", len(items), err))
+	}
+	if len(bulkResp.Items) != len(items) {
+		return retryAll(0, fmt.Errorf("bulk index: sent %d docs, got %d results", len(items), len(bulkResp.Items)))
+	}
+
+	var retry []*bulkItem
+	for i, result := range bulkResp.Items {
+		item := items[i]
+		for _, r := range result {
+			var err error
+			if r.Status != http.StatusOK && r.Status != http.StatusCreated {
+				err = fmt.Errorf("status %d", r.Status)
+				if r.Error != nil {
+					err = fmt.Errorf("%d %s: %s", r.Status, r.Error.Type, r.Error.Reason)
+				}
+			}
+			if retryableStatus(r.Status) {
+				b.throttled.Add(1)
+				item.status, item.err = r.Status, err
+				retry = append(retry, item)
+				continue
+			}
+			b.finish(item, r.Status, err)
+		}
+	}
+	return retry
+}
+
+// finish records a doc's outcome.
+func (b *BulkIndexer) finish(item *bulkItem, status int, err error) {
+	if err == nil {
+		b.indexed.Add(1)
+	} else {
+		b.failed.Add(1)
+	}
+	if b.cfg.OnResult != nil {
+		b.cfg.OnResult(BulkResult{ID: item.id, Status: status, Err: err, Attempts: item.attempts})
+	}
+}
+
+// retryableStatus reports whether a status means the cluster is
+// overloaded rather than that the request is wrong.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable
+}
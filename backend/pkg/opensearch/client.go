@@ -16,83 +16,84 @@ import (
 
 // Config holds OpenSearch connection settings.
 type Config struct {
-	Endpoint string // e.g. "http://localhost:9200"
-	Index    string // e.g. "papers"
-	Username string // optional
-	Password string // optional
+	Endpoint  string   // e.g. "http://localhost:9200"; may list several nodes, comma-separated
+	Endpoints []string // more nodes; requests go round-robin to those passing health checks
+	Index     string   // e.g. "papers"; the read alias of versioned indices (see versions.go)
+	Username  string   // optional
+	Password  string   // optional
+
+	// AWSRegion turns on SigV4 request signing for Amazon OpenSearch
+	// Service, instead of basic auth, with these credentials.
+	AWSRegion       string
+	AWSAccessKey    string
+	AWSSecretKey    string
+	AWSSessionToken string // optional
+	AWSService      string // "es" (default), or "aoss" for OpenSearch Serverless
+
+	// Timeout bounds each attempt of a request, including reading its
+	// response (default 30s; negative: none); the caller's context bounds
+	// the request as a whole, retries and backoff included. Retries is how
+	// many more times reads are tried on unreachable or unavailable nodes
+	// (default 2; negative: none). BreakerThreshold failed requests in a
+	// row open the circuit breaker for BreakerCooldown (defaults 5 and 30s;
+	// negative threshold: no breaker). See transport.go.
+	Timeout          time.Duration
+	Retries          int
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
 }
 
 // Client communicates with an OpenSearch cluster.
 type Client struct {
-	cfg        Config
-	httpClient *http.Client
+	cfg       Config
+	transport *transport
+	direct    bool // cfg.Index is a concrete index, written to directly
+	write     writeTarget
 }
 
 // NewClient creates a new OpenSearch client.
 func NewClient(cfg Config) *Client {
+	if endpoints := splitEndpoints(cfg); len(endpoints) > 0 {
+		cfg.Endpoint, cfg.Endpoints = endpoints[0], endpoints[1:]
+	}
 	return &Client{
-		cfg: cfg,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		cfg:       cfg,
+		transport: newTransport(cfg),
 	}
 }
 
 // ---------- Index Management ----------
 
-// IndexMapping defines the OpenSearch index mapping for papers.
-// Optimized for S2 (Semantic Scholar) data with citation counts, fields of study, etc.
-const IndexMapping = `{
-  "settings": {
-    "number_of_shards": 2,
-    "number_of_replicas": 0,
-    "analysis": {
-      "analyzer": {
-        "paper_analyzer": {
-          "type": "custom",
-          "tokenizer": "standard",
-          "filter": ["lowercase", "stop", "snowball"]
-        }
-      }
-    }
-  },
-  "mappings": {
-    "properties": {
-      "id":                        { "type": "keyword" },
-      "external_id":               { "type": "keyword" },
-      "source":                    { "type": "keyword" },
-      "title":                     { "type": "text", "analyzer": "paper_analyzer", "fields": { "keyword": { "type": "keyword", "ignore_above": 512 } } },
-      "abstract":                  { "type": "text", "analyzer": "paper_analyzer" },
-      "authors": {
-        "type": "nested",
-        "properties": {
-          "name":      { "type": "text", "fields": { "keyword": { "type": "keyword" } } },
-          "authorId":  { "type": "keyword" }
-        }
-      },
-      "published_date":            { "type": "date", "format": "yyyy-MM-dd||yyyy-MM||yyyy||epoch_millis" },
-      "year":                      { "type": "integer" },
-      "pdf_url":                   { "type": "keyword", "index": false },
-      "primary_category":          { "type": "keyword" },
-      "categories":                { "type": "keyword" },
-      "doi":                       { "type": "keyword" },
-      "journal_ref":               { "type": "text" },
-      "citation_count":            { "type": "integer" },
-      "reference_count":           { "type": "integer" },
-      "influential_citation_count": { "type": "integer" },
-      "venue":                     { "type": "keyword", "fields": { "text": { "type": "text" } } },
-      "publication_types":         { "type": "keyword" },
-      "s2_url":                    { "type": "keyword", "index": false },
-      "is_open_access":            { "type": "boolean" },
-      "tldr":                      { "type": "text", "analyzer": "paper_analyzer" }
-    }
-  }
-}`
-
-// CreateIndex creates the papers index with the proper mapping.
+// CreateIndex creates the first version of the papers index behind the read
+// and write aliases, unless the index already exists.
 func (c *Client) CreateIndex(ctx context.Context) error {
+	if c.direct {
+		return c.createIndex(ctx)
+	}
+	exists, err := c.exists(ctx)
+	if err != nil {
+		return err
+	}
+	if exists {
+		log.Printf("[OpenSearch] Index '%s' already exists", c.cfg.Index)
+		return nil
+	}
+
+	version, _, err := c.BuildVersion(ctx, 0)
+	if err != nil {
+		return err
+	}
+	return c.swapAliases(ctx, version.Index())
+}
+
+// createIndex creates the index with the proper mapping.
+func (c *Client) createIndex(ctx context.Context) error {
 	url := fmt.Sprintf("%s/%s", c.cfg.Endpoint, c.cfg.Index)
-	resp, err := c.doRequest(ctx, "PUT", url, []byte(IndexMapping))
+	mapping, err := indexBody()
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRequest(ctx, "PUT", url, mapping)
 	if err != nil {
 		return fmt.Errorf("create index: %w", err)
 	}
@@ -117,6 +118,31 @@ func (c *Client) CreateIndex(ctx context.Context) error {
 	return fmt.Errorf("create index failed (%d): %s", resp.StatusCode, string(body[:min(500, len(body))]))
 }
 
+// PutMapping adds fields from IndexMapping that an existing index is missing
+// (e.g. the fulltext fields). Existing fields cannot be changed this way.
+// Unlike ApplyMapping it leaves the index's mapping version alone.
+func (c *Client) PutMapping(ctx context.Context) error {
+	var full struct {
+		Mappings json.RawMessage `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(IndexMapping), &full); err != nil {
+		return fmt.Errorf("parse index mapping: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_mapping", c.cfg.Endpoint, c.cfg.Index)
+	resp, err := c.doRequest(ctx, "PUT", url, full.Mappings)
+	if err != nil {
+		return fmt.Errorf("put mapping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("put mapping failed (%d): %s", resp.StatusCode, string(body[:min(500, len(body))]))
+	}
+	return nil
+}
+
 // DeleteIndex deletes the papers index.
 func (c *Client) DeleteIndex(ctx context.Context) error {
 	url := fmt.Sprintf("%s/%s", c.cfg.Endpoint, c.cfg.Index)
@@ -160,16 +186,42 @@ type PaperDoc struct {
 	S2URL                    string      `json:"s2_url,omitempty"`
 	IsOpenAccess             bool        `json:"is_open_access"`
 	TLDR                     string      `json:"tldr,omitempty"`
+	// WorkID groups a preprint with its published versions (see paperid.WorkKey).
+	// IndexDoc and BulkIndex fill it in when empty.
+	WorkID string `json:"work_id,omitempty"`
+	// S2Fields marks a doc whose S2 fields (ReferenceCount through TLDR)
+	// were read from PostgreSQL, so BulkSync writes them instead of keeping
+	// the indexed ones. Never indexed.
+	S2Fields bool `json:"-"`
+
+	// Extracted PDF text. Indexed for search but excluded from search/get responses.
+	FullTextSections []FullTextSection `json:"fulltext_sections,omitempty"`
+	// FullTextSnippet is a highlighted match from the full text. Response-only: set by
+	// Search when IncludeFullText is on, never indexed.
+	FullTextSnippet string `json:"fulltext_snippet,omitempty"`
+	// RemoteSource marks a federated search hit not yet in our store (arxiv,
+	// pubmed, openalex, semanticscholar). Response-only, never indexed.
+	RemoteSource string `json:"remote_source,omitempty"`
 }
 
+// FullTextSection is one section of a paper's extracted text.
+type FullTextSection struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// sourceExcludes keeps large full-text fields out of read responses.
+var sourceExcludes = []string{"fulltext_sections"}
+
 // IndexDoc indexes a single document.
 func (c *Client) IndexDoc(ctx context.Context, doc *PaperDoc) error {
+	doc.setWorkID()
 	body, err := json.Marshal(doc)
 	if err != nil {
 		return fmt.Errorf("marshal doc: %w", err)
 	}
 
-	url := fmt.Sprintf("%s/%s/_doc/%s", c.cfg.Endpoint, c.cfg.Index, doc.ID)
+	url := fmt.Sprintf("%s/%s/_doc/%s", c.cfg.Endpoint, c.writeIndex(ctx), doc.ID)
 	resp, err := c.doRequest(ctx, "PUT", url, body)
 	if err != nil {
 		return fmt.Errorf("index doc: %w", err)
@@ -184,53 +236,116 @@ func (c *Client) IndexDoc(ctx context.Context, doc *PaperDoc) error {
 	return nil
 }
 
+// UpdateDoc merges fields into an existing document (partial update).
+// Returns (false, nil) if the document does not exist.
+func (c *Client) UpdateDoc(ctx context.Context, id string, fields map[string]interface{}) (bool, error) {
+	body, err := json.Marshal(map[string]interface{}{"doc": fields})
+	if err != nil {
+		return false, fmt.Errorf("marshal update: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_update/%s?retry_on_conflict=3", c.cfg.Endpoint, c.writeIndex(ctx), id)
+	resp, err := c.doRequest(ctx, "POST", url, body)
+	if err != nil {
+		return false, fmt.Errorf("update doc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("update doc failed (%d): %s", resp.StatusCode, string(respBody[:min(300, len(respBody))]))
+	}
+	return true, nil
+}
+
+// DeleteDoc removes a document. Returns (false, nil) if it does not exist.
+func (c *Client) DeleteDoc(ctx context.Context, id string) (bool, error) {
+	url := fmt.Sprintf("%s/%s/_doc/%s", c.cfg.Endpoint, c.writeIndex(ctx), id)
+	resp, err := c.doRequest(ctx, "DELETE", url, nil)
+	if err != nil {
+		return false, fmt.Errorf("delete doc: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return false, fmt.Errorf("delete doc failed (%d): %s", resp.StatusCode, string(respBody[:min(300, len(respBody))]))
+	}
+	return true, nil
+}
+
 // BulkIndex indexes multiple documents using the _bulk API.
 // Returns the number of successfully indexed documents and a non-nil error
-// if any item-level failures occur (individual errors are logged).
+// if any item-level failures occur (individual errors are logged). Docs
+// rejected with 429/503 are retried with backoff; use a BulkIndexer to
+// index concurrently or to learn which docs failed.
 func (c *Client) BulkIndex(ctx context.Context, docs []*PaperDoc) (int, error) {
 	if len(docs) == 0 {
 		return 0, nil
 	}
 
-	var buf bytes.Buffer
+	indexer := c.NewBulkIndexer(ctx, BulkIndexerConfig{
+		Workers:   1,
+		FlushDocs: len(docs),
+		OnResult: func(r BulkResult) {
+			if r.Err != nil {
+				log.Printf("[BulkIndex] FAIL doc %s: %v", r.ID, r.Err)
+			}
+		},
+	})
 	serialized := 0
 	for _, doc := range docs {
-		// Action line
-		action := map[string]interface{}{
-			"index": map[string]string{
-				"_index": c.cfg.Index,
-				"_id":    doc.ID,
-			},
-		}
-		actionJSON, err := json.Marshal(action)
-		if err != nil {
-			log.Printf("[BulkIndex] WARN: failed to marshal action for doc %s: %v", doc.ID, err)
+		if err := indexer.Add(ctx, doc); err != nil {
+			log.Printf("[BulkIndex] WARN: %v", err)
 			continue
 		}
-		buf.Write(actionJSON)
-		buf.WriteByte('\n')
-
-		// Document line
-		docJSON, err := json.Marshal(doc)
-		if err != nil {
-			log.Printf("[BulkIndex] WARN: failed to marshal doc %s: %v", doc.ID, err)
-			// Remove the action line we just wrote (rewind)
-			buf.Truncate(buf.Len() - len(actionJSON) - 1)
-			continue
-		}
-		buf.Write(docJSON)
-		buf.WriteByte('\n')
 		serialized++
 	}
-
+	err := indexer.Close()
 	if serialized == 0 {
 		return 0, fmt.Errorf("bulk index: all %d docs failed serialization", len(docs))
 	}
+	return int(indexer.Stats().Indexed), err
+}
+
+// BulkUpdate merges partial fields into existing documents (keyed by _id)
+// using the _bulk API. Documents that do not exist are skipped, not counted
+// as failures. Returns the number of updated documents and a non-nil error
+// if any other item-level failure occurs.
+func (c *Client) BulkUpdate(ctx context.Context, updates map[string]map[string]interface{}) (int, error) {
+	if len(updates) == 0 {
+		return 0, nil
+	}
+
+	var buf bytes.Buffer
+	index := c.writeIndex(ctx)
+	for id, fields := range updates {
+		action, err := json.Marshal(map[string]interface{}{
+			"update": map[string]interface{}{"_index": index, "_id": id, "retry_on_conflict": 3},
+		})
+		if err != nil {
+			return 0, fmt.Errorf("marshal action: %w", err)
+		}
+		doc, err := json.Marshal(map[string]interface{}{"doc": fields})
+		if err != nil {
+			return 0, fmt.Errorf("marshal update for doc %s: %w", id, err)
+		}
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(doc)
+		buf.WriteByte('\n')
+	}
 
 	url := fmt.Sprintf("%s/_bulk", c.cfg.Endpoint)
 	resp, err := c.doRequest(ctx, "POST", url, buf.Bytes())
 	if err != nil {
-		return 0, fmt.Errorf("bulk index: %w", err)
+		return 0, fmt.Errorf("bulk update: %w", err)
 	}
 	defer resp.Body.Close()
 
@@ -238,49 +353,45 @@ func (c *Client) BulkIndex(ctx context.Context, docs []*PaperDoc) (int, error) {
 	if err != nil {
 		return 0, fmt.Errorf("read bulk response: %w", err)
 	}
-
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("bulk index failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+		return 0, fmt.Errorf("bulk update failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
 	}
 
-	// Parse bulk response — NEVER assume success if parsing fails
 	var bulkResp struct {
-		Errors bool `json:"errors"`
-		Items  []struct {
-			Index struct {
+		Items []struct {
+			Update struct {
 				ID     string `json:"_id"`
 				Status int    `json:"status"`
 				Error  *struct {
 					Type   string `json:"type"`
 					Reason string `json:"reason"`
 				} `json:"error"`
-			} `json:"index"`
+			} `json:"update"`
 		} `json:"items"`
 	}
 	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
-		return 0, fmt.Errorf("bulk index: cannot parse response (sent %d docs): %w", serialized, err)
+		return 0, fmt.Errorf("bulk update: cannot parse response (sent %d updates): %w", len(updates), err)
 	}
 
-	success := 0
-	failures := 0
+	updated, failures := 0, 0
 	for _, item := range bulkResp.Items {
-		if item.Index.Status == 200 || item.Index.Status == 201 {
-			success++
-		} else {
+		switch item.Update.Status {
+		case http.StatusOK, http.StatusCreated:
+			updated++
+		case http.StatusNotFound:
+			// Not indexed (yet); cmd/index picks it up from PostgreSQL
+		default:
 			failures++
-			if item.Index.Error != nil {
-				log.Printf("[BulkIndex] FAIL doc %s: %d %s — %s",
-					item.Index.ID, item.Index.Status, item.Index.Error.Type, item.Index.Error.Reason)
-			} else {
-				log.Printf("[BulkIndex] FAIL doc %s: status %d (no error body)", item.Index.ID, item.Index.Status)
+			if item.Update.Error != nil {
+				log.Printf("[BulkUpdate] FAIL doc %s: %d %s — %s",
+					item.Update.ID, item.Update.Status, item.Update.Error.Type, item.Update.Error.Reason)
 			}
 		}
 	}
-
 	if failures > 0 {
-		return success, fmt.Errorf("bulk index: %d/%d items failed", failures, serialized)
+		return updated, fmt.Errorf("bulk update: %d/%d items failed", failures, len(updates))
 	}
-	return success, nil
+	return updated, nil
 }
 
 // VerifyDocCitation fetches a single document by _id and returns its citation_count.
@@ -389,10 +500,17 @@ func (c *Client) MaxCitationCount(ctx context.Context) (int, error) {
 // SearchParams defines search parameters.
 type SearchParams struct {
 	Query      string
+	Source     string // only docs from this source, if set
 	Categories []string
 	SortBy     string // "relevance", "citations", "date"
 	Limit      int
 	Offset     int
+	// IncludeFullText also matches extracted PDF text and returns a snippet per hit.
+	IncludeFullText bool
+	// Collapse returns one hit per work (preprint + journal versions) and
+	// counts works instead of documents. Every doc needs a work_id for this;
+	// docs indexed before work IDs existed are filled in by cmd/linkworks.
+	Collapse bool
 }
 
 // SearchResult is the result of a search operation.
@@ -445,10 +563,16 @@ func (c *Client) Search(ctx context.Context, params SearchParams) (*SearchResult
 				Value int `json:"value"`
 			} `json:"total"`
 			Hits []struct {
-				Source PaperDoc `json:"_source"`
-				Score  float64 `json:"_score"`
+				Source    PaperDoc            `json:"_source"`
+				Score     float64             `json:"_score"`
+				Highlight map[string][]string `json:"highlight"`
 			} `json:"hits"`
 		} `json:"hits"`
+		Aggregations struct {
+			Works struct {
+				Value int `json:"value"`
+			} `json:"works"`
+		} `json:"aggregations"`
 	}
 	if err := json.Unmarshal(respBody, &esResp); err != nil {
 		return nil, fmt.Errorf("parse search response: %w", err)
@@ -457,9 +581,17 @@ func (c *Client) Search(ctx context.Context, params SearchParams) (*SearchResult
 	result := &SearchResult{
 		Total: esResp.Hits.Total.Value,
 	}
+	if params.Collapse {
+		// hits.total counts documents; report works (approximate above 40k)
+		result.Total = esResp.Aggregations.Works.Value
+	}
 	for _, hit := range esResp.Hits.Hits {
+		doc := hit.Source
+		if frags := hit.Highlight["fulltext_sections.text"]; len(frags) > 0 {
+			doc.FullTextSnippet = frags[0]
+		}
 		result.Hits = append(result.Hits, &SearchHit{
-			Doc:   hit.Source,
+			Doc:   doc,
 			Score: hit.Score,
 		})
 	}
@@ -469,7 +601,7 @@ func (c *Client) Search(ctx context.Context, params SearchParams) (*SearchResult
 
 // GetByID retrieves a single document by its OpenSearch _id.
 func (c *Client) GetByID(ctx context.Context, id string) (*PaperDoc, error) {
-	url := fmt.Sprintf("%s/%s/_doc/%s", c.cfg.Endpoint, c.cfg.Index, id)
+	url := fmt.Sprintf("%s/%s/_doc/%s?_source_excludes=%s", c.cfg.Endpoint, c.cfg.Index, id, strings.Join(sourceExcludes, ","))
 	resp, err := c.doRequest(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("get by id: %w", err)
@@ -512,7 +644,8 @@ func (c *Client) SearchByExternalID(ctx context.Context, externalID string) (*Pa
 				"external_id": externalID,
 			},
 		},
-		"size": 1,
+		"size":    1,
+		"_source": map[string]interface{}{"excludes": sourceExcludes},
 	}
 
 	body, err := json.Marshal(query)
@@ -557,8 +690,9 @@ func (c *Client) SearchByExternalID(ctx context.Context, externalID string) (*Pa
 // buildSearchQuery constructs the OpenSearch query DSL.
 func (c *Client) buildSearchQuery(params SearchParams) map[string]interface{} {
 	query := map[string]interface{}{
-		"from": params.Offset,
-		"size": params.Limit,
+		"from":    params.Offset,
+		"size":    params.Limit,
+		"_source": map[string]interface{}{"excludes": sourceExcludes},
 	}
 
 	// Build the query part
@@ -618,6 +752,17 @@ func (c *Client) buildSearchQuery(params SearchParams) map[string]interface{} {
 				},
 			},
 		})
+		// 6. Full-text match (opt-in; low boost so metadata matches still rank first)
+		if params.IncludeFullText {
+			should = append(should, map[string]interface{}{
+				"match": map[string]interface{}{
+					"fulltext": map[string]interface{}{
+						"query": params.Query,
+						"boost": 0.5,
+					},
+				},
+			})
+		}
 	}
 
 	if len(params.Categories) > 0 {
@@ -627,6 +772,11 @@ func (c *Client) buildSearchQuery(params SearchParams) map[string]interface{} {
 			},
 		})
 	}
+	if params.Source != "" {
+		filter = append(filter, map[string]interface{}{
+			"term": map[string]interface{}{"source": params.Source},
+		})
+	}
 
 	boolQuery := map[string]interface{}{}
 	if len(should) > 0 {
@@ -674,13 +824,34 @@ func (c *Client) buildSearchQuery(params SearchParams) map[string]interface{} {
 		}
 	}
 
+	if params.Collapse {
+		query["collapse"] = map[string]interface{}{"field": "work_id"}
+		query["aggs"] = map[string]interface{}{
+			"works": map[string]interface{}{
+				"cardinality": map[string]interface{}{"field": "work_id", "precision_threshold": 40000},
+			},
+		}
+	}
+
 	// Highlight
 	if params.Query != "" {
+		fields := map[string]interface{}{
+			"title":    map[string]interface{}{"number_of_fragments": 0},
+			"abstract": map[string]interface{}{"fragment_size": 200, "number_of_fragments": 1},
+		}
+		if params.IncludeFullText {
+			// Highlighting reads the section text from the stored source even though it is
+			// excluded from the returned _source.
+			fields["fulltext_sections.text"] = map[string]interface{}{
+				"fragment_size":       200,
+				"number_of_fragments": 1,
+				"highlight_query": map[string]interface{}{
+					"match": map[string]interface{}{"fulltext_sections.text": params.Query},
+				},
+			}
+		}
 		query["highlight"] = map[string]interface{}{
-			"fields": map[string]interface{}{
-				"title":    map[string]interface{}{"number_of_fragments": 0},
-				"abstract": map[string]interface{}{"fragment_size": 200, "number_of_fragments": 1},
-			},
+			"fields":    fields,
 			"pre_tags":  []string{"<mark>"},
 			"post_tags": []string{"</mark>"},
 		}
@@ -800,7 +971,8 @@ func (c *Client) GetRandomPapers(ctx context.Context, categories []string, exclu
 	}
 
 	query := map[string]interface{}{
-		"size": limit,
+		"size":    limit,
+		"_source": map[string]interface{}{"excludes": sourceExcludes},
 		"query": map[string]interface{}{
 			"function_score": map[string]interface{}{
 				"query": innerQuery,
@@ -884,7 +1056,8 @@ func (c *Client) GetTopCitedDiverseFields(ctx context.Context, numFields int) ([
 					},
 					"top_paper": map[string]interface{}{
 						"top_hits": map[string]interface{}{
-							"size": 1,
+							"size":    1,
+							"_source": map[string]interface{}{"excludes": sourceExcludes},
 							"sort": []interface{}{
 								map[string]interface{}{
 									"citation_count": map[string]interface{}{"order": "desc"},
@@ -987,23 +1160,10 @@ func (c *Client) Ping(ctx context.Context) error {
 
 // ---------- HTTP helper ----------
 
+// doRequest sends a request built on cfg.Endpoint through the transport,
+// which picks the node, retries and keeps the circuit breaker.
 func (c *Client) doRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
-	var bodyReader io.Reader
-	if body != nil {
-		bodyReader = bytes.NewReader(body)
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
-	if err != nil {
-		return nil, err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	if c.cfg.Username != "" && c.cfg.Password != "" {
-		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
-	}
-
-	return c.httpClient.Do(req)
+	return c.transport.do(ctx, method, url, body)
 }
 
 func min(a, b int) int {
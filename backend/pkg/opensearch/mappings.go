@@ -0,0 +1,390 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ---------- Mapping versions ----------
+//
+// IndexMapping is the mapping new indices get. Every change to it bumps
+// the version in MappingHistory, which is stored in the index's mapping
+// _meta, so a live index can be told apart from the code's expectations.
+// A change that adds fields (or multi-fields) can be applied to the live
+// index in place; one that changes how existing fields are indexed,
+// including the analysis settings, is breaking and needs the documents
+// reindexed into a new version (see versions.go). cmd/osadmin does both.
+
+// MappingChange is one version of IndexMapping.
+type MappingChange struct {
+	Version int
+	// Breaking is set if documents indexed before the change must be
+	// reindexed, e.g. a field's type or an analyzer changed. Analysis
+	// settings aren't compared with the live index, so a change to them
+	// is only detected through this flag.
+	Breaking bool
+	Summary  string
+}
+
+// MappingHistory lists the versions of IndexMapping, oldest first. Append
+// to it with each change to IndexMapping.
+var MappingHistory = []MappingChange{
+	{Version: 1, Summary: "S2-aligned paper fields"},
+	{Version: 2, Summary: "fulltext and fulltext_sections"},
+	{Version: 3, Summary: "work_id"},
+}
+
+// MappingVersion is the version of IndexMapping.
+var MappingVersion = MappingHistory[len(MappingHistory)-1].Version
+
+// IndexMapping defines the OpenSearch index mapping for papers.
+// Optimized for S2 (Semantic Scholar) data with citation counts, fields of study, etc.
+const IndexMapping = `{
+  "settings": {
+    "number_of_shards": 2,
+    "number_of_replicas": 0,
+    "analysis": {
+      "analyzer": {
+        "paper_analyzer": {
+          "type": "custom",
+          "tokenizer": "standard",
+          "filter": ["lowercase", "stop", "snowball"]
+        }
+      }
+    }
+  },
+  "mappings": {
+    "properties": {
+      "id":                        { "type": "keyword" },
+      "external_id":               { "type": "keyword" },
+      "source":                    { "type": "keyword" },
+      "title":                     { "type": "text", "analyzer": "paper_analyzer", "fields": { "keyword": { "type": "keyword", "ignore_above": 512 } } },
+      "abstract":                  { "type": "text", "analyzer": "paper_analyzer" },
+      "authors": {
+        "type": "nested",
+        "properties": {
+          "name":      { "type": "text", "fields": { "keyword": { "type": "keyword" } } },
+          "authorId":  { "type": "keyword" }
+        }
+      },
+      "published_date":            { "type": "date", "format": "yyyy-MM-dd||yyyy-MM||yyyy||epoch_millis" },
+      "year":                      { "type": "integer" },
+      "pdf_url":                   { "type": "keyword", "index": false },
+      "primary_category":          { "type": "keyword" },
+      "categories":                { "type": "keyword" },
+      "doi":                       { "type": "keyword" },
+      "journal_ref":               { "type": "text" },
+      "citation_count":            { "type": "integer" },
+      "reference_count":           { "type": "integer" },
+      "influential_citation_count": { "type": "integer" },
+      "venue":                     { "type": "keyword", "fields": { "text": { "type": "text" } } },
+      "publication_types":         { "type": "keyword" },
+      "s2_url":                    { "type": "keyword", "index": false },
+      "is_open_access":            { "type": "boolean" },
+      "tldr":                      { "type": "text", "analyzer": "paper_analyzer" },
+      "work_id":                   { "type": "keyword" },
+      "fulltext":                  { "type": "text", "analyzer": "paper_analyzer" },
+      "fulltext_sections": {
+        "properties": {
+          "title":     { "type": "keyword" },
+          "text":      { "type": "text", "analyzer": "paper_analyzer", "copy_to": "fulltext" }
+        }
+      }
+    }
+  }
+}`
+
+// indexBody is IndexMapping with its version in the mapping _meta, the
+// body that creates an index.
+func indexBody() ([]byte, error) {
+	var body map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(IndexMapping), &body); err != nil {
+		return nil, fmt.Errorf("parse index mapping: %w", err)
+	}
+	body["mappings"]["_meta"] = map[string]interface{}{"mapping_version": MappingVersion}
+	return json.Marshal(body)
+}
+
+// MappingDiff is how an index's mapping differs from IndexMapping.
+type MappingDiff struct {
+	Index       string `json:"index"`        // the concrete index compared
+	LiveVersion int    `json:"live_version"` // 0 if it predates mapping versions
+	Version     int    `json:"version"`      // MappingVersion
+	// Added are fields IndexMapping has and the index lacks.
+	Added []string `json:"added,omitempty"`
+	// Changed are fields mapped differently, which can't be changed in
+	// place.
+	Changed []FieldChange `json:"changed,omitempty"`
+	// Extra are fields only the index has, e.g. dynamically mapped ones.
+	Extra []string `json:"extra,omitempty"`
+}
+
+// FieldChange is a field whose mapping parameters differ.
+type FieldChange struct {
+	Field string `json:"field"`
+	Live  string `json:"live"`
+	Want  string `json:"want"`
+}
+
+// Current reports whether the index needs no migration.
+func (d *MappingDiff) Current() bool {
+	return d.LiveVersion >= d.Version && len(d.Added) == 0 && len(d.Changed) == 0
+}
+
+// Breaking reports whether the index must be reindexed: a field changed,
+// or a version since the index's own is marked breaking. An index without
+// a version is judged by its fields alone.
+func (d *MappingDiff) Breaking() bool {
+	if len(d.Changed) > 0 {
+		return true
+	}
+	if d.LiveVersion == 0 {
+		return false
+	}
+	for _, m := range MappingHistory {
+		if m.Version > d.LiveVersion && m.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// inPlaceParams are mapping parameters an existing field's mapping can be
+// updated with.
+var inPlaceParams = map[string]bool{"ignore_above": true}
+
+// CheckMapping compares the live index's mapping with IndexMapping.
+func (c *Client) CheckMapping(ctx context.Context) (*MappingDiff, error) {
+	url := fmt.Sprintf("%s/%s/_mapping", c.cfg.Endpoint, c.cfg.Index)
+	resp, err := c.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get mapping: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get mapping failed (%d): %s", resp.StatusCode, string(body[:min(300, len(body))]))
+	}
+	var byIndex map[string]struct {
+		Mappings mappingDef `json:"mappings"`
+	}
+	if err := json.Unmarshal(body, &byIndex); err != nil {
+		return nil, fmt.Errorf("parse mapping: %w", err)
+	}
+	if len(byIndex) != 1 {
+		return nil, fmt.Errorf("%s resolves to %d indices, want 1", c.cfg.Index, len(byIndex))
+	}
+
+	var want struct {
+		Mappings mappingDef `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(IndexMapping), &want); err != nil {
+		return nil, fmt.Errorf("parse index mapping: %w", err)
+	}
+
+	diff := &MappingDiff{Version: MappingVersion}
+	for index, live := range byIndex {
+		diff.Index = index
+		diff.LiveVersion = live.Mappings.Meta.MappingVersion
+		liveFields, wantFields := map[string]map[string]interface{}{}, map[string]map[string]interface{}{}
+		flattenFields("", live.Mappings.Properties, liveFields)
+		flattenFields("", want.Mappings.Properties, wantFields)
+
+		for field, params := range wantFields {
+			liveParams, ok := liveFields[field]
+			if !ok {
+				diff.Added = append(diff.Added, field)
+				continue
+			}
+			if changed := changedParams(liveParams, params); changed != nil {
+				changed.Field = field
+				diff.Changed = append(diff.Changed, *changed)
+			}
+		}
+		for field := range liveFields {
+			if _, ok := wantFields[field]; !ok {
+				diff.Extra = append(diff.Extra, field)
+			}
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Extra)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Field < diff.Changed[j].Field })
+	return diff, nil
+}
+
+// mappingDef is the part of a mapping that is compared.
+type mappingDef struct {
+	Meta struct {
+		MappingVersion int `json:"mapping_version"`
+	} `json:"_meta"`
+	Properties map[string]map[string]interface{} `json:"properties"`
+}
+
+// flattenFields maps each field, object property and multi-field by its
+// dotted path to its own parameters.
+func flattenFields(prefix string, props map[string]map[string]interface{}, out map[string]map[string]interface{}) {
+	for name, def := range props {
+		path := prefix + name
+		params := map[string]interface{}{}
+		for k, v := range def {
+			switch k {
+			case "properties", "fields":
+				sub := map[string]map[string]interface{}{}
+				raw, _ := json.Marshal(v)
+				if json.Unmarshal(raw, &sub) == nil {
+					flattenFields(path+".", sub, out)
+				}
+			case "copy_to":
+				// Returned as a list even when set as a string
+				if s, ok := v.(string); ok {
+					v = []interface{}{s}
+				}
+				params[k] = v
+			default:
+				params[k] = v
+			}
+		}
+		if _, ok := params["type"]; !ok {
+			params["type"] = "object"
+		}
+		out[path] = params
+	}
+}
+
+// changedParams compares the parameters IndexMapping sets on a field with
+// the live ones, and returns the difference if it can't be applied in
+// place. Parameters only the live field has are defaults and ignored.
+func changedParams(live, want map[string]interface{}) *FieldChange {
+	var liveDiff, wantDiff []string
+	for k, v := range want {
+		if inPlaceParams[k] || reflect.DeepEqual(live[k], v) {
+			continue
+		}
+		lv, _ := json.Marshal(live[k])
+		wv, _ := json.Marshal(v)
+		liveDiff = append(liveDiff, k+"="+string(lv))
+		wantDiff = append(wantDiff, k+"="+string(wv))
+	}
+	if len(wantDiff) == 0 {
+		return nil
+	}
+	sort.Strings(liveDiff)
+	sort.Strings(wantDiff)
+	return &FieldChange{Live: strings.Join(liveDiff, " "), Want: strings.Join(wantDiff, " ")}
+}
+
+// ApplyMapping adds what the live index's mapping lacks from IndexMapping
+// and records MappingVersion in it. It refuses a breaking change, which
+// needs Reindex.
+func (c *Client) ApplyMapping(ctx context.Context) (*MappingDiff, error) {
+	diff, err := c.CheckMapping(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if diff.Breaking() {
+		return diff, fmt.Errorf("mapping of %s can't be migrated in place from version %d to %d; reindex it", diff.Index, diff.LiveVersion, diff.Version)
+	}
+	if diff.Current() {
+		return diff, nil
+	}
+
+	var full struct {
+		Mappings map[string]interface{} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(IndexMapping), &full); err != nil {
+		return nil, fmt.Errorf("parse index mapping: %w", err)
+	}
+	full.Mappings["_meta"] = map[string]interface{}{"mapping_version": MappingVersion}
+	body, err := json.Marshal(full.Mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_mapping", c.cfg.Endpoint, diff.Index)
+	resp, err := c.doRequest(ctx, "PUT", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("put mapping: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("put mapping failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+	}
+	log.Printf("[OpenSearch] Mapping of %s migrated from version %d to %d (%d fields added)",
+		diff.Index, diff.LiveVersion, diff.Version, len(diff.Added))
+	return diff, nil
+}
+
+// Reindex copies the live index into a new version created with
+// IndexMapping and promotes it, for changes that can't be applied in place,
+// returning the new version's name. Writes made meanwhile go to the new
+// version and aren't overwritten by the copy.
+func (c *Client) Reindex(ctx context.Context, th Thresholds, keep int) (string, error) {
+	target, _, err := c.BuildVersion(ctx, 0)
+	if err != nil {
+		return "", err
+	}
+	index := target.Index()
+	log.Printf("[OpenSearch] Reindexing %s into %s", c.cfg.Index, index)
+
+	err = c.reindexInto(ctx, index)
+	if err == nil {
+		err = c.Promote(ctx, index, th, keep)
+	}
+	if err != nil {
+		if resetErr := c.ResetWriteAlias(context.Background()); resetErr != nil {
+			log.Printf("[OpenSearch] WARN: reset write alias: %v", resetErr)
+		}
+		return "", fmt.Errorf("reindex into %s: %w", index, err)
+	}
+	return index, nil
+}
+
+// reindexInto runs _reindex from the read alias into index as a background
+// task and waits for it. Documents index already has are newer and kept.
+func (c *Client) reindexInto(ctx context.Context, index string) error {
+	payload, err := json.Marshal(map[string]interface{}{
+		"conflicts": "proceed",
+		"source":    map[string]interface{}{"index": c.cfg.Index, "size": 1000},
+		"dest":      map[string]interface{}{"index": index, "op_type": "create"},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("%s/_reindex?wait_for_completion=false", c.cfg.Endpoint)
+	resp, err := c.doRequest(ctx, "POST", url, payload)
+	if err != nil {
+		return fmt.Errorf("reindex: %w", err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("reindex failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+	}
+	var started struct {
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal(respBody, &started); err != nil || started.Task == "" {
+		return fmt.Errorf("reindex: no task in response: %s", string(respBody[:min(300, len(respBody))]))
+	}
+
+	result, err := c.waitTask(ctx, "reindex", started.Task)
+	if err != nil {
+		return err
+	}
+	log.Printf("[OpenSearch] Reindexed %d docs into %s (%d already there)", result.Created, index, result.VersionConflicts)
+	return nil
+}
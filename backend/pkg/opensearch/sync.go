@@ -0,0 +1,168 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ---------- Outbox sync (PostgreSQL → OpenSearch) ----------
+
+// BulkFailure is a document a bulk request could not write.
+type BulkFailure struct {
+	ID     string
+	Status int
+	Reason string
+}
+
+// syncFields returns the fields of a doc that PostgreSQL owns. S2's venue,
+// TLDR and reference counts are only written for docs that have them in
+// PostgreSQL (S2Fields): docs cmd/s2import indexed keep theirs.
+func (d *PaperDoc) syncFields() map[string]interface{} {
+	d.setWorkID()
+	fields := map[string]interface{}{
+		"id":               d.ID,
+		"external_id":      d.ExternalID,
+		"source":           d.Source,
+		"title":            d.Title,
+		"abstract":         d.Abstract,
+		"authors":          d.Authors,
+		"published_date":   d.PublishedDate,
+		"pdf_url":          d.PDFURL,
+		"primary_category": d.PrimaryCategory,
+		"categories":       d.Categories,
+		"doi":              d.DOI,
+		"journal_ref":      d.JournalRef,
+		"citation_count":   d.CitationCount,
+		"work_id":          d.WorkID,
+	}
+	if d.Year != 0 {
+		fields["year"] = d.Year
+	}
+	if len(d.FullTextSections) > 0 {
+		fields["fulltext_sections"] = d.FullTextSections
+	}
+	if d.S2Fields {
+		fields["reference_count"] = d.ReferenceCount
+		fields["influential_citation_count"] = d.InfluentialCitationCount
+		fields["venue"] = d.Venue
+		fields["publication_types"] = d.PublicationTypes
+		fields["s2_url"] = d.S2URL
+		fields["is_open_access"] = d.IsOpenAccess
+		fields["tldr"] = d.TLDR
+	}
+	return fields
+}
+
+// BulkSync writes PostgreSQL's view of papers in one _bulk request. Docs
+// are merged into the indexed documents, which are created if missing;
+// unlike BulkIndex this keeps the fields PostgreSQL doesn't have. Deletes
+// remove documents, and deleting one that isn't indexed succeeds. While a
+// new index version is being built, both it and the live version are
+// written, so neither misses the change.
+//
+// It returns the documents that failed; the error is for the request as a
+// whole, in which case nothing can be assumed written.
+func (c *Client) BulkSync(ctx context.Context, docs []*PaperDoc, deletes []string) ([]BulkFailure, error) {
+	if len(docs) == 0 && len(deletes) == 0 {
+		return nil, nil
+	}
+
+	targets := []string{c.writeIndex(ctx)}
+	if !c.direct {
+		aliases, err := c.aliases(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if live := aliases[c.cfg.Index]; live != "" && live != aliases[c.WriteAlias()] {
+			targets = []string{c.WriteAlias(), c.cfg.Index}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, index := range targets {
+		for _, doc := range docs {
+			action, err := json.Marshal(map[string]interface{}{
+				"update": map[string]interface{}{"_index": index, "_id": doc.ID, "retry_on_conflict": 3},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("marshal action: %w", err)
+			}
+			body, err := json.Marshal(map[string]interface{}{"doc": doc.syncFields(), "doc_as_upsert": true})
+			if err != nil {
+				return nil, fmt.Errorf("marshal doc %s: %w", doc.ID, err)
+			}
+			buf.Write(action)
+			buf.WriteByte('\n')
+			buf.Write(body)
+			buf.WriteByte('\n')
+		}
+		for _, id := range deletes {
+			action, err := json.Marshal(map[string]interface{}{
+				"delete": map[string]interface{}{"_index": index, "_id": id},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("marshal action: %w", err)
+			}
+			buf.Write(action)
+			buf.WriteByte('\n')
+		}
+	}
+	sent := (len(docs) + len(deletes)) * len(targets)
+
+	url := fmt.Sprintf("%s/_bulk", c.cfg.Endpoint)
+	resp, err := c.doRequest(ctx, "POST", url, buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("bulk sync: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read bulk response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bulk sync failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+	}
+
+	type bulkItem struct {
+		ID     string `json:"_id"`
+		Status int    `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	}
+	var bulkResp struct {
+		Items []map[string]bulkItem `json:"items"`
+	}
+	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+		return nil, fmt.Errorf("bulk sync: cannot parse response (sent %d items): %w", sent, err)
+	}
+	if len(bulkResp.Items) != sent {
+		return nil, fmt.Errorf("bulk sync: sent %d items, got %d results", sent, len(bulkResp.Items))
+	}
+
+	var failures []BulkFailure
+	failed := map[string]bool{}
+	for _, result := range bulkResp.Items {
+		for op, item := range result {
+			switch {
+			case item.Status == http.StatusOK || item.Status == http.StatusCreated:
+			case op == "delete" && item.Status == http.StatusNotFound:
+			case failed[item.ID]:
+			default:
+				failed[item.ID] = true
+				f := BulkFailure{ID: item.ID, Status: item.Status}
+				if item.Error != nil {
+					f.Reason = item.Error.Type + ": " + item.Error.Reason
+				}
+				failures = append(failures, f)
+			}
+		}
+	}
+	return failures, nil
+}
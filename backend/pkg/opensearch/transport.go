@@ -0,0 +1,426 @@
+package opensearch
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paper-app/backend/pkg/awsv4"
+)
+
+// ErrCircuitOpen is returned without a request while the cluster is
+// considered down: Config.BreakerThreshold requests in a row failed, and
+// the breaker hasn't yet let a probe through. Callers with a fallback
+// should take it straight away.
+var ErrCircuitOpen = errors.New("opensearch: circuit breaker open")
+
+const (
+	defaultTimeout          = 30 * time.Second
+	defaultRetries          = 2
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 30 * time.Second
+
+	retryBase      = 100 * time.Millisecond
+	retryMax       = 2 * time.Second
+	healthInterval = 5 * time.Second
+	healthTimeout  = 3 * time.Second
+)
+
+// transport sends the client's requests. It is shared by the clients
+// WithIndex derives, so they see the same node health and breaker.
+type transport struct {
+	httpClient *http.Client
+	signer     *awsv4.Signer // nil: basic auth, if any
+	username   string
+	password   string
+	timeout    time.Duration // per attempt; <= 0: none
+	retries    int
+
+	mu       sync.Mutex
+	nodes    []*node // nodes[0] is Config.Endpoint, which request URLs are built on
+	next     int
+	watching bool
+	breaker  breaker
+}
+
+type node struct {
+	url  string
+	down bool
+}
+
+func newTransport(cfg Config) *transport {
+	t := &transport{
+		httpClient: &http.Client{},
+		username:   cfg.Username,
+		password:   cfg.Password,
+		timeout:    cfg.Timeout,
+		retries:    cfg.Retries,
+		breaker: breaker{
+			threshold: cfg.BreakerThreshold,
+			cooldown:  cfg.BreakerCooldown,
+		},
+	}
+	if t.timeout == 0 {
+		t.timeout = defaultTimeout
+	}
+	if t.retries == 0 {
+		t.retries = defaultRetries
+	}
+	if t.breaker.threshold == 0 {
+		t.breaker.threshold = defaultBreakerThreshold
+	}
+	if t.breaker.cooldown <= 0 {
+		t.breaker.cooldown = defaultBreakerCooldown
+	}
+	if cfg.AWSRegion != "" {
+		service := cfg.AWSService
+		if service == "" {
+			service = "es"
+		}
+		t.signer = &awsv4.Signer{
+			Credentials: awsv4.Credentials{
+				AccessKey:    cfg.AWSAccessKey,
+				SecretKey:    cfg.AWSSecretKey,
+				SessionToken: cfg.AWSSessionToken,
+			},
+			Region:  cfg.AWSRegion,
+			Service: service,
+		}
+	}
+	for _, endpoint := range append([]string{cfg.Endpoint}, cfg.Endpoints...) {
+		t.nodes = append(t.nodes, &node{url: endpoint})
+	}
+	return t
+}
+
+// splitEndpoints reads Endpoint, which may list several nodes separated by
+// commas, and Endpoints into one list without trailing slashes.
+func splitEndpoints(cfg Config) []string {
+	var endpoints []string
+	seen := map[string]bool{}
+	for _, e := range append(strings.Split(cfg.Endpoint, ","), cfg.Endpoints...) {
+		e = strings.TrimRight(strings.TrimSpace(e), "/")
+		if e != "" && !seen[e] {
+			seen[e] = true
+			endpoints = append(endpoints, e)
+		}
+	}
+	return endpoints
+}
+
+// do sends a request to the next healthy node. Idempotent requests that
+// find the node unreachable, unavailable or too slow are retried on the
+// next one after a jittered backoff, until ctx is done. Every outcome
+// feeds the breaker.
+func (t *transport) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if err := t.allow(); err != nil {
+		return nil, err
+	}
+	attempts := 1
+	if t.retries > 0 && idempotent(method, url) {
+		attempts += t.retries
+	}
+
+	for attempt := 0; ; attempt++ {
+		n := t.pick()
+		resp, err := t.attempt(ctx, method, t.rewrite(url, n), body)
+		result := outcomeOf(ctx, resp, err)
+		if result == failed {
+			t.markDown(n, resp, err)
+		}
+		if result != failed || attempt+1 >= attempts || ctx.Err() != nil {
+			t.record(result)
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, backoff(attempt)); err != nil {
+			t.record(neutral)
+			return nil, err
+		}
+	}
+}
+
+func (t *transport) allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.breaker.allow()
+}
+
+func (t *transport) record(result outcome) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.breaker.record(result)
+}
+
+// attempt sends one try of a request, bounded by t.timeout on top of ctx.
+// The attempt's context lives until the response body is closed, so the
+// timeout covers reading the response too.
+func (t *transport) attempt(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	if t.timeout <= 0 {
+		return t.send(ctx, method, url, body)
+	}
+	attemptCtx, cancel := context.WithTimeout(ctx, t.timeout)
+	resp, err := t.send(attemptCtx, method, url, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody releases an attempt's context once its response is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// send makes one request to an absolute URL, signed or with basic auth.
+func (t *transport) send(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	switch {
+	case t.signer != nil:
+		payload := awsv4.EmptyPayload
+		if len(body) > 0 {
+			payload = awsv4.HashPayload(body)
+		}
+		t.signer.Sign(req, payload)
+	case t.username != "" && t.password != "":
+		req.SetBasicAuth(t.username, t.password)
+	}
+
+	return t.httpClient.Do(req)
+}
+
+// pick returns the next node round-robin, skipping nodes that are down
+// unless all of them are.
+func (t *transport) pick() *node {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := 0; i < len(t.nodes); i++ {
+		n := t.nodes[(t.next+i)%len(t.nodes)]
+		if !n.down {
+			t.next = (t.next + i + 1) % len(t.nodes)
+			return n
+		}
+	}
+	n := t.nodes[t.next]
+	t.next = (t.next + 1) % len(t.nodes)
+	return n
+}
+
+// rewrite points a URL built on nodes[0] at n.
+func (t *transport) rewrite(url string, n *node) string {
+	primary := t.nodes[0].url
+	if n == t.nodes[0] || !strings.HasPrefix(url, primary) {
+		return url
+	}
+	return n.url + url[len(primary):]
+}
+
+// markDown takes a node out of the rotation until a health check passes.
+// There is no need with a single node: it is picked either way.
+func (t *transport) markDown(n *node, resp *http.Response, err error) {
+	if len(t.nodes) < 2 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if n.down {
+		return
+	}
+	n.down = true
+	if err != nil {
+		log.Printf("[OpenSearch] Node %s is down: %v", n.url, err)
+	} else {
+		log.Printf("[OpenSearch] Node %s is down: HTTP %d", n.url, resp.StatusCode)
+	}
+	if !t.watching {
+		t.watching = true
+		go t.watch()
+	}
+}
+
+// watch pings the nodes that are down until all of them are back.
+func (t *transport) watch() {
+	ticker := time.NewTicker(healthInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		t.mu.Lock()
+		var down []*node
+		for _, n := range t.nodes {
+			if n.down {
+				down = append(down, n)
+			}
+		}
+		if len(down) == 0 {
+			t.watching = false
+			t.mu.Unlock()
+			return
+		}
+		t.mu.Unlock()
+
+		for _, n := range down {
+			if !t.healthy(n) {
+				continue
+			}
+			t.mu.Lock()
+			n.down = false
+			t.mu.Unlock()
+			log.Printf("[OpenSearch] Node %s is back", n.url)
+		}
+	}
+}
+
+func (t *transport) healthy(n *node) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), healthTimeout)
+	defer cancel()
+	resp, err := t.send(ctx, "GET", n.url, nil)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode == http.StatusOK
+}
+
+// idempotent reports whether a request can be sent again when its
+// response was lost: reads, including searches POSTed for their body.
+// Writes are not retried here; the bulk indexer and the search outbox
+// retry theirs knowing what went through.
+func idempotent(method, url string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPost:
+		path := url
+		if i := strings.IndexByte(path, '?'); i >= 0 {
+			path = path[:i]
+		}
+		for _, read := range []string{"/_search", "/_count", "/_mget", "/_msearch"} {
+			if strings.HasSuffix(path, read) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type outcome int
+
+const (
+	succeeded outcome = iota // the cluster answered
+	failed                   // unreachable, too slow or unavailable
+	neutral                  // the caller gave up; says nothing about the cluster
+)
+
+func outcomeOf(ctx context.Context, resp *http.Response, err error) outcome {
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return neutral
+		}
+		return failed
+	}
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return failed
+	}
+	return succeeded
+}
+
+// backoff is the wait before retry attempt+1: exponential, capped, with
+// half of it random so clients don't retry in step.
+func backoff(attempt int) time.Duration {
+	d := retryBase << attempt
+	if d > retryMax {
+		d = retryMax
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// breaker opens after threshold failed requests in a row. While open it
+// fails requests without sending them; after cooldown it lets one probe
+// through, which closes it or opens it for another cooldown. A negative
+// threshold disables it. Its fields are guarded by transport.mu.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+func (b *breaker) allow() error {
+	if b.threshold < 0 {
+		return nil
+	}
+	if b.failures < b.threshold {
+		return nil
+	}
+	if b.probing || time.Now().Before(b.openUntil) {
+		return ErrCircuitOpen
+	}
+	b.probing = true
+	return nil
+}
+
+func (b *breaker) record(result outcome) {
+	if b.threshold < 0 {
+		return
+	}
+	wasOpen := b.failures >= b.threshold
+	switch result {
+	case succeeded:
+		if wasOpen {
+			log.Printf("[OpenSearch] Circuit closed")
+		}
+		b.failures = 0
+	case failed:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.openUntil = time.Now().Add(b.cooldown)
+			if !wasOpen {
+				log.Printf("[OpenSearch] Circuit open after %d failed requests; probing again in %s", b.failures, b.cooldown)
+			}
+		}
+	}
+	b.probing = false
+}
@@ -0,0 +1,462 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ---------- Versioned indices ----------
+//
+// Config.Index names a read alias ("papers"). The documents live in
+// versioned indices (papers_v1, papers_v2, …) and writes go through a write
+// alias (papers_write). A rebuild fills a new version while the live one
+// keeps serving searches; the write alias moves to the new version for the
+// build, so updates made meanwhile land in it, and Promote moves the read
+// alias once the new version passes its checks. Old versions are kept for
+// Rollback.
+//
+// An index created before versioning (a concrete index named like the read
+// alias) keeps working as is; the first Promote replaces it.
+
+// writeAliasRecheck is how long a client that found no write alias keeps
+// writing to the read alias before looking again.
+const writeAliasRecheck = 30 * time.Second
+
+// IndexVersion is one versioned index.
+type IndexVersion struct {
+	Name      string    `json:"name"`
+	Version   int       `json:"version"`
+	Docs      int64     `json:"docs"`
+	Live      bool      `json:"live"`    // has the read alias
+	Writing   bool      `json:"writing"` // has the write alias
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Thresholds are the checks a new version must pass before Promote makes
+// it live.
+type Thresholds struct {
+	// MinDocs is the fewest documents the version may have.
+	MinDocs int64
+	// MinDocRatio is the fewest documents as a share of the live index's;
+	// 0 skips the comparison.
+	MinDocRatio float64
+	// MinMaxCitations is the lowest highest citation_count; an import that
+	// lost its citation data fails it. 0 skips the check.
+	MinMaxCitations int
+}
+
+// writeTarget caches whether the write alias exists.
+type writeTarget struct {
+	mu        sync.Mutex
+	found     bool
+	checkedAt time.Time
+}
+
+// WriteAlias is the alias writes go through.
+func (c *Client) WriteAlias() string {
+	return c.cfg.Index + "_write"
+}
+
+// Index is the index or alias the client reads from.
+func (c *Client) Index() string {
+	return c.cfg.Index
+}
+
+// WithIndex returns a client on the same cluster that reads and writes
+// the given index directly, e.g. a version being built.
+func (c *Client) WithIndex(index string) *Client {
+	cfg := c.cfg
+	cfg.Index = index
+	return &Client{cfg: cfg, transport: c.transport, direct: true}
+}
+
+// writeIndex is where writes go: the write alias if there is one, else the
+// read alias or legacy index.
+func (c *Client) writeIndex(ctx context.Context) string {
+	if c.direct {
+		return c.cfg.Index
+	}
+	c.write.mu.Lock()
+	defer c.write.mu.Unlock()
+	if !c.write.found && time.Since(c.write.checkedAt) > writeAliasRecheck {
+		aliases, err := c.aliases(ctx)
+		if err == nil {
+			_, c.write.found = aliases[c.WriteAlias()]
+			c.write.checkedAt = time.Now()
+		}
+	}
+	if c.write.found {
+		return c.WriteAlias()
+	}
+	return c.cfg.Index
+}
+
+// versionName is the index of version n.
+func (c *Client) versionName(n int) string {
+	return fmt.Sprintf("%s_v%d", c.cfg.Index, n)
+}
+
+// aliases maps the read and write aliases to the indices holding them.
+func (c *Client) aliases(ctx context.Context) (map[string]string, error) {
+	url := fmt.Sprintf("%s/_alias/%s,%s", c.cfg.Endpoint, c.cfg.Index, c.WriteAlias())
+	resp, err := c.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get aliases: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// 404 lists the aliases that exist, if any, along with the error
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("get aliases failed (%d): %s", resp.StatusCode, string(body[:min(300, len(body))]))
+	}
+	var byIndex map[string]json.RawMessage
+	if err := json.Unmarshal(body, &byIndex); err != nil {
+		return nil, fmt.Errorf("parse aliases: %w", err)
+	}
+
+	holders := map[string]string{}
+	for index, raw := range byIndex {
+		var entry struct {
+			Aliases map[string]json.RawMessage `json:"aliases"`
+		}
+		if json.Unmarshal(raw, &entry) != nil {
+			continue // "error" and "status" of a 404
+		}
+		for alias := range entry.Aliases {
+			holders[alias] = index
+		}
+	}
+	return holders, nil
+}
+
+// Versions lists the versioned indices, oldest first, and reports whether
+// a legacy index named like the read alias exists.
+func (c *Client) Versions(ctx context.Context) ([]*IndexVersion, bool, error) {
+	url := fmt.Sprintf("%s/_cat/indices/%s,%s_v*?format=json&h=index,docs.count,creation.date&expand_wildcards=open&ignore_unavailable=true",
+		c.cfg.Endpoint, c.cfg.Index, c.cfg.Index)
+	resp, err := c.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("list indices: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("list indices failed (%d): %s", resp.StatusCode, string(body[:min(300, len(body))]))
+	}
+	var rows []struct {
+		Index     string `json:"index"`
+		Docs      string `json:"docs.count"`
+		CreatedAt string `json:"creation.date"`
+	}
+	if err := json.Unmarshal(body, &rows); err != nil {
+		return nil, false, fmt.Errorf("parse indices: %w", err)
+	}
+	aliases, err := c.aliases(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var versions []*IndexVersion
+	legacy := false
+	prefix := c.cfg.Index + "_v"
+	seen := map[string]bool{} // the read alias lists its index again
+	for _, row := range rows {
+		if row.Index == c.cfg.Index {
+			legacy = true
+			continue
+		}
+		if seen[row.Index] {
+			continue
+		}
+		seen[row.Index] = true
+		n, err := strconv.Atoi(strings.TrimPrefix(row.Index, prefix))
+		if err != nil || !strings.HasPrefix(row.Index, prefix) {
+			continue // another index matching the pattern, e.g. papers_v2_old
+		}
+		v := &IndexVersion{
+			Name:    row.Index,
+			Version: n,
+			Live:    aliases[c.cfg.Index] == row.Index,
+			Writing: aliases[c.WriteAlias()] == row.Index,
+		}
+		v.Docs, _ = strconv.ParseInt(row.Docs, 10, 64)
+		if ms, err := strconv.ParseInt(row.CreatedAt, 10, 64); err == nil {
+			v.CreatedAt = time.UnixMilli(ms).UTC()
+		}
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Version < versions[j].Version })
+	return versions, legacy, nil
+}
+
+// BuildVersion returns a client on version n of the index to rebuild it
+// into, and n, creating the next version if n is 0 or gone. The write alias moves
+// to the version so that updates made during the build aren't lost (unless
+// a legacy index is live, which keeps them); the read alias stays until
+// Promote. A build that is given up should call ResetWriteAlias.
+func (c *Client) BuildVersion(ctx context.Context, n int) (*Client, int, error) {
+	versions, _, err := c.Versions(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var build *IndexVersion
+	for _, v := range versions {
+		if v.Version == n {
+			build = v
+		}
+	}
+	if build == nil {
+		next := 1
+		if len(versions) > 0 {
+			next = versions[len(versions)-1].Version + 1
+		}
+		build = &IndexVersion{Name: c.versionName(next), Version: next}
+		if err := c.WithIndex(build.Name).createIndex(ctx); err != nil {
+			return nil, 0, err
+		}
+		log.Printf("[OpenSearch] Created index version %s", build.Name)
+	}
+
+	if liveVersion(versions) != nil && !build.Writing {
+		if err := c.moveWriteAlias(ctx, versions, build.Name); err != nil {
+			return nil, 0, err
+		}
+	}
+	return c.WithIndex(build.Name), build.Version, nil
+}
+
+// ResetWriteAlias points the write alias back at the live version after a
+// build stopped short of Promote.
+func (c *Client) ResetWriteAlias(ctx context.Context) error {
+	versions, _, err := c.Versions(ctx)
+	if err != nil {
+		return err
+	}
+	live := liveVersion(versions)
+	if live == nil || live.Writing {
+		return nil
+	}
+	return c.moveWriteAlias(ctx, versions, live.Name)
+}
+
+func liveVersion(versions []*IndexVersion) *IndexVersion {
+	for _, v := range versions {
+		if v.Live {
+			return v
+		}
+	}
+	return nil
+}
+
+// moveWriteAlias points the write alias at index.
+func (c *Client) moveWriteAlias(ctx context.Context, versions []*IndexVersion, index string) error {
+	actions := []interface{}{}
+	for _, v := range versions {
+		if v.Writing {
+			actions = append(actions, aliasAction("remove", v.Name, c.WriteAlias()))
+		}
+	}
+	actions = append(actions, map[string]interface{}{
+		"add": map[string]interface{}{"index": index, "alias": c.WriteAlias(), "is_write_index": true},
+	})
+	return c.updateAliases(ctx, actions)
+}
+
+// Validate checks a version against the thresholds, comparing it with the
+// live index.
+func (c *Client) Validate(ctx context.Context, index string, th Thresholds) error {
+	version := c.WithIndex(index)
+	if err := version.Refresh(ctx); err != nil {
+		return err
+	}
+	docs, err := version.GetDocCount(ctx)
+	if err != nil {
+		return fmt.Errorf("count %s: %w", index, err)
+	}
+	if docs < th.MinDocs {
+		return fmt.Errorf("%s has %d docs, want at least %d", index, docs, th.MinDocs)
+	}
+	exists, err := c.exists(ctx)
+	if err != nil {
+		return err
+	}
+	if th.MinDocRatio > 0 && exists {
+		live, err := c.GetDocCount(ctx)
+		if err != nil {
+			return fmt.Errorf("count live index: %w", err)
+		}
+		if float64(docs) < th.MinDocRatio*float64(live) {
+			return fmt.Errorf("%s has %d docs, under %.0f%% of the live index's %d", index, docs, th.MinDocRatio*100, live)
+		}
+	}
+	if th.MinMaxCitations > 0 {
+		maxCit, err := version.MaxCitationCount(ctx)
+		if err != nil {
+			return fmt.Errorf("max citation count of %s: %w", index, err)
+		}
+		if maxCit < th.MinMaxCitations {
+			return fmt.Errorf("%s: max citation_count is %d, want at least %d", index, maxCit, th.MinMaxCitations)
+		}
+	}
+	return nil
+}
+
+// Promote validates a version and, if it passes, points both aliases at
+// it in one request, then deletes all but keep of the older versions.
+func (c *Client) Promote(ctx context.Context, index string, th Thresholds, keep int) error {
+	if err := c.Validate(ctx, index, th); err != nil {
+		return fmt.Errorf("validate: %w", err)
+	}
+	if err := c.swapAliases(ctx, index); err != nil {
+		return err
+	}
+	log.Printf("[OpenSearch] %s is live as %s", index, c.cfg.Index)
+	if _, err := c.PruneVersions(ctx, keep); err != nil {
+		log.Printf("[OpenSearch] WARN: prune old versions: %v", err)
+	}
+	return nil
+}
+
+// Rollback points both aliases back at the newest version older than the
+// live one and returns its name.
+func (c *Client) Rollback(ctx context.Context) (string, error) {
+	versions, _, err := c.Versions(ctx)
+	if err != nil {
+		return "", err
+	}
+	var prev *IndexVersion
+	for _, v := range versions {
+		if v.Live {
+			break
+		}
+		prev = v
+	}
+	if prev == nil || prev == versions[len(versions)-1] {
+		return "", fmt.Errorf("no older version of %s to roll back to", c.cfg.Index)
+	}
+	if err := c.swapAliases(ctx, prev.Name); err != nil {
+		return "", err
+	}
+	log.Printf("[OpenSearch] Rolled %s back to %s", c.cfg.Index, prev.Name)
+	return prev.Name, nil
+}
+
+// PruneVersions deletes the versions older than the live one beyond the
+// newest keep, and returns their names. Versions newer than the live one
+// may still be building and are left alone.
+func (c *Client) PruneVersions(ctx context.Context, keep int) ([]string, error) {
+	versions, _, err := c.Versions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	live := -1
+	for i, v := range versions {
+		if v.Live {
+			live = i
+		}
+	}
+	var deleted []string
+	for i := live - 1 - keep; i >= 0; i-- {
+		v := versions[i]
+		if v.Writing {
+			continue
+		}
+		if err := c.WithIndex(v.Name).DeleteIndex(ctx); err != nil {
+			return deleted, err
+		}
+		log.Printf("[OpenSearch] Deleted old version %s", v.Name)
+		deleted = append(deleted, v.Name)
+	}
+	return deleted, nil
+}
+
+// swapAliases points the read and write aliases at index in one request,
+// replacing a legacy index named like the read alias.
+func (c *Client) swapAliases(ctx context.Context, index string) error {
+	versions, legacy, err := c.Versions(ctx)
+	if err != nil {
+		return err
+	}
+	actions := []interface{}{}
+	for _, v := range versions {
+		if v.Live && v.Name != index {
+			actions = append(actions, aliasAction("remove", v.Name, c.cfg.Index))
+		}
+		if v.Writing && v.Name != index {
+			actions = append(actions, aliasAction("remove", v.Name, c.WriteAlias()))
+		}
+	}
+	if legacy {
+		log.Printf("[OpenSearch] Replacing unversioned index %s", c.cfg.Index)
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]string{"index": c.cfg.Index}})
+	}
+	actions = append(actions,
+		aliasAction("add", index, c.cfg.Index),
+		map[string]interface{}{
+			"add": map[string]interface{}{"index": index, "alias": c.WriteAlias(), "is_write_index": true},
+		})
+	return c.updateAliases(ctx, actions)
+}
+
+func aliasAction(action, index, alias string) map[string]interface{} {
+	return map[string]interface{}{action: map[string]string{"index": index, "alias": alias}}
+}
+
+// updateAliases applies alias actions atomically.
+func (c *Client) updateAliases(ctx context.Context, actions []interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRequest(ctx, "POST", c.cfg.Endpoint+"/_aliases", body)
+	if err != nil {
+		return fmt.Errorf("update aliases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("update aliases failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+	}
+	return nil
+}
+
+// exists reports whether the index or alias exists.
+func (c *Client) exists(ctx context.Context) (bool, error) {
+	resp, err := c.doRequest(ctx, "HEAD", fmt.Sprintf("%s/%s", c.cfg.Endpoint, c.cfg.Index), nil)
+	if err != nil {
+		return false, fmt.Errorf("check index %s: %w", c.cfg.Index, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// Refresh makes the index's recent writes visible to counts and searches.
+func (c *Client) Refresh(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("%s/%s/_refresh", c.cfg.Endpoint, c.cfg.Index), nil)
+	if err != nil {
+		return fmt.Errorf("refresh %s: %w", c.cfg.Index, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("refresh %s failed (%d): %s", c.cfg.Index, resp.StatusCode, string(body[:min(300, len(body))]))
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package opensearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// ---------- Works (preprint ↔ published versions) ----------
+
+// setWorkID gives a doc its own work key when the importer didn't set one.
+func (d *PaperDoc) setWorkID() {
+	if d.WorkID == "" {
+		d.WorkID = paperid.WorkKey(d.Source, d.ExternalID, d.DOI)
+	}
+}
+
+// SearchByWorkID returns the docs of one work, most cited first.
+func (c *Client) SearchByWorkID(ctx context.Context, workID string, limit int) ([]*PaperDoc, error) {
+	query := map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"work_id": workID},
+		},
+		"size": limit,
+		"sort": []interface{}{
+			map[string]interface{}{"citation_count": map[string]string{"order": "desc"}},
+			map[string]interface{}{"published_date": map[string]string{"order": "asc", "missing": "_last"}},
+		},
+		"_source": map[string]interface{}{"excludes": sourceExcludes},
+	}
+
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/%s/_search", c.cfg.Endpoint, c.cfg.Index)
+	resp, err := c.doRequest(ctx, "POST", url, body)
+	if err != nil {
+		return nil, fmt.Errorf("search by work_id: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search by work_id failed (%d): %s", resp.StatusCode, string(respBody[:min(300, len(respBody))]))
+	}
+
+	var esResp struct {
+		Hits struct {
+			Hits []struct {
+				Source PaperDoc `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.Unmarshal(respBody, &esResp); err != nil {
+		return nil, err
+	}
+
+	docs := make([]*PaperDoc, 0, len(esResp.Hits.Hits))
+	for i := range esResp.Hits.Hits {
+		docs = append(docs, &esResp.Hits.Hits[i].Source)
+	}
+	return docs, nil
+}
+
+// ReassignWorks moves docs from one work to another: every doc whose
+// work_id is a key of moves gets the mapped work_id. Matching on work keys
+// rather than _id finds a record whichever importer indexed it (PG uuid, S2
+// corpusid or OpenAlex ID). Returns the number of docs changed.
+func (c *Client) ReassignWorks(ctx context.Context, moves map[string]string) (int, error) {
+	if len(moves) == 0 {
+		return 0, nil
+	}
+	from := make([]string, 0, len(moves))
+	for k := range moves {
+		from = append(from, k)
+	}
+	return c.updateByQuery(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{"work_id": from},
+		},
+		"script": map[string]interface{}{
+			"source": `def to = params.moves.get(ctx._source.work_id);
+if (to == null || to == ctx._source.work_id) { ctx.op = 'noop' } else { ctx._source.work_id = to }`,
+			"lang":   "painless",
+			"params": map[string]interface{}{"moves": moves},
+		},
+	})
+}
+
+// backfillWorkIDScript mirrors paperid.WorkKey for docs indexed before
+// work_id existed. Painless regexes are off by default, so it only handles
+// the shapes our importers write: bare arXiv IDs and lowercase-able DOIs.
+const backfillWorkIDScript = `
+def src = ctx._source;
+def doi = src.doi == null ? '' : src.doi.toLowerCase();
+if (src.source == 'arxiv' && src.external_id != null && src.external_id != '') {
+  ctx._source.work_id = 'arxiv:' + src.external_id;
+} else if (doi.startsWith('10.48550/arxiv.')) {
+  ctx._source.work_id = 'arxiv:' + doi.substring(15);
+} else if (doi != '') {
+  ctx._source.work_id = 'doi:' + doi;
+} else if (src.external_id != null && src.external_id != '') {
+  ctx._source.work_id = src.source + ':' + src.external_id;
+} else {
+  ctx.op = 'noop';
+}`
+
+// BackfillWorkIDs sets work_id on every doc that has none, so that
+// collapsed search doesn't fold them all into one "missing" group.
+func (c *Client) BackfillWorkIDs(ctx context.Context) (int, error) {
+	return c.updateByQuery(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": []interface{}{map[string]interface{}{"exists": map[string]interface{}{"field": "work_id"}}},
+			},
+		},
+		"script": map[string]interface{}{"source": backfillWorkIDScript, "lang": "painless"},
+	})
+}
+
+// ResetWorks gives the docs of the given works their own keys back, for
+// works whose links were removed.
+func (c *Client) ResetWorks(ctx context.Context, workIDs []string) (int, error) {
+	if len(workIDs) == 0 {
+		return 0, nil
+	}
+	return c.updateByQuery(ctx, map[string]interface{}{
+		"query": map[string]interface{}{
+			"terms": map[string]interface{}{"work_id": workIDs},
+		},
+		"script": map[string]interface{}{"source": backfillWorkIDScript, "lang": "painless"},
+	})
+}
+
+// updateByQuery runs _update_by_query as a background task and waits for it,
+// so large updates aren't cut off by the HTTP client timeout. Returns the
+// number of docs updated.
+func (c *Client) updateByQuery(ctx context.Context, body map[string]interface{}) (int, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, fmt.Errorf("marshal update_by_query: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/%s/_update_by_query?conflicts=proceed&wait_for_completion=false", c.cfg.Endpoint, c.writeIndex(ctx))
+	resp, err := c.doRequest(ctx, "POST", url, payload)
+	if err != nil {
+		return 0, fmt.Errorf("update_by_query: %w", err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("update_by_query failed (%d): %s", resp.StatusCode, string(respBody[:min(500, len(respBody))]))
+	}
+
+	var started struct {
+		Task string `json:"task"`
+	}
+	if err := json.Unmarshal(respBody, &started); err != nil || started.Task == "" {
+		return 0, fmt.Errorf("update_by_query: no task in response: %s", string(respBody[:min(300, len(respBody))]))
+	}
+
+	result, err := c.waitTask(ctx, "update_by_query", started.Task)
+	if result == nil {
+		return 0, err
+	}
+	return result.Updated, err
+}
+
+// taskResult is the outcome of a finished _update_by_query or _reindex task.
+type taskResult struct {
+	Created          int               `json:"created"`
+	Updated          int               `json:"updated"`
+	VersionConflicts int               `json:"version_conflicts"`
+	Failures         []json.RawMessage `json:"failures"`
+}
+
+// waitTask polls a background task until it finishes. The result is also
+// returned with the error of a task that failed part way.
+func (c *Client) waitTask(ctx context.Context, op, taskID string) (*taskResult, error) {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("%s/_tasks/%s", c.cfg.Endpoint, taskID), nil)
+		if err != nil {
+			return nil, fmt.Errorf("%s task: %w", op, err)
+		}
+		taskBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s task failed (%d): %s", op, resp.StatusCode, string(taskBody[:min(300, len(taskBody))]))
+		}
+
+		var task struct {
+			Completed bool            `json:"completed"`
+			Response  taskResult      `json:"response"`
+			Error     json.RawMessage `json:"error"`
+		}
+		if err := json.Unmarshal(taskBody, &task); err != nil {
+			return nil, fmt.Errorf("parse task: %w", err)
+		}
+		if !task.Completed {
+			continue
+		}
+		if len(task.Error) > 0 {
+			return &task.Response, fmt.Errorf("%s: %s", op, string(task.Error))
+		}
+		if len(task.Response.Failures) > 0 {
+			return &task.Response, fmt.Errorf("%s: %d failures, first: %s",
+				op, len(task.Response.Failures), string(task.Response.Failures[0]))
+		}
+		return &task.Response, nil
+	}
+}
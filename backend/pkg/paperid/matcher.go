@@ -0,0 +1,138 @@
+package paperid
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Match methods, in order of confidence.
+const (
+	MethodDOI   = "doi"
+	MethodArxiv = "arxiv"
+	MethodTitle = "title"
+)
+
+// DefaultMinTitleSimilarity is the trigram similarity above which two titles
+// are considered the same paper.
+const DefaultMinTitleSimilarity = 0.8
+
+// Query describes a paper to look up. Any field may be empty.
+type Query struct {
+	DOI     string
+	ArxivID string
+	Title   string
+	Year    int // 0 = unknown; when set, title matches must be within a year
+}
+
+// Match is a paper found for a Query.
+type Match struct {
+	PaperID    string
+	ExternalID string
+	Method     string
+	Score      float64 // 1 for identifier matches, title similarity otherwise
+}
+
+// Matcher resolves identifiers against the papers table.
+type Matcher struct {
+	db                 *pgxpool.Pool
+	MinTitleSimilarity float64
+}
+
+// NewMatcher creates a Matcher on the given pool.
+func NewMatcher(db *pgxpool.Pool) *Matcher {
+	return &Matcher{db: db, MinTitleSimilarity: DefaultMinTitleSimilarity}
+}
+
+// Find tries DOI, then arXiv ID, then fuzzy title. It returns nil, nil when
+// nothing matches.
+func (m *Matcher) Find(ctx context.Context, q Query) (*Match, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if doi := NormalizeDOI(q.DOI); doi != "" {
+		if arxivID := NormalizeArxivID(doi); arxivID != "" && q.ArxivID == "" {
+			q.ArxivID = arxivID
+		}
+		match, err := m.findOne(ctx, MethodDOI, `SELECT id, external_id FROM papers WHERE lower(doi) = $1 AND visibility = 'public' LIMIT 1`, doi)
+		if match != nil || err != nil {
+			return match, err
+		}
+	}
+
+	if arxivID := NormalizeArxivID(q.ArxivID); arxivID != "" {
+		match, err := m.findOne(ctx, MethodArxiv, `
+			SELECT id, external_id FROM papers
+			WHERE (external_id = $1 OR lower(doi) = '10.48550/arxiv.' || $1) AND visibility = 'public'
+			LIMIT 1
+		`, arxivID)
+		if match != nil || err != nil {
+			return match, err
+		}
+	}
+
+	if len(NormalizeTitle(q.Title)) >= 10 {
+		return m.findByTitle(ctx, q)
+	}
+	return nil, nil
+}
+
+func (m *Matcher) findOne(ctx context.Context, method, query string, arg string) (*Match, error) {
+	match := &Match{Method: method, Score: 1}
+	err := m.db.QueryRow(ctx, query, arg).Scan(&match.PaperID, &match.ExternalID)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+// findByTitle uses the pg_trgm index to shortlist candidates, then confirms
+// them with TitleSimilarity and the publication year.
+func (m *Matcher) findByTitle(ctx context.Context, q Query) (*Match, error) {
+	rows, err := m.db.Query(ctx, `
+		SELECT id, external_id, title, COALESCE(EXTRACT(YEAR FROM published_date)::int, 0)
+		FROM papers
+		WHERE title % $1 AND visibility = 'public'
+		ORDER BY similarity(title, $1) DESC
+		LIMIT 5
+	`, q.Title)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var best *Match
+	for rows.Next() {
+		var id, externalID, title string
+		var year int
+		if err := rows.Scan(&id, &externalID, &title, &year); err != nil {
+			return nil, err
+		}
+		if q.Year != 0 && year != 0 && abs(q.Year-year) > 1 {
+			continue
+		}
+		score := TitleSimilarity(q.Title, title)
+		if score >= m.MinTitleSimilarity && (best == nil || score > best.Score) {
+			best = &Match{PaperID: id, ExternalID: externalID, Method: MethodTitle, Score: score}
+		}
+	}
+	return best, rows.Err()
+}
+
+// String formats a match for logs.
+func (m *Match) String() string {
+	return m.Method + ":" + m.ExternalID + " (" + strconv.FormatFloat(m.Score, 'f', 2, 64) + ")"
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,126 @@
+// Package paperid normalizes paper identifiers (DOIs, arXiv IDs, titles) and
+// matches them against the papers table. It is shared by the ingest tools so
+// every importer resolves "is this paper already here?" the same way.
+package paperid
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	// New-style (2301.01234, 0704.0001) and old-style (hep-ph/9901234) arXiv IDs
+	arxivNewRe = regexp.MustCompile(`(?i)^(\d{4}\.\d{4,5})(v\d+)?$`)
+	arxivOldRe = regexp.MustCompile(`(?i)^([a-z][a-z.-]+/\d{7})(v\d+)?$`)
+
+	// arXiv IDs inside URLs or DOIs:
+	//   arxiv.org/abs/2301.01234   – canonical
+	//   arxiv.org/pdf/2301.01234   – PDF link used as landing page
+	//   export.arxiv.org/pdf/2301.01234 – export mirror
+	//   doi.org/10.48550/arxiv.2301.01234 – DOI-based
+	arxivURLRe = regexp.MustCompile(`(?i)arxiv\.org/(?:abs|pdf)/([0-9]{4}\.[0-9]{4,5}|[a-z][a-z.-]+/[0-9]{7})`)
+	arxivDOIRe = regexp.MustCompile(`(?i)10\.48550/arxiv\.([0-9]{4}\.[0-9]{4,5})`)
+
+	// "arXiv:2301.01234v2", "arXiv preprint arXiv:hep-th/9901001", "arXiv 2301.01234"
+	arxivTextRe = regexp.MustCompile(`(?i)\barxiv[:\s]\s*(?:preprint\s+(?:arxiv:)?\s*)?([0-9]{4}\.[0-9]{4,5}|[a-z][a-z.-]+/[0-9]{7})(?:v\d+)?`)
+
+	doiRe = regexp.MustCompile(`(?i)\b(10\.\d{4,9}/[^\s"<>]+)`)
+)
+
+// NormalizeDOI lowercases a DOI and strips URL/"doi:" prefixes and trailing
+// punctuation. It returns "" if s does not contain a DOI.
+func NormalizeDOI(s string) string {
+	m := doiRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimRight(m[1], ".,;:)]}'"))
+}
+
+// NormalizeArxivID returns the bare, versionless arXiv ID for an ID, URL, DOI
+// or "arXiv:" reference, or "" if none is found.
+func NormalizeArxivID(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "arXiv:"), "arxiv:")
+	if m := arxivNewRe.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	if m := arxivOldRe.FindStringSubmatch(s); m != nil {
+		return strings.ToLower(m[1])
+	}
+	if m := arxivURLRe.FindStringSubmatch(s); m != nil {
+		return strings.ToLower(m[1])
+	}
+	if m := arxivDOIRe.FindStringSubmatch(s); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// FindArxivID finds an arXiv ID mentioned anywhere in free text
+// (URLs, DOIs, or "arXiv:" citations).
+func FindArxivID(text string) string {
+	if m := arxivTextRe.FindStringSubmatch(text); m != nil {
+		return strings.ToLower(m[1])
+	}
+	if m := arxivURLRe.FindStringSubmatch(text); m != nil {
+		return strings.ToLower(m[1])
+	}
+	if m := arxivDOIRe.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// FindDOI finds a DOI mentioned anywhere in free text.
+func FindDOI(text string) string {
+	return NormalizeDOI(text)
+}
+
+// NormalizeTitle lowercases a title and reduces it to letters and digits
+// separated by single spaces, so punctuation and LaTeX noise don't affect matching.
+func NormalizeTitle(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// TitleSimilarity returns the trigram similarity (0..1) of two titles after
+// normalization, computed like PostgreSQL's pg_trgm similarity().
+func TitleSimilarity(a, b string) float64 {
+	ta, tb := trigrams(NormalizeTitle(a)), trigrams(NormalizeTitle(b))
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams pads each word like pg_trgm ("  w", " wo", "wor", "ord", "rd ").
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,69 @@
+package paperid
+
+import "strings"
+
+// WorkKey returns the work ID a record gets before it is linked to other
+// versions of the same work: "arxiv:<id>" for arXiv papers (also when only
+// the arXiv DOI is known), "doi:<doi>" when there is a DOI, and
+// "<source>:<external id>" otherwise. Linked records share the key of the
+// published version.
+func WorkKey(source, externalID, doi string) string {
+	if source == "arxiv" {
+		if id := NormalizeArxivID(externalID); id != "" {
+			return "arxiv:" + id
+		}
+	}
+	if doi = NormalizeDOI(doi); doi != "" {
+		if id := NormalizeArxivID(doi); id != "" {
+			return "arxiv:" + id
+		}
+		return "doi:" + doi
+	}
+	if source == "" || externalID == "" {
+		return ""
+	}
+	return source + ":" + externalID
+}
+
+// AuthorOverlap returns the share (0..1) of the shorter author list whose
+// family names also appear in the other list. Names may be "First Last" or
+// "Last, First"; initials and case are ignored.
+func AuthorOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	names := map[string]bool{}
+	for _, n := range b {
+		if f := familyName(n); f != "" {
+			names[f] = true
+		}
+	}
+	shared, counted := 0, 0
+	for _, n := range a {
+		f := familyName(n)
+		if f == "" {
+			continue
+		}
+		counted++
+		if names[f] {
+			shared++
+		}
+	}
+	if counted == 0 {
+		return 0
+	}
+	return float64(shared) / float64(counted)
+}
+
+// familyName picks the family name from "Last, First" or "First M. Last".
+func familyName(name string) string {
+	if last, _, ok := strings.Cut(name, ","); ok {
+		name = last
+	} else if f := strings.Fields(name); len(f) > 0 {
+		name = f[len(f)-1]
+	}
+	return NormalizeTitle(name)
+}
@@ -0,0 +1,42 @@
+package pdfcache
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned by a Backend when the key is not cached.
+var ErrNotFound = errors.New("not cached")
+
+// Meta describes a cached PDF.
+type Meta struct {
+	URL          string    `json:"url"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Size         int64     `json:"size"`
+	FetchedAt    time.Time `json:"fetched_at"` // last download or successful revalidation
+}
+
+// Entry is a listed cache item.
+type Entry struct {
+	Key        string
+	Size       int64
+	LastAccess time.Time
+}
+
+// Backend stores cached files. Keys are hex strings produced by the Cache.
+// Implementations must be safe for concurrent use.
+type Backend interface {
+	// Get opens a cached file. The returned reader may implement io.ReadSeeker.
+	Get(ctx context.Context, key string) (io.ReadCloser, *Meta, error)
+	// Put stores r under key, replacing any existing file.
+	Put(ctx context.Context, key string, r io.Reader, meta *Meta) error
+	// SetMeta replaces the metadata of a cached file (after a 304 revalidation).
+	SetMeta(ctx context.Context, key string, meta *Meta) error
+	Delete(ctx context.Context, key string) error
+	// List returns all cached files; used to rebuild the LRU index at startup.
+	List(ctx context.Context) ([]Entry, error)
+}
@@ -0,0 +1,360 @@
+// Package pdfcache is a read-through cache for remote PDFs. Files are fetched
+// on a miss, revalidated with conditional requests (ETag/Last-Modified) once
+// they are older than a configurable age, and evicted least-recently-used when
+// the cache exceeds its size cap. Storage is pluggable (local directory or an
+// S3-compatible bucket) through the Backend interface.
+package pdfcache
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/paper-app/backend/pkg/ratelimit"
+)
+
+var (
+	ErrTooLarge     = errors.New("PDF exceeds cache size limit")
+	ErrNotPDF       = errors.New("upstream did not return a PDF")
+	ErrInvalidURL   = errors.New("invalid PDF URL")
+	ErrUpstream     = errors.New("upstream fetch failed")
+	ErrUpstreamGone = errors.New("PDF not found upstream")
+)
+
+// Cache status values reported in Object.Status (and the X-Cache header).
+const (
+	StatusHit         = "HIT"
+	StatusMiss        = "MISS"
+	StatusRevalidated = "REVALIDATED"
+	StatusStale       = "STALE" // revalidation failed; served the cached copy
+)
+
+// Options configures a Cache.
+type Options struct {
+	MaxBytes        int64         // total cache size before LRU eviction (0 = unlimited)
+	MaxFileSize     int64         // larger PDFs are not cached (0 = unlimited)
+	RevalidateAfter time.Duration // age after which a cached file is revalidated (0 = never)
+	FetchTimeout    time.Duration // per-download timeout (default 2m)
+	UserAgent       string
+	Limiter         *ratelimit.HostLimiter // optional per-host pacing of upstream requests
+	HTTPClient      *http.Client
+}
+
+// Object is an open cached PDF. The caller must close Body.
+type Object struct {
+	Body   io.ReadCloser // implements io.ReadSeeker for the directory backend
+	Meta   *Meta
+	Status string
+}
+
+// Stats are cumulative counters since startup plus current cache size.
+type Stats struct {
+	Hits        int64 `json:"hits"`
+	Misses      int64 `json:"misses"`
+	Revalidated int64 `json:"revalidated"`
+	StaleServed int64 `json:"stale_served"`
+	Errors      int64 `json:"errors"`
+	Evictions   int64 `json:"evictions"`
+	Entries     int   `json:"entries"`
+	Bytes       int64 `json:"bytes"`
+	MaxBytes    int64 `json:"max_bytes"`
+}
+
+type lruItem struct {
+	key  string
+	size int64
+}
+
+type call struct {
+	done chan struct{}
+	err  error
+}
+
+// Cache is safe for concurrent use. Concurrent misses for the same URL share
+// one upstream download.
+type Cache struct {
+	backend Backend
+	opts    Options
+
+	mu       sync.Mutex
+	lru      *list.List // front = most recently used
+	items    map[string]*list.Element
+	size     int64
+	inflight map[string]*call
+
+	hits, misses, revalidated, stale, errs, evictions atomic.Int64
+}
+
+// New creates a cache and rebuilds its LRU index from the backend.
+func New(ctx context.Context, backend Backend, opts Options) (*Cache, error) {
+	if opts.FetchTimeout == 0 {
+		opts.FetchTimeout = 2 * time.Minute
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{}
+	}
+	c := &Cache{
+		backend:  backend,
+		opts:     opts,
+		lru:      list.New(),
+		items:    map[string]*list.Element{},
+		inflight: map[string]*call{},
+	}
+
+	entries, err := backend.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list cache: %w", err)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].LastAccess.After(entries[j].LastAccess) })
+	for _, e := range entries {
+		c.items[e.Key] = c.lru.PushBack(&lruItem{key: e.Key, size: e.Size})
+		c.size += e.Size
+	}
+	c.evict(ctx, "")
+	return c, nil
+}
+
+// Key returns the cache key for a URL.
+func Key(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return hex.EncodeToString(sum[:])
+}
+
+// Open returns the cached PDF for rawURL, fetching or revalidating it as needed.
+func (c *Cache) Open(ctx context.Context, rawURL string) (*Object, error) {
+	key := Key(rawURL)
+
+	body, meta, err := c.backend.Get(ctx, key)
+	switch {
+	case err == nil:
+		if c.opts.RevalidateAfter == 0 || time.Since(meta.FetchedAt) < c.opts.RevalidateAfter {
+			c.touch(key, meta.Size)
+			c.hits.Add(1)
+			return &Object{Body: body, Meta: meta, Status: StatusHit}, nil
+		}
+		body.Close()
+		status := StatusRevalidated
+		if ferr := c.fetchOnce(ctx, key, rawURL, meta); ferr != nil {
+			log.Printf("pdfcache: revalidate %s: %v (serving cached copy)", rawURL, ferr)
+			c.stale.Add(1)
+			status = StatusStale
+		} else {
+			c.revalidated.Add(1)
+		}
+		return c.open(ctx, key, status)
+
+	case errors.Is(err, ErrNotFound):
+		c.misses.Add(1)
+		if err := c.fetchOnce(ctx, key, rawURL, nil); err != nil {
+			c.errs.Add(1)
+			return nil, err
+		}
+		return c.open(ctx, key, StatusMiss)
+
+	default:
+		c.errs.Add(1)
+		return nil, err
+	}
+}
+
+// Stats returns the current counters.
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	entries, size := len(c.items), c.size
+	c.mu.Unlock()
+	return Stats{
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		Revalidated: c.revalidated.Load(),
+		StaleServed: c.stale.Load(),
+		Errors:      c.errs.Load(),
+		Evictions:   c.evictions.Load(),
+		Entries:     entries,
+		Bytes:       size,
+		MaxBytes:    c.opts.MaxBytes,
+	}
+}
+
+func (c *Cache) open(ctx context.Context, key, status string) (*Object, error) {
+	body, meta, err := c.backend.Get(ctx, key)
+	if err != nil {
+		c.errs.Add(1)
+		return nil, err
+	}
+	c.touch(key, meta.Size)
+	return &Object{Body: body, Meta: meta, Status: status}, nil
+}
+
+// fetchOnce deduplicates concurrent fetches of the same key. The download
+// is not tied to the first caller's context so a cancelled request does not
+// fail the others waiting on it.
+func (c *Cache) fetchOnce(ctx context.Context, key, rawURL string, old *Meta) error {
+	c.mu.Lock()
+	if cl, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-cl.done:
+			return cl.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	cl := &call{done: make(chan struct{})}
+	c.inflight[key] = cl
+	c.mu.Unlock()
+
+	fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.opts.FetchTimeout)
+	cl.err = c.fetch(fetchCtx, key, rawURL, old)
+	cancel()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	c.mu.Unlock()
+	close(cl.done)
+	return cl.err
+}
+
+func (c *Cache) fetch(ctx context.Context, key, rawURL string, old *Meta) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+	if c.opts.Limiter != nil {
+		if err := c.opts.Limiter.Wait(ctx, rawURL); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return ErrInvalidURL
+	}
+	req.Header.Set("Accept", "application/pdf")
+	if c.opts.UserAgent != "" {
+		req.Header.Set("User-Agent", c.opts.UserAgent)
+	}
+	if old != nil {
+		if old.ETag != "" {
+			req.Header.Set("If-None-Match", old.ETag)
+		}
+		if old.LastModified != "" {
+			req.Header.Set("If-Modified-Since", old.LastModified)
+		}
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUpstream, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && old != nil:
+		old.FetchedAt = time.Now()
+		return c.backend.SetMeta(ctx, key, old)
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrUpstreamGone
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%w: HTTP %d", ErrUpstream, resp.StatusCode)
+	}
+
+	if c.opts.MaxFileSize > 0 && resp.ContentLength > c.opts.MaxFileSize {
+		return ErrTooLarge
+	}
+	br := bufio.NewReader(resp.Body)
+	if head, _ := br.Peek(5); !bytes.Equal(head, []byte("%PDF-")) {
+		return ErrNotPDF
+	}
+
+	meta := &Meta{
+		URL:          rawURL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  "application/pdf",
+		FetchedAt:    time.Now(),
+	}
+	var src io.Reader = br
+	if c.opts.MaxFileSize > 0 {
+		src = &capReader{r: br, remaining: c.opts.MaxFileSize}
+	}
+	if err := c.backend.Put(ctx, key, src, meta); err != nil {
+		return err
+	}
+
+	c.touch(key, meta.Size)
+	c.evict(ctx, key)
+	return nil
+}
+
+// touch marks key as most recently used, adding it to the index if needed.
+func (c *Cache) touch(key string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		item := el.Value.(*lruItem)
+		c.size += size - item.size
+		item.size = size
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&lruItem{key: key, size: size})
+	c.size += size
+}
+
+// evict removes least-recently-used files until the cache fits MaxBytes.
+// keep is never evicted (the file just fetched for a waiting request).
+func (c *Cache) evict(ctx context.Context, keep string) {
+	if c.opts.MaxBytes <= 0 {
+		return
+	}
+	var victims []string
+	c.mu.Lock()
+	for el := c.lru.Back(); el != nil && c.size > c.opts.MaxBytes; {
+		prev := el.Prev()
+		item := el.Value.(*lruItem)
+		if item.key != keep {
+			c.lru.Remove(el)
+			delete(c.items, item.key)
+			c.size -= item.size
+			victims = append(victims, item.key)
+		}
+		el = prev
+	}
+	c.mu.Unlock()
+
+	for _, key := range victims {
+		if err := c.backend.Delete(ctx, key); err != nil {
+			log.Printf("pdfcache: evict %s: %v", key, err)
+			continue
+		}
+		c.evictions.Add(1)
+	}
+}
+
+// capReader fails with ErrTooLarge once more than remaining bytes are read.
+type capReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (c *capReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.remaining -= int64(n)
+	if c.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,128 @@
+package pdfcache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DirBackend stores files in a local directory as <key>.pdf with a <key>.json
+// metadata sidecar. File modification times track last access across restarts.
+type DirBackend struct {
+	root string
+}
+
+// NewDirBackend creates root if needed.
+func NewDirBackend(root string) (*DirBackend, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirBackend{root: root}, nil
+}
+
+func (d *DirBackend) Get(ctx context.Context, key string) (io.ReadCloser, *Meta, error) {
+	meta, err := d.readMeta(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(d.path(key, ".pdf"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	now := time.Now()
+	os.Chtimes(f.Name(), now, now)
+	return f, meta, nil
+}
+
+func (d *DirBackend) Put(ctx context.Context, key string, r io.Reader, meta *Meta) error {
+	tmp, err := os.CreateTemp(d.root, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	meta.Size = n
+	if err := os.Rename(tmp.Name(), d.path(key, ".pdf")); err != nil {
+		return err
+	}
+	return d.SetMeta(ctx, key, meta)
+}
+
+func (d *DirBackend) SetMeta(ctx context.Context, key string, meta *Meta) error {
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	tmp := d.path(key, ".json.tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path(key, ".json"))
+}
+
+func (d *DirBackend) Delete(ctx context.Context, key string) error {
+	for _, ext := range []string{".pdf", ".json"} {
+		if err := os.Remove(d.path(key, ext)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *DirBackend) List(ctx context.Context) ([]Entry, error) {
+	files, err := os.ReadDir(d.root)
+	if err != nil {
+		return nil, err
+	}
+	var entries []Entry
+	for _, f := range files {
+		name := f.Name()
+		if !strings.HasSuffix(name, ".pdf") {
+			continue
+		}
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Key:        strings.TrimSuffix(name, ".pdf"),
+			Size:       info.Size(),
+			LastAccess: info.ModTime(),
+		})
+	}
+	return entries, nil
+}
+
+func (d *DirBackend) readMeta(key string) (*Meta, error) {
+	data, err := os.ReadFile(d.path(key, ".json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	var meta Meta
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (d *DirBackend) path(key, ext string) string {
+	return filepath.Join(d.root, key+ext)
+}
@@ -0,0 +1,225 @@
+package pdfcache
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/pkg/awsv4"
+)
+
+// S3Config configures an S3-compatible backend (AWS S3, MinIO, R2, ...).
+type S3Config struct {
+	Endpoint  string // e.g. "https://s3.us-east-1.amazonaws.com" or "http://localhost:9000"
+	Bucket    string
+	Prefix    string // key prefix inside the bucket, e.g. "pdfcache/"
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// S3Backend stores files in an S3-compatible bucket using path-style URLs.
+// Metadata is kept in x-amz-meta-* headers; S3 has no access time, so the
+// LRU index is seeded from LastModified after a restart.
+type S3Backend struct {
+	cfg        S3Config
+	signer     *awsv4.Signer
+	httpClient *http.Client
+}
+
+// NewS3Backend creates a backend for an existing bucket.
+func NewS3Backend(cfg S3Config) *S3Backend {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Endpoint = strings.TrimRight(cfg.Endpoint, "/")
+	return &S3Backend{
+		cfg: cfg,
+		signer: &awsv4.Signer{
+			Credentials: awsv4.Credentials{AccessKey: cfg.AccessKey, SecretKey: cfg.SecretKey},
+			Region:      cfg.Region,
+			Service:     "s3",
+		},
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (s *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, *Meta, error) {
+	resp, err := s.do(ctx, "GET", s.objectURL(key), nil, 0, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, s3Error(resp)
+	}
+	return resp.Body, metaFromHeaders(resp.Header, resp.ContentLength), nil
+}
+
+func (s *S3Backend) Put(ctx context.Context, key string, r io.Reader, meta *Meta) error {
+	// S3 needs the length up front; spool to a temp file first.
+	tmp, err := os.CreateTemp("", "pdfcache-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	meta.Size = n
+
+	resp, err := s.do(ctx, "PUT", s.objectURL(key), tmp, n, metaHeaders(meta))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3Backend) SetMeta(ctx context.Context, key string, meta *Meta) error {
+	h := metaHeaders(meta)
+	h.Set("X-Amz-Copy-Source", "/"+s.cfg.Bucket+"/"+s.cfg.Prefix+key)
+	h.Set("X-Amz-Metadata-Directive", "REPLACE")
+	resp, err := s.do(ctx, "PUT", s.objectURL(key), nil, 0, h)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (s *S3Backend) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, "DELETE", s.objectURL(key), nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Backend) List(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	token := ""
+	for {
+		q := url.Values{"list-type": {"2"}, "prefix": {s.cfg.Prefix}}
+		if token != "" {
+			q.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, "GET", s.cfg.Endpoint+"/"+s.cfg.Bucket+"?"+q.Encode(), nil, 0, nil)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, s3Error(resp)
+		}
+		var res listResult
+		err = xml.NewDecoder(resp.Body).Decode(&res)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode list response: %w", err)
+		}
+		for _, c := range res.Contents {
+			entries = append(entries, Entry{
+				Key:        strings.TrimPrefix(c.Key, s.cfg.Prefix),
+				Size:       c.Size,
+				LastAccess: c.LastModified,
+			})
+		}
+		if !res.IsTruncated || res.NextContinuationToken == "" {
+			return entries, nil
+		}
+		token = res.NextContinuationToken
+	}
+}
+
+func (s *S3Backend) objectURL(key string) string {
+	return s.cfg.Endpoint + "/" + s.cfg.Bucket + "/" + s.cfg.Prefix + key
+}
+
+func (s *S3Backend) do(ctx context.Context, method, rawURL string, body io.Reader, size int64, headers http.Header) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	payload := awsv4.EmptyPayload
+	if body != nil {
+		payload = awsv4.UnsignedPayload
+	}
+	s.signer.Sign(req, payload)
+	return s.httpClient.Do(req)
+}
+
+func metaHeaders(meta *Meta) http.Header {
+	h := http.Header{}
+	h.Set("Content-Type", "application/pdf")
+	h.Set("X-Amz-Meta-Source-Url", meta.URL)
+	if meta.ETag != "" {
+		h.Set("X-Amz-Meta-Source-Etag", meta.ETag)
+	}
+	if meta.LastModified != "" {
+		h.Set("X-Amz-Meta-Source-Last-Modified", meta.LastModified)
+	}
+	h.Set("X-Amz-Meta-Fetched-At", strconv.FormatInt(meta.FetchedAt.Unix(), 10))
+	return h
+}
+
+func metaFromHeaders(h http.Header, size int64) *Meta {
+	meta := &Meta{
+		URL:          h.Get("X-Amz-Meta-Source-Url"),
+		ETag:         h.Get("X-Amz-Meta-Source-Etag"),
+		LastModified: h.Get("X-Amz-Meta-Source-Last-Modified"),
+		ContentType:  h.Get("Content-Type"),
+		Size:         size,
+	}
+	if sec, err := strconv.ParseInt(h.Get("X-Amz-Meta-Fetched-At"), 10, 64); err == nil {
+		meta.FetchedAt = time.Unix(sec, 0)
+	}
+	return meta
+}
+
+func s3Error(resp *http.Response) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s: HTTP %d: %s", resp.Request.Method, resp.StatusCode, strings.TrimSpace(string(body)))
+}
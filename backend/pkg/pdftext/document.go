@@ -0,0 +1,304 @@
+package pdftext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// maxDecodedStream caps a single decompressed stream to guard against zip bombs.
+const maxDecodedStream = 64 << 20
+
+var objHeaderRe = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// document is a minimal PDF object store. It does not read the xref table:
+// objects are found by scanning the file body, which also works for files
+// with broken or missing cross-reference data. Once ctx is done, parsing
+// stops and streams decode to nothing.
+type document struct {
+	ctx     context.Context
+	objects map[int]interface{}
+}
+
+func parseDocument(ctx context.Context, data []byte) *document {
+	doc := &document{ctx: ctx, objects: map[int]interface{}{}}
+
+	pos := 0
+	for pos < len(data) && ctx.Err() == nil {
+		loc := objHeaderRe.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		start := pos + loc[1]
+
+		lex := &lexer{b: data, pos: start}
+		p := &parser{lex: lex}
+		val, ok := p.parseValue(0)
+		if !ok {
+			pos = start
+			continue
+		}
+
+		// A dictionary followed by "stream" carries binary data.
+		if d, isDict := val.(dict); isDict {
+			save := lex.pos
+			if t := lex.next(); t.kind == tokKeyword && t.str == "stream" {
+				raw, end := readStreamData(data, lex.pos, d)
+				val = &stream{dict: d, raw: raw}
+				lex.pos = end
+			} else {
+				lex.pos = save
+			}
+		}
+
+		// Later definitions win: incremental updates append newer versions.
+		doc.objects[num] = val
+		pos = lex.pos
+	}
+
+	doc.expandObjectStreams()
+	return doc
+}
+
+// readStreamData returns the bytes between "stream" and "endstream" and the position after.
+func readStreamData(data []byte, pos int, d dict) ([]byte, int) {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+
+	// Trust a direct /Length when "endstream" follows it.
+	if n, ok := d["Length"].(float64); ok {
+		end := pos + int(n)
+		if end <= len(data) && end >= pos {
+			rest := bytes.TrimLeft(data[end:min(end+16, len(data))], "\r\n \t")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				return data[pos:end], end + bytes.Index(data[end:], []byte("endstream")) + len("endstream")
+			}
+		}
+	}
+
+	idx := bytes.Index(data[pos:], []byte("endstream"))
+	if idx < 0 {
+		return data[pos:], len(data)
+	}
+	return bytes.TrimRight(data[pos:pos+idx], "\r\n"), pos + idx + len("endstream")
+}
+
+// expandObjectStreams loads objects packed in /Type /ObjStm streams (PDF 1.5+).
+func (doc *document) expandObjectStreams() {
+	nums := make([]int, 0, len(doc.objects))
+	for n := range doc.objects {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+
+	for _, n := range nums {
+		s, ok := doc.objects[n].(*stream)
+		if !ok || s.dict["Type"] != name("ObjStm") {
+			continue
+		}
+		data := doc.decode(s)
+		if data == nil {
+			continue
+		}
+		count, _ := s.dict["N"].(float64)
+		first, _ := s.dict["First"].(float64)
+		if int(first) > len(data) {
+			continue
+		}
+
+		hdr := &lexer{b: data[:int(first)]}
+		for i := 0; i < int(count); i++ {
+			t1, t2 := hdr.next(), hdr.next()
+			if t1.kind != tokNumber || t2.kind != tokNumber {
+				break
+			}
+			objNum, off := int(t1.num), int(first)+int(t2.num)
+			if off >= len(data) {
+				continue
+			}
+			if _, exists := doc.objects[objNum]; exists {
+				continue
+			}
+			p := &parser{lex: &lexer{b: data, pos: off}}
+			if v, ok := p.parseValue(0); ok {
+				doc.objects[objNum] = v
+			}
+		}
+	}
+}
+
+// resolve follows indirect references.
+func (doc *document) resolve(v interface{}) interface{} {
+	for i := 0; i < 16; i++ {
+		r, ok := v.(ref)
+		if !ok {
+			return v
+		}
+		v = doc.objects[r.num]
+	}
+	return nil
+}
+
+func (doc *document) dictOf(v interface{}) dict {
+	switch x := doc.resolve(v).(type) {
+	case dict:
+		return x
+	case *stream:
+		return x.dict
+	}
+	return nil
+}
+
+// decode returns the decompressed stream contents, or nil for unsupported filters.
+func (doc *document) decode(s *stream) []byte {
+	var filters []name
+	switch f := doc.resolve(s.dict["Filter"]).(type) {
+	case name:
+		filters = []name{f}
+	case array:
+		for _, x := range f {
+			if n, ok := doc.resolve(x).(name); ok {
+				filters = append(filters, n)
+			}
+		}
+	}
+
+	if doc.ctx.Err() != nil {
+		return nil
+	}
+	data := s.raw
+	for _, f := range filters {
+		switch f {
+		case "FlateDecode", "Fl":
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil
+			}
+			// Truncated streams are common; keep whatever decompressed cleanly.
+			out, _ := io.ReadAll(io.LimitReader(ctxReader{doc.ctx, r}, maxDecodedStream))
+			r.Close()
+			if len(out) == 0 {
+				return nil
+			}
+			data = out
+		case "ASCIIHexDecode", "AHx":
+			data = (&lexer{b: append(append([]byte{'<'}, data...), '>')}).readHexString()
+		default:
+			return nil
+		}
+	}
+	return data
+}
+
+// pages returns page dictionaries in document order, with inherited /Resources applied.
+func (doc *document) pages() []dict {
+	var root dict
+	for _, v := range doc.objects {
+		if d := doc.dictOf(v); d != nil && d["Type"] == name("Catalog") {
+			root = d
+			break
+		}
+	}
+
+	var out []dict
+	if root != nil {
+		seen := map[int]bool{}
+		doc.walkPages(root["Pages"], nil, seen, &out, 0)
+	}
+	if len(out) > 0 {
+		return out
+	}
+
+	// No usable page tree: fall back to every /Type /Page object in object order.
+	nums := make([]int, 0, len(doc.objects))
+	for n := range doc.objects {
+		nums = append(nums, n)
+	}
+	sort.Ints(nums)
+	for _, n := range nums {
+		if d := doc.dictOf(doc.objects[n]); d != nil && d["Type"] == name("Page") {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func (doc *document) walkPages(node interface{}, inherited interface{}, seen map[int]bool, out *[]dict, depth int) {
+	if depth > 64 {
+		return
+	}
+	if r, ok := node.(ref); ok {
+		if seen[r.num] {
+			return
+		}
+		seen[r.num] = true
+	}
+	d := doc.dictOf(node)
+	if d == nil {
+		return
+	}
+	if res, ok := d["Resources"]; ok {
+		inherited = res
+	}
+	if d["Type"] == name("Page") || d["Kids"] == nil {
+		page := dict{}
+		for k, v := range d {
+			page[k] = v
+		}
+		if _, ok := page["Resources"]; !ok && inherited != nil {
+			page["Resources"] = inherited
+		}
+		*out = append(*out, page)
+		return
+	}
+	kids, _ := doc.resolve(d["Kids"]).(array)
+	for _, k := range kids {
+		doc.walkPages(k, inherited, seen, out, depth+1)
+	}
+}
+
+// pageContent concatenates the page's content streams.
+func (doc *document) pageContent(page dict) []byte {
+	var parts [][]byte
+	switch c := doc.resolve(page["Contents"]).(type) {
+	case *stream:
+		parts = append(parts, doc.decode(c))
+	case array:
+		for _, x := range c {
+			if s, ok := doc.resolve(x).(*stream); ok {
+				parts = append(parts, doc.decode(s))
+			}
+		}
+	}
+	return bytes.Join(parts, []byte("\n"))
+}
+
+// ctxReader fails reads once ctx is done, so that a long decompression
+// stops part way.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,212 @@
+package pdftext
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// font maps character codes in shown strings to Unicode text.
+type font struct {
+	toUnicode map[uint32]string // from the /ToUnicode CMap
+	codeLen   int               // bytes per code (2 for composite fonts)
+	diffs     map[int]string    // glyph names from /Encoding /Differences
+}
+
+func (doc *document) loadFont(v interface{}) *font {
+	d := doc.dictOf(v)
+	if d == nil {
+		return &font{codeLen: 1}
+	}
+	f := &font{codeLen: 1}
+	if d["Subtype"] == name("Type0") {
+		f.codeLen = 2
+	}
+
+	if s, ok := doc.resolve(d["ToUnicode"]).(*stream); ok {
+		if data := doc.decode(s); data != nil {
+			cmap, codeLen := parseCMap(data)
+			if len(cmap) > 0 {
+				f.toUnicode = cmap
+				if codeLen > 0 {
+					f.codeLen = codeLen
+				}
+			}
+		}
+	}
+
+	if enc := doc.dictOf(d["Encoding"]); enc != nil {
+		if diffs, ok := doc.resolve(enc["Differences"]).(array); ok {
+			f.diffs = map[int]string{}
+			code := 0
+			for _, x := range diffs {
+				switch v := doc.resolve(x).(type) {
+				case float64:
+					code = int(v)
+				case name:
+					f.diffs[code] = string(v)
+					code++
+				}
+			}
+		}
+	}
+	return f
+}
+
+// decode converts the bytes of a shown string to text.
+func (f *font) decode(s []byte) string {
+	var b strings.Builder
+	if f.codeLen == 2 {
+		for i := 0; i+1 < len(s); i += 2 {
+			code := uint32(s[i])<<8 | uint32(s[i+1])
+			if u, ok := f.toUnicode[code]; ok {
+				b.WriteString(u)
+			}
+			// Composite fonts without a mapping cannot be decoded reliably; drop the glyph.
+		}
+		return b.String()
+	}
+
+	for _, c := range s {
+		if u, ok := f.toUnicode[uint32(c)]; ok {
+			b.WriteString(u)
+			continue
+		}
+		if gn, ok := f.diffs[int(c)]; ok {
+			if u := glyphToUnicode(gn); u != "" {
+				b.WriteString(u)
+				continue
+			}
+		}
+		switch {
+		case c == 0x27:
+			b.WriteRune('’')
+		case c == 0x60:
+			b.WriteRune('‘')
+		case c >= 0x20 && c < 0x7f:
+			b.WriteByte(c)
+		case c >= 0xa0:
+			b.WriteRune(rune(c)) // Latin-1 range of PDFDocEncoding
+		}
+	}
+	return b.String()
+}
+
+// glyphNames covers glyphs that commonly appear in /Differences arrays of scientific papers.
+var glyphNames = map[string]string{
+	"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl",
+	"quoteright": "’", "quoteleft": "‘", "quotedblleft": "“", "quotedblright": "”",
+	"endash": "–", "emdash": "—", "bullet": "•", "hyphen": "-", "minus": "−",
+	"space": " ", "period": ".", "comma": ",", "colon": ":", "semicolon": ";",
+	"parenleft": "(", "parenright": ")", "bracketleft": "[", "bracketright": "]",
+	"slash": "/", "question": "?", "exclam": "!", "quotesingle": "'", "quotedbl": "\"",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"plus": "+", "equal": "=", "percent": "%", "ampersand": "&", "asterisk": "*",
+	"dieresis": "¨", "acute": "´", "grave": "`", "circumflex": "^", "tilde": "~",
+}
+
+func glyphToUnicode(gn string) string {
+	if u, ok := glyphNames[gn]; ok {
+		return u
+	}
+	if len(gn) == 1 {
+		return gn
+	}
+	if strings.HasPrefix(gn, "uni") && len(gn) == 7 {
+		if v, err := strconv.ParseUint(gn[3:], 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	return ""
+}
+
+// parseCMap reads bfchar/bfrange mappings from a ToUnicode CMap.
+// It returns the mapping and the code length in bytes from the codespace range.
+func parseCMap(data []byte) (map[uint32]string, int) {
+	m := map[uint32]string{}
+	codeLen := 0
+	lex := &lexer{b: data}
+	p := &parser{lex: lex}
+
+	var operands []interface{}
+	for {
+		v, ok := p.parseValue(0)
+		if !ok {
+			break
+		}
+		kw, isKw := v.(keyword)
+		if !isKw {
+			operands = append(operands, v)
+			continue
+		}
+		switch kw {
+		case "endcodespacerange":
+			if len(operands) > 0 {
+				if lo, ok := operands[0].([]byte); ok && len(lo) > 0 {
+					codeLen = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok1 := operands[i].([]byte)
+				dst, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					m[bytesToCode(src)] = utf16BE(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].([]byte)
+				hi, ok2 := operands[i+1].([]byte)
+				if !ok1 || !ok2 {
+					continue
+				}
+				loC, hiC := bytesToCode(lo), bytesToCode(hi)
+				if hiC < loC || hiC-loC > 0xffff {
+					continue
+				}
+				switch dst := operands[i+2].(type) {
+				case []byte:
+					base := []rune(utf16BE(dst))
+					if len(base) == 0 {
+						continue
+					}
+					for c := loC; c <= hiC; c++ {
+						r := make([]rune, len(base))
+						copy(r, base)
+						r[len(r)-1] += rune(c - loC)
+						m[c] = string(r)
+					}
+				case array:
+					for j, x := range dst {
+						if b, ok := x.([]byte); ok && loC+uint32(j) <= hiC {
+							m[loC+uint32(j)] = utf16BE(b)
+						}
+					}
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return m, codeLen
+}
+
+func bytesToCode(b []byte) uint32 {
+	var c uint32
+	for _, x := range b {
+		c = c<<8 | uint32(x)
+	}
+	return c
+}
+
+func utf16BE(b []byte) string {
+	if len(b)%2 == 1 {
+		b = append(b, 0)
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(u))
+}
@@ -0,0 +1,327 @@
+package pdftext
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// PDF value types produced by the parser.
+type (
+	name  string
+	ref   struct{ num, gen int }
+	dict  map[name]interface{}
+	array []interface{}
+	// keyword is a bare token: an operator in content streams, or obj/endobj/R/stream in the file body.
+	keyword string
+)
+
+type stream struct {
+	dict dict
+	raw  []byte
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokName
+	tokString
+	tokKeyword
+	tokArrayStart
+	tokArrayEnd
+	tokDictStart
+	tokDictEnd
+)
+
+type token struct {
+	kind tokenKind
+	num  float64
+	str  string // name or keyword
+	raw  []byte // string bytes
+}
+
+// lexer tokenizes PDF object syntax. It is also used for content streams and CMaps,
+// which share the same token grammar.
+type lexer struct {
+	b   []byte
+	pos int
+}
+
+func isWhite(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func isDelim(c byte) bool {
+	switch c {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func (l *lexer) skipWhite() {
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		if isWhite(c) {
+			l.pos++
+			continue
+		}
+		if c == '%' {
+			for l.pos < len(l.b) && l.b[l.pos] != '\n' && l.b[l.pos] != '\r' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+}
+
+func (l *lexer) next() token {
+	l.skipWhite()
+	if l.pos >= len(l.b) {
+		return token{kind: tokEOF}
+	}
+	c := l.b[l.pos]
+	switch {
+	case c == '[':
+		l.pos++
+		return token{kind: tokArrayStart}
+	case c == ']':
+		l.pos++
+		return token{kind: tokArrayEnd}
+	case c == '<' && l.pos+1 < len(l.b) && l.b[l.pos+1] == '<':
+		l.pos += 2
+		return token{kind: tokDictStart}
+	case c == '>' && l.pos+1 < len(l.b) && l.b[l.pos+1] == '>':
+		l.pos += 2
+		return token{kind: tokDictEnd}
+	case c == '<':
+		return token{kind: tokString, raw: l.readHexString()}
+	case c == '(':
+		return token{kind: tokString, raw: l.readLiteralString()}
+	case c == '/':
+		l.pos++
+		return token{kind: tokName, str: l.readName()}
+	case c == '{' || c == '}' || c == ')' || c == '>':
+		l.pos++
+		return token{kind: tokKeyword, str: string(c)}
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := l.pos
+		l.pos++
+		for l.pos < len(l.b) {
+			d := l.b[l.pos]
+			if (d >= '0' && d <= '9') || d == '.' || d == '-' {
+				l.pos++
+				continue
+			}
+			break
+		}
+		f, err := strconv.ParseFloat(string(l.b[start:l.pos]), 64)
+		if err != nil {
+			return token{kind: tokKeyword, str: string(l.b[start:l.pos])}
+		}
+		return token{kind: tokNumber, num: f}
+	}
+
+	start := l.pos
+	for l.pos < len(l.b) && !isWhite(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+		l.pos++
+	}
+	if l.pos == start {
+		l.pos++ // unknown byte; skip it
+	}
+	return token{kind: tokKeyword, str: string(l.b[start:l.pos])}
+}
+
+func (l *lexer) readName() string {
+	var buf bytes.Buffer
+	for l.pos < len(l.b) && !isWhite(l.b[l.pos]) && !isDelim(l.b[l.pos]) {
+		c := l.b[l.pos]
+		if c == '#' && l.pos+2 < len(l.b) {
+			if v, err := strconv.ParseUint(string(l.b[l.pos+1:l.pos+3]), 16, 8); err == nil {
+				buf.WriteByte(byte(v))
+				l.pos += 3
+				continue
+			}
+		}
+		buf.WriteByte(c)
+		l.pos++
+	}
+	return buf.String()
+}
+
+func (l *lexer) readHexString() []byte {
+	l.pos++ // '<'
+	var out []byte
+	var hi byte
+	half := false
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		l.pos++
+		if c == '>' {
+			break
+		}
+		v, ok := hexVal(c)
+		if !ok {
+			continue
+		}
+		if half {
+			out = append(out, hi<<4|v)
+		} else {
+			hi = v
+		}
+		half = !half
+	}
+	if half {
+		out = append(out, hi<<4)
+	}
+	return out
+}
+
+func hexVal(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+func (l *lexer) readLiteralString() []byte {
+	l.pos++ // '('
+	var out []byte
+	depth := 1
+	for l.pos < len(l.b) {
+		c := l.b[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return out
+			}
+			out = append(out, c)
+		case '\\':
+			if l.pos >= len(l.b) {
+				return out
+			}
+			e := l.b[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if l.pos < len(l.b) && l.b[l.pos] == '\n' {
+					l.pos++
+				}
+			case '\n':
+				// line continuation
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.b) && l.b[l.pos] >= '0' && l.b[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.b[l.pos]-'0')
+						l.pos++
+					}
+					out = append(out, byte(v))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// parser builds PDF values from lexer tokens.
+type parser struct {
+	lex *lexer
+}
+
+// parseValue reads one value. Bare keywords are returned as keyword values.
+func (p *parser) parseValue(depth int) (interface{}, bool) {
+	if depth > 64 {
+		return nil, false
+	}
+	t := p.lex.next()
+	switch t.kind {
+	case tokEOF:
+		return nil, false
+	case tokNumber:
+		// Look ahead for an indirect reference: "num gen R"
+		save := p.lex.pos
+		t2 := p.lex.next()
+		if t2.kind == tokNumber {
+			t3 := p.lex.next()
+			if t3.kind == tokKeyword && t3.str == "R" {
+				return ref{num: int(t.num), gen: int(t2.num)}, true
+			}
+		}
+		p.lex.pos = save
+		return t.num, true
+	case tokName:
+		return name(t.str), true
+	case tokString:
+		return t.raw, true
+	case tokArrayStart:
+		var arr array
+		for {
+			save := p.lex.pos
+			if nt := p.lex.next(); nt.kind == tokArrayEnd || nt.kind == tokEOF {
+				return arr, true
+			}
+			p.lex.pos = save
+			v, ok := p.parseValue(depth + 1)
+			if !ok {
+				return arr, true
+			}
+			arr = append(arr, v)
+		}
+	case tokDictStart:
+		d := dict{}
+		for {
+			kt := p.lex.next()
+			if kt.kind == tokDictEnd || kt.kind == tokEOF {
+				return d, true
+			}
+			if kt.kind != tokName {
+				continue
+			}
+			v, ok := p.parseValue(depth + 1)
+			if !ok {
+				return d, true
+			}
+			d[name(kt.str)] = v
+		}
+	case tokArrayEnd, tokDictEnd:
+		return keyword(""), true
+	case tokKeyword:
+		switch t.str {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		case "null":
+			return nil, true
+		}
+		return keyword(t.str), true
+	}
+	return nil, false
+}
@@ -0,0 +1,270 @@
+// Package pdftext extracts plain text from PDF files in pure Go.
+//
+// It targets the PDFs we ingest (arXiv/pdfTeX and publisher PDFs): Flate-compressed
+// content streams, object streams, simple fonts with /Differences and composite
+// fonts with ToUnicode CMaps. Layout is approximated from text positioning
+// operators; encrypted PDFs and scanned images yield no text.
+package pdftext
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrNotPDF    = errors.New("not a PDF file")
+	ErrEncrypted = errors.New("encrypted PDF")
+	ErrNoText    = errors.New("no extractable text")
+)
+
+// Result is the text extracted from one PDF.
+type Result struct {
+	Pages []string // text per page
+	Text  string   // all pages, normalized
+}
+
+// Extract returns the text of a PDF.
+func Extract(data []byte) (*Result, error) {
+	return ExtractContext(context.Background(), data)
+}
+
+// ExtractContext is Extract that gives up with ctx's error once ctx is
+// done. It is checked while parsing, decompressing and interpreting content,
+// so that one pathological stream can't outlast it.
+func ExtractContext(ctx context.Context, data []byte) (*Result, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data[:min(len(data), 1024)], "\x00\r\n\t "), []byte("%PDF-")) {
+		return nil, ErrNotPDF
+	}
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return nil, ErrEncrypted
+	}
+
+	doc := parseDocument(ctx, data)
+	res := &Result{}
+	fontCache := map[interface{}]*font{}
+
+	for _, page := range doc.pages() {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		fonts := map[string]*font{}
+		if resDict := doc.dictOf(page["Resources"]); resDict != nil {
+			if fontDict := doc.dictOf(resDict["Font"]); fontDict != nil {
+				for fname, fv := range fontDict {
+					key := fv
+					if _, isRef := fv.(ref); !isRef {
+						key = nil // inline font dicts are not cacheable
+					}
+					if f, ok := fontCache[key]; ok && key != nil {
+						fonts[string(fname)] = f
+						continue
+					}
+					f := doc.loadFont(fv)
+					if key != nil {
+						fontCache[key] = f
+					}
+					fonts[string(fname)] = f
+				}
+			}
+		}
+
+		content := doc.pageContent(page)
+		if len(content) == 0 {
+			res.Pages = append(res.Pages, "")
+			continue
+		}
+		text, err := runContent(ctx, content, fonts)
+		if err != nil {
+			return nil, err
+		}
+		res.Pages = append(res.Pages, normalize(text))
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	res.Text = strings.TrimSpace(strings.Join(res.Pages, "\n\n"))
+	if res.Text == "" {
+		return res, ErrNoText
+	}
+	return res, nil
+}
+
+// ctxCheckEvery is how many content stream values runContent reads between
+// checks of its context.
+const ctxCheckEvery = 4096
+
+// runContent interprets the text operators of a content stream, until ctx
+// is done.
+func runContent(ctx context.Context, content []byte, fonts map[string]*font) (string, error) {
+	var out strings.Builder
+	lex := &lexer{b: content}
+	p := &parser{lex: lex}
+
+	cur := &font{codeLen: 1}
+	var operands []interface{}
+	lastY := math.NaN()
+
+	newline := func() {
+		s := out.String()
+		if len(s) > 0 && !strings.HasSuffix(s, "\n") {
+			out.WriteByte('\n')
+		}
+	}
+	space := func() {
+		s := out.String()
+		if len(s) > 0 && !strings.HasSuffix(s, " ") && !strings.HasSuffix(s, "\n") {
+			out.WriteByte(' ')
+		}
+	}
+	num := func(i int) float64 {
+		if i < len(operands) {
+			if f, ok := operands[i].(float64); ok {
+				return f
+			}
+		}
+		return 0
+	}
+	show := func(v interface{}) {
+		if s, ok := v.([]byte); ok {
+			out.WriteString(cur.decode(s))
+		}
+	}
+
+	for n := 1; ; n++ {
+		if n%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return "", err
+			}
+		}
+		v, ok := p.parseValue(0)
+		if !ok {
+			break
+		}
+		op, isOp := v.(keyword)
+		if !isOp {
+			operands = append(operands, v)
+			continue
+		}
+
+		switch op {
+		case "BT":
+			lastY = math.NaN()
+		case "ET":
+			space()
+		case "Tf":
+			if len(operands) >= 1 {
+				if n, ok := operands[0].(name); ok {
+					if f, ok := fonts[string(n)]; ok {
+						cur = f
+					} else {
+						cur = &font{codeLen: 1}
+					}
+				}
+			}
+		case "Td", "TD":
+			tx, ty := num(0), num(1)
+			if ty != 0 {
+				newline()
+			} else if tx > 0 {
+				space()
+			}
+		case "Tm":
+			y := num(5)
+			if !math.IsNaN(lastY) && math.Abs(y-lastY) > 0.5 {
+				newline()
+			} else {
+				space()
+			}
+			lastY = y
+		case "T*":
+			newline()
+		case "Tj":
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "'", "\"":
+			newline()
+			if len(operands) > 0 {
+				show(operands[len(operands)-1])
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				if arr, ok := operands[len(operands)-1].(array); ok {
+					for _, x := range arr {
+						switch e := x.(type) {
+						case []byte:
+							show(e)
+						case float64:
+							// Large negative kerning (in thousandths of an em) is an inter-word gap.
+							if e < -200 {
+								space()
+							}
+						}
+					}
+				}
+			}
+		case "BI":
+			skipInlineImage(lex)
+		}
+		operands = operands[:0]
+	}
+	return out.String(), nil
+}
+
+// skipInlineImage advances past inline image data (BI ... ID <binary> EI).
+func skipInlineImage(lex *lexer) {
+	idx := bytes.Index(lex.b[lex.pos:], []byte("ID"))
+	if idx < 0 {
+		lex.pos = len(lex.b)
+		return
+	}
+	lex.pos += idx + 2
+	for lex.pos < len(lex.b) {
+		i := bytes.Index(lex.b[lex.pos:], []byte("EI"))
+		if i < 0 {
+			lex.pos = len(lex.b)
+			return
+		}
+		at := lex.pos + i
+		before := at == 0 || isWhite(lex.b[at-1])
+		after := at+2 >= len(lex.b) || isWhite(lex.b[at+2])
+		lex.pos = at + 2
+		if before && after {
+			return
+		}
+	}
+}
+
+var (
+	multiSpaceRe = regexp.MustCompile(`[ \t]+`)
+	hyphenWrapRe = regexp.MustCompile(`(\p{L})-\n(\p{Ll})`)
+	manyNLRe     = regexp.MustCompile(`\n{3,}`)
+)
+
+// normalize collapses whitespace and rejoins words hyphenated across lines.
+func normalize(s string) string {
+	s = strings.ReplaceAll(s, "\r", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == '\u00a0' {
+			return ' '
+		}
+		if r < 0x20 && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	s = multiSpaceRe.ReplaceAllString(s, " ")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	s = strings.Join(lines, "\n")
+	s = hyphenWrapRe.ReplaceAllString(s, "$1$2")
+	s = manyNLRe.ReplaceAllString(s, "\n\n")
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,141 @@
+package pdftext
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Section is a titled span of paper text.
+type Section struct {
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// FrontMatter is the title given to text before the first recognized heading
+// (title block, author list, and usually the abstract).
+const FrontMatter = "Front Matter"
+
+var (
+	// "3", "3.", "3.2", "IV.", "A." followed by a heading title
+	numberedHeadingRe = regexp.MustCompile(`^((?:\d+(?:\.\d+)*|[IVX]+|[A-H])\.?)\s+(\p{Lu}[\p{L}\p{N} ,:&/'()\-]{1,78})$`)
+	bareHeadingRe     = regexp.MustCompile(`^(\p{Lu}[\p{L} &\-]{2,40})$`)
+)
+
+// knownSections are headings recognized even without numbering.
+var knownSections = map[string]bool{
+	"abstract": true, "introduction": true, "background": true, "related work": true,
+	"preliminaries": true, "method": true, "methods": true, "methodology": true,
+	"approach": true, "model": true, "experiments": true, "experimental setup": true,
+	"experimental results": true, "evaluation": true, "results": true, "discussion": true,
+	"analysis": true, "limitations": true, "conclusion": true, "conclusions": true,
+	"future work": true, "acknowledgments": true, "acknowledgements": true,
+	"acknowledgment": true, "acknowledgement": true, "references": true,
+	"bibliography": true, "appendix": true, "supplementary material": true,
+	"materials and methods": true, "results and discussion": true,
+}
+
+// SplitSections splits extracted text at detected section headings.
+func SplitSections(text string) []Section {
+	var sections []Section
+	cur := Section{Title: FrontMatter}
+	var body []string
+
+	flush := func() {
+		cur.Text = strings.TrimSpace(strings.Join(body, "\n"))
+		if cur.Text != "" || cur.Title != FrontMatter {
+			sections = append(sections, cur)
+		}
+		body = body[:0]
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if title, ok := headingTitle(line); ok {
+			flush()
+			cur = Section{Title: title}
+			continue
+		}
+		// "Abstract—We propose ..." style run-in headings
+		if rest, ok := runInAbstract(line); ok && len(sections) == 0 && cur.Title == FrontMatter {
+			flush()
+			cur = Section{Title: "Abstract"}
+			body = append(body, rest)
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return sections
+}
+
+func headingTitle(line string) (string, bool) {
+	if len(line) < 4 || len(line) > 90 || strings.HasSuffix(line, ".") || strings.HasSuffix(line, ",") {
+		return "", false
+	}
+	// Table-of-contents entries use dot leaders
+	if strings.Contains(line, ":::") || strings.Contains(line, "....") || strings.Contains(line, ". . .") {
+		return "", false
+	}
+	if m := numberedHeadingRe.FindStringSubmatch(line); m != nil {
+		title := strings.TrimSpace(m[2])
+		words := strings.Fields(title)
+		if len(words) == 0 || len(words) > 10 {
+			return "", false
+		}
+		// Numbered lines in running text ("3 We then ...") start with a verb or pronoun;
+		// headings are known section names or title-cased.
+		if knownSections[strings.ToLower(title)] || isTitleCase(words) {
+			return title, true
+		}
+		return "", false
+	}
+	if bareHeadingRe.MatchString(line) && knownSections[strings.ToLower(line)] {
+		return normalizeTitle(line), true
+	}
+	return "", false
+}
+
+func runInAbstract(line string) (string, bool) {
+	lower := strings.ToLower(line)
+	if !strings.HasPrefix(lower, "abstract") || len(line) < 20 {
+		return "", false
+	}
+	// Require punctuation after the word so "Abstract Syntax Notation" is not a heading.
+	after := strings.TrimLeft(line[len("abstract"):], " ")
+	if strings.IndexAny(after, ".:—–-") != 0 {
+		return "", false
+	}
+	rest := strings.TrimLeft(after, " .:—–-")
+	if rest == "" {
+		return "", false
+	}
+	return rest, true
+}
+
+// isTitleCase reports whether most significant words are capitalized.
+func isTitleCase(words []string) bool {
+	caps, significant := 0, 0
+	for _, w := range words {
+		if len(w) <= 3 {
+			continue // skip short function words: of, and, the, for
+		}
+		significant++
+		r := []rune(w)
+		if r[0] >= 'A' && r[0] <= 'Z' {
+			caps++
+		}
+	}
+	if significant == 0 {
+		return true
+	}
+	return caps*2 >= significant
+}
+
+// normalizeTitle turns "INTRODUCTION" into "Introduction".
+func normalizeTitle(s string) string {
+	if strings.ToUpper(s) != s {
+		return s
+	}
+	lower := strings.ToLower(s)
+	return strings.ToUpper(lower[:1]) + lower[1:]
+}
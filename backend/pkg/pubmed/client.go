@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/paper-app/backend/internal/domain"
@@ -16,10 +17,24 @@ import (
 const (
 	esearchURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/esearch.fcgi"
 	efetchURL  = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/efetch.fcgi"
+
+	// NCBI allows 3 requests/second per IP, or 10 with an API key
+	minInterval        = 340 * time.Millisecond
+	minIntervalWithKey = 100 * time.Millisecond
+
+	// MaxHistoryRecords is how far into one search result E-utilities will
+	// page, history server or not. Split larger queries by date.
+	MaxHistoryRecords = 9999
 )
 
 type Client struct {
 	httpClient *http.Client
+	apiKey     string
+	email      string
+
+	mu       sync.Mutex
+	interval time.Duration
+	lastCall time.Time
 }
 
 func NewClient() *Client {
@@ -27,9 +42,33 @@ func NewClient() *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		interval: minInterval,
+	}
+}
+
+// SetAPIKey sends an NCBI API key with every request, which raises the
+// rate limit from 3 to 10 requests per second.
+func (c *Client) SetAPIKey(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiKey = key
+	c.interval = minInterval
+	if key != "" {
+		c.interval = minIntervalWithKey
 	}
 }
 
+// SetEmail identifies us to NCBI (tool/email parameters), so they can get in
+// touch before blocking a misbehaving harvester.
+func (c *Client) SetEmail(email string) {
+	c.email = email
+}
+
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
 type SearchResult struct {
 	Papers       []*domain.Paper
 	TotalResults int
@@ -37,9 +76,12 @@ type SearchResult struct {
 
 // ESearch response types
 type ESearchResult struct {
-	XMLName xml.Name `xml:"eSearchResult"`
-	Count   int      `xml:"Count"`
-	IDList  IDList   `xml:"IdList"`
+	XMLName  xml.Name `xml:"eSearchResult"`
+	Count    int      `xml:"Count"`
+	IDList   IDList   `xml:"IdList"`
+	WebEnv   string   `xml:"WebEnv"`
+	QueryKey string   `xml:"QueryKey"`
+	Error    string   `xml:"ERROR"`
 }
 
 type IDList struct {
@@ -58,8 +100,23 @@ type PubmedArticle struct {
 }
 
 type MedlineCitation struct {
-	PMID    PMID    `xml:"PMID"`
-	Article Article `xml:"Article"`
+	PMID         PMID          `xml:"PMID"`
+	Article      Article       `xml:"Article"`
+	MeshHeadings []MeshHeading `xml:"MeshHeadingList>MeshHeading"`
+	Keywords     []string      `xml:"KeywordList>Keyword"`
+}
+
+// MeshHeading is one MeSH descriptor with its optional qualifiers, e.g.
+// "Neoplasms" / "drug therapy".
+type MeshHeading struct {
+	Descriptor MeshTerm   `xml:"DescriptorName"`
+	Qualifiers []MeshTerm `xml:"QualifierName"`
+}
+
+type MeshTerm struct {
+	UI         string `xml:"UI,attr"`
+	MajorTopic string `xml:"MajorTopicYN,attr"`
+	Name       string `xml:",chardata"`
 }
 
 type PMID struct {
@@ -146,17 +203,10 @@ func (c *Client) Search(query string, limit, offset int) (*SearchResult, error)
 	params.Set("sort", "relevance")
 	params.Set("retmode", "xml")
 
-	searchURL := fmt.Sprintf("%s?%s", esearchURL, params.Encode())
-	resp, err := c.httpClient.Get(searchURL)
+	body, err := c.get(esearchURL, params)
 	if err != nil {
 		return nil, fmt.Errorf("pubmed esearch request failed: %w", err)
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read esearch response: %w", err)
-	}
 
 	var searchResult ESearchResult
 	if err := xml.Unmarshal(body, &searchResult); err != nil {
@@ -200,16 +250,73 @@ func (c *Client) fetchArticles(pmids []string) ([]*domain.Paper, error) {
 	params.Set("retmode", "xml")
 	params.Set("rettype", "abstract")
 
-	fetchURL := fmt.Sprintf("%s?%s", efetchURL, params.Encode())
-	resp, err := c.httpClient.Get(fetchURL)
+	return c.efetch(params)
+}
+
+// HistorySearch is a search result stored on the NCBI history server, to
+// be fetched page by page with FetchHistory. WebEnv expires after a few
+// hours of inactivity.
+type HistorySearch struct {
+	Count    int
+	WebEnv   string
+	QueryKey string
+}
+
+// SearchHistory runs term restricted to [from, to] (inclusive days) on
+// dateType ("edat" = added to PubMed, "mdat" = last modified, "pdat" =
+// published) and keeps the result on the history server. An empty term
+// matches every record in the range.
+func (c *Client) SearchHistory(term string, from, to time.Time, dateType string) (*HistorySearch, error) {
+	if term == "" {
+		term = "all[sb]"
+	}
+	params := url.Values{}
+	params.Set("db", "pubmed")
+	params.Set("term", term)
+	params.Set("usehistory", "y")
+	params.Set("retmax", "0")
+	params.Set("datetype", dateType)
+	params.Set("mindate", from.Format("2006/01/02"))
+	params.Set("maxdate", to.Format("2006/01/02"))
+	params.Set("retmode", "xml")
+
+	body, err := c.get(esearchURL, params)
 	if err != nil {
-		return nil, fmt.Errorf("pubmed efetch request failed: %w", err)
+		return nil, fmt.Errorf("pubmed esearch request failed: %w", err)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	var res ESearchResult
+	if err := xml.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("failed to parse esearch response: %w", err)
+	}
+	if res.Error != "" {
+		return nil, fmt.Errorf("pubmed esearch: %s", res.Error)
+	}
+	if res.Count > 0 && res.WebEnv == "" {
+		return nil, fmt.Errorf("pubmed esearch returned no WebEnv")
+	}
+	return &HistorySearch{Count: res.Count, WebEnv: res.WebEnv, QueryKey: res.QueryKey}, nil
+}
+
+// FetchHistory fetches records [retstart, retstart+retmax) of a history
+// search. Records beyond MaxHistoryRecords are not retrievable.
+func (c *Client) FetchHistory(h *HistorySearch, retstart, retmax int) ([]*domain.Paper, error) {
+	params := url.Values{}
+	params.Set("db", "pubmed")
+	params.Set("WebEnv", h.WebEnv)
+	params.Set("query_key", h.QueryKey)
+	params.Set("retstart", fmt.Sprintf("%d", retstart))
+	params.Set("retmax", fmt.Sprintf("%d", retmax))
+	params.Set("retmode", "xml")
+	params.Set("rettype", "abstract")
+
+	return c.efetch(params)
+}
+
+func (c *Client) efetch(params url.Values) ([]*domain.Paper, error) {
+	body, err := c.get(efetchURL, params)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read efetch response: %w", err)
+		return nil, fmt.Errorf("pubmed efetch request failed: %w", err)
 	}
 
 	var articleSet PubmedArticleSet
@@ -228,6 +335,46 @@ func (c *Client) fetchArticles(pmids []string) ([]*domain.Paper, error) {
 	return papers, nil
 }
 
+// get issues one E-utilities request, waiting out the rate limit first.
+func (c *Client) get(endpoint string, params url.Values) ([]byte, error) {
+	c.mu.Lock()
+	if wait := c.interval - time.Since(c.lastCall); wait > 0 {
+		time.Sleep(wait)
+	}
+	c.lastCall = time.Now()
+	apiKey := c.apiKey
+	c.mu.Unlock()
+
+	if apiKey != "" {
+		params.Set("api_key", apiKey)
+	}
+	if c.email != "" {
+		params.Set("tool", "PaperApp")
+		params.Set("email", c.email)
+	}
+
+	resp, err := c.httpClient.Get(fmt.Sprintf("%s?%s", endpoint, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return nil, fmt.Errorf("rate limited (429)")
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(body) > 300 {
+			body = body[:300]
+		}
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
 func articleToPaper(article *PubmedArticle) *domain.Paper {
 	pmid := article.MedlineCitation.PMID.Value
 	if pmid == "" {
@@ -309,16 +456,61 @@ func articleToPaper(article *PubmedArticle) *domain.Paper {
 		metadata["pmc_id"] = pmcID
 		metadata["html_url"] = fmt.Sprintf("https://www.ncbi.nlm.nih.gov/pmc/articles/%s/", pmcID)
 	}
+	categories := meshCategories(article.MedlineCitation.MeshHeadings)
+	if len(article.MedlineCitation.MeshHeadings) > 0 {
+		mesh := make([]string, 0, len(article.MedlineCitation.MeshHeadings))
+		for _, h := range article.MedlineCitation.MeshHeadings {
+			mesh = append(mesh, h.Descriptor.UI)
+		}
+		metadata["mesh_ids"] = mesh
+	}
+	if len(article.MedlineCitation.Keywords) > 0 {
+		metadata["keywords"] = article.MedlineCitation.Keywords
+	}
 	metadataJSON, _ := json.Marshal(metadata)
 
+	primaryCategory := ""
+	if len(categories) > 0 {
+		primaryCategory = categories[0]
+	}
+
 	return &domain.Paper{
-		ExternalID:    pmid,
-		Source:        "pubmed",
-		Title:         strings.TrimSpace(article.MedlineCitation.Article.ArticleTitle),
-		Abstract:      abstract,
-		Authors:       authorsJSON,
-		PublishedDate: publishedDate,
-		PDFURL:        pdfURL,
-		Metadata:      metadataJSON,
+		ExternalID:      pmid,
+		Source:          "pubmed",
+		Title:           strings.TrimSpace(article.MedlineCitation.Article.ArticleTitle),
+		Abstract:        abstract,
+		Authors:         authorsJSON,
+		PublishedDate:   publishedDate,
+		PDFURL:          pdfURL,
+		Metadata:        metadataJSON,
+		DOI:             strings.ToLower(strings.TrimSpace(doi)),
+		JournalRef:      strings.TrimSpace(article.MedlineCitation.Article.Journal.Title),
+		PrimaryCategory: primaryCategory,
+		Categories:      categories,
+	}
+}
+
+// meshCategories turns MeSH descriptors into paper categories: major topics
+// first (the article's main subject), then the rest, without duplicates.
+func meshCategories(headings []MeshHeading) []string {
+	var major, minor []string
+	seen := map[string]bool{}
+	for _, h := range headings {
+		name := strings.TrimSpace(h.Descriptor.Name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		isMajor := h.Descriptor.MajorTopic == "Y"
+		for _, q := range h.Qualifiers {
+			isMajor = isMajor || q.MajorTopic == "Y"
+		}
+		if isMajor {
+			major = append(major, name)
+		} else {
+			minor = append(minor, name)
+		}
 	}
+	return append(major, minor...)
 }
@@ -0,0 +1,82 @@
+// Package ratelimit provides simple request pacing for outbound HTTP clients.
+package ratelimit
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HostLimiter spaces requests to the same host by a minimum interval.
+// Different hosts do not block each other.
+type HostLimiter struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	overrides map[string]time.Duration
+	next      map[string]time.Time
+}
+
+// NewHostLimiter returns a limiter allowing one request per interval per host.
+func NewHostLimiter(interval time.Duration) *HostLimiter {
+	return &HostLimiter{
+		interval:  interval,
+		overrides: map[string]time.Duration{},
+		next:      map[string]time.Time{},
+	}
+}
+
+// SetInterval overrides the interval for one host (e.g. "arxiv.org").
+// Subdomains of host share the override.
+func (l *HostLimiter) SetInterval(host string, interval time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[strings.ToLower(host)] = interval
+}
+
+// Wait blocks until a request to rawURL's host may proceed.
+func (l *HostLimiter) Wait(ctx context.Context, rawURL string) error {
+	host := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	host = strings.ToLower(host)
+
+	l.mu.Lock()
+	interval := l.intervalFor(host)
+	now := time.Now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return nil
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (l *HostLimiter) intervalFor(host string) time.Duration {
+	for h := host; h != ""; {
+		if d, ok := l.overrides[h]; ok {
+			return d
+		}
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	return l.interval
+}
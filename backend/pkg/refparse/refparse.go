@@ -0,0 +1,366 @@
+// Package refparse splits the bibliography of a paper (as extracted by pdftext)
+// into individual references and pulls out authors, title, year, venue and
+// identifiers. It is heuristic: it handles the numbered ([1], 1.) and
+// author-year layouts used by arXiv, ACL, IEEE and NeurIPS style files.
+package refparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// Reference is one parsed bibliography entry.
+type Reference struct {
+	Raw     string   `json:"raw"`
+	Authors []string `json:"authors,omitempty"`
+	Title   string   `json:"title,omitempty"`
+	Year    int      `json:"year,omitempty"`
+	Venue   string   `json:"venue,omitempty"`
+	DOI     string   `json:"doi,omitempty"`
+	ArxivID string   `json:"arxiv_id,omitempty"`
+}
+
+// maxEntryLen guards against a split failure swallowing the rest of the section.
+const maxEntryLen = 1200
+
+var (
+	bracketMarkerRe = regexp.MustCompile(`^\[([\p{L}\p{N}+.\- ]{1,20})\]\s*`)
+	numberMarkerRe  = regexp.MustCompile(`^(\d{1,3})\.?\s+`)
+	// "Smith, J.", "van der Berg, A.", "Smith J," and "J. Smith," at the start of a line
+	authorStartRe = regexp.MustCompile(`^(?:\p{Lu}[\p{L}'’\-]+(?: \p{Ll}+)?,? (?:\p{Lu}\.|\p{Lu}[\p{Ll}\-]+,)|\p{Lu}\.(?: ?\p{Lu}\.)* \p{Lu}[\p{L}'’\-]+[,.]|(?:van|de|von|der|di|le) )`)
+
+	yearParenRe = regexp.MustCompile(`\((?:\p{L}+\.? )?((?:19|20)\d{2})[a-z]?\)`)
+	yearRe      = regexp.MustCompile(`\b((?:19|20)\d{2})[a-z]?\b`)
+	urlRe       = regexp.MustCompile(`(?i)(?:https?://|www\.)\S+`)
+	quotedRe    = regexp.MustCompile(`[“"]([^”"]{10,300}?)[,.]?[”"]`)
+	hyphenWrap  = regexp.MustCompile(`(\p{L})- (\p{Ll})`)
+	spaceRe     = regexp.MustCompile(`\s+`)
+	etAlRe      = regexp.MustCompile(`(?i)\bet\.? al\.?`)
+	venuePrefix = regexp.MustCompile(`^(?i)(?:in:?\s+|in proceedings of\s+|proceedings of\s+)`)
+	// Venue ends where volume, pages or the year begin: "Nature, 521(7553)", "CVPR, pages 1–9"
+	venueEndRe = regexp.MustCompile(`(?i)[,;:.(]?\s*(?:pages?\b|pp\.|vol(?:ume)?\b|\d+(?:[^\p{L}\d]|$))`)
+	// "Smith, J." (surname first) and "Smith J" (Vancouver) author lists
+	surnameFirstRe = regexp.MustCompile(`^\p{Lu}[\p{L}'’\-]+, (?:\p{Lu}\. ?)+`)
+	vancouverRe    = regexp.MustCompile(`^\p{Lu}[\p{L}'’\-]+ \p{Lu}{1,3}[,.]`)
+	initialsRe     = regexp.MustCompile(`^(?:\p{Lu}\.\s*[\-]?)+$`)
+	doiTextRe      = regexp.MustCompile(`(?i)\b(?:doi:?\s*)?10\.\d{4,9}/\S+`)
+	arxivTextRe    = regexp.MustCompile(`(?i)\barxiv(?: preprint)?:?\s*(?:arxiv:)?\s*[\w./-]+`)
+	andRe          = regexp.MustCompile(`,?\s+and\s+`)
+	authorSepRe    = regexp.MustCompile(`\s*[,;]\s*`)
+)
+
+// abbreviations whose trailing period does not end a sentence.
+var abbreviations = map[string]bool{
+	"proc": true, "conf": true, "vol": true, "pp": true, "no": true,
+	"eds": true, "ed": true, "int": true, "intl": true, "trans": true,
+	"univ": true, "dept": true, "inc": true, "jr": true, "st": true, "e.g": true,
+	"i.e": true, "vs": true, "comput": true, "assoc": true, "annu": true,
+	"adv": true, "inf": true, "syst": true, "mach": true, "res": true, "natl": true,
+	"acad": true, "sci": true, "phys": true, "rev": true, "lett": true, "math": true,
+	"stat": true,
+}
+
+// Split breaks the text of a references section into raw entry strings.
+func Split(text string) []string {
+	lines := nonEmptyLines(text)
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var entries []string
+	switch {
+	case countMatches(lines, bracketMarkerRe) >= 2:
+		entries = splitByMarker(lines, func(l string) (string, bool) {
+			if m := bracketMarkerRe.FindString(l); m != "" {
+				return l[len(m):], true
+			}
+			return "", false
+		})
+	case isSequentiallyNumbered(lines):
+		next := 1
+		entries = splitByMarker(lines, func(l string) (string, bool) {
+			m := numberMarkerRe.FindStringSubmatch(l)
+			if m == nil {
+				return "", false
+			}
+			if n, _ := strconv.Atoi(m[1]); n == next {
+				next++
+				return l[len(m[0]):], true
+			}
+			return "", false
+		})
+	default:
+		entries = splitAuthorYear(lines)
+	}
+
+	var out []string
+	for _, e := range entries {
+		e = clean(e)
+		if len(e) >= 20 && len(e) <= maxEntryLen {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Parse splits a references section and parses each entry.
+func Parse(text string) []Reference {
+	raws := Split(text)
+	refs := make([]Reference, 0, len(raws))
+	for _, raw := range raws {
+		refs = append(refs, ParseEntry(raw))
+	}
+	return refs
+}
+
+// ParseEntry extracts fields from a single bibliography entry.
+func ParseEntry(raw string) Reference {
+	raw = clean(raw)
+	ref := Reference{
+		Raw:     raw,
+		DOI:     paperid.FindDOI(raw),
+		ArxivID: paperid.FindArxivID(raw),
+	}
+
+	// Identifiers and URLs contain digits that look like years and periods that
+	// look like sentence ends; drop them before segmenting.
+	body := urlRe.ReplaceAllString(raw, "")
+	body = doiTextRe.ReplaceAllString(body, "")
+	body = arxivTextRe.ReplaceAllString(body, "")
+	body = strings.TrimSpace(body)
+
+	if m := yearParenRe.FindStringSubmatch(body); m != nil {
+		ref.Year, _ = strconv.Atoi(m[1])
+	} else if all := yearRe.FindAllStringSubmatch(body, -1); len(all) > 0 {
+		ref.Year, _ = strconv.Atoi(all[len(all)-1][1])
+	}
+
+	// IEEE style: A. Smith and B. Jones, “Title,” in Venue, 2020.
+	if m := quotedRe.FindStringSubmatchIndex(body); m != nil {
+		ref.Title = strings.TrimSpace(body[m[2]:m[3]])
+		ref.Authors = splitAuthors(strings.TrimRight(strings.TrimSpace(body[:m[0]]), ",."))
+		ref.Venue = cleanVenue(body[m[1]:])
+		return ref
+	}
+
+	segs := segments(body, surnameFirstRe.MatchString(body) || vancouverRe.MatchString(body))
+	if len(segs) == 0 {
+		return ref
+	}
+	ref.Authors = splitAuthors(strings.TrimSpace(yearParenRe.ReplaceAllString(segs[0], "")))
+	rest := segs[1:]
+	// ACL/APA put the year right after the authors: "Smith, J. 2020. Title."
+	for len(rest) > 0 && isYearOnly(rest[0]) {
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		ref.Title = strings.TrimSpace(rest[0])
+		rest = rest[1:]
+	}
+	if len(rest) > 0 {
+		ref.Venue = cleanVenue(strings.Join(rest, ". "))
+	}
+	return ref
+}
+
+// segments splits an entry at sentence-ending periods, skipping initials and
+// common abbreviations. In surname-first author lists an initial followed by
+// a word other than "and" or another initial ends the list.
+func segments(s string, surnameFirst bool) []string {
+	var segs []string
+	start := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] != '.' && s[i] != '?' && s[i] != '!' {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] != ' ' {
+			continue
+		}
+		if s[i] == '.' {
+			word := lastWord(s[start:i])
+			if abbreviations[strings.ToLower(word)] {
+				continue
+			}
+			if isInitial(word) && !(surnameFirst && len(segs) == 0 && endsAuthorList(s[i+1:])) {
+				continue
+			}
+		}
+		if seg := strings.TrimSpace(s[start : i+1]); seg != "" {
+			segs = append(segs, strings.TrimRight(seg, "."))
+		}
+		start = i + 1
+	}
+	if seg := strings.TrimSpace(s[start:]); seg != "" {
+		segs = append(segs, strings.TrimRight(seg, "."))
+	}
+	return segs
+}
+
+// endsAuthorList reports whether the text after an initial's period starts
+// something other than more of the author list.
+func endsAuthorList(after string) bool {
+	fields := strings.Fields(after)
+	if len(fields) == 0 {
+		return true
+	}
+	w := fields[0]
+	if w == "and" || w == "&" || strings.HasSuffix(w, ",") && len(fields) > 1 && isInitial(strings.TrimSuffix(fields[1], ".")) {
+		return false
+	}
+	return !initialsRe.MatchString(w)
+}
+
+// splitAuthors handles "A. Smith, B. Jones, and C. Lee", "Smith, A., Jones, B."
+// and "Smith A, Jones B".
+func splitAuthors(s string) []string {
+	s = etAlRe.ReplaceAllString(s, "")
+	s = strings.ReplaceAll(s, " & ", ", ")
+	s = andRe.ReplaceAllString(s, ", ")
+	parts := authorSepRe.Split(s, -1)
+
+	var authors []string
+	for _, p := range parts {
+		p = strings.TrimSpace(strings.TrimRight(p, "."))
+		if p == "" {
+			continue
+		}
+		// "Smith, J." — initials belong to the preceding surname
+		if initialsRe.MatchString(p+".") && len(authors) > 0 && !strings.Contains(authors[len(authors)-1], " ") {
+			authors[len(authors)-1] = p + ". " + authors[len(authors)-1]
+			continue
+		}
+		if first, _ := utf8.DecodeRuneInString(p); len(p) > 60 || !unicode.IsUpper(first) && !isLowerParticle(p) {
+			// Not an author list (e.g. a title-first entry)
+			return nil
+		}
+		authors = append(authors, p)
+	}
+	if len(authors) > 100 {
+		authors = authors[:100]
+	}
+	return authors
+}
+
+func cleanVenue(s string) string {
+	s = venuePrefix.ReplaceAllString(strings.Trim(s, " ,."), "")
+	if loc := venueEndRe.FindStringIndex(s); loc != nil {
+		s = s[:loc[0]]
+	}
+	s = spaceRe.ReplaceAllString(strings.Trim(s, " ,.;:()"), " ")
+	if len(s) > 200 {
+		s = s[:200]
+	}
+	return s
+}
+
+func splitByMarker(lines []string, marker func(string) (string, bool)) []string {
+	var entries []string
+	var cur []string
+	for _, l := range lines {
+		if rest, ok := marker(l); ok {
+			if len(cur) > 0 {
+				entries = append(entries, strings.Join(cur, " "))
+			}
+			cur = []string{rest}
+			continue
+		}
+		if len(cur) > 0 {
+			cur = append(cur, l)
+		}
+	}
+	if len(cur) > 0 {
+		entries = append(entries, strings.Join(cur, " "))
+	}
+	return entries
+}
+
+// splitAuthorYear starts a new entry when a line begins like an author list
+// and the previous line ended an entry.
+func splitAuthorYear(lines []string) []string {
+	var entries []string
+	var cur []string
+	for _, l := range lines {
+		prevEnded := len(cur) == 0 || strings.HasSuffix(cur[len(cur)-1], ".")
+		if prevEnded && authorStartRe.MatchString(l) && len(cur) > 0 {
+			entries = append(entries, strings.Join(cur, " "))
+			cur = nil
+		}
+		cur = append(cur, l)
+	}
+	if len(cur) > 0 {
+		entries = append(entries, strings.Join(cur, " "))
+	}
+	return entries
+}
+
+func isSequentiallyNumbered(lines []string) bool {
+	next := 1
+	for _, l := range lines {
+		if m := numberMarkerRe.FindStringSubmatch(l); m != nil {
+			if n, _ := strconv.Atoi(m[1]); n == next {
+				next++
+			}
+		}
+	}
+	return next > 3
+}
+
+func countMatches(lines []string, re *regexp.Regexp) int {
+	n := 0
+	for _, l := range lines {
+		if re.MatchString(l) {
+			n++
+		}
+	}
+	return n
+}
+
+func nonEmptyLines(text string) []string {
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func clean(s string) string {
+	s = spaceRe.ReplaceAllString(strings.TrimSpace(s), " ")
+	return hyphenWrap.ReplaceAllString(s, "$1$2")
+}
+
+func isYearOnly(s string) bool {
+	s = strings.Trim(s, " ()")
+	if len(s) == 5 && s[4] >= 'a' && s[4] <= 'z' {
+		s = s[:4]
+	}
+	return len(s) == 4 && yearRe.MatchString(s)
+}
+
+func isInitial(w string) bool {
+	r := []rune(w)
+	return len(r) == 1 && r[0] >= 'A' && r[0] <= 'Z' || initialsRe.MatchString(w+".")
+}
+
+func isLowerParticle(s string) bool {
+	for _, p := range []string{"van ", "de ", "von ", "der ", "di ", "le ", "da "} {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastWord(s string) string {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexAny(s, " ("); i >= 0 {
+		return s[i+1:]
+	}
+	return s
+}
@@ -11,6 +11,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -44,6 +45,24 @@ type Dataset struct {
 	Files       []string `json:"files"`
 }
 
+// DiffList lists the diffs that take a dataset from one release to another,
+// one per intermediate release, oldest first.
+type DiffList struct {
+	Dataset      string `json:"dataset"`
+	StartRelease string `json:"start_release"`
+	EndRelease   string `json:"end_release"`
+	Diffs        []Diff `json:"diffs"`
+}
+
+// Diff holds the files that take a dataset from FromRelease to ToRelease:
+// records to upsert and keys of records to delete.
+type Diff struct {
+	FromRelease string   `json:"from_release"`
+	ToRelease   string   `json:"to_release"`
+	UpdateFiles []string `json:"update_files"`
+	DeleteFiles []string `json:"delete_files"`
+}
+
 // S2Paper represents a single paper from the S2 bulk dataset (JSONL format).
 type S2Paper struct {
 	CorpusID                 int                    `json:"corpusid"`
@@ -99,6 +118,66 @@ func (p *S2Paper) GetDOI() string {
 	return ""
 }
 
+// GetPubMedID extracts the PMID from the ExternalIDs map.
+func (p *S2Paper) GetPubMedID() string {
+	if v, ok := p.ExternalIDs["PubMed"]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// GetPMCID extracts the PubMed Central ID from the ExternalIDs map.
+func (p *S2Paper) GetPMCID() string {
+	if v, ok := p.ExternalIDs["PubMedCentral"]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// PaperID returns the S2 paperId (the 40-hex hash in the paper URL), which
+// the bulk papers dataset only carries inside URL.
+func (p *S2Paper) PaperID() string {
+	if i := strings.LastIndex(p.URL, "/"); i >= 0 {
+		return p.URL[i+1:]
+	}
+	return ""
+}
+
+// S2Deleted is a record of a diff's delete files: the key of a removed record.
+type S2Deleted struct {
+	CorpusID int `json:"corpusid"`
+}
+
+// S2Abstract is a record of the abstracts dataset.
+type S2Abstract struct {
+	CorpusID       int     `json:"corpusid"`
+	Abstract       string  `json:"abstract"`
+	OpenAccessInfo *OAInfo `json:"openaccessinfo"`
+}
+
+// OAInfo describes the open-access copy of a paper.
+type OAInfo struct {
+	License string `json:"license"`
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+}
+
+// S2TLDR is a record of the tldrs dataset.
+type S2TLDR struct {
+	CorpusID int    `json:"corpusid"`
+	Model    string `json:"model"`
+	Text     string `json:"text"`
+}
+
+// S2Citation is a record of the citations dataset: one citing → cited edge.
+type S2Citation struct {
+	CitationID     int64    `json:"citationid"`
+	CitingCorpusID *int     `json:"citingcorpusid"`
+	CitedCorpusID  *int     `json:"citedcorpusid"`
+	IsInfluential  bool     `json:"isinfluential"`
+	Contexts       []string `json:"contexts"`
+}
+
 // GetLatestRelease fetches the latest S2 dataset release info.
 func (c *Client) GetLatestRelease(ctx context.Context) (*Release, error) {
 	req, err := http.NewRequestWithContext(ctx, "GET", baseURL+"/release/latest", nil)
@@ -156,11 +235,47 @@ func (c *Client) GetDataset(ctx context.Context, releaseID, datasetName string)
 	return &dataset, nil
 }
 
+// GetDiffs fetches the diffs of a dataset between two releases.
+func (c *Client) GetDiffs(ctx context.Context, fromRelease, toRelease, datasetName string) (*DiffList, error) {
+	url := fmt.Sprintf("%s/diffs/%s/to/%s/%s", baseURL, fromRelease, toRelease, datasetName)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.apiKey != "" {
+		req.Header.Set("x-api-key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("get diffs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get diffs: HTTP %d: %s", resp.StatusCode, truncate(string(body), 500))
+	}
+
+	var diffs DiffList
+	if err := json.NewDecoder(resp.Body).Decode(&diffs); err != nil {
+		return nil, fmt.Errorf("decode diffs: %w", err)
+	}
+	return &diffs, nil
+}
+
 // StreamPapersFile downloads a gzip JSONL file and streams papers through the callback.
 // filterFn is called for each paper to decide whether to include it.
 // callback receives matched papers in batches.
 // Returns total matched papers and any error.
 func (c *Client) StreamPapersFile(ctx context.Context, fileURL string, batchSize int, filterFn func(*S2Paper) bool, callback func(papers []S2Paper) error) (int, error) {
+	return StreamFile(ctx, c, fileURL, batchSize, filterFn, callback)
+}
+
+// StreamFile is StreamPapersFile for any dataset's records (S2Abstract,
+// S2TLDR, S2Citation, ...). Only one batch is held in memory at a time.
+// filterFn sees every well-formed record, in file order.
+func StreamFile[T any](ctx context.Context, c *Client, fileURL string, batchSize int, filterFn func(*T) bool, callback func(records []T) error) (int, error) {
 	start := time.Now()
 
 	req, err := http.NewRequestWithContext(ctx, "GET", fileURL, nil)
@@ -187,7 +302,7 @@ func (c *Client) StreamPapersFile(ctx context.Context, fileURL string, batchSize
 	scanner := bufio.NewScanner(gzReader)
 	scanner.Buffer(make([]byte, 0), 10*1024*1024) // 10MB max line
 
-	batch := make([]S2Paper, 0, batchSize)
+	batch := make([]T, 0, batchSize)
 	total := 0
 	scanned := 0
 
@@ -198,25 +313,25 @@ func (c *Client) StreamPapersFile(ctx context.Context, fileURL string, batchSize
 			continue
 		}
 
-		var paper S2Paper
-		if err := json.Unmarshal(line, &paper); err != nil {
+		var record T
+		if err := json.Unmarshal(line, &record); err != nil {
 			continue // skip malformed lines
 		}
 
-		if filterFn != nil && !filterFn(&paper) {
+		if filterFn != nil && !filterFn(&record) {
 			continue
 		}
 
-		batch = append(batch, paper)
+		batch = append(batch, record)
 		total++
 
 		if len(batch) >= batchSize {
 			if err := callback(batch); err != nil {
-				return total, fmt.Errorf("callback error after %d papers: %w", total, err)
+				return total, fmt.Errorf("callback error after %d records: %w", total, err)
 			}
 			batch = batch[:0]
 
-			// Progress log every 5000 matched papers
+			// Progress log every 5000 matched records
 			if total%5000 == 0 {
 				elapsed := time.Since(start)
 				rate := float64(total) / elapsed.Seconds()
@@ -226,7 +341,7 @@ func (c *Client) StreamPapersFile(ctx context.Context, fileURL string, batchSize
 	}
 
 	if err := scanner.Err(); err != nil {
-		return total, fmt.Errorf("scanner error after %d papers: %w", total, err)
+		return total, fmt.Errorf("scanner error after %d records: %w", total, err)
 	}
 
 	// Flush remaining batch
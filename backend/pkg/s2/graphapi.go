@@ -31,6 +31,11 @@ func NewGraphClient(apiKey string) *GraphClient {
 	}
 }
 
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *GraphClient) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
 // GraphPaper represents a paper from the S2 Graph API with all requested fields.
 type GraphPaper struct {
 	PaperID        string                 `json:"paperId"`
@@ -0,0 +1,219 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/pkg/ingestjob"
+)
+
+// lockKey is the Postgres advisory lock a Scheduler holds while it ticks, so
+// that of several schedulers (API servers, cmd/worker) one acts at a time.
+const lockKey int64 = 0x5343_4845_4455_4c45 // "SCHEDULE"
+
+// Scheduler starts the schedules that have come due and moves their runs
+// from step to step. Steps are queued as ingestjob jobs for a Worker.
+type Scheduler struct {
+	Store *Store
+	Jobs  *ingestjob.Store
+	// Interval is how often schedules and runs are checked.
+	Interval time.Duration
+
+	db *pgxpool.Pool
+}
+
+// New creates a Scheduler on the given pool.
+func New(db *pgxpool.Pool) *Scheduler {
+	return &Scheduler{
+		Store:    NewStore(db),
+		Jobs:     ingestjob.NewStore(db),
+		Interval: 30 * time.Second,
+		db:       db,
+	}
+}
+
+// NextRun returns the first time after t at which a cron expression is
+// due.
+func NextRun(expr string, after time.Time) (*time.Time, error) {
+	c, err := ParseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+	next := c.Next(after)
+	if next.IsZero() {
+		return nil, fmt.Errorf("cron %q never comes due", expr)
+	}
+	return &next, nil
+}
+
+// Run ticks until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	log.Printf("Scheduler started (checking every %s)", s.Interval)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("WARN: scheduler: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick advances the running schedule runs and starts the due schedules. It
+// does nothing if another scheduler holds the lock.
+func (s *Scheduler) Tick(ctx context.Context) error {
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&locked); err != nil {
+		return fmt.Errorf("take lock: %w", err)
+	}
+	if !locked {
+		return nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			// Ending the session releases the lock
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	runs, err := s.Store.running(ctx)
+	if err != nil {
+		return fmt.Errorf("list running: %w", err)
+	}
+	active := map[uuid.UUID]bool{}
+	for _, r := range runs {
+		sched, err := s.Store.Get(ctx, r.ScheduleID)
+		if err != nil {
+			return err
+		}
+		if sched == nil {
+			continue // deleted with its runs since
+		}
+		if err := s.advanceRun(ctx, sched, r); err != nil {
+			log.Printf("WARN: schedule %s run %s: %v", sched.Name, r.ID, err)
+		}
+		if r.Status == RunRunning {
+			active[r.ScheduleID] = true
+		}
+	}
+
+	now := time.Now().UTC()
+	due, err := s.Store.due(ctx, now)
+	if err != nil {
+		return fmt.Errorf("list due: %w", err)
+	}
+	for _, sched := range due {
+		if err := s.start(ctx, sched, now, active[sched.ID]); err != nil {
+			log.Printf("WARN: schedule %s: %v", sched.Name, err)
+		}
+	}
+	return nil
+}
+
+// start runs a due schedule and moves its next run time on. Due times
+// missed while no scheduler ran are run once, not once each.
+func (s *Scheduler) start(ctx context.Context, sched *Schedule, now time.Time, busy bool) error {
+	scheduledFor := *sched.NextRunAt
+	next, err := NextRun(sched.Cron, now)
+	if err != nil {
+		log.Printf("WARN: schedule %s: %v; not scheduling it again", sched.Name, err)
+	}
+	if err := s.Store.advance(ctx, sched.ID, scheduledFor, next); err != nil {
+		return err
+	}
+
+	if busy {
+		log.Printf("Schedule %s: previous run still going; skipping %s", sched.Name, scheduledFor.Format(time.RFC3339))
+		_, err := s.Store.createRun(ctx, sched.ID, scheduledFor, RunSkipped, "previous run still going")
+		return err
+	}
+	r, err := s.Store.createRun(ctx, sched.ID, scheduledFor, RunRunning, "")
+	if err != nil || r == nil {
+		return err
+	}
+	log.Printf("Schedule %s: run %s for %s started", sched.Name, r.ID, scheduledFor.Format(time.RFC3339))
+	if len(sched.Steps) == 0 {
+		r.Status = RunCompleted
+		return s.Store.saveRun(ctx, r)
+	}
+	return s.queueStep(ctx, sched, r)
+}
+
+// advanceRun checks the job of a run's current step and queues the next
+// step once it has completed. The run ends with the first step that
+// doesn't complete.
+func (s *Scheduler) advanceRun(ctx context.Context, sched *Schedule, r *Run) error {
+	if r.Step >= len(sched.Steps) {
+		r.Status = RunCompleted
+		return s.Store.saveRun(ctx, r)
+	}
+	if r.Step >= len(r.JobIDs) {
+		// Queuing the step failed last time
+		return s.queueStep(ctx, sched, r)
+	}
+
+	step := sched.Steps[r.Step]
+	job, err := s.Jobs.Get(ctx, r.JobIDs[r.Step])
+	if err != nil {
+		return err
+	}
+	switch {
+	case job == nil:
+		r.Status, r.Error = RunFailed, fmt.Sprintf("step %d (%s): job was deleted", r.Step+1, step.Type)
+	case !job.Finished():
+		return nil
+	case job.Status == ingestjob.StatusCompleted:
+		r.Step++
+		if r.Step < len(sched.Steps) {
+			return s.queueStep(ctx, sched, r)
+		}
+		r.Status = RunCompleted
+	case job.Status == ingestjob.StatusCancelled:
+		r.Status, r.Error = RunCancelled, fmt.Sprintf("step %d (%s) was cancelled", r.Step+1, step.Type)
+	default:
+		r.Status, r.Error = RunFailed, fmt.Sprintf("step %d (%s) failed: %s", r.Step+1, step.Type, job.Error)
+	}
+	log.Printf("Schedule %s: run %s %s", sched.Name, r.ID, r.Status)
+	return s.Store.saveRun(ctx, r)
+}
+
+// queueStep queues the job of a run's current step.
+func (s *Scheduler) queueStep(ctx context.Context, sched *Schedule, r *Run) error {
+	step := sched.Steps[r.Step]
+	cmd := ingestjob.Commands[step.Type]
+	if cmd == nil {
+		r.Status, r.Error = RunFailed, fmt.Sprintf("step %d: unknown job type %q", r.Step+1, step.Type)
+		return s.Store.saveRun(ctx, r)
+	}
+	params := make(map[string]interface{}, len(step.Params))
+	for k, v := range step.Params {
+		params[k] = v
+	}
+	flags, err := cmd.Params(params)
+	if err != nil {
+		r.Status, r.Error = RunFailed, fmt.Sprintf("step %d: %v", r.Step+1, err)
+		return s.Store.saveRun(ctx, r)
+	}
+
+	job, err := s.Jobs.Enqueue(ctx, step.Type, cmd.Name(flags), flags, sched.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("queue step %d: %w", r.Step+1, err)
+	}
+	r.JobIDs = append(r.JobIDs, job.ID)
+	log.Printf("Schedule %s: queued step %d/%d (%s job %s)", sched.Name, r.Step+1, len(sched.Steps), step.Type, job.ID)
+	return s.Store.saveRun(ctx, r)
+}
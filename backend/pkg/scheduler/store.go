@@ -0,0 +1,303 @@
+// Package scheduler runs ingestion jobs on cron schedules kept in the
+// job_schedules table. A schedule is a list of steps, each a job type with
+// params (see ingestjob.Commands); when the schedule comes due the Scheduler
+// queues the first step and queues each next one once the previous job has
+// completed. Every run is recorded in schedule_runs.
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Run statuses.
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunFailed    = "failed"
+	RunCancelled = "cancelled"
+	RunSkipped   = "skipped" // the previous run was still going
+)
+
+// ErrNameTaken is returned when another schedule has the name.
+var ErrNameTaken = errors.New("a schedule with this name already exists")
+
+// Step is one job of a schedule.
+type Step struct {
+	Type   string            `json:"type"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// Schedule queues its steps whenever Cron comes due.
+type Schedule struct {
+	ID        uuid.UUID  `json:"id"`
+	Name      string     `json:"name"`
+	Cron      string     `json:"cron"`
+	Steps     []Step     `json:"steps"`
+	Enabled   bool       `json:"enabled"`
+	NextRunAt *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// Run is one execution of a schedule.
+type Run struct {
+	ID           uuid.UUID   `json:"id"`
+	ScheduleID   uuid.UUID   `json:"schedule_id"`
+	ScheduledFor time.Time   `json:"scheduled_for"`
+	Status       string      `json:"status"`
+	Step         int         `json:"step"`
+	JobIDs       []uuid.UUID `json:"job_ids"`
+	Error        string      `json:"error,omitempty"`
+	StartedAt    time.Time   `json:"started_at"`
+	FinishedAt   *time.Time  `json:"finished_at,omitempty"`
+}
+
+// Store reads and writes job_schedules and schedule_runs.
+type Store struct {
+	db *pgxpool.Pool
+}
+
+// NewStore creates a Store on the given pool.
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+const scheduleColumns = `id, name, cron, steps, enabled, next_run_at, last_run_at, created_by, created_at, updated_at`
+
+func scanSchedule(row pgx.Row) (*Schedule, error) {
+	s := &Schedule{}
+	var steps []byte
+	err := row.Scan(&s.ID, &s.Name, &s.Cron, &steps, &s.Enabled, &s.NextRunAt, &s.LastRunAt,
+		&s.CreatedBy, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(steps, &s.Steps); err != nil {
+		return nil, fmt.Errorf("schedule %s: bad steps: %w", s.Name, err)
+	}
+	return s, nil
+}
+
+func querySchedules(ctx context.Context, db *pgxpool.Pool, query string, args ...interface{}) ([]*Schedule, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+const runColumns = `id, schedule_id, scheduled_for, status, step, job_ids, COALESCE(error, ''), started_at, finished_at`
+
+func scanRun(row pgx.Row) (*Run, error) {
+	r := &Run{}
+	err := row.Scan(&r.ID, &r.ScheduleID, &r.ScheduledFor, &r.Status, &r.Step, &r.JobIDs, &r.Error,
+		&r.StartedAt, &r.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if r.JobIDs == nil {
+		r.JobIDs = []uuid.UUID{}
+	}
+	return r, nil
+}
+
+func queryRuns(ctx context.Context, db *pgxpool.Pool, query string, args ...interface{}) ([]*Run, error) {
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*Run
+	for rows.Next() {
+		r, err := scanRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// isUniqueViolation reports whether err is a unique index violation.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// List returns all schedules by name.
+func (s *Store) List(ctx context.Context) ([]*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return querySchedules(ctx, s.db, `SELECT `+scheduleColumns+` FROM job_schedules ORDER BY name`)
+}
+
+// Get returns a schedule, or nil if there is none with the ID.
+func (s *Store) Get(ctx context.Context, id uuid.UUID) (*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sched, err := scanSchedule(s.db.QueryRow(ctx, `SELECT `+scheduleColumns+` FROM job_schedules WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return sched, err
+}
+
+// Create adds a schedule. NextRunAt should be set from the cron expression.
+func (s *Store) Create(ctx context.Context, sched *Schedule) (*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	steps, err := json.Marshal(sched.Steps)
+	if err != nil {
+		return nil, err
+	}
+	created, err := scanSchedule(s.db.QueryRow(ctx, `
+		INSERT INTO job_schedules (name, cron, steps, enabled, next_run_at, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING `+scheduleColumns,
+		sched.Name, sched.Cron, steps, sched.Enabled, sched.NextRunAt, sched.CreatedBy))
+	if isUniqueViolation(err) {
+		return nil, ErrNameTaken
+	}
+	return created, err
+}
+
+// Update saves a schedule's name, cron expression, steps, enabled flag and
+// next run time. Returns nil, nil if the schedule is gone.
+func (s *Store) Update(ctx context.Context, sched *Schedule) (*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	steps, err := json.Marshal(sched.Steps)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := scanSchedule(s.db.QueryRow(ctx, `
+		UPDATE job_schedules SET name = $2, cron = $3, steps = $4, enabled = $5, next_run_at = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+scheduleColumns,
+		sched.ID, sched.Name, sched.Cron, steps, sched.Enabled, sched.NextRunAt))
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, nil
+	case isUniqueViolation(err):
+		return nil, ErrNameTaken
+	}
+	return updated, err
+}
+
+// Delete removes a schedule and its run history; jobs it queued stay.
+// Returns false if there was no such schedule.
+func (s *Store) Delete(ctx context.Context, id uuid.UUID) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tag, err := s.db.Exec(ctx, `DELETE FROM job_schedules WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Runs returns a schedule's most recent runs, newest first.
+func (s *Store) Runs(ctx context.Context, scheduleID uuid.UUID, limit int) ([]*Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if limit <= 0 {
+		limit = 50
+	}
+	return queryRuns(ctx, s.db, `
+		SELECT `+runColumns+` FROM schedule_runs
+		WHERE schedule_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2
+	`, scheduleID, limit)
+}
+
+// due returns the enabled schedules whose next run time has come.
+func (s *Store) due(ctx context.Context, now time.Time) ([]*Schedule, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return querySchedules(ctx, s.db, `
+		SELECT `+scheduleColumns+` FROM job_schedules
+		WHERE enabled AND next_run_at <= $1
+		ORDER BY next_run_at
+	`, now)
+}
+
+// running returns the runs still going, oldest first.
+func (s *Store) running(ctx context.Context) ([]*Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return queryRuns(ctx, s.db, `
+		SELECT `+runColumns+` FROM schedule_runs WHERE status = 'running' ORDER BY started_at
+	`)
+}
+
+// createRun records a run of a schedule for its due time. Returns nil, nil
+// if that due time already has a run.
+func (s *Store) createRun(ctx context.Context, scheduleID uuid.UUID, scheduledFor time.Time, status, errMsg string) (*Run, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	r, err := scanRun(s.db.QueryRow(ctx, `
+		INSERT INTO schedule_runs (schedule_id, scheduled_for, status, error, finished_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), CASE WHEN $3 = 'running' THEN NULL ELSE NOW() END)
+		RETURNING `+runColumns,
+		scheduleID, scheduledFor, status, errMsg))
+	if isUniqueViolation(err) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// saveRun saves a run's step, jobs and status.
+func (s *Store) saveRun(ctx context.Context, r *Run) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE schedule_runs SET status = $2, step = $3, job_ids = $4, error = NULLIF($5, ''),
+			finished_at = CASE WHEN $2 = 'running' THEN NULL ELSE COALESCE(finished_at, NOW()) END
+		WHERE id = $1
+	`, r.ID, r.Status, r.Step, r.JobIDs, r.Error)
+	return err
+}
+
+// advance moves a schedule's next run time on after a run was due.
+func (s *Store) advance(ctx context.Context, id uuid.UUID, lastRun time.Time, next *time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE job_schedules SET last_run_at = $2, next_run_at = $3 WHERE id = $1
+	`, id, lastRun, next)
+	return err
+}
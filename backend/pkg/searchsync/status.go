@@ -0,0 +1,114 @@
+package searchsync
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Status is how far the search index trails PostgreSQL.
+type Status struct {
+	Pending  int64 `json:"pending"`
+	Retrying int64 `json:"retrying"` // pending rows that have failed before
+	Dead     int64 `json:"dead"`
+	// OldestPendingAt is when the oldest change not yet synced was made.
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	// LagSeconds is the age of that change: 0 when the index is current.
+	LagSeconds float64 `json:"lag_seconds"`
+}
+
+// DeadEntry is a dead-lettered outbox row.
+type DeadEntry struct {
+	ID        int64     `json:"id"`
+	PaperID   uuid.UUID `json:"paper_id"`
+	DocID     string    `json:"doc_id"`
+	Op        string    `json:"op"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+	DeadAt    time.Time `json:"dead_at"`
+}
+
+// Status reads the outbox's backlog.
+func (s *Syncer) Status(ctx context.Context) (*Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	st := &Status{}
+	err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE attempts > 0), MIN(created_at),
+			COALESCE(EXTRACT(EPOCH FROM NOW() - MIN(created_at)), 0)::float8,
+			(SELECT COUNT(*) FROM search_outbox_dead)
+		FROM search_outbox
+	`).Scan(&st.Pending, &st.Retrying, &st.OldestPendingAt, &st.LagSeconds, &st.Dead)
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// Dead returns the most recently dead-lettered rows.
+func (s *Syncer) Dead(ctx context.Context, limit int) ([]*DeadEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, paper_id, doc_id, op, attempts, COALESCE(last_error, ''), created_at, dead_at
+		FROM search_outbox_dead
+		ORDER BY dead_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*DeadEntry{}
+	for rows.Next() {
+		e := &DeadEntry{}
+		if err := rows.Scan(&e.ID, &e.PaperID, &e.DocID, &e.Op, &e.Attempts, &e.LastError, &e.CreatedAt, &e.DeadAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// RequeueDead moves the dead-lettered rows back into the outbox with their
+// attempts reset, and returns how many it moved.
+func (s *Syncer) RequeueDead(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	tag, err := s.db.Exec(ctx, `
+		WITH dead AS (DELETE FROM search_outbox_dead RETURNING paper_id, doc_id, op)
+		INSERT INTO search_outbox (paper_id, doc_id, op)
+		SELECT paper_id, doc_id, op FROM dead
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+// EnqueueAll queues every public paper for an upsert, to fill a new or
+// emptied index, and returns how many it queued.
+func (s *Syncer) EnqueueAll(ctx context.Context) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Minute)
+	defer cancel()
+
+	tag, err := s.db.Exec(ctx, enqueueAllSQL)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+const enqueueAllSQL = `
+	INSERT INTO search_outbox (paper_id, doc_id, op)
+	SELECT id, COALESCE(metadata->>'search_doc_id', id::text), 'upsert'
+	FROM papers
+	WHERE visibility = 'public' AND title IS NOT NULL AND title != ''
+	ORDER BY id
+`
@@ -0,0 +1,403 @@
+// Package searchsync keeps the search index in step with the papers table.
+// A trigger on papers and paper_fulltext queues every change to a paper's
+// searchable columns in search_outbox, in the writer's transaction; the
+// Syncer drains the outbox into the index in bulk. Documents the index
+// rejects are retried with backoff and, after MaxAttempts, moved to
+// search_outbox_dead until an admin requeues them.
+//
+// The trigger only queues changes once a Syncer has registered in
+// search_sync_state, so that databases nobody syncs from don't collect an
+// outbox; the first Syncer to start queues every paper.
+package searchsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/paper-app/backend/pkg/opensearch"
+)
+
+// lockKey is the Postgres advisory lock held while a batch is synced, so
+// that with several syncers running, an older view of a paper can't be
+// written over a newer one. It is a session lock: no transaction stays
+// open while the batch is sent to the index.
+const lockKey int64 = 0x5352_4348_5359_4e43 // "SRCHSYNC"
+
+// Target is an index the outbox is drained into: an OpenSearch client or
+// the embedded local index (internal/search).
+type Target interface {
+	// BulkSync merges docs into the index and deletes documents by ID. It
+	// returns the documents that failed; the error is for the batch as a
+	// whole.
+	BulkSync(ctx context.Context, docs []*opensearch.PaperDoc, deletes []string) ([]opensearch.BulkFailure, error)
+}
+
+// Syncer drains search_outbox into an index.
+type Syncer struct {
+	Index Target
+	// BatchSize is how many outbox rows go into one bulk request.
+	BatchSize int
+	// MaxAttempts is how often a document may fail before its rows are
+	// dead-lettered.
+	MaxAttempts int
+	// PollInterval is how often the outbox is checked once it is drained.
+	PollInterval time.Duration
+	// StatusInterval is how often Run logs the lag while it isn't zero.
+	StatusInterval time.Duration
+
+	db      *pgxpool.Pool
+	enabled atomic.Bool
+}
+
+// New creates a Syncer on the given pool and index.
+func New(db *pgxpool.Pool, index Target) *Syncer {
+	return &Syncer{
+		Index:          index,
+		BatchSize:      500,
+		MaxAttempts:    8,
+		PollInterval:   2 * time.Second,
+		StatusInterval: time.Minute,
+		db:             db,
+	}
+}
+
+// Run syncs until ctx is cancelled. While the index can't be reached the
+// outbox is left alone and retried with backoff; that doesn't count
+// against MaxAttempts.
+func (s *Syncer) Run(ctx context.Context) {
+	log.Printf("Search sync started (batches of %d, polling every %s)", s.BatchSize, s.PollInterval)
+	wait, lastStatus := time.Duration(0), time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+
+		n, err := s.SyncOnce(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			wait = min(max(2*wait, s.PollInterval), time.Minute)
+			log.Printf("WARN: search sync: %v (retrying in %s)", err, wait)
+			continue
+		case n >= s.BatchSize:
+			wait = 0 // more rows are ready
+		default:
+			wait = s.PollInterval
+		}
+
+		if time.Since(lastStatus) >= s.StatusInterval {
+			lastStatus = time.Now()
+			if st, err := s.Status(ctx); err == nil && (st.Pending > 0 || st.Dead > 0) {
+				log.Printf("Search sync: %d pending, %d dead, index %.0fs behind", st.Pending, st.Dead, st.LagSeconds)
+			}
+		}
+	}
+}
+
+// Drain syncs until the outbox has nothing ready. Rows waiting out a
+// retry backoff are left for later.
+func (s *Syncer) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := s.SyncOnce(ctx)
+		total += n
+		if err != nil || n < s.BatchSize {
+			return total, err
+		}
+	}
+}
+
+// entry is one search_outbox row.
+type entry struct {
+	id      int64
+	paperID uuid.UUID
+	docID   string
+	op      string
+}
+
+// paper is a paper as indexed, with the state that decides whether it is.
+type paper struct {
+	doc    *opensearch.PaperDoc
+	public bool
+}
+
+// SyncOnce syncs one batch of ready outbox rows and returns how many rows
+// it handled. Each row is synced from the paper's current state, so
+// several changes to one paper make one write. It does nothing if another
+// syncer is busy. The rows and papers are read, the index is written and
+// the outcome is recorded in a short transaction, in that order; rows
+// queued meanwhile are left for the next batch.
+func (s *Syncer) SyncOnce(ctx context.Context) (int, error) {
+	if !s.enabled.Load() {
+		if err := s.enable(ctx); err != nil {
+			return 0, fmt.Errorf("enable outbox: %w", err)
+		}
+		s.enabled.Store(true)
+	}
+
+	conn, err := s.db.Acquire(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Release()
+
+	var locked bool
+	if err := conn.QueryRow(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&locked); err != nil {
+		return 0, fmt.Errorf("take lock: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+	defer func() {
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			// Ending the session releases the lock
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	entries, err := readyEntries(ctx, conn, s.BatchSize)
+	if err != nil || len(entries) == 0 {
+		return 0, err
+	}
+	var ids []uuid.UUID
+	for _, e := range entries {
+		if e.op == "upsert" {
+			ids = append(ids, e.paperID)
+		}
+	}
+	papers, err := loadPapers(ctx, conn, ids)
+	if err != nil {
+		return 0, fmt.Errorf("load papers: %w", err)
+	}
+
+	// The last row of a document decides what happens to it
+	docs := map[string]*opensearch.PaperDoc{}
+	deletes := map[string]bool{}
+	rowsByDoc := map[string][]int64{}
+	for _, e := range entries {
+		p := papers[e.paperID]
+		if e.op == "upsert" && p != nil && p.public && p.doc.Title != "" && p.doc.ID == e.docID {
+			docs[e.docID] = p.doc
+			delete(deletes, e.docID)
+		} else {
+			// Deleted, made private or indexed under another ID since
+			deletes[e.docID] = true
+			delete(docs, e.docID)
+		}
+		rowsByDoc[e.docID] = append(rowsByDoc[e.docID], e.id)
+	}
+
+	upserts := make([]*opensearch.PaperDoc, 0, len(docs))
+	for _, doc := range docs {
+		upserts = append(upserts, doc)
+	}
+	deleteIDs := make([]string, 0, len(deletes))
+	for id := range deletes {
+		deleteIDs = append(deleteIDs, id)
+	}
+	failures, err := s.Index.BulkSync(ctx, upserts, deleteIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(context.Background())
+	for _, f := range failures {
+		reason := f.Reason
+		if reason == "" {
+			reason = fmt.Sprintf("status %d", f.Status)
+		}
+		if err := s.fail(ctx, tx, rowsByDoc[f.ID], reason); err != nil {
+			return 0, fmt.Errorf("record failure: %w", err)
+		}
+		delete(rowsByDoc, f.ID)
+	}
+	var done []int64
+	for _, rows := range rowsByDoc {
+		done = append(done, rows...)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM search_outbox WHERE id = ANY($1)`, done); err != nil {
+		return 0, fmt.Errorf("delete synced rows: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	if len(failures) > 0 {
+		log.Printf("WARN: search sync: %d/%d docs failed", len(failures), len(upserts)+len(deleteIDs))
+	}
+	return len(entries), nil
+}
+
+// enable registers the syncer in search_sync_state, which turns the outbox
+// trigger on. The first to register queues every paper in the same
+// transaction, since the changes made before it were not recorded.
+func (s *Syncer) enable(ctx context.Context) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+
+	tag, err := tx.Exec(ctx, `INSERT INTO search_sync_state DEFAULT VALUES ON CONFLICT DO NOTHING`)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return nil
+	}
+	queued, err := tx.Exec(ctx, enqueueAllSQL)
+	if err != nil {
+		return fmt.Errorf("queue papers: %w", err)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+	log.Printf("Search outbox enabled: queued %d papers", queued.RowsAffected())
+	return nil
+}
+
+// readyEntries returns the oldest outbox rows not waiting out a backoff.
+func readyEntries(ctx context.Context, conn *pgxpool.Conn, limit int) ([]*entry, error) {
+	rows, err := conn.Query(ctx, `
+		SELECT id, paper_id, doc_id, op FROM search_outbox
+		WHERE available_at <= NOW()
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*entry
+	for rows.Next() {
+		e := &entry{}
+		if err := rows.Scan(&e.id, &e.paperID, &e.docID, &e.op); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// loadPapers reads papers as cmd/index indexes them.
+func loadPapers(ctx context.Context, conn *pgxpool.Conn, ids []uuid.UUID) (map[uuid.UUID]*paper, error) {
+	papers := map[uuid.UUID]*paper{}
+	if len(ids) == 0 {
+		return papers, nil
+	}
+	rows, err := conn.Query(ctx, `
+		SELECT id, COALESCE(metadata->>'search_doc_id', id::text), external_id, source, title,
+			COALESCE(abstract, ''), authors, published_date, COALESCE(pdf_url, ''),
+			COALESCE(primary_category, ''), categories, COALESCE(doi, ''), COALESCE(journal_ref, ''),
+			COALESCE(citation_count, 0), COALESCE(work_id, ''), visibility = 'public',
+			(SELECT ft.sections FROM paper_fulltext ft WHERE ft.paper_id = papers.id),
+			CASE WHEN metadata ?| ARRAY['s2_url', 'is_open_access'] THEN jsonb_build_object(
+				'reference_count', metadata->'reference_count',
+				'influential_citation_count', metadata->'influential_citation_count',
+				'venue', metadata->'venue',
+				'publication_types', metadata->'publication_types',
+				's2_url', metadata->'s2_url',
+				'is_open_access', metadata->'is_open_access',
+				'tldr', metadata->'tldr'
+			) END
+		FROM papers
+		WHERE id = ANY($1)
+	`, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id            uuid.UUID
+			p             = &paper{doc: &opensearch.PaperDoc{}}
+			authorsJSON   []byte
+			publishedDate *time.Time
+			sectionsJSON  []byte
+			s2JSON        []byte
+		)
+		d := p.doc
+		if err := rows.Scan(&id, &d.ID, &d.ExternalID, &d.Source, &d.Title, &d.Abstract, &authorsJSON,
+			&publishedDate, &d.PDFURL, &d.PrimaryCategory, &d.Categories, &d.DOI, &d.JournalRef,
+			&d.CitationCount, &d.WorkID, &p.public, &sectionsJSON, &s2JSON); err != nil {
+			return nil, err
+		}
+		d.Authors = parseAuthors(authorsJSON)
+		if publishedDate != nil {
+			date := publishedDate.Format("2006-01-02")
+			d.PublishedDate = &date
+			d.Year = publishedDate.Year()
+		}
+		if len(sectionsJSON) > 0 {
+			if err := json.Unmarshal(sectionsJSON, &d.FullTextSections); err != nil {
+				log.Printf("WARN: search sync: bad fulltext sections for %s: %v", d.ExternalID, err)
+			}
+		}
+		if len(s2JSON) > 0 {
+			if err := json.Unmarshal(s2JSON, d); err != nil {
+				log.Printf("WARN: search sync: bad S2 metadata for %s: %v", d.ExternalID, err)
+			} else {
+				d.S2Fields = true
+			}
+		}
+		papers[id] = p
+	}
+	return papers, rows.Err()
+}
+
+// parseAuthors reads the papers.authors column into the nested authors
+// field, which wants name and affiliation strings.
+func parseAuthors(raw []byte) []map[string]string {
+	var authors []map[string]string
+	if len(raw) == 0 || json.Unmarshal(raw, &authors) == nil {
+		return authors
+	}
+	var objs []struct {
+		Name        string `json:"name"`
+		Affiliation string `json:"affiliation"`
+	}
+	if json.Unmarshal(raw, &objs) == nil {
+		for _, a := range objs {
+			authors = append(authors, map[string]string{"name": a.Name, "affiliation": a.Affiliation})
+		}
+	}
+	return authors
+}
+
+// fail records a failed sync of rows: they are retried after a backoff
+// that doubles from 5s up to an hour, or dead-lettered on their last
+// attempt.
+func (s *Syncer) fail(ctx context.Context, tx pgx.Tx, ids []int64, reason string) error {
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO search_outbox_dead (id, paper_id, doc_id, op, attempts, last_error, created_at)
+		SELECT id, paper_id, doc_id, op, attempts + 1, $2, created_at FROM search_outbox
+		WHERE id = ANY($1) AND attempts + 1 >= $3
+		ON CONFLICT (id) DO NOTHING
+	`, ids, reason, s.MaxAttempts); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM search_outbox WHERE id = ANY($1) AND attempts + 1 >= $2`, ids, s.MaxAttempts); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE search_outbox SET attempts = attempts + 1, last_error = $2,
+			available_at = NOW() + LEAST(INTERVAL '5 seconds' * POWER(2, attempts), INTERVAL '1 hour')
+		WHERE id = ANY($1)
+	`, ids, reason)
+	return err
+}
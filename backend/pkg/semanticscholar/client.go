@@ -26,6 +26,11 @@ func NewClient() *Client {
 	}
 }
 
+// SetHTTPClient replaces the HTTP client (e.g. to replay recorded responses).
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
 type SearchResult struct {
 	Papers       []*domain.Paper
 	TotalResults int
@@ -134,6 +139,41 @@ func (c *Client) Search(query string, limit, offset int, sortBy string) (*Search
 	}, nil
 }
 
+// GetPaper fetches one paper by S2 paper ID or a prefixed external ID
+// ("ARXIV:2301.00001", "DOI:10.1038/nature12373", "PMID:12345").
+// Returns nil, nil if Semantic Scholar has no such paper.
+func (c *Client) GetPaper(id string) (*domain.Paper, error) {
+	params := url.Values{}
+	params.Set("fields", "title,abstract,year,citationCount,url,authors,externalIds,openAccessPdf,publicationDate")
+	reqURL := fmt.Sprintf("%s/paper/%s?%s", apiBaseURL, url.PathEscape(id), params.Encode())
+
+	req, err := http.NewRequest("GET", reqURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "PaperApp/1.0 (academic-reader)")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("semantic scholar API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("semantic scholar API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result paperResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return resultToPaper(&result), nil
+}
+
 func resultToPaper(r *paperResult) *domain.Paper {
 	if r.Title == "" {
 		return nil
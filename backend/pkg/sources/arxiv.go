@@ -0,0 +1,58 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/arxiv"
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// arxivSource wraps the arXiv export API. Incremental listing goes through
+// OAI-PMH instead (see oaipmhSource).
+type arxivSource struct {
+	client *arxiv.Client
+}
+
+func newArxiv(hc *http.Client) *arxivSource {
+	c := arxiv.NewClient()
+	if hc != nil {
+		c.SetHTTPClient(hc)
+	}
+	return &arxivSource{client: c}
+}
+
+func (s *arxivSource) Name() string { return Arxiv }
+
+func (s *arxivSource) Capabilities() Capabilities {
+	return Capabilities{
+		Search:      true,
+		GetByID:     true,
+		MaxLimit:    100,
+		IDTypes:     []string{"arxiv"},
+		MinInterval: 3 * time.Second, // arXiv API terms of use
+	}
+}
+
+func (s *arxivSource) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := limitOr(q.Limit, 20, 100)
+	res, err := call(ctx, func() (*arxiv.SearchResult, error) { return s.client.Search(q.Text, limit, q.Offset) })
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Papers: res.Papers, Total: res.TotalResults}, nil
+}
+
+func (s *arxivSource) GetByID(ctx context.Context, id string) (*domain.Paper, error) {
+	arxivID := paperid.NormalizeArxivID(id)
+	if arxivID == "" {
+		return nil, nil
+	}
+	return call(ctx, func() (*domain.Paper, error) { return s.client.GetPaper(arxivID) })
+}
+
+func (s *arxivSource) ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error) {
+	return nil, ErrUnsupported
+}
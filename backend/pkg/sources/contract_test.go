@@ -0,0 +1,43 @@
+package sources_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/paper-app/backend/pkg/sources"
+	"github.com/paper-app/backend/pkg/sources/sourcetest"
+)
+
+// TestContract runs the sourcetest contract against every registered
+// source, replaying its recorded fixtures in testdata/<source>.
+// cmd/conformance runs the same suite and can re-record the fixtures.
+func TestContract(t *testing.T) {
+	for _, name := range sources.All {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join("testdata", name)
+			suite, err := sourcetest.LoadSuite(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			replay := sourcetest.NewReplay(dir, suite)
+			src, err := sources.New(name, &sources.Config{}, replay.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			for _, r := range sourcetest.Run(ctx, src, suite) {
+				if r.Err != nil {
+					t.Errorf("%s: %v", r.Check, r.Err)
+				}
+			}
+			for _, u := range replay.Unmatched() {
+				t.Logf("unrecorded request: %s", u)
+			}
+		})
+	}
+}
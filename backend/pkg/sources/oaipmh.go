@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/oaipmh"
+)
+
+// oaipmhSource harvests arXiv through OAI-PMH. It only lists; the cursor is
+// the OAI resumption token.
+type oaipmhSource struct {
+	client *oaipmh.Client
+	set    string
+}
+
+func newOAIPMH(baseURL, set string, hc *http.Client) *oaipmhSource {
+	opts := []oaipmh.Option{}
+	if baseURL != "" {
+		opts = append(opts, oaipmh.WithBaseURL(baseURL))
+	}
+	if hc != nil {
+		opts = append(opts, oaipmh.WithHTTPClient(hc))
+	}
+	return &oaipmhSource{client: oaipmh.NewClient(opts...), set: set}
+}
+
+func (s *oaipmhSource) Name() string { return OAIPMH }
+
+func (s *oaipmhSource) Capabilities() Capabilities {
+	return Capabilities{
+		ListSince:   true,
+		MinInterval: 3 * time.Second, // enforced by the client itself
+	}
+}
+
+func (s *oaipmhSource) Search(ctx context.Context, q Query) (*Page, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *oaipmhSource) GetByID(ctx context.Context, id string) (*domain.Paper, error) {
+	return nil, ErrUnsupported
+}
+
+func (s *oaipmhSource) ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error) {
+	params := oaipmh.ListRecordsParams{
+		MetadataPrefix:  oaipmh.MetadataPrefixArXiv,
+		Set:             s.set,
+		From:            since.Format("2006-01-02"),
+		ResumptionToken: cursor,
+	}
+	res, err := call(ctx, func() (*oaipmh.ListRecordsResult, error) { return s.client.ListRecords(params) })
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Total: -1, Next: res.ResumptionToken}
+	if n, err := strconv.Atoi(res.CompleteSize); err == nil {
+		page.Total = n
+	}
+	for _, hp := range res.Papers {
+		if hp.IsDeleted {
+			page.Deleted = append(page.Deleted, hp.ArXivID)
+			continue
+		}
+		page.Papers = append(page.Papers, harvestedToDomain(hp))
+	}
+	return page, nil
+}
+
+// harvestedToDomain matches cmd/harvest's conversion.
+func harvestedToDomain(hp *oaipmh.HarvestedPaper) *domain.Paper {
+	authorsJSON, _ := json.Marshal(hp.Authors)
+
+	var pubDate *time.Time
+	if !hp.PublishedDate.IsZero() {
+		pubDate = &hp.PublishedDate
+	}
+
+	return &domain.Paper{
+		ExternalID:      hp.ArXivID,
+		Source:          Arxiv,
+		Title:           hp.Title,
+		Abstract:        hp.Abstract,
+		Authors:         authorsJSON,
+		PublishedDate:   pubDate,
+		UpdatedDate:     hp.UpdatedDate,
+		PDFURL:          fmt.Sprintf("https://arxiv.org/pdf/%s", hp.ArXivID),
+		PrimaryCategory: hp.PrimaryCategory,
+		Categories:      hp.Categories,
+		DOI:             hp.DOI,
+		JournalRef:      hp.JournalRef,
+		Comments:        hp.Comments,
+		License:         hp.License,
+	}
+}
@@ -0,0 +1,74 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/openalex"
+	"github.com/paper-app/backend/pkg/paperid"
+)
+
+// openalexSource wraps the OpenAlex works API.
+type openalexSource struct {
+	client *openalex.Client
+}
+
+func newOpenAlex(mailto string, hc *http.Client) *openalexSource {
+	c := openalex.NewClient(mailto)
+	if hc != nil {
+		c.SetHTTPClient(hc)
+	}
+	return &openalexSource{client: c}
+}
+
+func (s *openalexSource) Name() string { return OpenAlex }
+
+func (s *openalexSource) Capabilities() Capabilities {
+	return Capabilities{
+		Search:      true,
+		GetByID:     true,
+		ListSince:   true,
+		MaxLimit:    100,
+		IDTypes:     []string{"openalex", "doi", "pmid"},
+		MinInterval: 100 * time.Millisecond, // 10 req/s
+	}
+}
+
+func (s *openalexSource) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := limitOr(q.Limit, 20, 100)
+	res, err := call(ctx, func() (*openalex.SearchResult, error) {
+		return s.client.Search(q.Text, "", q.Sort, limit, q.Offset)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Papers: res.Papers, Total: res.TotalResults}, nil
+}
+
+func (s *openalexSource) GetByID(ctx context.Context, id string) (*domain.Paper, error) {
+	id = strings.TrimSpace(id)
+	switch lower := strings.ToLower(id); {
+	case strings.HasPrefix(lower, "pmid:"):
+		// passed through as is
+	case paperid.NormalizeDOI(id) != "":
+		id = "doi:" + paperid.NormalizeDOI(id)
+	case !strings.HasPrefix(strings.TrimPrefix(id, "https://openalex.org/"), "W"):
+		return nil, nil
+	}
+	return call(ctx, func() (*domain.Paper, error) { return s.client.GetWork(id) })
+}
+
+func (s *openalexSource) ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error) {
+	res, err := call(ctx, func() (*openalex.SearchResult, error) { return s.client.ListSince(since, cursor, 200) })
+	if err != nil {
+		return nil, err
+	}
+	page := &Page{Papers: res.Papers, Total: res.TotalResults}
+	if len(res.Papers) > 0 {
+		page.Next = res.NextCursor
+	}
+	return page, nil
+}
@@ -0,0 +1,108 @@
+package sources
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Config holds the settings of the sources that take any.
+type Config struct {
+	Enabled        []string // Sources FromConfig registers; see All
+	OpenAlexMailto string   // Joins OpenAlex's polite pool
+	S2APIKey       string   // Optional, raises Semantic Scholar rate limits
+	NCBIAPIKey     string   // Optional, raises PubMed E-utilities from 3 to 10 req/s
+	NCBIEmail      string   // Contact address sent to NCBI with each request
+	OAIBaseURL     string   // OAI-PMH endpoint; empty = arXiv's
+	OAISet         string   // OAI-PMH set to list, e.g. "cs"; empty = all
+}
+
+// Registry holds the enabled sources by name, in priority order.
+type Registry struct {
+	sources map[string]Source
+	names   []string
+}
+
+// NewRegistry returns a registry holding srcs.
+func NewRegistry(srcs ...Source) *Registry {
+	r := &Registry{sources: make(map[string]Source)}
+	for _, s := range srcs {
+		r.Register(s)
+	}
+	return r
+}
+
+// FromConfig builds the sources enabled in cfg. hc overrides every client's
+// HTTP client when non-nil (used to replay or record fixtures).
+func FromConfig(cfg *Config, hc *http.Client) (*Registry, error) {
+	r := NewRegistry()
+	for _, name := range cfg.Enabled {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || name == "none" {
+			continue
+		}
+		src, err := New(name, cfg, hc)
+		if err != nil {
+			return nil, err
+		}
+		r.Register(src)
+	}
+	return r, nil
+}
+
+// New builds a single source by name.
+func New(name string, cfg *Config, hc *http.Client) (Source, error) {
+	switch name {
+	case Arxiv:
+		return newArxiv(hc), nil
+	case PubMed:
+		return newPubMed(cfg.NCBIAPIKey, cfg.NCBIEmail, hc), nil
+	case OpenAlex:
+		return newOpenAlex(cfg.OpenAlexMailto, hc), nil
+	case SemanticScholar:
+		return newSemanticScholar(hc), nil
+	case S2:
+		return newS2(cfg.S2APIKey, hc), nil
+	case OAIPMH:
+		return newOAIPMH(cfg.OAIBaseURL, cfg.OAISet, hc), nil
+	}
+	return nil, fmt.Errorf("unknown source %q (known: %s)", name, strings.Join(All, ", "))
+}
+
+// Register adds or replaces a source.
+func (r *Registry) Register(s Source) {
+	if _, ok := r.sources[s.Name()]; !ok {
+		r.names = append(r.names, s.Name())
+	}
+	r.sources[s.Name()] = s
+}
+
+// Get returns a source by name.
+func (r *Registry) Get(name string) (Source, bool) {
+	s, ok := r.sources[name]
+	return s, ok
+}
+
+// Names returns the registered source names in priority order.
+func (r *Registry) Names() []string {
+	return append([]string(nil), r.names...)
+}
+
+// Select returns the named sources that are registered and pass filter (nil
+// = all), in the order given. Unknown names are skipped.
+func (r *Registry) Select(names []string, filter func(Capabilities) bool) []Source {
+	var out []Source
+	for _, name := range names {
+		s, ok := r.sources[strings.ToLower(strings.TrimSpace(name))]
+		if ok && (filter == nil || filter(s.Capabilities())) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// CanSearch is a Select filter for sources that support Search.
+func CanSearch(c Capabilities) bool { return c.Search }
+
+// CanListSince is a Select filter for sources that support ListSince.
+func CanListSince(c Capabilities) bool { return c.ListSince }
@@ -0,0 +1,166 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/s2"
+)
+
+// s2Source wraps the Semantic Scholar Graph API bulk endpoints
+// (/paper/search/bulk, /paper/batch), which return every field we store.
+// Search pages by continuation token; rows of a bulk page past Query.Limit are
+// skipped, so page through everything with Limit=1000.
+type s2Source struct {
+	client *s2.GraphClient
+}
+
+func newS2(apiKey string, hc *http.Client) *s2Source {
+	c := s2.NewGraphClient(apiKey)
+	if hc != nil {
+		c.SetHTTPClient(hc)
+	}
+	return &s2Source{client: c}
+}
+
+func (s *s2Source) Name() string { return S2 }
+
+func (s *s2Source) Capabilities() Capabilities {
+	return Capabilities{
+		Search:      true,
+		GetByID:     true,
+		MaxLimit:    1000,
+		IDTypes:     []string{"s2", "arxiv", "doi", "pmid"},
+		MinInterval: time.Second,
+	}
+}
+
+func (s *s2Source) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := limitOr(q.Limit, 100, 1000)
+	res, err := s.client.BulkSearch(ctx, q.Text, q.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{Total: res.Total, Next: res.Token}
+	for i := range res.Data {
+		if len(page.Papers) == limit {
+			break
+		}
+		if p := graphPaperToDomain(&res.Data[i]); p != nil {
+			page.Papers = append(page.Papers, p)
+		}
+	}
+	return page, nil
+}
+
+func (s *s2Source) GetByID(ctx context.Context, id string) (*domain.Paper, error) {
+	papers, err := s.client.BatchPaper(ctx, []string{s2PaperID(id)})
+	if err != nil {
+		return nil, err
+	}
+	// Unknown IDs come back as null entries
+	if len(papers) == 0 || papers[0].PaperID == "" {
+		return nil, nil
+	}
+	return graphPaperToDomain(&papers[0]), nil
+}
+
+func (s *s2Source) ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error) {
+	return nil, ErrUnsupported
+}
+
+// graphPaperToDomain mirrors the semanticscholar client's mapping: arXiv
+// papers are keyed by arXiv ID, everything else by S2 paper ID.
+func graphPaperToDomain(p *s2.GraphPaper) *domain.Paper {
+	if strings.TrimSpace(p.Title) == "" {
+		return nil
+	}
+
+	source, externalID := SemanticScholar, p.PaperID
+	arxivID := p.GetArXivID()
+	if arxivID != "" {
+		source, externalID = Arxiv, arxivID
+	}
+
+	authors := make([]domain.Author, 0, len(p.Authors))
+	for _, a := range p.Authors {
+		if a.Name != "" {
+			authors = append(authors, domain.Author{Name: strings.TrimSpace(a.Name)})
+		}
+	}
+	authorsJSON, _ := json.Marshal(authors)
+
+	var pubDate *time.Time
+	if p.PublicationDate != nil {
+		if t, err := time.Parse("2006-01-02", *p.PublicationDate); err == nil {
+			pubDate = &t
+		}
+	}
+	if pubDate == nil && p.Year > 0 {
+		t := time.Date(p.Year, 1, 1, 0, 0, 0, 0, time.UTC)
+		pubDate = &t
+	}
+
+	var categories []string
+	seen := map[string]bool{}
+	for _, f := range p.S2FieldsOfStudy {
+		if !seen[f.Category] {
+			categories = append(categories, f.Category)
+			seen[f.Category] = true
+		}
+	}
+	primary := ""
+	if len(categories) > 0 {
+		primary = categories[0]
+	}
+
+	pdfURL := ""
+	if p.OpenAccessPdf != nil && p.OpenAccessPdf.URL != "" {
+		pdfURL = p.OpenAccessPdf.URL
+	} else if arxivID != "" {
+		pdfURL = fmt.Sprintf("https://arxiv.org/pdf/%s", arxivID)
+	}
+
+	abstract := ""
+	if p.Abstract != nil {
+		abstract = *p.Abstract
+	}
+	journalRef := ""
+	if p.Journal != nil {
+		journalRef = p.Journal.Name
+	}
+
+	metadata := map[string]interface{}{
+		"corpus_id":      p.CorpusID,
+		"s2_paper_id":    p.PaperID,
+		"s2_url":         p.URL,
+		"venue":          p.Venue,
+		"is_open_access": p.IsOpenAccess,
+	}
+	if p.TLDR != nil && p.TLDR.Text != "" {
+		metadata["tldr"] = p.TLDR.Text
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	return &domain.Paper{
+		ExternalID:      externalID,
+		Source:          source,
+		Title:           strings.TrimSpace(p.Title),
+		Abstract:        strings.TrimSpace(abstract),
+		Authors:         authorsJSON,
+		PublishedDate:   pubDate,
+		PDFURL:          pdfURL,
+		Metadata:        metadataJSON,
+		CitationCount:   p.CitationCount,
+		PrimaryCategory: primary,
+		Categories:      categories,
+		DOI:             strings.ToLower(p.GetDOI()),
+		JournalRef:      journalRef,
+	}
+}
@@ -0,0 +1,82 @@
+package sources
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/paperid"
+	"github.com/paper-app/backend/pkg/semanticscholar"
+)
+
+// semanticScholarSource wraps the Semantic Scholar relevance search API.
+// Bulk harvesting uses the s2 source instead.
+type semanticScholarSource struct {
+	client *semanticscholar.Client
+}
+
+func newSemanticScholar(hc *http.Client) *semanticScholarSource {
+	c := semanticscholar.NewClient()
+	if hc != nil {
+		c.SetHTTPClient(hc)
+	}
+	return &semanticScholarSource{client: c}
+}
+
+func (s *semanticScholarSource) Name() string { return SemanticScholar }
+
+func (s *semanticScholarSource) Capabilities() Capabilities {
+	return Capabilities{
+		Search:      true,
+		GetByID:     true,
+		MaxLimit:    100,
+		IDTypes:     []string{"s2", "arxiv", "doi", "pmid"},
+		MinInterval: time.Second, // shared unauthenticated pool
+	}
+}
+
+func (s *semanticScholarSource) Search(ctx context.Context, q Query) (*Page, error) {
+	limit := limitOr(q.Limit, 20, 100)
+	sortBy := ""
+	switch q.Sort {
+	case "citations":
+		sortBy = "citationCount"
+	case "date":
+		sortBy = "publicationDate"
+	}
+	res, err := call(ctx, func() (*semanticscholar.SearchResult, error) {
+		return s.client.Search(q.Text, limit, q.Offset, sortBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Page{Papers: res.Papers, Total: res.TotalResults}, nil
+}
+
+func (s *semanticScholarSource) GetByID(ctx context.Context, id string) (*domain.Paper, error) {
+	s2ID := s2PaperID(id)
+	return call(ctx, func() (*domain.Paper, error) { return s.client.GetPaper(s2ID) })
+}
+
+func (s *semanticScholarSource) ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error) {
+	return nil, ErrUnsupported
+}
+
+// s2PaperID converts an ID to the prefixed form the Graph API accepts.
+func s2PaperID(id string) string {
+	id = strings.TrimSpace(id)
+	lower := strings.ToLower(id)
+	switch {
+	case strings.HasPrefix(lower, "pmid:"):
+		return "PMID:" + id[5:]
+	case strings.HasPrefix(lower, "corpusid:"):
+		return "CorpusId:" + id[9:]
+	case paperid.NormalizeDOI(id) != "":
+		return "DOI:" + paperid.NormalizeDOI(id)
+	case paperid.NormalizeArxivID(id) != "":
+		return "ARXIV:" + paperid.NormalizeArxivID(id)
+	}
+	return id
+}
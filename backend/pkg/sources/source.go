@@ -0,0 +1,111 @@
+// Package sources puts the external paper APIs (arXiv, PubMed, OpenAlex,
+// Semantic Scholar, the S2 Graph API and arXiv OAI-PMH) behind one interface,
+// so search, lookup and incremental harvesting code doesn't need to know each
+// client's signature. The contract every adapter must meet is checked by
+// sourcetest against recorded HTTP fixtures (go run ./cmd/conformance).
+package sources
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+)
+
+// Source names, as used in config (SOURCES, FEDERATED_SOURCES).
+const (
+	Arxiv           = "arxiv"
+	PubMed          = "pubmed"
+	OpenAlex        = "openalex"
+	SemanticScholar = "semanticscholar"
+	S2              = "s2"
+	OAIPMH          = "oaipmh"
+)
+
+// All lists every known source in default priority order.
+var All = []string{Arxiv, SemanticScholar, OpenAlex, PubMed, S2, OAIPMH}
+
+// ErrUnsupported is returned by operations a source doesn't offer (see Capabilities).
+var ErrUnsupported = errors.New("operation not supported by source")
+
+// Source is an external provider of paper metadata.
+type Source interface {
+	Name() string
+	Capabilities() Capabilities
+
+	// Search runs a relevance (or sorted) query.
+	Search(ctx context.Context, q Query) (*Page, error)
+	// GetByID fetches one paper. Returns nil, nil if the source has no such paper.
+	GetByID(ctx context.Context, id string) (*domain.Paper, error)
+	// ListSince pages through papers added or updated on or after since.
+	// Pass cursor "" for the first page and Page.Next afterwards.
+	ListSince(ctx context.Context, since time.Time, cursor string) (*Page, error)
+}
+
+// Capabilities describes what a source supports.
+type Capabilities struct {
+	Search    bool
+	GetByID   bool
+	ListSince bool
+
+	MaxLimit    int           // largest Query.Limit honoured by Search
+	IDTypes     []string      // ID forms GetByID accepts: "arxiv", "doi", "pmid", "openalex", "s2"
+	MinInterval time.Duration // polite spacing between requests to the API
+}
+
+// Query is a search request. Sources that page by cursor use Cursor and
+// ignore Offset.
+type Query struct {
+	Text   string
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   string // "relevance" (default), "citations" or "date"; best effort
+}
+
+// Page is one page of results.
+type Page struct {
+	Papers  []*domain.Paper
+	Total   int      // total matches, -1 if the source doesn't say
+	Next    string   // cursor for the next page; "" on the last page
+	Deleted []string // external IDs withdrawn upstream (ListSince only)
+}
+
+// call runs fn but returns as soon as ctx is done. Most wrapped clients
+// predate context support; an abandoned call finishes in the background,
+// bounded by the client's own HTTP timeout, and its result is dropped.
+func call[T any](ctx context.Context, fn func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	type result struct {
+		v   T
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		v, err := fn()
+		ch <- result{v, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.v, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// limitOr clamps a requested page size to (0, max], defaulting to def.
+func limitOr(limit, def, max int) int {
+	if limit <= 0 {
+		return def
+	}
+	if limit > max {
+		return max
+	}
+	return limit
+}
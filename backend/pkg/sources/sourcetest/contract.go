@@ -0,0 +1,212 @@
+package sourcetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paper-app/backend/internal/domain"
+	"github.com/paper-app/backend/pkg/sources"
+)
+
+// Result is the outcome of one contract check.
+type Result struct {
+	Check string
+	Err   error // nil = passed
+}
+
+// Run checks src against the contract every sources.Source must meet:
+//   - operations missing from Capabilities return sources.ErrUnsupported
+//   - supported operations return well-formed papers for the suite's cases
+//   - Search respects Query.Limit and returns no duplicate external IDs
+//   - GetByID returns the requested paper, and nil, nil for unknown IDs
+//   - a cancelled context makes calls fail instead of hitting the network
+func Run(ctx context.Context, src sources.Source, suite *Suite) []Result {
+	var results []Result
+	check := func(name string, fn func() error) {
+		results = append(results, Result{Check: name, Err: fn()})
+	}
+	caps := src.Capabilities()
+
+	check("name", func() error {
+		if src.Name() != suite.Source {
+			return fmt.Errorf("Name() = %q, suite is for %q", src.Name(), suite.Source)
+		}
+		return nil
+	})
+
+	if caps.Search {
+		check("search", func() error {
+			if suite.Search == nil {
+				return errors.New("source can search but the suite has no search case")
+			}
+			q := sources.Query{Text: suite.Search.Query, Limit: suite.Search.Limit}
+			page, err := src.Search(ctx, q)
+			if err != nil {
+				return err
+			}
+			if len(page.Papers) < suite.Search.MinResults {
+				return fmt.Errorf("got %d papers, want at least %d", len(page.Papers), suite.Search.MinResults)
+			}
+			if q.Limit > 0 && len(page.Papers) > q.Limit {
+				return fmt.Errorf("got %d papers for limit %d", len(page.Papers), q.Limit)
+			}
+			return checkPapers(page.Papers)
+		})
+		check("search/cancelled", func() error {
+			return expectCancelled(func(ctx context.Context) error {
+				_, err := src.Search(ctx, sources.Query{Text: "cancelled", Limit: 1})
+				return err
+			})
+		})
+	} else {
+		check("search/unsupported", func() error {
+			_, err := src.Search(ctx, sources.Query{Text: "x", Limit: 1})
+			return expectUnsupported(err)
+		})
+	}
+
+	if caps.GetByID {
+		check("get_by_id", func() error {
+			if suite.GetByID == nil {
+				return errors.New("source supports GetByID but the suite has no get_by_id case")
+			}
+			p, err := src.GetByID(ctx, suite.GetByID.ID)
+			if err != nil {
+				return err
+			}
+			if p == nil {
+				return fmt.Errorf("GetByID(%q) = nil", suite.GetByID.ID)
+			}
+			if p.ExternalID != suite.GetByID.ExternalID {
+				return fmt.Errorf("GetByID(%q).ExternalID = %q, want %q", suite.GetByID.ID, p.ExternalID, suite.GetByID.ExternalID)
+			}
+			return checkPapers([]*domain.Paper{p})
+		})
+		if suite.GetByID != nil && suite.GetByID.NotFoundID != "" {
+			check("get_by_id/not_found", func() error {
+				p, err := src.GetByID(ctx, suite.GetByID.NotFoundID)
+				if err != nil {
+					return fmt.Errorf("unknown ID must return nil, nil; got error %v", err)
+				}
+				if p != nil {
+					return fmt.Errorf("unknown ID must return nil, nil; got %q", p.ExternalID)
+				}
+				return nil
+			})
+		}
+		if suite.GetByID != nil {
+			check("get_by_id/cancelled", func() error {
+				return expectCancelled(func(ctx context.Context) error {
+					_, err := src.GetByID(ctx, suite.GetByID.ID)
+					return err
+				})
+			})
+		}
+	} else {
+		check("get_by_id/unsupported", func() error {
+			_, err := src.GetByID(ctx, "x")
+			return expectUnsupported(err)
+		})
+	}
+
+	if caps.ListSince {
+		check("list_since", func() error {
+			if suite.ListSince == nil {
+				return errors.New("source supports ListSince but the suite has no list_since case")
+			}
+			since, err := time.Parse("2006-01-02", suite.ListSince.Since)
+			if err != nil {
+				return fmt.Errorf("bad since date: %v", err)
+			}
+			page, err := src.ListSince(ctx, since, "")
+			if err != nil {
+				return err
+			}
+			if len(page.Papers) < suite.ListSince.MinResults {
+				return fmt.Errorf("got %d papers, want at least %d", len(page.Papers), suite.ListSince.MinResults)
+			}
+			if suite.ListSince.ExpectNext && page.Next == "" {
+				return errors.New("first page has no next cursor")
+			}
+			return checkPapers(page.Papers)
+		})
+		check("list_since/cancelled", func() error {
+			return expectCancelled(func(ctx context.Context) error {
+				_, err := src.ListSince(ctx, time.Now(), "")
+				return err
+			})
+		})
+	} else {
+		check("list_since/unsupported", func() error {
+			_, err := src.ListSince(ctx, time.Now(), "")
+			return expectUnsupported(err)
+		})
+	}
+
+	return results
+}
+
+// checkPapers verifies the fields every stored paper needs.
+func checkPapers(papers []*domain.Paper) error {
+	seen := map[string]bool{}
+	for i, p := range papers {
+		if p == nil {
+			return fmt.Errorf("paper %d is nil", i)
+		}
+		switch {
+		case strings.TrimSpace(p.Title) == "":
+			return fmt.Errorf("paper %d (%s) has no title", i, p.ExternalID)
+		case p.ExternalID == "":
+			return fmt.Errorf("paper %d (%q) has no external ID", i, p.Title)
+		case p.Source == "":
+			return fmt.Errorf("paper %s has no source", p.ExternalID)
+		}
+		key := p.Source + ":" + p.ExternalID
+		if seen[key] {
+			return fmt.Errorf("duplicate paper %s", key)
+		}
+		seen[key] = true
+
+		var authors []domain.Author
+		if err := json.Unmarshal(p.Authors, &authors); err != nil {
+			return fmt.Errorf("paper %s: authors are not a JSON array of authors: %v", key, err)
+		}
+		for _, a := range authors {
+			if strings.TrimSpace(a.Name) == "" {
+				return fmt.Errorf("paper %s has an author without a name", key)
+			}
+		}
+		if len(p.Metadata) > 0 && !json.Valid(p.Metadata) {
+			return fmt.Errorf("paper %s: metadata is not valid JSON", key)
+		}
+	}
+	return nil
+}
+
+func expectUnsupported(err error) error {
+	if !errors.Is(err, sources.ErrUnsupported) {
+		return fmt.Errorf("want sources.ErrUnsupported, got %v", err)
+	}
+	return nil
+}
+
+func expectCancelled(fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			return errors.New("call with a cancelled context succeeded")
+		}
+		return nil
+	case <-time.After(2 * time.Second):
+		return errors.New("call with a cancelled context did not return")
+	}
+}
@@ -0,0 +1,255 @@
+// Package sourcetest is the shared contract suite for pkg/sources adapters.
+// Each source has a directory of recorded HTTP exchanges and expectations
+// (suite.json); Replay serves the recordings so Run can check an adapter
+// offline and deterministically. Record captures fresh fixtures from the
+// live APIs. cmd/conformance drives both.
+package sourcetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// SuiteFile is the name of the expectations file in a fixture directory.
+const SuiteFile = "suite.json"
+
+// Suite is one source's fixtures and expectations.
+type Suite struct {
+	Source    string      `json:"source"`
+	Search    *SearchCase `json:"search,omitempty"`
+	GetByID   *GetCase    `json:"get_by_id,omitempty"`
+	ListSince *ListCase   `json:"list_since,omitempty"`
+	Exchanges []Exchange  `json:"exchanges"`
+}
+
+// SearchCase is the query Search is checked with.
+type SearchCase struct {
+	Query      string `json:"query"`
+	Limit      int    `json:"limit"`
+	MinResults int    `json:"min_results"`
+}
+
+// GetCase is the lookup GetByID is checked with. NotFoundID, if set, must
+// return nil, nil.
+type GetCase struct {
+	ID         string `json:"id"`
+	ExternalID string `json:"external_id"`
+	NotFoundID string `json:"not_found_id,omitempty"`
+}
+
+// ListCase is the listing ListSince is checked with.
+type ListCase struct {
+	Since      string `json:"since"` // YYYY-MM-DD
+	MinResults int    `json:"min_results"`
+	ExpectNext bool   `json:"expect_next"` // first page must have a cursor
+}
+
+// Exchange is one recorded HTTP response. A request matches when method,
+// host and path are equal and every listed query parameter has the listed
+// value; unlisted parameters are ignored. BodyContains, if set, must occur in
+// the request body (for POST lookups such as /paper/batch).
+type Exchange struct {
+	Method       string            `json:"method"`
+	Host         string            `json:"host"`
+	Path         string            `json:"path"`
+	Query        map[string]string `json:"query,omitempty"`
+	BodyContains string            `json:"body_contains,omitempty"`
+	Status       int               `json:"status"`
+	ContentType  string            `json:"content_type,omitempty"`
+	BodyFile     string            `json:"body_file"`
+}
+
+func (e *Exchange) matches(req *http.Request, body string) bool {
+	if !strings.EqualFold(e.Method, req.Method) || !strings.EqualFold(e.Host, req.URL.Hostname()) || e.Path != req.URL.EscapedPath() {
+		return false
+	}
+	q := req.URL.Query()
+	for k, v := range e.Query {
+		if q.Get(k) != v {
+			return false
+		}
+	}
+	return strings.Contains(body, e.BodyContains)
+}
+
+// requestBody reads req's body and puts it back for the real transport.
+func requestBody(req *http.Request) (string, error) {
+	if req.Body == nil {
+		return "", nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+// LoadSuite reads dir/suite.json.
+func LoadSuite(dir string) (*Suite, error) {
+	data, err := os.ReadFile(filepath.Join(dir, SuiteFile))
+	if err != nil {
+		return nil, err
+	}
+	var s Suite
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, SuiteFile), err)
+	}
+	return &s, nil
+}
+
+// SaveSuite writes dir/suite.json.
+func SaveSuite(dir string, s *Suite) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, SuiteFile), append(data, '\n'), 0o644)
+}
+
+// Replay is an http.RoundTripper serving a suite's recorded exchanges.
+// Requests without a recording fail, so an adapter calling an endpoint the
+// fixtures don't cover is caught rather than sent to the network.
+type Replay struct {
+	dir   string
+	suite *Suite
+
+	mu        sync.Mutex
+	unmatched []string
+}
+
+// NewReplay serves the exchanges of suite, with bodies read from dir.
+func NewReplay(dir string, suite *Suite) *Replay {
+	return &Replay{dir: dir, suite: suite}
+}
+
+// Client returns an http.Client using the replay transport.
+func (r *Replay) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Unmatched lists requests that had no recording.
+func (r *Replay) Unmatched() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.unmatched...)
+}
+
+func (r *Replay) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	for i := range r.suite.Exchanges {
+		e := &r.suite.Exchanges[i]
+		if !e.matches(req, reqBody) {
+			continue
+		}
+		body, err := os.ReadFile(filepath.Join(r.dir, e.BodyFile))
+		if err != nil {
+			return nil, err
+		}
+		header := http.Header{}
+		if e.ContentType != "" {
+			header.Set("Content-Type", e.ContentType)
+		}
+		return &http.Response{
+			StatusCode:    e.Status,
+			Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+		}, nil
+	}
+
+	r.mu.Lock()
+	r.unmatched = append(r.unmatched, req.Method+" "+req.URL.String())
+	r.mu.Unlock()
+	return nil, fmt.Errorf("sourcetest: no recorded response for %s %s", req.Method, req.URL)
+}
+
+// Record is an http.RoundTripper that forwards to the live API and appends
+// every exchange (with all query parameters) to the suite, writing bodies
+// into dir. Save the suite afterwards with SaveSuite.
+type Record struct {
+	dir   string
+	suite *Suite
+	base  http.RoundTripper
+
+	mu sync.Mutex
+}
+
+// NewRecord records into suite and dir. The suite's existing exchanges are
+// dropped; its cases are kept.
+func NewRecord(dir string, suite *Suite) *Record {
+	suite.Exchanges = nil
+	return &Record{dir: dir, suite: suite, base: http.DefaultTransport}
+}
+
+// Client returns an http.Client using the recording transport.
+func (r *Record) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+func (r *Record) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := requestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ext := ".txt"
+	switch ct := resp.Header.Get("Content-Type"); {
+	case strings.Contains(ct, "json"):
+		ext = ".json"
+	case strings.Contains(ct, "xml"):
+		ext = ".xml"
+	}
+	name := fmt.Sprintf("%02d%s", len(r.suite.Exchanges)+1, ext)
+	if err := os.WriteFile(filepath.Join(r.dir, name), body, 0o644); err != nil {
+		return nil, err
+	}
+
+	query := map[string]string{}
+	for k := range req.URL.Query() {
+		query[k] = req.URL.Query().Get(k)
+	}
+	r.suite.Exchanges = append(r.suite.Exchanges, Exchange{
+		Method:       req.Method,
+		Host:         req.URL.Hostname(),
+		Path:         req.URL.EscapedPath(),
+		Query:        query,
+		BodyContains: reqBody,
+		Status:       resp.StatusCode,
+		ContentType:  resp.Header.Get("Content-Type"),
+		BodyFile:     name,
+	})
+	return resp, nil
+}
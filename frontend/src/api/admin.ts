@@ -56,6 +56,97 @@ export interface AdminActivityResponse {
   offset: number;
 }
 
+export type JobStatus = 'pending' | 'running' | 'completed' | 'failed' | 'cancelled';
+
+export interface Job {
+  id: string;
+  type: string;
+  name: string;
+  params: Record<string, string>;
+  cursor: string;
+  counters: Record<string, number>;
+  status: JobStatus;
+  error?: string;
+  errors: { at: string; message: string }[] | null;
+  cancel_requested: boolean;
+  worker?: string;
+  heartbeat_at?: string;
+  started_at?: string;
+  finished_at?: string;
+  created_by?: string;
+  created_at: string;
+}
+
+export interface JobType {
+  type: string;
+  params: string[];
+  required?: string[];
+}
+
+export interface AdminJobsResponse {
+  jobs: Job[];
+  types: JobType[];
+}
+
+export interface ScheduleStep {
+  type: string;
+  params?: Record<string, string>;
+}
+
+export interface Schedule {
+  id: string;
+  name: string;
+  cron: string;
+  steps: ScheduleStep[];
+  enabled: boolean;
+  next_run_at?: string;
+  last_run_at?: string;
+  created_by?: string;
+  created_at: string;
+  updated_at: string;
+}
+
+export type ScheduleRunStatus = 'running' | 'completed' | 'failed' | 'cancelled' | 'skipped';
+
+export interface ScheduleRun {
+  id: string;
+  schedule_id: string;
+  scheduled_for: string;
+  status: ScheduleRunStatus;
+  step: number;
+  job_ids: string[];
+  error?: string;
+  started_at: string;
+  finished_at?: string;
+}
+
+export interface ScheduleInput {
+  name: string;
+  cron: string;
+  steps: { type: string; params?: Record<string, string | number | boolean> }[];
+  enabled?: boolean;
+}
+
+export interface SearchSyncDead {
+  id: number;
+  paper_id: string;
+  doc_id: string;
+  op: 'upsert' | 'delete';
+  attempts: number;
+  last_error: string;
+  created_at: string;
+  dead_at: string;
+}
+
+export interface SearchSyncStatus {
+  pending: number;
+  retrying: number;
+  dead: number;
+  oldest_pending_at?: string;
+  lag_seconds: number;
+  recent_dead: SearchSyncDead[];
+}
+
 export const adminApi = {
   getUsers(limit = 50, offset = 0): Promise<AdminUsersResponse> {
     return api.get('/api/v1/admin/users', { limit, offset });
@@ -72,4 +163,60 @@ export const adminApi = {
   getUserActivity(userId: string, limit = 20): Promise<{ events: LoginEvent[] }> {
     return api.get(`/api/v1/admin/users/${userId}/activity`, { limit });
   },
+
+  getJobs(filter: { type?: string; status?: JobStatus; limit?: number } = {}): Promise<AdminJobsResponse> {
+    const params: Record<string, string | number> = {};
+    if (filter.type) params.type = filter.type;
+    if (filter.status) params.status = filter.status;
+    if (filter.limit) params.limit = filter.limit;
+    return api.get('/api/v1/admin/jobs', params);
+  },
+
+  getJob(id: string): Promise<Job> {
+    return api.get(`/api/v1/admin/jobs/${id}`);
+  },
+
+  startJob(type: string, params: Record<string, string | number | boolean>): Promise<Job> {
+    return api.post('/api/v1/admin/jobs', { type, params });
+  },
+
+  cancelJob(id: string): Promise<Job> {
+    return api.post(`/api/v1/admin/jobs/${id}/cancel`);
+  },
+
+  retryJob(id: string): Promise<Job> {
+    return api.post(`/api/v1/admin/jobs/${id}/retry`);
+  },
+
+  getSchedules(): Promise<{ schedules: Schedule[] }> {
+    return api.get('/api/v1/admin/schedules');
+  },
+
+  createSchedule(input: ScheduleInput): Promise<Schedule> {
+    return api.post('/api/v1/admin/schedules', input);
+  },
+
+  updateSchedule(id: string, input: ScheduleInput): Promise<Schedule> {
+    return api.put(`/api/v1/admin/schedules/${id}`, input);
+  },
+
+  deleteSchedule(id: string): Promise<void> {
+    return api.delete(`/api/v1/admin/schedules/${id}`);
+  },
+
+  runSchedule(id: string): Promise<Schedule> {
+    return api.post(`/api/v1/admin/schedules/${id}/run`);
+  },
+
+  getScheduleRuns(id: string, limit = 50): Promise<{ runs: ScheduleRun[] }> {
+    return api.get(`/api/v1/admin/schedules/${id}/runs`, { limit });
+  },
+
+  getSearchSync(): Promise<SearchSyncStatus> {
+    return api.get('/api/v1/admin/search/sync');
+  },
+
+  retrySearchSync(): Promise<{ requeued: number }> {
+    return api.post('/api/v1/admin/search/sync/retry');
+  },
 };
@@ -1,5 +1,5 @@
 import { api } from './client';
-import type { Paper, SearchResult, CategoryInfo, DiscoverResult } from '../types';
+import type { Paper, SearchResult, CategoryInfo, DiscoverResult, WorkResult, ResolveResult } from '../types';
 
 export const papersApi = {
   search(
@@ -27,6 +27,19 @@ export const papersApi = {
     return api.get(`/api/v1/papers/${id}`);
   },
 
+  // Preprint and published records of the same work
+  getWork(id: string): Promise<WorkResult> {
+    return api.get(`/api/v1/papers/${id}/work`);
+  },
+
+  // Look a paper up by DOI, arXiv ID/URL, PMID, S2 or OpenAlex URL; with
+  // fetch, papers we don't have are imported from the external APIs
+  resolve(id: string, fetch = false): Promise<ResolveResult> {
+    const params: Record<string, string | number> = { id };
+    if (fetch) params.fetch = 'true';
+    return api.get('/api/v1/papers/resolve', params);
+  },
+
   getCategories(): Promise<CategoryInfo[]> {
     return api.get('/api/v1/papers/categories');
   },
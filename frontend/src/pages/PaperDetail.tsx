@@ -38,6 +38,15 @@ export default function PaperDetail() {
     enabled: !!id,
   });
 
+  const { data: work } = useQuery({
+    queryKey: ['paper-work', id],
+    queryFn: () => papersApi.getWork(id!),
+    enabled: !!id,
+  });
+  const otherVersions = work?.papers?.filter(
+    (p) => p.id !== paper?.id && p.external_id !== paper?.external_id
+  ) ?? [];
+
   const { data: libraryData } = useQuery({
     queryKey: ['library', '', 'all'],
     queryFn: () => libraryApi.getLibrary('', 1000, 0),
@@ -473,6 +482,31 @@ export default function PaperDetail() {
         </div>
       )}
 
+      {/* Other versions of the same work (preprint / journal article) */}
+      {otherVersions.length > 0 && (
+        <div className="mt-8 pt-8 border-t border-surface-200 dark:border-surface-800">
+          <h2 className="text-lg font-semibold text-surface-900 dark:text-surface-100 mb-4">Other Versions</h2>
+          <ul className="space-y-3">
+            {otherVersions.map((v) => (
+              <li key={v.id}>
+                <button
+                  onClick={() => navigate(`/paper/${v.id}`)}
+                  className="text-left w-full rounded-xl p-3 border border-surface-200 dark:border-surface-800 hover:border-primary-300 dark:hover:border-primary-700 transition-colors"
+                >
+                  <div className="font-medium text-surface-900 dark:text-surface-100">{v.title}</div>
+                  <div className="text-sm text-surface-500 flex flex-wrap gap-3 mt-1">
+                    <span className="uppercase text-xs font-medium">{v.source}</span>
+                    {v.published_date && <span>{v.published_date}</span>}
+                    {(v.journal_ref || v.venue) && <span className="italic">{v.journal_ref || v.venue}</span>}
+                    {v.doi && <span>DOI: {v.doi}</span>}
+                  </div>
+                </button>
+              </li>
+            ))}
+          </ul>
+        </div>
+      )}
+
       {/* Notes */}
       {userPaper?.notes && (
         <div className="mt-8 pt-8 border-t border-surface-200 dark:border-surface-800">
@@ -32,6 +32,7 @@ export interface Paper {
   tldr?: string;
   metadata?: Record<string, unknown>;
   created_at?: string;
+  work_id?: string;
 }
 
 export interface Author {
@@ -65,6 +66,23 @@ export interface AuthResponse {
   tokens: TokenPair;
 }
 
+export interface WorkResult {
+  work_id: string;
+  papers: Paper[];
+}
+
+export interface PaperIdentifier {
+  scheme: 'arxiv' | 'doi' | 'pmid' | 'pmcid' | 's2' | 's2paper' | 'openalex';
+  value: string;
+}
+
+export interface ResolveResult {
+  paper: Paper;
+  identifiers: PaperIdentifier[];
+  matched_by: string; // "database", "search_index" or the source it was fetched from
+  imported: boolean;
+}
+
 export interface SearchResult {
   papers: Paper[];
   total: number;